@@ -39,3 +39,13 @@ func MergeLabels(l ...map[string]string) map[string]string {
 func LabelsForKafka(name string) map[string]string {
 	return map[string]string{"app": "kafka", "kafka_cr": name}
 }
+
+// StringSliceContains returns true if the given string is present in the slice
+func StringSliceContains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
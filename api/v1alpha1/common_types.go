@@ -26,6 +26,9 @@ type TopicState string
 // UserState defines the state of a KafkaUser
 type UserState string
 
+// UserAuthenticationType defines the authentication mechanism of a KafkaUser
+type UserAuthenticationType string
+
 // ClusterReference states a reference to a cluster for topic/user
 // provisioning
 type ClusterReference struct {
@@ -46,6 +49,18 @@ const (
 	OperationRemoveBroker CruiseControlTaskOperation = "remove_broker"
 	// OperationRebalance means a Cruise Control rebalance operation
 	OperationRebalance CruiseControlTaskOperation = "rebalance"
+	// OperationDemoteBroker means a Cruise Control demote_broker operation
+	OperationDemoteBroker CruiseControlTaskOperation = "demote_broker"
+	// OperationFixOfflineReplicas means a Cruise Control fix_offline_replicas operation
+	OperationFixOfflineReplicas CruiseControlTaskOperation = "fix_offline_replicas"
+	// OperationTopicConfiguration means a Cruise Control topic_configuration operation
+	OperationTopicConfiguration CruiseControlTaskOperation = "topic_configuration"
+	// OperationRemoveDisks means a Cruise Control remove_disks operation
+	OperationRemoveDisks CruiseControlTaskOperation = "remove_disks"
+	// OperationPauseSampling means a Cruise Control pause_sampling operation
+	OperationPauseSampling CruiseControlTaskOperation = "pause_sampling"
+	// OperationResumeSampling means a Cruise Control resume_sampling operation
+	OperationResumeSampling CruiseControlTaskOperation = "resume_sampling"
 	// KafkaAccessTypeRead states that a user wants consume access to a topic
 	KafkaAccessTypeRead KafkaAccessType = "read"
 	// KafkaAccessTypeWrite states that a user wants produce access to a topic
@@ -82,4 +97,14 @@ const (
 	PeerPrivateKeyKey string = "peerKey"
 	// PasswordKey stores the JKS password
 	PasswordKey string = "password"
+	// UsernameKey stores the name of a SCRAM user in the user secret
+	UsernameKey string = "username"
+	// SaslJaasConfigKey stores the JAAS configuration of a SCRAM user in the user secret
+	SaslJaasConfigKey string = "sasl.jaas.config"
+	// SaslMechanismKey stores the SASL mechanism of a SCRAM user in the user secret
+	SaslMechanismKey string = "sasl.mechanism"
+	// UserAuthenticationTypeTLS states that the user authenticates with a TLS client certificate
+	UserAuthenticationTypeTLS UserAuthenticationType = "tls"
+	// UserAuthenticationTypeScramSha512 states that the user authenticates with SCRAM-SHA-512 credentials
+	UserAuthenticationTypeScramSha512 UserAuthenticationType = "scram-sha-512"
 )
@@ -15,6 +15,9 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"hash/fnv"
+	"math"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,10 +28,14 @@ import (
 const (
 	// ErrorPolicyIgnore means the Koperator handles the failed task as completed.
 	ErrorPolicyIgnore ErrorPolicyType = "ignore"
-	// ErrorPolicyRetry means Koperator re-executes the failed task in every 30 sec (by default).
+	// ErrorPolicyRetry means Koperator re-executes the failed task with exponential back off, 30 sec after the first
+	// failure (by default).
 	ErrorPolicyRetry ErrorPolicyType = "retry"
-	// DefaultRetryBackOffDurationSec defines the time between retries of the failed tasks.
+	// DefaultRetryBackOffDurationSec defines the time between the failure of the task and its first retry.
 	DefaultRetryBackOffDurationSec = 30
+	// ProposalApprovedAnnotationKey is the annotation of the dry-run CruiseControlOperations which approves the execution
+	// of their proposal, "kafka.banzaicloud.io/proposal-approved"
+	ProposalApprovedAnnotationKey = "kafka.banzaicloud.io/proposal-approved"
 )
 
 //+kubebuilder:object:root=true
@@ -54,19 +61,29 @@ type CruiseControlOperationList struct {
 // CruiseControlOperationSpec defines the desired state of CruiseControlOperation.
 type CruiseControlOperationSpec struct {
 	// ErrorPolicy defines how failed Cruise Control operation should be handled.
-	// When it is "retry", the Koperator re-executes the failed task in every 30 sec (by default).
+	// When it is "retry", the Koperator re-executes the failed task according to the retry policy.
 	// When it is "ignore", the Koperator handles the failed task as completed.
 	// +kubebuilder:validation:Enum=ignore;retry
 	// +kubebuilder:default=retry
 	// +optional
 	ErrorPolicy ErrorPolicyType `json:"errorPolicy,omitempty"`
-	// When TTLSecondsAfterFinished is specified, the created and finished (completed successfully or completedWithError and errorPolicy: ignore)
-	// cruiseControlOperation custom resource will be deleted after the given time elapsed.
+	// When TTLSecondsAfterFinished is specified, the created and finished (completed successfully, completedWithError and errorPolicy: ignore,
+	// or failed and not retried anymore) cruiseControlOperation custom resource will be deleted after the given time elapsed.
 	// When it is 0 then the resource is going to be deleted instantly after the operation is finished.
 	// When it is not specified the resource is not going to be removed.
 	// Value can be only zero and positive integers
 	// +kubebuilder:validation:Minimum=0
 	TTLSecondsAfterFinished *int `json:"ttlSecondsAfterFinished,omitempty"`
+	// When DryRun is true, the Koperator computes the proposal of the add_broker, remove_broker, rebalance, demote_broker,
+	// fix_offline_replicas or topic_configuration operation with Cruise Control in dry-run mode first and stores it in
+	// the status.proposal field. The operation is executed only after the proposal is approved with the
+	// "kafka.banzaicloud.io/proposal-approved: true" annotation.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// RetryPolicy defines how the failed task is retried when the error policy is "retry".
+	// When it is not specified, the retry policy of the Kafka cluster is used.
+	// +optional
+	RetryPolicy *v1beta1.CruiseControlOperationRetryPolicy `json:"retryPolicy,omitempty"`
 }
 
 // ErrorPolicyType defines methods of handling Cruise Control user task errors.
@@ -78,6 +95,41 @@ type CruiseControlOperationStatus struct {
 	ErrorPolicy ErrorPolicyType     `json:"errorPolicy"`
 	RetryCount  int                 `json:"retryCount"`
 	FailedTasks []CruiseControlTask `json:"failedTasks,omitempty"`
+	// RetryPolicy is the retry policy in effect, the one of the operation or the default of the Kafka cluster.
+	RetryPolicy *v1beta1.CruiseControlOperationRetryPolicy `json:"retryPolicy,omitempty"`
+	// Proposal is the result of the dry-run execution of the operation.
+	Proposal *CruiseControlProposal `json:"proposal,omitempty"`
+	// ProposalTaskID is the ID of the Cruise Control user task which computes the proposal of the dry-run operation.
+	// +optional
+	ProposalTaskID string `json:"proposalTaskId,omitempty"`
+	// StoppedOutsideExecutionWindow is true when the execution of the operation was stopped as the execution window
+	// of the Kafka cluster closed. The operation is executed again in the next execution window.
+	// +optional
+	StoppedOutsideExecutionWindow bool `json:"stoppedOutsideExecutionWindow,omitempty"`
+}
+
+// CruiseControlProposal defines the observed proposal of the Cruise Control operation computed in dry-run mode.
+type CruiseControlProposal struct {
+	Generated *metav1.Time `json:"generated,omitempty"`
+	// Summary of the proposal, e.g. the data to move and the number of replica and leader movements.
+	Summary map[string]string `json:"summary,omitempty"`
+	// Brokers contains the load of the brokers before and after the execution of the proposal.
+	Brokers []CruiseControlBrokerLoadDelta `json:"brokers,omitempty"`
+	// GoalsViolatedBefore contains the goals which are violated before the execution of the proposal.
+	GoalsViolatedBefore []string `json:"goalsViolatedBefore,omitempty"`
+	// GoalsViolatedAfter contains the goals which are still violated after the execution of the proposal.
+	GoalsViolatedAfter []string `json:"goalsViolatedAfter,omitempty"`
+}
+
+// CruiseControlBrokerLoadDelta defines the load of a broker before and after the execution of a proposal.
+type CruiseControlBrokerLoadDelta struct {
+	BrokerID       int32 `json:"brokerId"`
+	ReplicasBefore int32 `json:"replicasBefore"`
+	ReplicasAfter  int32 `json:"replicasAfter"`
+	LeadersBefore  int32 `json:"leadersBefore"`
+	LeadersAfter   int32 `json:"leadersAfter"`
+	DiskMBBefore   int64 `json:"diskMBBefore"`
+	DiskMBAfter    int64 `json:"diskMBAfter"`
 }
 
 // CruiseControlTask defines the observed state of the Cruise Control user task.
@@ -165,6 +217,12 @@ func (o *CruiseControlOperation) CurrentTaskOperation() CruiseControlTaskOperati
 	return o.CurrentTask().Operation
 }
 
+// IsWaitingForExecutionWindow returns true when the execution of the operation was stopped as the execution window
+// of the Kafka cluster closed, and it has not been executed again yet
+func (o *CruiseControlOperation) IsWaitingForExecutionWindow() bool {
+	return o.Status.StoppedOutsideExecutionWindow && o.CurrentTaskState() == "" && o.CurrentTaskID() == ""
+}
+
 func (o *CruiseControlOperation) IsWaitingForFirstExecution() bool {
 	if o.CurrentTaskState() == "" && o.CurrentTaskID() == "" && o.Status.RetryCount == 0 {
 		return true
@@ -172,6 +230,35 @@ func (o *CruiseControlOperation) IsWaitingForFirstExecution() bool {
 	return false
 }
 
+// IsDryRun returns true when the proposal of the operation has to be approved before its execution
+func (o *CruiseControlOperation) IsDryRun() bool {
+	if !o.Spec.DryRun {
+		return false
+	}
+	switch o.CurrentTaskOperation() {
+	case OperationAddBroker, OperationRemoveBroker, OperationRebalance, OperationDemoteBroker,
+		OperationFixOfflineReplicas, OperationTopicConfiguration, OperationRemoveDisks:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsProposalApproved returns true when the execution of the dry-run operation is approved
+func (o *CruiseControlOperation) IsProposalApproved() bool {
+	return o.GetAnnotations()[ProposalApprovedAnnotationKey] == "true"
+}
+
+// IsWaitingForProposal returns true when the proposal of the dry-run operation has not been computed yet
+func (o *CruiseControlOperation) IsWaitingForProposal() bool {
+	return o.IsDryRun() && o.IsWaitingForFirstExecution() && o.Status.Proposal == nil
+}
+
+// IsWaitingForApproval returns true when the dry-run operation cannot be executed until its proposal is approved
+func (o *CruiseControlOperation) IsWaitingForApproval() bool {
+	return o.IsDryRun() && o.IsWaitingForFirstExecution() && (o.Status.Proposal == nil || !o.IsProposalApproved())
+}
+
 func (o *CruiseControlOperation) IsInProgress() bool {
 	if o.CurrentTaskID() != "" && (o.CurrentTaskState() == v1beta1.CruiseControlTaskActive || o.CurrentTaskState() == v1beta1.CruiseControlTaskInExecution) {
 		return true
@@ -183,6 +270,11 @@ func (o *CruiseControlOperation) IsDone() bool {
 	return (o.IsPaused() && o.CurrentTaskState() == v1beta1.CruiseControlTaskCompletedWithError) || o.IsFinished()
 }
 
+// IsFailed returns true when the failed task is not retried anymore according to the retry policy
+func (o *CruiseControlOperation) IsFailed() bool {
+	return o.CurrentTaskState() == v1beta1.CruiseControlTaskFailed
+}
+
 func (o *CruiseControlOperation) IsPaused() bool {
 	return o.GetLabels()["pause"] == "true"
 }
@@ -191,8 +283,10 @@ func (o *CruiseControlOperation) IsErrorPolicyIgnore() bool {
 	return o.Spec.ErrorPolicy == ErrorPolicyIgnore
 }
 
+// IsFinished returns true when the task completed successfully, its failure is ignored or it is not retried anymore
 func (o *CruiseControlOperation) IsFinished() bool {
-	return o.CurrentTaskState() == v1beta1.CruiseControlTaskCompleted || (o.Spec.ErrorPolicy == ErrorPolicyIgnore && o.CurrentTaskState() == v1beta1.CruiseControlTaskCompletedWithError)
+	return o.CurrentTaskState() == v1beta1.CruiseControlTaskCompleted || (o.Spec.ErrorPolicy == ErrorPolicyIgnore && o.CurrentTaskState() == v1beta1.CruiseControlTaskCompletedWithError) ||
+		o.IsFailed()
 }
 
 func (o *CruiseControlOperation) IsErrorPolicyRetry() bool {
@@ -208,7 +302,26 @@ func (o *CruiseControlOperation) IsWaitingForRetryExecution() bool {
 }
 
 func (o *CruiseControlOperation) IsReadyForRetryExecution() bool {
-	return o.IsWaitingForRetryExecution() && o.CurrentTaskFinished() != nil && o.CurrentTaskFinished().Add(time.Second*DefaultRetryBackOffDurationSec).Before(time.Now())
+	return o.IsWaitingForRetryExecution() && o.CurrentTaskFinished() != nil && o.CurrentTaskFinished().Add(o.RetryBackOff()).Before(time.Now())
+}
+
+// RetryBackOff returns the time between the failure of the current task and its retry. It is doubled after each retry
+// up to the maximum back off of the retry policy, and it is shortened by a jitter of at most its half. The jitter
+// is derived from the operation and the number of retries, so it does not change between reconciliations.
+func (o *CruiseControlOperation) RetryBackOff() time.Duration {
+	backOff := o.Status.RetryPolicy.GetBackOff()
+	maxBackOff := o.Status.RetryPolicy.GetMaxBackOff()
+	for i := 0; i < o.Status.RetryCount && backOff < maxBackOff; i++ {
+		backOff *= 2
+	}
+	if backOff > maxBackOff {
+		backOff = maxBackOff
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(fmt.Sprintf("%s/%d", o.GetUID(), o.Status.RetryCount)))
+	jitter := time.Duration(float64(backOff/2) * float64(hash.Sum32()) / math.MaxUint32)
+	return backOff - jitter
 }
 
 func (o *CruiseControlOperation) IsCurrentTaskRunning() bool {
@@ -220,6 +333,11 @@ func (o *CruiseControlOperation) IsCurrentTaskFinished() bool {
 }
 
 func (o *CruiseControlOperation) IsCurrentTaskOperationValid() bool {
-	return o.CurrentTaskOperation() == OperationAddBroker ||
-		o.CurrentTaskOperation() == OperationRebalance || o.CurrentTaskOperation() == OperationRemoveBroker || o.CurrentTaskOperation() == OperationStopExecution
+	switch o.CurrentTaskOperation() {
+	case OperationAddBroker, OperationRebalance, OperationRemoveBroker, OperationStopExecution, OperationDemoteBroker,
+		OperationFixOfflineReplicas, OperationTopicConfiguration, OperationRemoveDisks, OperationPauseSampling, OperationResumeSampling:
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,175 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KafkaACLResourceTypeTopic is the topic resource type of a Kafka ACL
+	KafkaACLResourceTypeTopic KafkaACLResourceType = "topic"
+	// KafkaACLResourceTypeGroup is the consumer group resource type of a Kafka ACL
+	KafkaACLResourceTypeGroup KafkaACLResourceType = "group"
+	// KafkaACLResourceTypeCluster is the cluster resource type of a Kafka ACL
+	KafkaACLResourceTypeCluster KafkaACLResourceType = "cluster"
+	// KafkaACLResourceTypeTransactionalID is the transactional id resource type of a Kafka ACL
+	KafkaACLResourceTypeTransactionalID KafkaACLResourceType = "transactionalId"
+	// KafkaACLResourceTypeDelegationToken is the delegation token resource type of a Kafka ACL
+	KafkaACLResourceTypeDelegationToken KafkaACLResourceType = "delegationToken"
+
+	// KafkaACLClusterResourceName is the only valid resource name of the cluster resource type
+	KafkaACLClusterResourceName = "kafka-cluster"
+	// KafkaACLWildcardHost is the host which matches every host
+	KafkaACLWildcardHost = "*"
+
+	// Kafka ACL operations. More info: https://kafka.apache.org/documentation/#operations_resources_and_protocols
+	KafkaACLOperationAll             KafkaACLOperation = "all"
+	KafkaACLOperationRead            KafkaACLOperation = "read"
+	KafkaACLOperationWrite           KafkaACLOperation = "write"
+	KafkaACLOperationCreate          KafkaACLOperation = "create"
+	KafkaACLOperationDelete          KafkaACLOperation = "delete"
+	KafkaACLOperationAlter           KafkaACLOperation = "alter"
+	KafkaACLOperationDescribe        KafkaACLOperation = "describe"
+	KafkaACLOperationClusterAction   KafkaACLOperation = "clusterAction"
+	KafkaACLOperationDescribeConfigs KafkaACLOperation = "describeConfigs"
+	KafkaACLOperationAlterConfigs    KafkaACLOperation = "alterConfigs"
+	KafkaACLOperationIdempotentWrite KafkaACLOperation = "idempotentWrite"
+
+	// KafkaACLPermissionTypeAllow allows the operation on the resource
+	KafkaACLPermissionTypeAllow KafkaACLPermissionType = "allow"
+	// KafkaACLPermissionTypeDeny denies the operation on the resource
+	KafkaACLPermissionTypeDeny KafkaACLPermissionType = "deny"
+
+	// ACLStateCreated describes the status of a KafkaACL as created
+	ACLStateCreated ACLState = "created"
+)
+
+// KafkaACLResourceType defines the type of the resource a Kafka ACL is applied on
+type KafkaACLResourceType string
+
+// KafkaACLOperation defines the operation a Kafka ACL allows or denies
+type KafkaACLOperation string
+
+// KafkaACLPermissionType defines whether a Kafka ACL allows or denies the operation
+type KafkaACLPermissionType string
+
+// ACLState defines the state of a KafkaACL
+type ACLState string
+
+// KafkaACLSpec defines the desired state of KafkaACL
+// +k8s:openapi-gen=true
+type KafkaACLSpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+	// Principal is the Kafka principal the rules are applied to in the <type>:<name> form, e.g. "User:CN=alice"
+	// +kubebuilder:validation:MinLength=1
+	Principal string `json:"principal"`
+	// Rules are the ACLs bound to the principal
+	// +kubebuilder:validation:MinItems=1
+	Rules []KafkaACLRule `json:"rules"`
+}
+
+// KafkaACLRule describes a single Kafka ACL bound to the principal of the KafkaACL
+type KafkaACLRule struct {
+	Resource KafkaACLResource `json:"resource"`
+	// +kubebuilder:validation:Enum={"all","read","write","create","delete","alter","describe","clusterAction","describeConfigs","alterConfigs","idempotentWrite"}
+	Operation KafkaACLOperation `json:"operation"`
+	// +kubebuilder:validation:Enum={"allow","deny"}
+	// +kubebuilder:default=allow
+	PermissionType KafkaACLPermissionType `json:"permissionType,omitempty"`
+	// Host is the host the principal is allowed or denied to access the resource from, "*" means every host
+	// +kubebuilder:default="*"
+	Host string `json:"host,omitempty"`
+}
+
+// KafkaACLResource describes the resource a Kafka ACL is applied on
+type KafkaACLResource struct {
+	// +kubebuilder:validation:Enum={"topic","group","cluster","transactionalId","delegationToken"}
+	Type KafkaACLResourceType `json:"type"`
+	// Name of the resource, "*" means every resource of the given type. It defaults to "kafka-cluster" for the cluster resource type.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +kubebuilder:validation:Enum={"literal","prefixed"}
+	// +kubebuilder:default=literal
+	PatternType KafkaPatternType `json:"patternType,omitempty"`
+}
+
+// KafkaACLStatus defines the observed state of KafkaACL
+// +k8s:openapi-gen=true
+type KafkaACLStatus struct {
+	State ACLState `json:"state"`
+	// ACLs lists the Kafka ACLs which are managed by this KafkaACL
+	ACLs []string `json:"acls,omitempty"`
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-kafka-banzaicloud-io-v1alpha1-kafkaacl,mutating=false,failurePolicy=fail,groups=kafka.banzaicloud.io,resources=kafkaacls,versions=v1alpha1,name=kafkaacls.kafka.banzaicloud.io,sideEffects=None,admissionReviewVersions=v1
+
+// KafkaACL is the Schema for the kafkaacls API
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+type KafkaACL struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaACLSpec   `json:"spec,omitempty"`
+	Status KafkaACLStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KafkaACLList contains a list of KafkaACL
+type KafkaACLList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaACL `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaACL{}, &KafkaACLList{})
+}
+
+// GetResourceName returns the name of the resource the rule is applied on
+func (r KafkaACLRule) GetResourceName() string {
+	if r.Resource.Type == KafkaACLResourceTypeCluster && r.Resource.Name == "" {
+		return KafkaACLClusterResourceName
+	}
+	return r.Resource.Name
+}
+
+// GetPatternType returns the resource pattern type of the rule
+func (r KafkaACLRule) GetPatternType() KafkaPatternType {
+	if r.Resource.PatternType == "" {
+		return KafkaPatternTypeDefault
+	}
+	return r.Resource.PatternType
+}
+
+// GetPermissionType returns whether the rule allows or denies the operation
+func (r KafkaACLRule) GetPermissionType() KafkaACLPermissionType {
+	if r.PermissionType == "" {
+		return KafkaACLPermissionTypeAllow
+	}
+	return r.PermissionType
+}
+
+// GetHost returns the host the rule is applied on
+func (r KafkaACLRule) GetHost() string {
+	if r.Host == "" {
+		return KafkaACLWildcardHost
+	}
+	return r.Host
+}
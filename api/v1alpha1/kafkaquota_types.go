@@ -0,0 +1,112 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KafkaQuotaEntityTypeClientID applies the quotas on a client id
+	KafkaQuotaEntityTypeClientID KafkaQuotaEntityType = "client-id"
+	// KafkaQuotaEntityTypeUser applies the quotas on a user principal
+	KafkaQuotaEntityTypeUser KafkaQuotaEntityType = "user"
+
+	// QuotaStateCreated describes the status of a KafkaQuota as created
+	QuotaStateCreated QuotaState = "created"
+)
+
+// KafkaQuotaEntityType defines the type of the entity the quotas are applied on
+type KafkaQuotaEntityType string
+
+// QuotaState defines the state of a KafkaQuota
+type QuotaState string
+
+// KafkaQuotas defines the client quotas enforced by the brokers.
+// More info: https://kafka.apache.org/documentation/#design_quotas
+type KafkaQuotas struct {
+	// ProducerByteRate is the upper bound of the bytes/sec the clients can produce to a single broker
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ProducerByteRate *int64 `json:"producerByteRate,omitempty"`
+	// ConsumerByteRate is the upper bound of the bytes/sec the clients can fetch from a single broker
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ConsumerByteRate *int64 `json:"consumerByteRate,omitempty"`
+	// RequestPercentage is the percentage of time the clients can use the request handler and network threads of a single broker
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RequestPercentage *int32 `json:"requestPercentage,omitempty"`
+}
+
+// KafkaQuotaEntity defines the entity the quotas are applied on
+type KafkaQuotaEntity struct {
+	// +kubebuilder:validation:Enum={"client-id","user"}
+	Type KafkaQuotaEntityType `json:"type"`
+	// Name of the entity. When it is not set, the quotas are applied as the default quotas of the entity type.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// IsDefault returns true if the entity refers to the default quotas of the entity type
+func (e KafkaQuotaEntity) IsDefault() bool {
+	return e.Name == ""
+}
+
+// KafkaQuotaSpec defines the desired state of KafkaQuota
+// +k8s:openapi-gen=true
+type KafkaQuotaSpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+	Entity     KafkaQuotaEntity `json:"entity"`
+	Quotas     KafkaQuotas      `json:"quotas"`
+}
+
+// KafkaQuotaStatus defines the observed state of KafkaQuota
+// +k8s:openapi-gen=true
+type KafkaQuotaStatus struct {
+	State QuotaState `json:"state"`
+	// Entity is the entity the quotas are currently applied on
+	Entity *KafkaQuotaEntity `json:"entity,omitempty"`
+	// ObservedGeneration is the generation of the KafkaQuota the quotas were last applied from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-kafka-banzaicloud-io-v1alpha1-kafkaquota,mutating=false,failurePolicy=fail,groups=kafka.banzaicloud.io,resources=kafkaquotas,versions=v1alpha1,name=kafkaquotas.kafka.banzaicloud.io,sideEffects=None,admissionReviewVersions=v1
+
+// KafkaQuota is the Schema for the kafkaquotas API
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+type KafkaQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaQuotaSpec   `json:"spec,omitempty"`
+	Status KafkaQuotaStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KafkaQuotaList contains a list of KafkaQuota
+type KafkaQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaQuota{}, &KafkaQuotaList{})
+}
@@ -16,13 +16,67 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
 )
 
 const (
 	MinPartitions        = -1
 	MinReplicationFactor = -1
+
+	// DefaultReplicationThrottleRate is the default upper bound of the replication traffic (bytes/sec)
+	// on each broker while the replication factor of a topic is being changed
+	DefaultReplicationThrottleRate int64 = 50 * 1024 * 1024
+
+	// TopicReassignmentStateInProgress describes that the replicas of the topic are being reassigned
+	TopicReassignmentStateInProgress TopicReassignmentState = "InProgress"
+	// TopicReassignmentStateCompleted describes that the replicas of the topic have been reassigned
+	TopicReassignmentStateCompleted TopicReassignmentState = "Completed"
+
+	// TopicConditionReady is true when every partition of the topic has a leader and enough in-sync replicas
+	// to satisfy min.insync.replicas
+	TopicConditionReady = "Ready"
+	// TopicConditionFullyReplicated is true when every replica of the topic is in-sync
+	TopicConditionFullyReplicated = "FullyReplicated"
+	// TopicConditionInSync is true when the topic on the Kafka cluster matches the KafkaTopic
+	TopicConditionInSync = "InSync"
+
+	// TopicDriftPolicyEnforce reverts the changes made to the topic outside of the KafkaTopic
+	TopicDriftPolicyEnforce TopicDriftPolicy = "enforce"
+	// TopicDriftPolicyReportOnly only reports the changes made to the topic outside of the KafkaTopic
+	TopicDriftPolicyReportOnly TopicDriftPolicy = "reportOnly"
+	// TopicDriftPolicyAdopt writes the configuration changes made outside of the KafkaTopic back into its spec
+	TopicDriftPolicyAdopt TopicDriftPolicy = "adopt"
+
+	// TopicDriftActionReverted describes that the drift has been reverted
+	TopicDriftActionReverted TopicDriftAction = "Reverted"
+	// TopicDriftActionReported describes that the drift has only been reported
+	TopicDriftActionReported TopicDriftAction = "Reported"
+	// TopicDriftActionAdopted describes that the drift has been written back into the spec
+	TopicDriftActionAdopted TopicDriftAction = "Adopted"
+
+	// TopicDeletionPolicyDelete deletes the topic and its data from the Kafka cluster when the KafkaTopic is deleted
+	TopicDeletionPolicyDelete TopicDeletionPolicy = "Delete"
+	// TopicDeletionPolicyRetain keeps the topic and its data on the Kafka cluster when the KafkaTopic is deleted,
+	// the settings applied by the operator (e.g. replication throttling) are removed from the topic
+	TopicDeletionPolicyRetain TopicDeletionPolicy = "Retain"
+	// TopicDeletionPolicyOrphan releases the KafkaTopic without connecting to the Kafka cluster,
+	// so the topic is left as it is even when the Kafka cluster is unreachable
+	TopicDeletionPolicyOrphan TopicDeletionPolicy = "Orphan"
 )
 
+// TopicDriftPolicy defines how the changes made to the topic outside of the KafkaTopic are handled
+type TopicDriftPolicy string
+
+// TopicDeletionPolicy defines what happens to the topic on the Kafka cluster when its KafkaTopic is deleted
+type TopicDeletionPolicy string
+
+// TopicDriftAction defines the action taken on a detected drift
+type TopicDriftAction string
+
+// TopicReassignmentState defines the state of the replica reassignment of a KafkaTopic
+type TopicReassignmentState string
+
 // KafkaTopicSpec defines the desired state of KafkaTopic
 // +k8s:openapi-gen=true
 type KafkaTopicSpec struct {
@@ -35,6 +89,51 @@ type KafkaTopicSpec struct {
 	ReplicationFactor int32             `json:"replicationFactor"`
 	Config            map[string]string `json:"config,omitempty"`
 	ClusterRef        ClusterReference  `json:"clusterRef"`
+	// ReplicationThrottleRate is the upper bound of the replication traffic (bytes/sec) on each broker
+	// while the replication factor of the existing topic is being changed. Defaults to 50MiB/s.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReplicationThrottleRate *int64 `json:"replicationThrottleRate,omitempty"`
+	// DriftPolicy defines how the changes made to the topic outside of the KafkaTopic are handled.
+	// "enforce" reverts them, "reportOnly" only records them and "adopt" writes the configuration changes back into the spec.
+	// The drift is handled by the periodic drift scan, otherwise the configuration is only pushed to Kafka when the KafkaTopic changes.
+	// +kubebuilder:validation:Enum={"enforce","reportOnly","adopt"}
+	// +kubebuilder:default=enforce
+	// +optional
+	DriftPolicy TopicDriftPolicy `json:"driftPolicy,omitempty"`
+	// DeletionPolicy defines what happens to the topic on the Kafka cluster when the KafkaTopic is deleted.
+	// "Delete" deletes the topic and its data, "Retain" keeps them and "Orphan" leaves the topic untouched
+	// without connecting to the Kafka cluster. Defaults to the topicDeletionPolicy of the KafkaCluster.
+	// +kubebuilder:validation:Enum={"Delete","Retain","Orphan"}
+	// +optional
+	DeletionPolicy TopicDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// GetDeletionPolicy returns the deletion policy of the topic, falling back to the default of the Kafka cluster
+func (spec *KafkaTopicSpec) GetDeletionPolicy(cluster *v1beta1.KafkaCluster) TopicDeletionPolicy {
+	if spec.DeletionPolicy != "" {
+		return spec.DeletionPolicy
+	}
+	if cluster != nil && cluster.Spec.TopicDeletionPolicy != "" {
+		return TopicDeletionPolicy(cluster.Spec.TopicDeletionPolicy)
+	}
+	return TopicDeletionPolicyDelete
+}
+
+// GetDriftPolicy returns the drift policy of the topic
+func (spec *KafkaTopicSpec) GetDriftPolicy() TopicDriftPolicy {
+	if spec.DriftPolicy == "" {
+		return TopicDriftPolicyEnforce
+	}
+	return spec.DriftPolicy
+}
+
+// GetReplicationThrottleRate returns the replication throttle rate to be used during replica reassignments
+func (spec *KafkaTopicSpec) GetReplicationThrottleRate() int64 {
+	if spec.ReplicationThrottleRate == nil {
+		return DefaultReplicationThrottleRate
+	}
+	return *spec.ReplicationThrottleRate
 }
 
 // KafkaTopicStatus defines the observed state of KafkaTopic
@@ -45,15 +144,111 @@ type KafkaTopicStatus struct {
 	// Manager of the Kafka topic can be changed by adding the "managedBy: <manager>" annotation to the KafkaTopic CR.
 	ManagedBy string     `json:"managedBy"`
 	State     TopicState `json:"state"`
+	// Reassignment describes the progress of the latest replication factor change of the topic
+	// +optional
+	Reassignment *TopicReassignmentStatus `json:"reassignment,omitempty"`
+	// Partitions describes the leader and the replicas of each partition of the topic
+	// +optional
+	Partitions []TopicPartitionStatus `json:"partitions,omitempty"`
+	// PartitionCount is the observed number of partitions of the topic
+	// +optional
+	PartitionCount int32 `json:"partitionCount,omitempty"`
+	// ReplicationFactor is the observed replication factor of the topic
+	// +optional
+	ReplicationFactor int32 `json:"replicationFactor,omitempty"`
+	// OfflinePartitions is the number of partitions without a leader
+	// +optional
+	OfflinePartitions int32 `json:"offlinePartitions,omitempty"`
+	// UnderReplicatedPartitions is the number of partitions with replicas which are not in-sync
+	// +optional
+	UnderReplicatedPartitions int32 `json:"underReplicatedPartitions,omitempty"`
+	// UnderMinISRPartitions is the number of partitions with less in-sync replicas than min.insync.replicas
+	// +optional
+	UnderMinISRPartitions int32 `json:"underMinISRPartitions,omitempty"`
+	// Config is the effective configuration of the topic including the broker defaults
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+	// ObservedGeneration is the generation of the KafkaTopic which was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Drift describes the last detected difference between the topic on the Kafka cluster and the KafkaTopic
+	// +optional
+	Drift *TopicDriftStatus `json:"drift,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// TopicDriftStatus describes a difference between the topic on the Kafka cluster and the KafkaTopic
+type TopicDriftStatus struct {
+	// Missing is true when the topic has been deleted from the Kafka cluster
+	// +optional
+	Missing bool `json:"missing,omitempty"`
+	// Config lists the topic configs which differ from the spec
+	// +optional
+	Config []TopicConfigDrift `json:"config,omitempty"`
+	// Action is the action taken on the drift according to the drift policy
+	Action TopicDriftAction `json:"action"`
+	// DetectedTime is the time when the drift has been detected
+	DetectedTime metav1.Time `json:"detectedTime"`
+}
+
+// TopicConfigDrift describes a topic config which differs from the spec
+type TopicConfigDrift struct {
+	Name string `json:"name"`
+	// Desired is the value of the config in the spec, empty when the config is not set in the spec
+	// +optional
+	Desired string `json:"desired,omitempty"`
+	// Actual is the value of the config on the Kafka cluster, empty when the config is not overridden on the topic
+	// +optional
+	Actual string `json:"actual,omitempty"`
+}
+
+// TopicPartitionStatus describes the observed state of a partition of a KafkaTopic
+type TopicPartitionStatus struct {
+	ID int32 `json:"id"`
+	// Leader is the id of the broker leading the partition, -1 when the partition is offline
+	Leader int32 `json:"leader"`
+	// Replicas are the ids of the brokers hosting the replicas of the partition
+	Replicas []int32 `json:"replicas,omitempty"`
+	// ISR are the ids of the brokers hosting in-sync replicas of the partition
+	ISR []int32 `json:"isr,omitempty"`
+	// OfflineReplicas are the ids of the brokers hosting offline replicas of the partition
+	OfflineReplicas []int32 `json:"offlineReplicas,omitempty"`
+}
+
+// TopicReassignmentStatus describes the progress of a replication factor change of a KafkaTopic
+type TopicReassignmentStatus struct {
+	State TopicReassignmentState `json:"state"`
+	// ReplicationFactor is the replication factor the replicas are being reassigned to
+	ReplicationFactor int32 `json:"replicationFactor"`
+	// Partitions is the number of partitions whose replicas had to be reassigned
+	Partitions int32 `json:"partitions"`
+	// PendingPartitions is the number of partitions whose reassignment is still in progress
+	PendingPartitions int32 `json:"pendingPartitions"`
+	// StartTime is the time when the reassignment has been submitted
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time when the reassignment has been finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
-// +kubebuilder:webhook:verbs=create;update,path=/validate-kafka-banzaicloud-io-v1alpha1-kafkatopic,mutating=false,failurePolicy=fail,groups=kafka.banzaicloud.io,resources=kafkatopics,versions=v1alpha1,name=kafkatopics.kafka.banzaicloud.io,sideEffects=None,admissionReviewVersions=v1
+// +kubebuilder:webhook:verbs=create;update;delete,path=/validate-kafka-banzaicloud-io-v1alpha1-kafkatopic,mutating=false,failurePolicy=fail,groups=kafka.banzaicloud.io,resources=kafkatopics,versions=v1alpha1,name=kafkatopics.kafka.banzaicloud.io,sideEffects=None,admissionReviewVersions=v1
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
 // KafkaTopic is the Schema for the kafkatopics API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.name",name="Topic",type="string"
+// +kubebuilder:printcolumn:JSONPath=".status.partitionCount",name="Partitions",type="integer"
+// +kubebuilder:printcolumn:JSONPath=".status.replicationFactor",name="Replication factor",type="integer"
+// +kubebuilder:printcolumn:JSONPath=".status.underReplicatedPartitions",name="Under replicated",type="integer"
+// +kubebuilder:printcolumn:JSONPath=".status.underMinISRPartitions",name="Under min ISR",type="integer"
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Ready\")].status",name="Ready",type="string"
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type="date"
 type KafkaTopic struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,109 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TopicImportStateInProgress describes that the topics are being imported
+	TopicImportStateInProgress TopicImportState = "InProgress"
+	// TopicImportStateCompleted describes that every topic has been imported
+	TopicImportStateCompleted TopicImportState = "Completed"
+	// TopicImportStateFailed describes that the import can not be carried out
+	TopicImportStateFailed TopicImportState = "Failed"
+)
+
+// DefaultTopicImportExcludePatterns exclude the internal topics of Kafka and Cruise Control,
+// e.g. __consumer_offsets, __transaction_state and __CruiseControlMetrics
+var DefaultTopicImportExcludePatterns = []string{"^__.*"}
+
+// TopicImportState defines the state of a KafkaTopicImport
+type TopicImportState string
+
+// KafkaTopicImportSpec defines the desired state of KafkaTopicImport
+// +k8s:openapi-gen=true
+type KafkaTopicImportSpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+	// IncludePatterns are regular expressions of the topic names to be imported, every topic is imported when empty
+	// +optional
+	IncludePatterns []string `json:"includePatterns,omitempty"`
+	// ExcludePatterns are regular expressions of the topic names not to be imported.
+	// Internal topics starting with "__" are always excluded.
+	// +optional
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+}
+
+// GetExcludePatterns returns the patterns of the topic names not to be imported including the internal topics
+func (spec *KafkaTopicImportSpec) GetExcludePatterns() []string {
+	return append(append([]string{}, DefaultTopicImportExcludePatterns...), spec.ExcludePatterns...)
+}
+
+// KafkaTopicImportStatus defines the observed state of KafkaTopicImport
+// +k8s:openapi-gen=true
+type KafkaTopicImportStatus struct {
+	State TopicImportState `json:"state"`
+	// Message describes why the import failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ImportedTopics lists the topics KafkaTopics have been created for
+	// +optional
+	ImportedTopics []string `json:"importedTopics,omitempty"`
+	// SkippedTopics lists the topics which already had a KafkaTopic
+	// +optional
+	SkippedTopics []string `json:"skippedTopics,omitempty"`
+	// FailedTopics lists the topics whose KafkaTopic could not be created, they are retried until the import completes
+	// +optional
+	FailedTopics []TopicImportFailure `json:"failedTopics,omitempty"`
+	// CompletionTime is the time when every topic has been imported
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// TopicImportFailure describes why the KafkaTopic of a topic could not be created
+type TopicImportFailure struct {
+	Topic  string `json:"topic"`
+	Reason string `json:"reason"`
+}
+
+// KafkaTopicImport is the Schema for the kafkatopicimports API.
+// It creates KafkaTopics for the existing topics of a Kafka cluster once.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.state",name="State",type="string"
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type="date"
+type KafkaTopicImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaTopicImportSpec   `json:"spec,omitempty"`
+	Status KafkaTopicImportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KafkaTopicImportList contains a list of KafkaTopicImport
+type KafkaTopicImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaTopicImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaTopicImport{}, &KafkaTopicImportList{})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/banzaicloud/koperator/api/util"
 
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -33,6 +34,23 @@ type KafkaUserSpec struct {
 	IncludeJKS     bool              `json:"includeJKS,omitempty"`
 	CreateCert     *bool             `json:"createCert,omitempty"`
 	PKIBackendSpec *PKIBackendSpec   `json:"pkiBackendSpec,omitempty"`
+	// Authentication defines how the user authenticates to the Kafka cluster.
+	// When it is not set, the user is authenticated with a TLS client certificate (unless createCert is set to false).
+	// +optional
+	Authentication *UserAuthentication `json:"authentication,omitempty"`
+	// Quotas defines the client quotas applied on the user principal.
+	// +optional
+	Quotas *KafkaQuotas `json:"quotas,omitempty"`
+}
+
+// UserAuthentication defines the authentication mechanism and the credentials of the KafkaUser
+type UserAuthentication struct {
+	// +kubebuilder:validation:Enum={"tls","scram-sha-512"}
+	Type UserAuthenticationType `json:"type"`
+	// PasswordSecretRef refers to a key of a Secret in the namespace of the KafkaUser which holds the password of the user.
+	// When it is not set, a random password is generated. It is only used with the scram-sha-512 authentication type.
+	// +optional
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
 }
 
 type PKIBackendSpec struct {
@@ -57,6 +75,14 @@ type UserTopicGrant struct {
 type KafkaUserStatus struct {
 	State UserState `json:"state"`
 	ACLs  []string  `json:"acls,omitempty"`
+	// AddedACLs lists the ACLs which were created during the last reconciliation that changed the ACLs of the user
+	AddedACLs []string `json:"addedACLs,omitempty"`
+	// RemovedACLs lists the stale ACLs which were removed during the last reconciliation that changed the ACLs of the user
+	RemovedACLs []string `json:"removedACLs,omitempty"`
+	// Quotas are the client quotas currently applied on the user principal
+	Quotas *KafkaQuotas `json:"quotas,omitempty"`
+	// ScramCredentials is true when the SCRAM-SHA-512 credentials of the user were created in Kafka
+	ScramCredentials bool `json:"scramCredentials,omitempty"`
 }
 
 // KafkaUser is the Schema for the kafka users API
@@ -86,12 +112,21 @@ func init() {
 }
 
 func (spec *KafkaUserSpec) GetIfCertShouldBeCreated() bool {
+	// SCRAM users don't need any certificate to authenticate
+	if spec.IsScramAuthentication() {
+		return false
+	}
 	if spec.CreateCert != nil {
 		return *spec.CreateCert
 	}
 	return true
 }
 
+// IsScramAuthentication returns true if the user authenticates with SCRAM-SHA-512 credentials
+func (spec *KafkaUserSpec) IsScramAuthentication() bool {
+	return spec.Authentication != nil && spec.Authentication.Type == UserAuthenticationTypeScramSha512
+}
+
 // GetAnnotations returns Annotations to use for certificate or certificate signing request object
 func (spec *KafkaUserSpec) GetAnnotations() map[string]string {
 	return util.CloneMap(spec.Annotations)
@@ -20,7 +20,10 @@
 package v1alpha1
 
 import (
-	"github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -39,6 +42,21 @@ func (in *ClusterReference) DeepCopy() *ClusterReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlBrokerLoadDelta) DeepCopyInto(out *CruiseControlBrokerLoadDelta) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlBrokerLoadDelta.
+func (in *CruiseControlBrokerLoadDelta) DeepCopy() *CruiseControlBrokerLoadDelta {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlBrokerLoadDelta)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CruiseControlOperation) DeepCopyInto(out *CruiseControlOperation) {
 	*out = *in
@@ -106,6 +124,11 @@ func (in *CruiseControlOperationSpec) DeepCopyInto(out *CruiseControlOperationSp
 		*out = new(int)
 		**out = **in
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(v1beta1.CruiseControlOperationRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationSpec.
@@ -133,6 +156,16 @@ func (in *CruiseControlOperationStatus) DeepCopyInto(out *CruiseControlOperation
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(v1beta1.CruiseControlOperationRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proposal != nil {
+		in, out := &in.Proposal, &out.Proposal
+		*out = new(CruiseControlProposal)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationStatus.
@@ -145,6 +178,47 @@ func (in *CruiseControlOperationStatus) DeepCopy() *CruiseControlOperationStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlProposal) DeepCopyInto(out *CruiseControlProposal) {
+	*out = *in
+	if in.Generated != nil {
+		in, out := &in.Generated, &out.Generated
+		*out = (*in).DeepCopy()
+	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]CruiseControlBrokerLoadDelta, len(*in))
+		copy(*out, *in)
+	}
+	if in.GoalsViolatedBefore != nil {
+		in, out := &in.GoalsViolatedBefore, &out.GoalsViolatedBefore
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GoalsViolatedAfter != nil {
+		in, out := &in.GoalsViolatedAfter, &out.GoalsViolatedAfter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlProposal.
+func (in *CruiseControlProposal) DeepCopy() *CruiseControlProposal {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlProposal)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CruiseControlTask) DeepCopyInto(out *CruiseControlTask) {
 	*out = *in
@@ -187,13 +261,286 @@ func (in *CruiseControlTask) DeepCopy() *CruiseControlTask {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACL) DeepCopyInto(out *KafkaACL) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACL.
+func (in *KafkaACL) DeepCopy() *KafkaACL {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaACL) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLList) DeepCopyInto(out *KafkaACLList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KafkaACL, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLList.
+func (in *KafkaACLList) DeepCopy() *KafkaACLList {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaACLList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLResource) DeepCopyInto(out *KafkaACLResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLResource.
+func (in *KafkaACLResource) DeepCopy() *KafkaACLResource {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLRule) DeepCopyInto(out *KafkaACLRule) {
+	*out = *in
+	out.Resource = in.Resource
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLRule.
+func (in *KafkaACLRule) DeepCopy() *KafkaACLRule {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLSpec) DeepCopyInto(out *KafkaACLSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]KafkaACLRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLSpec.
+func (in *KafkaACLSpec) DeepCopy() *KafkaACLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLStatus) DeepCopyInto(out *KafkaACLStatus) {
+	*out = *in
+	if in.ACLs != nil {
+		in, out := &in.ACLs, &out.ACLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLStatus.
+func (in *KafkaACLStatus) DeepCopy() *KafkaACLStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuota) DeepCopyInto(out *KafkaQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuota.
+func (in *KafkaQuota) DeepCopy() *KafkaQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotaEntity) DeepCopyInto(out *KafkaQuotaEntity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotaEntity.
+func (in *KafkaQuotaEntity) DeepCopy() *KafkaQuotaEntity {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotaEntity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotaList) DeepCopyInto(out *KafkaQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KafkaQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotaList.
+func (in *KafkaQuotaList) DeepCopy() *KafkaQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotaSpec) DeepCopyInto(out *KafkaQuotaSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	out.Entity = in.Entity
+	in.Quotas.DeepCopyInto(&out.Quotas)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotaSpec.
+func (in *KafkaQuotaSpec) DeepCopy() *KafkaQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotaStatus) DeepCopyInto(out *KafkaQuotaStatus) {
+	*out = *in
+	if in.Entity != nil {
+		in, out := &in.Entity, &out.Entity
+		*out = new(KafkaQuotaEntity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotaStatus.
+func (in *KafkaQuotaStatus) DeepCopy() *KafkaQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotas) DeepCopyInto(out *KafkaQuotas) {
+	*out = *in
+	if in.ProducerByteRate != nil {
+		in, out := &in.ProducerByteRate, &out.ProducerByteRate
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ConsumerByteRate != nil {
+		in, out := &in.ConsumerByteRate, &out.ConsumerByteRate
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestPercentage != nil {
+		in, out := &in.RequestPercentage, &out.RequestPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotas.
+func (in *KafkaQuotas) DeepCopy() *KafkaQuotas {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopic) DeepCopyInto(out *KafkaTopic) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopic.
@@ -214,6 +561,125 @@ func (in *KafkaTopic) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicImport) DeepCopyInto(out *KafkaTopicImport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicImport.
+func (in *KafkaTopicImport) DeepCopy() *KafkaTopicImport {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaTopicImport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicImportList) DeepCopyInto(out *KafkaTopicImportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KafkaTopicImport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicImportList.
+func (in *KafkaTopicImportList) DeepCopy() *KafkaTopicImportList {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicImportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KafkaTopicImportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicImportSpec) DeepCopyInto(out *KafkaTopicImportSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.IncludePatterns != nil {
+		in, out := &in.IncludePatterns, &out.IncludePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludePatterns != nil {
+		in, out := &in.ExcludePatterns, &out.ExcludePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicImportSpec.
+func (in *KafkaTopicImportSpec) DeepCopy() *KafkaTopicImportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicImportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicImportStatus) DeepCopyInto(out *KafkaTopicImportStatus) {
+	*out = *in
+	if in.ImportedTopics != nil {
+		in, out := &in.ImportedTopics, &out.ImportedTopics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedTopics != nil {
+		in, out := &in.SkippedTopics, &out.SkippedTopics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedTopics != nil {
+		in, out := &in.FailedTopics, &out.FailedTopics
+		*out = make([]TopicImportFailure, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicImportStatus.
+func (in *KafkaTopicImportStatus) DeepCopy() *KafkaTopicImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopicList) DeepCopyInto(out *KafkaTopicList) {
 	*out = *in
@@ -257,6 +723,11 @@ func (in *KafkaTopicSpec) DeepCopyInto(out *KafkaTopicSpec) {
 		}
 	}
 	out.ClusterRef = in.ClusterRef
+	if in.ReplicationThrottleRate != nil {
+		in, out := &in.ReplicationThrottleRate, &out.ReplicationThrottleRate
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicSpec.
@@ -272,6 +743,37 @@ func (in *KafkaTopicSpec) DeepCopy() *KafkaTopicSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopicStatus) DeepCopyInto(out *KafkaTopicStatus) {
 	*out = *in
+	if in.Reassignment != nil {
+		in, out := &in.Reassignment, &out.Reassignment
+		*out = new(TopicReassignmentStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Partitions != nil {
+		in, out := &in.Partitions, &out.Partitions
+		*out = make([]TopicPartitionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(TopicDriftStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicStatus.
@@ -374,6 +876,16 @@ func (in *KafkaUserSpec) DeepCopyInto(out *KafkaUserSpec) {
 		*out = new(PKIBackendSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(UserAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(KafkaQuotas)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaUserSpec.
@@ -394,6 +906,21 @@ func (in *KafkaUserStatus) DeepCopyInto(out *KafkaUserStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AddedACLs != nil {
+		in, out := &in.AddedACLs, &out.AddedACLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemovedACLs != nil {
+		in, out := &in.RemovedACLs, &out.RemovedACLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(KafkaQuotas)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaUserStatus.
@@ -411,7 +938,7 @@ func (in *PKIBackendSpec) DeepCopyInto(out *PKIBackendSpec) {
 	*out = *in
 	if in.IssuerRef != nil {
 		in, out := &in.IssuerRef, &out.IssuerRef
-		*out = new(v1.ObjectReference)
+		*out = new(metav1.ObjectReference)
 		**out = **in
 	}
 }
@@ -426,6 +953,130 @@ func (in *PKIBackendSpec) DeepCopy() *PKIBackendSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicConfigDrift) DeepCopyInto(out *TopicConfigDrift) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicConfigDrift.
+func (in *TopicConfigDrift) DeepCopy() *TopicConfigDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicConfigDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicDriftStatus) DeepCopyInto(out *TopicDriftStatus) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make([]TopicConfigDrift, len(*in))
+		copy(*out, *in)
+	}
+	in.DetectedTime.DeepCopyInto(&out.DetectedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicDriftStatus.
+func (in *TopicDriftStatus) DeepCopy() *TopicDriftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicDriftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicImportFailure) DeepCopyInto(out *TopicImportFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicImportFailure.
+func (in *TopicImportFailure) DeepCopy() *TopicImportFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicImportFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicPartitionStatus) DeepCopyInto(out *TopicPartitionStatus) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.ISR != nil {
+		in, out := &in.ISR, &out.ISR
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.OfflineReplicas != nil {
+		in, out := &in.OfflineReplicas, &out.OfflineReplicas
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicPartitionStatus.
+func (in *TopicPartitionStatus) DeepCopy() *TopicPartitionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicPartitionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicReassignmentStatus) DeepCopyInto(out *TopicReassignmentStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicReassignmentStatus.
+func (in *TopicReassignmentStatus) DeepCopy() *TopicReassignmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicReassignmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserAuthentication) DeepCopyInto(out *UserAuthentication) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserAuthentication.
+func (in *UserAuthentication) DeepCopy() *UserAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(UserAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserTopicGrant) DeepCopyInto(out *UserTopicGrant) {
 	*out = *in
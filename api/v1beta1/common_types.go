@@ -63,6 +63,23 @@ type KafkaVersion struct {
 // PKIBackend represents an interface implementing the PKIManager
 type PKIBackend string
 
+// CanaryUpgradeState holds the state of the canary upgrade of the brokers
+type CanaryUpgradeState string
+
+// KafkaVersionUpgradeState holds the state of the upgrade of the brokers to a Kafka version
+type KafkaVersionUpgradeState string
+
+// BrokerReplacementState holds the state of the replacement of a broker whose storage is lost
+type BrokerReplacementState string
+
+// IsActive returns true if the broker is being replaced
+func (s BrokerReplacementState) IsActive() bool {
+	return s == BrokerReplacementRequired || s == BrokerReplacementRunning
+}
+
+// RebalanceTrigger describes what requested a rebalance of the cluster
+type RebalanceTrigger string
+
 // CruiseControlVolumeState holds information about the state of volume rebalance
 type CruiseControlVolumeState string
 
@@ -192,6 +209,16 @@ type GracefulActionState struct {
 	CruiseControlOperationReference *corev1.LocalObjectReference `json:"cruiseControlOperationReference,omitempty"`
 	// VolumeStates holds the information about the CC disk rebalance states and CruiseControlOperation reference
 	VolumeStates map[string]VolumeState `json:"volumeStates,omitempty"`
+	// ErrorMessage holds the reason why the graceful action of the broker could not be finished
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// EvacuationAttempts is the number of times the graceful downscale of the broker was requested again because
+	// the broker still held partition replicas after it
+	// +optional
+	EvacuationAttempts int `json:"evacuationAttempts,omitempty"`
+	// ReplacementState holds the state of the replacement of the broker
+	// +optional
+	ReplacementState BrokerReplacementState `json:"replacementState,omitempty"`
 }
 
 type VolumeState struct {
@@ -268,6 +295,8 @@ const (
 	GracefulDiskRebalanceCompletedWithError CruiseControlVolumeState = "GracefulDiskRebalanceCompletedWithError"
 	// GracefulDiskRebalancePaused states that the broker volume rebalance task is completed with an error and it will not be retried, it is paused
 	GracefulDiskRebalancePaused CruiseControlVolumeState = "GracefulDiskRebalancePaused"
+	// GracefulDiskDrained states that the broker volume holds no partition replicas anymore and it is removed from the broker
+	GracefulDiskDrained CruiseControlVolumeState = "GracefulDiskDrained"
 
 	// CruiseControlTopicNotReady states the CC required topic is not yet created
 	CruiseControlTopicNotReady CruiseControlTopicStatus = "CruiseControlTopicNotReady"
@@ -281,6 +310,8 @@ const (
 	CruiseControlTaskCompleted CruiseControlUserTaskState = "Completed"
 	// CruiseControlTaskCompletedWithError states the CC task completed with error
 	CruiseControlTaskCompletedWithError CruiseControlUserTaskState = "CompletedWithError"
+	// CruiseControlTaskFailed states the CC task completed with error and it is not retried anymore
+	CruiseControlTaskFailed CruiseControlUserTaskState = "Failed"
 	// KafkaClusterReconciling states that the cluster is still in reconciling stage
 	KafkaClusterReconciling ClusterState = "ClusterReconciling"
 	// KafkaClusterRollingUpgrading states that the cluster is rolling upgrading
@@ -288,6 +319,40 @@ const (
 	// KafkaClusterRunning states that the cluster is in running state
 	KafkaClusterRunning ClusterState = "ClusterRunning"
 
+	// CanaryUpgradeInProgress states that the canary brokers are being upgraded
+	CanaryUpgradeInProgress CanaryUpgradeState = "CanaryUpgradeInProgress"
+	// CanaryUpgradeSoaking states that the canary brokers have been upgraded and their health is being watched
+	CanaryUpgradeSoaking CanaryUpgradeState = "CanaryUpgradeSoaking"
+	// CanaryUpgradeSucceeded states that the canary brokers stayed healthy and the other brokers can be upgraded
+	CanaryUpgradeSucceeded CanaryUpgradeState = "CanaryUpgradeSucceeded"
+	// CanaryUpgradeRolledBack states that the health of the cluster degraded and the canary brokers were rolled back
+	CanaryUpgradeRolledBack CanaryUpgradeState = "CanaryUpgradeRolledBack"
+
+	// KafkaVersionUpgradeRollingBinaries states that the brokers are being upgraded to the new Kafka version
+	// with the previous protocol version
+	KafkaVersionUpgradeRollingBinaries KafkaVersionUpgradeState = "KafkaVersionUpgradeRollingBinaries"
+	// KafkaVersionUpgradeBumpingProtocol states that every broker reports the new Kafka version and the brokers
+	// are being restarted with the new protocol version
+	KafkaVersionUpgradeBumpingProtocol KafkaVersionUpgradeState = "KafkaVersionUpgradeBumpingProtocol"
+	// KafkaVersionUpgradeBumpingMessageFormat states that the brokers run the new protocol version and they are being
+	// restarted with the new log message format version
+	KafkaVersionUpgradeBumpingMessageFormat KafkaVersionUpgradeState = "KafkaVersionUpgradeBumpingMessageFormat"
+	// KafkaVersionUpgradeCompleted states that the brokers run the new Kafka version with the new protocol version
+	KafkaVersionUpgradeCompleted KafkaVersionUpgradeState = "KafkaVersionUpgradeCompleted"
+
+	// BrokerReplacementRequired states that the broker replacement is requested and its pod and PVCs are going to be deleted
+	BrokerReplacementRequired BrokerReplacementState = "BrokerReplacementRequired"
+	// BrokerReplacementRunning states that the pod and PVCs of the broker are deleted, and the broker is being recreated
+	// with new storage and its partitions re-replicated by Cruise Control
+	BrokerReplacementRunning BrokerReplacementState = "BrokerReplacementRunning"
+	// BrokerReplacementSucceeded states that the broker is recreated and its partitions are re-replicated
+	BrokerReplacementSucceeded BrokerReplacementState = "BrokerReplacementSucceeded"
+
+	// RebalanceTriggerSchedule states that the rebalance was requested by the rebalance schedule
+	RebalanceTriggerSchedule RebalanceTrigger = "Schedule"
+	// RebalanceTriggerImbalance states that the rebalance was requested as the load of the brokers was imbalanced
+	RebalanceTriggerImbalance RebalanceTrigger = "Imbalance"
+
 	// ConfigInSync states that the generated brokerConfig is in sync with the Broker
 	ConfigInSync ConfigurationState = "ConfigInSync"
 	// ConfigOutOfSync states that the generated brokerConfig is out of sync with the Broker
@@ -16,7 +16,9 @@ package v1beta1
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 
@@ -47,6 +49,12 @@ const (
 	DefaultEnvoyAdminPort = 8081
 	// DefaultBrokerTerminationGracePeriod default kafka pod termination grace period
 	DefaultBrokerTerminationGracePeriod = 120
+	// DefaultCruiseControlOperationRetryBackOff is the default time between the failure of a CruiseControlOperation
+	// task and its first retry
+	DefaultCruiseControlOperationRetryBackOff = 30 * time.Second
+	// DefaultCruiseControlOperationMaxRetryBackOff is the default longest time between two retries of
+	// a CruiseControlOperation task
+	DefaultCruiseControlOperationMaxRetryBackOff = time.Hour
 
 	// AppLabelKey is used to represent the reserved operator label, "app"
 	AppLabelKey = "app"
@@ -54,6 +62,23 @@ const (
 	KafkaCRLabelKey = "kafka_cr"
 	// BrokerIdLabelKey is used to represent the reserved operator label, "brokerId"
 	BrokerIdLabelKey = "brokerId"
+	// BrokerConfigGroupLabelKey is used to represent the reserved operator label of the broker pods which holds
+	// the broker config group of the broker, "brokerConfigGroup"
+	BrokerConfigGroupLabelKey = "brokerConfigGroup"
+	// RestartApprovedAnnotationKey is the annotation of the broker pods which approves their restart when the rolling
+	// upgrade requires approval, "kafka.banzaicloud.io/restart-approved"
+	RestartApprovedAnnotationKey = "kafka.banzaicloud.io/restart-approved"
+	// PVCDeleteAfterAnnotationKey is the annotation of the retained PVCs of the removed brokers which holds the time
+	// in RFC3339 format after which they are deleted, "kafka.banzaicloud.io/delete-after"
+	PVCDeleteAfterAnnotationKey = "kafka.banzaicloud.io/delete-after"
+	// ReplaceBrokerAnnotationKey is the annotation of the broker pods which requests the replacement of the broker
+	// whose storage is lost, e.g. with its node, "kafka.banzaicloud.io/replace-broker"
+	ReplaceBrokerAnnotationKey = "kafka.banzaicloud.io/replace-broker"
+
+	// ProcessRoleBroker is the KRaft process role of the nodes that handle the client requests and store the data
+	ProcessRoleBroker = "broker"
+	// ProcessRoleController is the KRaft process role of the nodes that take part in the metadata quorum
+	ProcessRoleController = "controller"
 )
 
 // KafkaClusterSpec defines the desired state of KafkaCluster
@@ -62,9 +87,16 @@ type KafkaClusterSpec struct {
 	ListenersConfig        ListenersConfig `json:"listenersConfig"`
 	// Custom ports to expose in the container. Example use case: a custom kafka distribution, that includes an integrated metrics api endpoint
 	AdditionalPorts []corev1.ContainerPort `json:"additionalPorts,omitempty"`
+	// KRaftMode enables running the Kafka cluster without ZooKeeper using the KRaft consensus protocol.
+	// When enabled, the role(s) of each broker must be set through the processRoles field of the broker config
+	// (or broker config group) and zkAddresses must be left empty.
+	// +optional
+	KRaftMode bool `json:"kRaft,omitempty"`
 	// ZKAddresses specifies the ZooKeeper connection string
 	// in the form hostname:port where host and port are the host and port of a ZooKeeper server.
-	ZKAddresses []string `json:"zkAddresses"`
+	// It is required unless the cluster runs in KRaft mode.
+	// +optional
+	ZKAddresses []string `json:"zkAddresses,omitempty"`
 	// ZKPath specifies the ZooKeeper chroot path as part
 	// of its ZooKeeper connection string which puts its data under some path in the global ZooKeeper namespace.
 	ZKPath                      string                  `json:"zkPath,omitempty"`
@@ -77,6 +109,28 @@ type KafkaClusterSpec struct {
 	Brokers                     []Broker                `json:"brokers"`
 	DisruptionBudget            DisruptionBudget        `json:"disruptionBudget,omitempty"`
 	RollingUpgradeConfig        RollingUpgradeConfig    `json:"rollingUpgradeConfig"`
+	// KafkaVersion is the Kafka version the brokers are upgraded to. When it is set the operator manages the
+	// inter.broker.protocol.version and log.message.format.version of the brokers: the new binaries are rolled out
+	// with the protocol version in effect (set in readOnlyConfig or derived from the running Kafka version) first,
+	// the protocol version is bumped once every broker reports the new Kafka version, and the log message format
+	// version is bumped after the brokers run the new protocol version. The image of the brokers must ship this
+	// version. Once it is removed the brokers keep the protocol versions of the last upgrade unless they are set in
+	// the readOnlyConfig. It is ignored in KRaft mode.
+	// +kubebuilder:validation:Pattern=`^[0-9]+\.[0-9]+\.[0-9]+$`
+	// +optional
+	KafkaVersion string `json:"kafkaVersion,omitempty"`
+	// ScaleBrokerConfigGroup is the broker config group whose number of brokers is driven by spec.replicas,
+	// e.g. by a HorizontalPodAutoscaler through the /scale subresource
+	// +optional
+	ScaleBrokerConfigGroup string `json:"scaleBrokerConfigGroup,omitempty"`
+	// Replicas is the number of brokers of the scaleBrokerConfigGroup broker config group,
+	// it takes precedence over the replicas set in the broker config group
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// DecommissionConfig defines how the brokers removed from the cluster are decommissioned
+	// +optional
+	DecommissionConfig DecommissionConfig `json:"decommissionConfig,omitempty"`
 	// +kubebuilder:validation:Enum=envoy;istioingress
 	// IngressController specifies the type of the ingress controller to be used for external listeners. The `istioingress` ingress controller type requires the `spec.istioControlPlane` field to be populated as well.
 	IngressController string `json:"ingressController,omitempty"`
@@ -103,6 +157,12 @@ type KafkaClusterSpec struct {
 	// The secret must contain the keystore, truststore jks files and the password for them in base64 encoded format
 	// under the keystore.jks, truststore.jks, password data fields.
 	ClientSSLCertSecret *corev1.LocalObjectReference `json:"clientSSLCertSecret,omitempty"`
+	// TopicDeletionPolicy is the default deletion policy of the KafkaTopics of the cluster which do not set one.
+	// "Delete" deletes the topic and its data when the KafkaTopic is deleted, "Retain" keeps them and
+	// "Orphan" leaves the topic untouched without connecting to the Kafka cluster. Defaults to "Delete".
+	// +kubebuilder:validation:Enum={"Delete","Retain","Orphan"}
+	// +optional
+	TopicDeletionPolicy string `json:"topicDeletionPolicy,omitempty"`
 }
 
 // KafkaClusterStatus defines the observed state of KafkaCluster
@@ -113,6 +173,62 @@ type KafkaClusterStatus struct {
 	RollingUpgrade           RollingUpgradeStatus     `json:"rollingUpgradeStatus,omitempty"`
 	AlertCount               int                      `json:"alertCount"`
 	ListenerStatuses         ListenerStatuses         `json:"listenerStatuses,omitempty"`
+	// ClusterID is the identifier of the Kafka cluster which is used to format the storage of the brokers in KRaft mode.
+	// It is generated once by the operator and must not change during the lifetime of the cluster.
+	ClusterID string `json:"clusterID,omitempty"`
+	// KafkaVersionUpgrade tracks the upgrade of the brokers to the Kafka version set in spec.kafkaVersion
+	// +optional
+	KafkaVersionUpgrade *KafkaVersionUpgradeStatus `json:"kafkaVersionUpgrade,omitempty"`
+	// LastBrokerID is the highest broker ID used by the cluster so far, the broker IDs allocated for the broker
+	// config groups with replicas are always higher than this
+	// +optional
+	LastBrokerID *int32 `json:"lastBrokerID,omitempty"`
+	// Replicas is the number of brokers of the scaleBrokerConfigGroup broker config group
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the broker pods of the scaleBrokerConfigGroup broker config group
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// Rebalance tracks the rebalances requested by the rebalance config of Cruise Control
+	// +optional
+	Rebalance *RebalanceStatus `json:"rebalance,omitempty"`
+}
+
+// RebalanceStatus defines the status of the scheduled and imbalance triggered rebalances
+type RebalanceStatus struct {
+	// LastScheduleTime is the time the last scheduled rebalance was due
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastImbalanceCheckTime is the time the load of the brokers was last checked for imbalance
+	// +optional
+	LastImbalanceCheckTime *metav1.Time `json:"lastImbalanceCheckTime,omitempty"`
+	// Imbalance is the load imbalance of the brokers in percent found by the last check
+	// +optional
+	Imbalance *int32 `json:"imbalance,omitempty"`
+	// LastRebalanceTime is the time the last rebalance was requested
+	// +optional
+	LastRebalanceTime *metav1.Time `json:"lastRebalanceTime,omitempty"`
+	// LastRebalanceFinishTime is the time the last rebalance was found finished
+	// +optional
+	LastRebalanceFinishTime *metav1.Time `json:"lastRebalanceFinishTime,omitempty"`
+	// LastTrigger describes what requested the last rebalance
+	// +optional
+	LastTrigger RebalanceTrigger `json:"lastTrigger,omitempty"`
+	// CruiseControlOperationReference refers to the CruiseControlOperation of the last rebalance
+	// +optional
+	CruiseControlOperationReference *corev1.LocalObjectReference `json:"cruiseControlOperationReference,omitempty"`
+}
+
+// KafkaVersionUpgradeStatus defines the status of the upgrade of the brokers to a Kafka version
+type KafkaVersionUpgradeStatus struct {
+	State KafkaVersionUpgradeState `json:"state"`
+	// TargetVersion is the Kafka version the brokers are upgraded to
+	TargetVersion string `json:"targetVersion"`
+	// ProtocolVersion is the inter.broker.protocol.version set on the brokers
+	ProtocolVersion string `json:"protocolVersion"`
+	// MessageFormatVersion is the log.message.format.version set on the brokers
+	// +optional
+	MessageFormatVersion string `json:"messageFormatVersion,omitempty"`
 }
 
 // RollingUpgradeStatus defines status of rolling upgrade
@@ -121,6 +237,49 @@ type RollingUpgradeStatus struct {
 	// ErrorCount keeps track the number of errors reported by alerts labeled with 'rollingupgrade'.
 	// It's reset once these alerts stop firing.
 	ErrorCount int `json:"errorCount"`
+	// LeaderMigratedBrokers are the brokers whose partition leaderships were migrated before their restart
+	// and which are waiting for the preferred leader election
+	// +optional
+	LeaderMigratedBrokers []string `json:"leaderMigratedBrokers,omitempty"`
+	// LeaderMigrationStartedAt is the time when the leadership migration of the brokers about to be restarted started
+	// +optional
+	LeaderMigrationStartedAt *metav1.Time `json:"leaderMigrationStartedAt,omitempty"`
+	// CompletedBrokers are the brokers restarted by the current (or the last) rolling upgrade
+	// +optional
+	CompletedBrokers []string `json:"completedBrokers,omitempty"`
+	// PendingBrokers are the brokers which have not been checked or restarted by the current rolling upgrade yet
+	// +optional
+	PendingBrokers []string `json:"pendingBrokers,omitempty"`
+	// CurrentBrokers are the brokers being restarted or waiting for the approval of their restart,
+	// more than one when the brokers of a rack are restarted at once
+	// +optional
+	CurrentBrokers []string `json:"currentBrokers,omitempty"`
+	// PausedAt is the time when the rolling upgrade was paused
+	// +optional
+	PausedAt *metav1.Time `json:"pausedAt,omitempty"`
+	// Canary is the status of the canary upgrade of the broker images
+	// +optional
+	Canary *CanaryUpgradeStatus `json:"canary,omitempty"`
+}
+
+// CanaryUpgradeStatus defines the status of the canary upgrade of the broker images
+type CanaryUpgradeStatus struct {
+	State CanaryUpgradeState `json:"state"`
+	// Brokers are the canary brokers affected by the upgrade
+	// +optional
+	Brokers []string `json:"brokers,omitempty"`
+	// PreviousImages are the images of the upgraded brokers before the upgrade by broker ID, the brokers are rolled back to them
+	PreviousImages map[string]string `json:"previousImages"`
+	// TargetImages are the images of the upgraded brokers by broker ID
+	TargetImages map[string]string `json:"targetImages"`
+	// StartedAt is the time when the canary upgrade started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// SoakStartedAt is the time when every canary broker was upgraded and healthy
+	// +optional
+	SoakStartedAt *metav1.Time `json:"soakStartedAt,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // RollingUpgradeConfig defines the desired config of the RollingUpgrade
@@ -130,6 +289,107 @@ type RollingUpgradeConfig struct {
 	// distinct broker replicas with either offline replicas or out of sync replicas and the number of alerts triggered by
 	// alerts with 'rollingupgrade'
 	FailureThreshold int `json:"failureThreshold"`
+	// ConcurrentRackRestart restarts every broker of the same rack at once instead of one broker at a time.
+	// It only takes effect when rackAwareness is configured. A rack is only restarted when every partition has
+	// an in-sync replica outside of it, and the failure threshold is checked before each rack is started.
+	// +optional
+	ConcurrentRackRestart bool `json:"concurrentRackRestart,omitempty"`
+	// LeaderMigration moves the partition leaderships off the brokers with Cruise Control before they are restarted,
+	// and runs a preferred leader election once the restarted brokers are back in sync. It requires Cruise Control.
+	// +optional
+	LeaderMigration bool `json:"leaderMigration,omitempty"`
+	// LeaderMigrationTimeoutMinutes is how long the leadership migration of the brokers about to be restarted may take,
+	// the brokers are restarted without moving their remaining leaderships once it is exceeded, 10 minutes by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LeaderMigrationTimeoutMinutes int `json:"leaderMigrationTimeoutMinutes,omitempty"`
+	// BlockUnderMinISR refuses to restart brokers while the restart would take partitions below their
+	// min.insync.replicas, making producers with acks=all fail. Such partitions are only reported otherwise.
+	// Partitions whose replication factor is not larger than their min.insync.replicas block the restart
+	// of every one of their in-sync replicas.
+	// +optional
+	BlockUnderMinISR bool `json:"blockUnderMinISR,omitempty"`
+	// Paused stops the rolling upgrade before the next broker (or rack) restart until it is set back to false
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// RequireApproval restarts a broker (or the brokers of a rack with concurrentRackRestart) only once its pod is
+	// annotated with "kafka.banzaicloud.io/restart-approved: true". The annotation is gone with the restarted pod.
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+	// Canary upgrades the canary brokers first when the image of the brokers changes, and upgrades the other brokers
+	// only once the canaries stayed healthy for the soak period. The canaries are rolled back when the health degrades.
+	// +optional
+	Canary *CanaryUpgradeConfig `json:"canary,omitempty"`
+}
+
+// GetLeaderMigrationTimeout returns how long the leadership migration of the brokers may take before their restart
+func (c *RollingUpgradeConfig) GetLeaderMigrationTimeout() time.Duration {
+	if c.LeaderMigrationTimeoutMinutes == 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.LeaderMigrationTimeoutMinutes) * time.Minute
+}
+
+// CanaryUpgradeConfig defines the canary strategy of the broker image upgrades
+type CanaryUpgradeConfig struct {
+	// BrokerIDs are the brokers upgraded first
+	// +kubebuilder:validation:MinItems=1
+	BrokerIDs []int32 `json:"brokerIds"`
+	// SoakPeriodMinutes is how long the upgraded canaries have to stay healthy before the other brokers are upgraded,
+	// 10 minutes by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SoakPeriodMinutes int `json:"soakPeriodMinutes,omitempty"`
+	// ReadinessTimeoutMinutes is how long the canaries may take to run the new image and become healthy,
+	// the canary upgrade is rolled back once it is exceeded, 15 minutes by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ReadinessTimeoutMinutes int `json:"readinessTimeoutMinutes,omitempty"`
+}
+
+// GetSoakPeriod returns the soak period of the canary upgrade
+func (c *CanaryUpgradeConfig) GetSoakPeriod() time.Duration {
+	if c.SoakPeriodMinutes == 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.SoakPeriodMinutes) * time.Minute
+}
+
+// GetReadinessTimeout returns how long the canaries may take to become healthy with the new image
+func (c *CanaryUpgradeConfig) GetReadinessTimeout() time.Duration {
+	if c.ReadinessTimeoutMinutes == 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(c.ReadinessTimeoutMinutes) * time.Minute
+}
+
+// DecommissionConfig defines how the brokers removed from the cluster are decommissioned. The pod of a removed broker
+// is only deleted once Kafka confirms that the broker holds no partition replicas.
+type DecommissionConfig struct {
+	// PVCRetentionMinutes keeps the PVCs of the removed brokers for the given minutes after their pod was deleted.
+	// The PVCs are deleted together with the pod when it is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PVCRetentionMinutes int `json:"pvcRetentionMinutes,omitempty"`
+	// MaxEvacuationAttempts is the number of times the graceful downscale of a removed broker is requested again
+	// when the broker still holds partition replicas after it. Once they are used up the pod of the broker is kept
+	// and the failure is reported in the status of the broker. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxEvacuationAttempts int `json:"maxEvacuationAttempts,omitempty"`
+}
+
+// GetPVCRetention returns how long the PVCs of the removed brokers are kept
+func (c *DecommissionConfig) GetPVCRetention() time.Duration {
+	return time.Duration(c.PVCRetentionMinutes) * time.Minute
+}
+
+// GetMaxEvacuationAttempts returns how many times the graceful downscale of a removed broker is requested again
+func (c *DecommissionConfig) GetMaxEvacuationAttempts() int {
+	if c.MaxEvacuationAttempts == 0 {
+		return 3
+	}
+	return c.MaxEvacuationAttempts
 }
 
 // DisruptionBudget defines the configuration for PodDisruptionBudget where the workload is managed by the kafka-operator
@@ -183,7 +443,7 @@ type BrokerConfig struct {
 	BrokerAnnotations map[string]string `json:"brokerAnnotations,omitempty"`
 	// Custom labels for the broker pods, example use case: for Prometheus monitoring to capture the group for each broker as a label, e.g.:
 	// kafka_broker_group: "default_group"
-	// these labels will not override the reserved labels that the operator relies on, for example, "app", "brokerId", and "kafka_cr"
+	// these labels will not override the reserved labels that the operator relies on, for example, "app", "brokerId", "brokerConfigGroup", and "kafka_cr"
 	// +optional
 	BrokerLabels map[string]string `json:"brokerLabels,omitempty"`
 	// Network throughput information in kB/s used by Cruise Control to determine broker network capacity.
@@ -234,6 +494,18 @@ type BrokerConfig struct {
 	// If not specified, the broker pods' priority is default to zero.
 	// +optional
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// ProcessRoles defines the role(s) of the broker when the cluster runs in KRaft mode.
+	// Set it to "broker", "controller" or both of them to run the broker in combined mode.
+	// The value set for the individual broker overrides the one set in the broker config group.
+	// +kubebuilder:validation:items:Enum=broker;controller
+	// +optional
+	ProcessRoles []string `json:"processRoles,omitempty"`
+	// Replicas is the number of brokers of the broker config group. When it is set the operator adds the missing
+	// brokers of the group to spec.brokers with broker IDs which are never reused, and removes the brokers with the
+	// highest IDs through the graceful downscale. It can only be set in brokerConfigGroups.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
 }
 
 type NetworkConfig struct {
@@ -262,6 +534,12 @@ type CruiseControlConfig struct {
 	Log4jConfig                string                        `json:"log4jConfig,omitempty"`
 	Image                      string                        `json:"image,omitempty"`
 	TopicConfig                *TopicConfig                  `json:"topicConfig,omitempty"`
+	// RebalanceConfig defines the rebalances requested periodically or when the load of the brokers is imbalanced
+	// +optional
+	RebalanceConfig *RebalanceConfig `json:"rebalanceConfig,omitempty"`
+	// ExecutionPolicy limits the partition and leadership movements of the operations executed by Cruise Control
+	// +optional
+	ExecutionPolicy *CruiseControlExecutionPolicy `json:"executionPolicy,omitempty"`
 	//  Annotations to be applied to CruiseControl pod
 	// +optional
 	CruiseControlAnnotations map[string]string `json:"cruiseControlAnnotations,omitempty"`
@@ -283,13 +561,17 @@ type CruiseControlConfig struct {
 
 // CruiseControlOperationSpec specifies the configuration of the CruiseControlOperation handling
 type CruiseControlOperationSpec struct {
-	// When TTLSecondsAfterFinished is specified, the created and finished (completed successfully or completedWithError and errorPolicy: ignore)
-	// cruiseControlOperation custom resource will be deleted after the given time elapsed.
+	// When TTLSecondsAfterFinished is specified, the created and finished (completed successfully, completedWithError and errorPolicy: ignore,
+	// or failed and not retried anymore) cruiseControlOperation custom resource will be deleted after the given time elapsed.
 	// When it is 0 then the resource is going to be deleted instantly after the operation is finished.
 	// When it is not specified the resource is not going to be removed.
 	// Value can be only zero and positive integers.
 	// +kubebuilder:validation:Minimum=0
 	TTLSecondsAfterFinished *int `json:"ttlSecondsAfterFinished,omitempty"`
+	// RetryPolicy is the default retry policy of the CruiseControlOperations of the cluster with retry error policy
+	// which do not specify their own
+	// +optional
+	RetryPolicy *CruiseControlOperationRetryPolicy `json:"retryPolicy,omitempty"`
 }
 
 // GetTTLSecondsAfterFinished returns NIL when CruiseControlOperationSpec is not specified otherwise it returns itself
@@ -300,6 +582,240 @@ func (c *CruiseControlOperationSpec) GetTTLSecondsAfterFinished() *int {
 	return c.TTLSecondsAfterFinished
 }
 
+// GetRetryPolicy returns NIL when CruiseControlOperationSpec is not specified otherwise it returns the retry policy
+func (c *CruiseControlOperationSpec) GetRetryPolicy() *CruiseControlOperationRetryPolicy {
+	if c == nil {
+		return nil
+	}
+	return c.RetryPolicy
+}
+
+// CruiseControlOperationRetryPolicy specifies how the failed tasks of the CruiseControlOperations with retry error
+// policy are retried. The time between the retries is doubled after each retry up to the maximum back off, and it is
+// shortened by a random jitter of at most its half.
+type CruiseControlOperationRetryPolicy struct {
+	// MaxRetries is the number of retries after which the operation is marked Failed and not retried anymore.
+	// The failed tasks are retried until they succeed when it is not specified.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// BackOffSeconds is the time between the failure of the task and its first retry, 30 seconds by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BackOffSeconds int32 `json:"backOffSeconds,omitempty"`
+	// MaxBackOffSeconds is the longest time between two retries, 1 hour by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxBackOffSeconds int32 `json:"maxBackOffSeconds,omitempty"`
+	// RetryOnErrors contains regular expressions matching the error messages of the failed tasks which are retried.
+	// When it is specified, the operation is marked Failed when the error message of its failed task matches none
+	// of them. The tasks failed without an error message are retried.
+	// +optional
+	RetryOnErrors []string `json:"retryOnErrors,omitempty"`
+}
+
+// GetBackOff returns the time between the failure of the task and its first retry
+func (p *CruiseControlOperationRetryPolicy) GetBackOff() time.Duration {
+	if p == nil || p.BackOffSeconds == 0 {
+		return DefaultCruiseControlOperationRetryBackOff
+	}
+	return time.Duration(p.BackOffSeconds) * time.Second
+}
+
+// GetMaxBackOff returns the longest time between two retries
+func (p *CruiseControlOperationRetryPolicy) GetMaxBackOff() time.Duration {
+	if p == nil || p.MaxBackOffSeconds == 0 {
+		return DefaultCruiseControlOperationMaxRetryBackOff
+	}
+	return time.Duration(p.MaxBackOffSeconds) * time.Second
+}
+
+// AllowsRetry returns true when the task failed with the error message can be retried after the given number of retries
+func (p *CruiseControlOperationRetryPolicy) AllowsRetry(retryCount int, errorMessage string) bool {
+	if p == nil {
+		return true
+	}
+	if p.MaxRetries != nil && retryCount >= int(*p.MaxRetries) {
+		return false
+	}
+	if len(p.RetryOnErrors) == 0 || errorMessage == "" {
+		return true
+	}
+	for _, pattern := range p.RetryOnErrors {
+		if matched, err := regexp.MatchString(pattern, errorMessage); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// RebalanceConfig defines the rebalances the operator requests from Cruise Control on its own. Each rebalance is
+// executed by a CruiseControlOperation, and no rebalance is requested while another one or a broker operation is in progress.
+type RebalanceConfig struct {
+	// Schedule is the cron expression of the periodic rebalances in the standard five field format, e.g. "0 2 * * 6"
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// ImbalanceTrigger requests a rebalance when the load of the brokers is imbalanced
+	// +optional
+	ImbalanceTrigger *ImbalanceTrigger `json:"imbalanceTrigger,omitempty"`
+	// Goals are the Cruise Control goals of the rebalances, the default goals of Cruise Control are used when not set
+	// +optional
+	Goals []string `json:"goals,omitempty"`
+	// ExcludedTopics is a regular expression matching the topics whose replicas are not moved by the rebalances
+	// +optional
+	ExcludedTopics string `json:"excludedTopics,omitempty"`
+	// ConcurrentPartitionMovementsPerBroker limits the number of partition replicas moved to or from a broker at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentPartitionMovementsPerBroker *int32 `json:"concurrentPartitionMovementsPerBroker,omitempty"`
+	// ConcurrentLeaderMovements limits the number of partition leaderships moved at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentLeaderMovements *int32 `json:"concurrentLeaderMovements,omitempty"`
+}
+
+// CruiseControlExecutionPolicy defines the limits of the partition and leadership movements of the operations
+// executed by Cruise Control. The limits are added to the parameters of the CruiseControlOperations created by the operator.
+type CruiseControlExecutionPolicy struct {
+	// ConcurrentPartitionMovementsPerBroker limits the number of partition replicas moved to or from a broker at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentPartitionMovementsPerBroker *int32 `json:"concurrentPartitionMovementsPerBroker,omitempty"`
+	// ConcurrentLeaderMovements limits the number of partition leaderships moved at once
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentLeaderMovements *int32 `json:"concurrentLeaderMovements,omitempty"`
+	// ReplicationThrottle limits the replication traffic of the partition movements in bytes per second
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReplicationThrottle *int64 `json:"replicationThrottle,omitempty"`
+	// ExecutionWindows are the time windows when the CruiseControlOperations moving partitions or leaderships can be
+	// executed. The operations can be executed at any time when it is empty. The execution of the operation in progress
+	// is stopped when the window ends, and the operation is executed again in the next window.
+	// +optional
+	ExecutionWindows []ExecutionWindow `json:"executionWindows,omitempty"`
+}
+
+// ExecutionWindow defines a daily time window
+type ExecutionWindow struct {
+	// Days are the days of the week when the window is open, e.g. "Saturday". Every day when it is empty.
+	// +optional
+	Days []Weekday `json:"days,omitempty"`
+	// Start is the beginning of the window in "HH:MM" format
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the end of the window in "HH:MM" format. When it is not after Start, the window ends on the next day.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// TimeZone is the IANA name of the time zone of Start and End, e.g. "Europe/Budapest". UTC by default.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Weekday is a day of the week
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type Weekday string
+
+// IsInExecutionWindow returns true when the operations can be started at the given time
+func (p *CruiseControlExecutionPolicy) IsInExecutionWindow(t time.Time) (bool, error) {
+	if p == nil || len(p.ExecutionWindows) == 0 {
+		return true, nil
+	}
+	for _, window := range p.ExecutionWindows {
+		contains, err := window.Contains(t)
+		if err != nil {
+			return false, err
+		}
+		if contains {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Contains returns true when the window is open at the given time
+func (w ExecutionWindow) Contains(t time.Time) (bool, error) {
+	location := time.UTC
+	if w.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return false, errors.WrapIfWithDetails(err, "invalid time zone of the execution window", "timeZone", w.TimeZone)
+		}
+	}
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "invalid start of the execution window", "start", w.Start)
+	}
+	end, err := time.Parse("15:04", w.End)
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "invalid end of the execution window", "end", w.End)
+	}
+
+	t = t.In(location)
+	minutes := t.Hour()*60 + t.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	switch {
+	case startMinutes < endMinutes:
+		return w.isOpenOn(t.Weekday()) && startMinutes <= minutes && minutes < endMinutes, nil
+	case minutes >= startMinutes:
+		return w.isOpenOn(t.Weekday()), nil
+	case minutes < endMinutes:
+		// the window was opened on the previous day
+		return w.isOpenOn((t.Weekday() + 6) % 7), nil
+	default:
+		return false, nil
+	}
+}
+
+func (w ExecutionWindow) isOpenOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if string(d) == day.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// ImbalanceTrigger defines when the load of the brokers is considered imbalanced. The load is the disk usage and
+// the number of partition replicas of the brokers, and it is imbalanced when the most loaded broker exceeds the
+// average of the brokers by more than the threshold.
+type ImbalanceTrigger struct {
+	// ThresholdPercent is the highest allowed deviation of the most loaded broker from the average in percent
+	// +kubebuilder:validation:Minimum=1
+	ThresholdPercent int32 `json:"thresholdPercent"`
+	// CheckIntervalMinutes is the time between two checks of the broker load, 10 minutes by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CheckIntervalMinutes int32 `json:"checkIntervalMinutes,omitempty"`
+	// CooldownMinutes is the minimum time between the end of the last rebalance and a rebalance requested because
+	// of imbalance, 30 minutes by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CooldownMinutes int32 `json:"cooldownMinutes,omitempty"`
+}
+
+// GetCheckInterval returns the time between two checks of the broker load
+func (t *ImbalanceTrigger) GetCheckInterval() time.Duration {
+	if t.CheckIntervalMinutes == 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(t.CheckIntervalMinutes) * time.Minute
+}
+
+// GetCooldown returns the minimum time between the end of the last rebalance and a rebalance requested because
+// of imbalance
+func (t *ImbalanceTrigger) GetCooldown() time.Duration {
+	if t.CooldownMinutes == 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(t.CooldownMinutes) * time.Minute
+}
+
 // CruiseControlTaskSpec specifies the configuration of the CC Tasks
 type CruiseControlTaskSpec struct {
 	// RetryDurationMinutes describes the amount of time the Operator waits for the task
@@ -430,6 +946,12 @@ type StorageConfig struct {
 	// the `pvcSpec` is used by default.
 	// +optional
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+
+	// Drain moves the partition replicas of the log dir onto the other log dirs of the broker with Cruise Control,
+	// then removes its PVC once the log dir holds no replicas, e.g. to migrate the data onto a new storage class or a
+	// smaller volume added as a new storage config. The storage config can be removed once it has been drained.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
 }
 
 // ListenersConfig defines the Kafka listener types
@@ -624,6 +1146,7 @@ type ListenerStatus struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 // +kubebuilder:printcolumn:JSONPath=".status.state",name="Cluster state",type="string"
 // +kubebuilder:printcolumn:JSONPath=".status.alertCount",name="Cluster alert count",type="integer"
 // +kubebuilder:printcolumn:JSONPath=".status.rollingUpgradeStatus.lastSuccess",name="Last successful upgrade",type="string"
@@ -729,6 +1252,22 @@ func (kSpec *KafkaClusterSpec) GetZkPath() string {
 	return kSpec.ZKPath
 }
 
+// GetBrokerConfigGroupReplicas returns the number of brokers of the broker config group,
+// false is returned when the number of brokers of the group is not managed by the operator
+func (kSpec *KafkaClusterSpec) GetBrokerConfigGroupReplicas(groupName string) (int32, bool) {
+	group, ok := kSpec.BrokerConfigGroups[groupName]
+	if !ok {
+		return 0, false
+	}
+	if groupName == kSpec.ScaleBrokerConfigGroup && kSpec.Replicas != nil {
+		return *kSpec.Replicas, true
+	}
+	if group.Replicas != nil {
+		return *group.Replicas, true
+	}
+	return 0, false
+}
+
 // GetClusterImage returns the default container image for Kafka Cluster
 func (kSpec *KafkaClusterSpec) GetClusterImage() string {
 	if kSpec.ClusterImage != "" {
@@ -778,6 +1317,22 @@ func (eConfig *EnvoyConfig) GetReplicas() int32 {
 	return eConfig.Replicas
 }
 
+// IsBrokerNode returns true when the broker has the broker process role.
+// Brokers without any process role (e.g. in ZooKeeper mode) are considered as broker nodes.
+func (bConfig *BrokerConfig) IsBrokerNode() bool {
+	return len(bConfig.ProcessRoles) == 0 || util.StringSliceContains(bConfig.ProcessRoles, ProcessRoleBroker)
+}
+
+// IsControllerNode returns true when the broker takes part in the KRaft controller quorum
+func (bConfig *BrokerConfig) IsControllerNode() bool {
+	return util.StringSliceContains(bConfig.ProcessRoles, ProcessRoleController)
+}
+
+// IsControllerOnlyNode returns true when the broker has only the controller process role
+func (bConfig *BrokerConfig) IsControllerOnlyNode() bool {
+	return bConfig.IsControllerNode() && !bConfig.IsBrokerNode()
+}
+
 // GetServiceAccount returns the Kubernetes Service Account to use for Kafka Cluster
 func (bConfig *BrokerConfig) GetServiceAccount() string {
 	if bConfig.ServiceAccountName != "" {
@@ -1063,6 +1618,8 @@ func (b *Broker) GetBrokerConfig(kafkaClusterSpec KafkaClusterSpec) (*BrokerConf
 	} else if b.BrokerConfig != nil {
 		bConfig = b.BrokerConfig.DeepCopy()
 	}
+	// process roles are not merged, the ones set for the broker take precedence
+	processRoles := bConfig.ProcessRoles
 
 	groupConfig, exists := brokerConfigGroups[b.BrokerConfigGroup]
 	if !exists {
@@ -1081,6 +1638,9 @@ func (b *Broker) GetBrokerConfig(kafkaClusterSpec KafkaClusterSpec) (*BrokerConf
 	}
 
 	bConfig.StorageConfigs = dedupStorageConfigs(bConfig.StorageConfigs)
+	if len(processRoles) > 0 {
+		bConfig.ProcessRoles = processRoles
+	}
 	if groupConfig.Affinity != nil || bConfig.Affinity != nil {
 		bConfig.Affinity = dstAffinity
 	}
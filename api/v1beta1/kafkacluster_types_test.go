@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
 
@@ -427,6 +428,45 @@ func TestGetBrokerConfigEnvs(t *testing.T) {
 	}
 }
 
+func TestGetBrokerConfigProcessRoles(t *testing.T) {
+	spec := KafkaClusterSpec{
+		BrokerConfigGroups: map[string]BrokerConfig{
+			"default": {
+				ProcessRoles: []string{ProcessRoleBroker},
+			},
+		},
+	}
+
+	// the process roles of the group are inherited when they are not set for the broker
+	broker := Broker{Id: 0, BrokerConfigGroup: "default"}
+	result, err := broker.GetBrokerConfig(spec)
+	if err != nil {
+		t.Error("Error GetBrokerConfig throw an unexpected error")
+	}
+	if !reflect.DeepEqual(result.ProcessRoles, []string{ProcessRoleBroker}) {
+		t.Error("Expected:", []string{ProcessRoleBroker}, "Got:", result.ProcessRoles)
+	}
+
+	// the process roles set for the broker override the ones set in the group
+	broker = Broker{
+		Id:                1,
+		BrokerConfigGroup: "default",
+		BrokerConfig: &BrokerConfig{
+			ProcessRoles: []string{ProcessRoleController},
+		},
+	}
+	result, err = broker.GetBrokerConfig(spec)
+	if err != nil {
+		t.Error("Error GetBrokerConfig throw an unexpected error")
+	}
+	if !reflect.DeepEqual(result.ProcessRoles, []string{ProcessRoleController}) {
+		t.Error("Expected:", []string{ProcessRoleController}, "Got:", result.ProcessRoles)
+	}
+	if !result.IsControllerOnlyNode() {
+		t.Error("Expected broker to be a controller only node")
+	}
+}
+
 // TestGetBrokerLabels makes sure the reserved labels "app", "brokerId", and "kafka_cr" are not overridden by the BrokerConfig
 func TestGetBrokerLabels(t *testing.T) {
 	const (
@@ -458,3 +498,64 @@ func TestGetBrokerLabels(t *testing.T) {
 		t.Error("Expected:", expected, "Got:", result)
 	}
 }
+
+func TestCruiseControlOperationRetryPolicyAllowsRetry(t *testing.T) {
+	maxRetries := int32(3)
+	retryPolicy := &CruiseControlOperationRetryPolicy{
+		MaxRetries:    &maxRetries,
+		RetryOnErrors: []string{"NotEnoughValidWindowsException", "^timed out"},
+	}
+
+	testCases := []struct {
+		testName     string
+		retryPolicy  *CruiseControlOperationRetryPolicy
+		retryCount   int
+		errorMessage string
+		expected     bool
+	}{
+		{testName: "no retry policy", retryCount: 100, errorMessage: "error", expected: true},
+		{testName: "retryable error", retryPolicy: retryPolicy, retryCount: 2, errorMessage: "timed out waiting for response", expected: true},
+		{testName: "retries exhausted", retryPolicy: retryPolicy, retryCount: 3, errorMessage: "timed out waiting for response", expected: false},
+		{testName: "non-retryable error", retryPolicy: retryPolicy, errorMessage: "NullPointerException", expected: false},
+		{testName: "missing error message", retryPolicy: retryPolicy, expected: true},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.retryPolicy.AllowsRetry(testCase.retryCount, testCase.errorMessage), testCase.testName)
+	}
+}
+
+func TestExecutionWindowContains(t *testing.T) {
+	// 2023-06-03 is a Saturday
+	saturday := func(hour, minute int) time.Time {
+		return time.Date(2023, time.June, 3, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		testName string
+		window   ExecutionWindow
+		time     time.Time
+		expected bool
+	}{
+		{testName: "in window", window: ExecutionWindow{Start: "01:00", End: "05:00"}, time: saturday(3, 0), expected: true},
+		{testName: "end of window", window: ExecutionWindow{Start: "01:00", End: "05:00"}, time: saturday(5, 0), expected: false},
+		{testName: "other day", window: ExecutionWindow{Days: []Weekday{"Sunday"}, Start: "01:00", End: "05:00"}, time: saturday(3, 0), expected: false},
+		{testName: "window ends on the next day", window: ExecutionWindow{Days: []Weekday{"Saturday"}, Start: "22:00", End: "06:00"}, time: saturday(23, 0), expected: true},
+		{testName: "window opened on the previous day", window: ExecutionWindow{Days: []Weekday{"Friday"}, Start: "22:00", End: "06:00"}, time: saturday(5, 0), expected: true},
+		{testName: "window opened on another day", window: ExecutionWindow{Days: []Weekday{"Saturday"}, Start: "22:00", End: "06:00"}, time: saturday(5, 0), expected: false},
+		{testName: "time zone", window: ExecutionWindow{Start: "01:00", End: "05:00", TimeZone: "Europe/Budapest"}, time: saturday(4, 0), expected: false},
+	}
+
+	for _, testCase := range testCases {
+		contains, err := testCase.window.Contains(testCase.time)
+		assert.NilError(t, err, testCase.testName)
+		assert.Equal(t, testCase.expected, contains, testCase.testName)
+	}
+
+	_, err := ExecutionWindow{Start: "01:00", End: "05:00", TimeZone: "Mars/Olympus_Mons"}.Contains(saturday(3, 0))
+	assert.ErrorContains(t, err, "invalid time zone")
+
+	inWindow, err := (*CruiseControlExecutionPolicy)(nil).IsInExecutionWindow(saturday(12, 0))
+	assert.NilError(t, err)
+	assert.Assert(t, inWindow)
+}
@@ -181,6 +181,16 @@ func (in *BrokerConfig) DeepCopyInto(out *BrokerConfig) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ProcessRoles != nil {
+		in, out := &in.ProcessRoles, &out.ProcessRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerConfig.
@@ -214,6 +224,68 @@ func (in *BrokerState) DeepCopy() *BrokerState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryUpgradeConfig) DeepCopyInto(out *CanaryUpgradeConfig) {
+	*out = *in
+	if in.BrokerIDs != nil {
+		in, out := &in.BrokerIDs, &out.BrokerIDs
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryUpgradeConfig.
+func (in *CanaryUpgradeConfig) DeepCopy() *CanaryUpgradeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryUpgradeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryUpgradeStatus) DeepCopyInto(out *CanaryUpgradeStatus) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousImages != nil {
+		in, out := &in.PreviousImages, &out.PreviousImages
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TargetImages != nil {
+		in, out := &in.TargetImages, &out.TargetImages
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.SoakStartedAt != nil {
+		in, out := &in.SoakStartedAt, &out.SoakStartedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryUpgradeStatus.
+func (in *CanaryUpgradeStatus) DeepCopy() *CanaryUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonListenerSpec) DeepCopyInto(out *CommonListenerSpec) {
 	*out = *in
@@ -294,6 +366,16 @@ func (in *CruiseControlConfig) DeepCopyInto(out *CruiseControlConfig) {
 		*out = new(TopicConfig)
 		**out = **in
 	}
+	if in.RebalanceConfig != nil {
+		in, out := &in.RebalanceConfig, &out.RebalanceConfig
+		*out = new(RebalanceConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExecutionPolicy != nil {
+		in, out := &in.ExecutionPolicy, &out.ExecutionPolicy
+		*out = new(CruiseControlExecutionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CruiseControlAnnotations != nil {
 		in, out := &in.CruiseControlAnnotations, &out.CruiseControlAnnotations
 		*out = make(map[string]string, len(*in))
@@ -344,6 +426,68 @@ func (in *CruiseControlConfig) DeepCopy() *CruiseControlConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlExecutionPolicy) DeepCopyInto(out *CruiseControlExecutionPolicy) {
+	*out = *in
+	if in.ConcurrentPartitionMovementsPerBroker != nil {
+		in, out := &in.ConcurrentPartitionMovementsPerBroker, &out.ConcurrentPartitionMovementsPerBroker
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentLeaderMovements != nil {
+		in, out := &in.ConcurrentLeaderMovements, &out.ConcurrentLeaderMovements
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReplicationThrottle != nil {
+		in, out := &in.ReplicationThrottle, &out.ReplicationThrottle
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ExecutionWindows != nil {
+		in, out := &in.ExecutionWindows, &out.ExecutionWindows
+		*out = make([]ExecutionWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlExecutionPolicy.
+func (in *CruiseControlExecutionPolicy) DeepCopy() *CruiseControlExecutionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlExecutionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CruiseControlOperationRetryPolicy) DeepCopyInto(out *CruiseControlOperationRetryPolicy) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryOnErrors != nil {
+		in, out := &in.RetryOnErrors, &out.RetryOnErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationRetryPolicy.
+func (in *CruiseControlOperationRetryPolicy) DeepCopy() *CruiseControlOperationRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CruiseControlOperationRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CruiseControlOperationSpec) DeepCopyInto(out *CruiseControlOperationSpec) {
 	*out = *in
@@ -352,6 +496,11 @@ func (in *CruiseControlOperationSpec) DeepCopyInto(out *CruiseControlOperationSp
 		*out = new(int)
 		**out = **in
 	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(CruiseControlOperationRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CruiseControlOperationSpec.
@@ -379,6 +528,21 @@ func (in *CruiseControlTaskSpec) DeepCopy() *CruiseControlTaskSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecommissionConfig) DeepCopyInto(out *DecommissionConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecommissionConfig.
+func (in *DecommissionConfig) DeepCopy() *DecommissionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DecommissionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DisruptionBudget) DeepCopyInto(out *DisruptionBudget) {
 	*out = *in
@@ -508,6 +672,26 @@ func (in *EnvoyConfig) DeepCopy() *EnvoyConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutionWindow) DeepCopyInto(out *ExecutionWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionWindow.
+func (in *ExecutionWindow) DeepCopy() *ExecutionWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutionWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalListenerConfig) DeepCopyInto(out *ExternalListenerConfig) {
 	*out = *in
@@ -581,6 +765,21 @@ func (in *GracefulActionState) DeepCopy() *GracefulActionState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImbalanceTrigger) DeepCopyInto(out *ImbalanceTrigger) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImbalanceTrigger.
+func (in *ImbalanceTrigger) DeepCopy() *ImbalanceTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(ImbalanceTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressConfig) DeepCopyInto(out *IngressConfig) {
 	*out = *in
@@ -826,7 +1025,13 @@ func (in *KafkaClusterSpec) DeepCopyInto(out *KafkaClusterSpec) {
 		}
 	}
 	out.DisruptionBudget = in.DisruptionBudget
-	out.RollingUpgradeConfig = in.RollingUpgradeConfig
+	in.RollingUpgradeConfig.DeepCopyInto(&out.RollingUpgradeConfig)
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.DecommissionConfig = in.DecommissionConfig
 	if in.IstioControlPlane != nil {
 		in, out := &in.IstioControlPlane, &out.IstioControlPlane
 		*out = new(IstioControlPlaneReference)
@@ -875,8 +1080,23 @@ func (in *KafkaClusterStatus) DeepCopyInto(out *KafkaClusterStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
-	out.RollingUpgrade = in.RollingUpgrade
+	in.RollingUpgrade.DeepCopyInto(&out.RollingUpgrade)
 	in.ListenerStatuses.DeepCopyInto(&out.ListenerStatuses)
+	if in.KafkaVersionUpgrade != nil {
+		in, out := &in.KafkaVersionUpgrade, &out.KafkaVersionUpgrade
+		*out = new(KafkaVersionUpgradeStatus)
+		**out = **in
+	}
+	if in.LastBrokerID != nil {
+		in, out := &in.LastBrokerID, &out.LastBrokerID
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Rebalance != nil {
+		in, out := &in.Rebalance, &out.Rebalance
+		*out = new(RebalanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaClusterStatus.
@@ -904,6 +1124,21 @@ func (in *KafkaVersion) DeepCopy() *KafkaVersion {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaVersionUpgradeStatus) DeepCopyInto(out *KafkaVersionUpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaVersionUpgradeStatus.
+func (in *KafkaVersionUpgradeStatus) DeepCopy() *KafkaVersionUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaVersionUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ListenerStatus) DeepCopyInto(out *ListenerStatus) {
 	*out = *in
@@ -1074,9 +1309,90 @@ func (in *RackAwareness) DeepCopy() *RackAwareness {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebalanceConfig) DeepCopyInto(out *RebalanceConfig) {
+	*out = *in
+	if in.ImbalanceTrigger != nil {
+		in, out := &in.ImbalanceTrigger, &out.ImbalanceTrigger
+		*out = new(ImbalanceTrigger)
+		**out = **in
+	}
+	if in.Goals != nil {
+		in, out := &in.Goals, &out.Goals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConcurrentPartitionMovementsPerBroker != nil {
+		in, out := &in.ConcurrentPartitionMovementsPerBroker, &out.ConcurrentPartitionMovementsPerBroker
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentLeaderMovements != nil {
+		in, out := &in.ConcurrentLeaderMovements, &out.ConcurrentLeaderMovements
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebalanceConfig.
+func (in *RebalanceConfig) DeepCopy() *RebalanceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RebalanceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebalanceStatus) DeepCopyInto(out *RebalanceStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastImbalanceCheckTime != nil {
+		in, out := &in.LastImbalanceCheckTime, &out.LastImbalanceCheckTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Imbalance != nil {
+		in, out := &in.Imbalance, &out.Imbalance
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LastRebalanceTime != nil {
+		in, out := &in.LastRebalanceTime, &out.LastRebalanceTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRebalanceFinishTime != nil {
+		in, out := &in.LastRebalanceFinishTime, &out.LastRebalanceFinishTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CruiseControlOperationReference != nil {
+		in, out := &in.CruiseControlOperationReference, &out.CruiseControlOperationReference
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebalanceStatus.
+func (in *RebalanceStatus) DeepCopy() *RebalanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RebalanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpgradeConfig) DeepCopyInto(out *RollingUpgradeConfig) {
 	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryUpgradeConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpgradeConfig.
@@ -1092,6 +1408,39 @@ func (in *RollingUpgradeConfig) DeepCopy() *RollingUpgradeConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpgradeStatus) DeepCopyInto(out *RollingUpgradeStatus) {
 	*out = *in
+	if in.LeaderMigratedBrokers != nil {
+		in, out := &in.LeaderMigratedBrokers, &out.LeaderMigratedBrokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LeaderMigrationStartedAt != nil {
+		in, out := &in.LeaderMigrationStartedAt, &out.LeaderMigrationStartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedBrokers != nil {
+		in, out := &in.CompletedBrokers, &out.CompletedBrokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingBrokers != nil {
+		in, out := &in.PendingBrokers, &out.PendingBrokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CurrentBrokers != nil {
+		in, out := &in.CurrentBrokers, &out.CurrentBrokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PausedAt != nil {
+		in, out := &in.PausedAt, &out.PausedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpgradeStatus.
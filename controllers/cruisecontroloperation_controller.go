@@ -17,7 +17,9 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
 	"sort"
 	"time"
 
@@ -51,10 +53,30 @@ const (
 
 var (
 	defaultRequeueIntervalInSeconds = 10
-	executionPriorityMap            = map[banzaiv1alpha1.CruiseControlTaskOperation]int{
-		banzaiv1alpha1.OperationAddBroker:    2,
-		banzaiv1alpha1.OperationRemoveBroker: 1,
-		banzaiv1alpha1.OperationRebalance:    0,
+	// The operations waiting for the execution window of the cluster are checked in every minute
+	executionWindowRequeueIntervalInSeconds = 60
+	// movementOperations move partition replicas or leaderships, they are started only in the execution windows
+	movementOperations = map[banzaiv1alpha1.CruiseControlTaskOperation]struct{}{
+		banzaiv1alpha1.OperationAddBroker:          {},
+		banzaiv1alpha1.OperationRemoveBroker:       {},
+		banzaiv1alpha1.OperationRebalance:          {},
+		banzaiv1alpha1.OperationDemoteBroker:       {},
+		banzaiv1alpha1.OperationFixOfflineReplicas: {},
+		banzaiv1alpha1.OperationTopicConfiguration: {},
+		banzaiv1alpha1.OperationRemoveDisks:        {},
+	}
+	executionPriorityMap = map[banzaiv1alpha1.CruiseControlTaskOperation]int{
+		// Offline replicas are fixed first to restore the availability of their partitions
+		banzaiv1alpha1.OperationFixOfflineReplicas: 4,
+		banzaiv1alpha1.OperationDemoteBroker:       3,
+		banzaiv1alpha1.OperationAddBroker:          2,
+		banzaiv1alpha1.OperationRemoveBroker:       1,
+		banzaiv1alpha1.OperationRemoveDisks:        1,
+		banzaiv1alpha1.OperationRebalance:          0,
+		banzaiv1alpha1.OperationTopicConfiguration: 0,
+		// Pausing and resuming the metric sampling do not move data, they are executed after the other operations
+		banzaiv1alpha1.OperationPauseSampling:  -1,
+		banzaiv1alpha1.OperationResumeSampling: -1,
 	}
 	missingCCResErr = errors.New("missing Cruise Control user task result")
 )
@@ -172,7 +194,8 @@ func (r *CruiseControlOperationReconciler) Reconcile(ctx context.Context, reques
 	}
 
 	// Update currentTask states from Cruise Control
-	err = r.updateCurrentTasks(ctx, ccOperationsKafkaClusterFiltered)
+	defaultRetryPolicy := kafkaCluster.Spec.CruiseControlConfig.CruiseControlOperationSpec.GetRetryPolicy()
+	err = r.updateCurrentTasks(ctx, ccOperationsKafkaClusterFiltered, defaultRetryPolicy)
 	if err != nil {
 		log.Error(err, "requeue event as updating state of currentTask(s) failed")
 		return requeueAfter(defaultRequeueIntervalInSeconds)
@@ -187,9 +210,27 @@ func (r *CruiseControlOperationReconciler) Reconcile(ctx context.Context, reques
 		return reconciled()
 	}
 
+	// Computing the proposal of the dry-run operation, it is executed only after the proposal is approved
+	if currentCCOperation.IsWaitingForProposal() {
+		return r.computeProposal(ctx, log, currentCCOperation)
+	}
+
 	// Sorting operations into categories which are sorted by priority
 	ccOperationQueueMap := sortOperations(ccOperationsKafkaClusterFiltered)
 
+	// Operations moving partitions or leaderships are executed only in the execution windows of the cluster
+	inExecutionWindow, err := kafkaCluster.Spec.CruiseControlConfig.ExecutionPolicy.IsInExecutionWindow(time.Now())
+	if err != nil {
+		return requeueWithError(log, "could not check the execution windows of the Cruise Control operations", err)
+	}
+	if !inExecutionWindow {
+		for _, operation := range ccOperationQueueMap[ccOperationInProgress] {
+			if _, ok := movementOperations[operation.CurrentTaskOperation()]; ok {
+				return r.stopOutsideExecutionWindow(ctx, log, operation)
+			}
+		}
+	}
+
 	// When there is no more job present in the cluster we reconciled.
 	if len(ccOperationQueueMap[ccOperationForStopExecution]) == 0 && len(ccOperationQueueMap[ccOperationFirstExecution]) == 0 &&
 		len(ccOperationQueueMap[ccOperationRetryExecution]) == 0 && len(ccOperationQueueMap[ccOperationInProgress]) == 0 {
@@ -209,6 +250,12 @@ func (r *CruiseControlOperationReconciler) Reconcile(ctx context.Context, reques
 		return requeueAfter(defaultRequeueIntervalInSeconds)
 	}
 
+	if _, ok := movementOperations[ccOperationExecution.CurrentTaskOperation()]; ok && !inExecutionWindow {
+		log.Info("requeue event as Cruise Control task can only be started in the execution windows", "name", ccOperationExecution.GetName(),
+			"namespace", ccOperationExecution.GetNamespace(), "operation", ccOperationExecution.CurrentTaskOperation())
+		return requeueAfter(executionWindowRequeueIntervalInSeconds)
+	}
+
 	log.Info("executing Cruise Control task", "operation", ccOperationExecution.CurrentTaskOperation(), "parameters", ccOperationExecution.CurrentTaskParameters())
 	// Executing operation
 	cruseControlTaskResult, err := r.executeOperation(ctx, ccOperationExecution)
@@ -217,12 +264,12 @@ func (r *CruiseControlOperationReconciler) Reconcile(ctx context.Context, reques
 		log.Error(err, "Cruise Control task execution got an error", "name", ccOperationExecution.GetName(), "namespace", ccOperationExecution.GetNamespace(), "operation", ccOperationExecution.CurrentTaskOperation(), "parameters", ccOperationExecution.CurrentTaskParameters())
 		// This can happen when the CruiseControlOperation parameter is wrong
 		if cruseControlTaskResult == nil {
-			return requeueWithError(log, "CruiseControlOperation custom resource is invalid", err)
+			return r.failInvalidOperation(ctx, log, ccOperationExecution, err)
 		}
 	}
 
 	conflictRetryFunction := func() error {
-		if err = updateResult(log, cruseControlTaskResult, ccOperationExecution, true); err != nil {
+		if err = updateResult(log, cruseControlTaskResult, ccOperationExecution, defaultRetryPolicy, true); err != nil {
 			return err
 		}
 		err = r.Status().Update(ctx, ccOperationExecution)
@@ -265,6 +312,18 @@ func (r *CruiseControlOperationReconciler) executeOperation(ctx context.Context,
 		cruseControlTaskResult, err = r.scaler.RemoveBrokersWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
 	case banzaiv1alpha1.OperationRebalance:
 		cruseControlTaskResult, err = r.scaler.RebalanceWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationDemoteBroker:
+		cruseControlTaskResult, err = r.scaler.DemoteBrokersWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationFixOfflineReplicas:
+		cruseControlTaskResult, err = r.scaler.FixOfflineReplicasWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationTopicConfiguration:
+		cruseControlTaskResult, err = r.scaler.TopicConfigurationWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationRemoveDisks:
+		cruseControlTaskResult, err = r.scaler.RemoveDisksWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationPauseSampling:
+		cruseControlTaskResult, err = r.scaler.PauseSamplingWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
+	case banzaiv1alpha1.OperationResumeSampling:
+		cruseControlTaskResult, err = r.scaler.ResumeSamplingWithParams(ctx, ccOperationExecution.CurrentTaskParameters())
 	case banzaiv1alpha1.OperationStopExecution:
 		cruseControlTaskResult, err = r.scaler.StopExecution(ctx)
 	default:
@@ -273,13 +332,114 @@ func (r *CruiseControlOperationReconciler) executeOperation(ctx context.Context,
 	return cruseControlTaskResult, err
 }
 
+// computeProposal requests Cruise Control to compute the proposal of the operation without executing it
+// and stores the proposal in the CruiseControlOperation status. The user task computing the proposal is polled
+// until it is completed, a new one is only requested when it failed.
+func (r *CruiseControlOperationReconciler) computeProposal(ctx context.Context, log logr.Logger, operation *banzaiv1alpha1.CruiseControlOperation) (ctrl.Result, error) {
+	if taskID := operation.Status.ProposalTaskID; taskID != "" {
+		res, err := r.scaler.UserTaskResult(ctx, taskID)
+		if err != nil {
+			log.Error(err, "could not get the user task computing the proposal of Cruise Control task", "name", operation.GetName(),
+				"namespace", operation.GetNamespace(), "task ID", taskID)
+			return requeueAfter(defaultRequeueIntervalInSeconds)
+		}
+		switch {
+		case res != nil && res.State == banzaiv1beta1.CruiseControlTaskCompleted && res.Result != nil:
+			return r.updateProposal(ctx, log, operation, res)
+		case res != nil && res.State != banzaiv1beta1.CruiseControlTaskCompleted && res.State != banzaiv1beta1.CruiseControlTaskCompletedWithError:
+			log.Info("requeue event as Cruise Control is still computing the proposal", "task ID", taskID)
+			return requeueAfter(defaultRequeueIntervalInSeconds)
+		}
+		log.Info("user task computing the proposal of Cruise Control task failed, computing it again", "name", operation.GetName(),
+			"namespace", operation.GetNamespace(), "task ID", taskID)
+	}
+
+	dryRunOperation := operation.DeepCopy()
+	dryRunOperation.CurrentTask().Parameters = make(map[string]string, len(operation.CurrentTaskParameters())+1)
+	for param, value := range operation.CurrentTaskParameters() {
+		dryRunOperation.CurrentTask().Parameters[param] = value
+	}
+	dryRunOperation.CurrentTask().Parameters[scale.ParamDryRun] = "true"
+
+	log.Info("computing proposal of Cruise Control task", "operation", operation.CurrentTaskOperation(), "parameters", operation.CurrentTaskParameters())
+	res, err := r.executeOperation(ctx, dryRunOperation)
+	if err != nil {
+		// This can happen when the CruiseControlOperation parameter is wrong
+		if res == nil {
+			return r.failInvalidOperation(ctx, log, operation, err)
+		}
+		log.Error(err, "could not compute proposal of Cruise Control task", "name", operation.GetName(), "namespace", operation.GetNamespace(), "operation", operation.CurrentTaskOperation())
+		return requeueAfter(defaultRequeueIntervalInSeconds)
+	}
+	if res.Result != nil {
+		return r.updateProposal(ctx, log, operation, res)
+	}
+
+	// The proposal is still being computed by Cruise Control, its user task is polled
+	operation.Status.ProposalTaskID = res.TaskID
+	if err := r.Status().Update(ctx, operation); err != nil {
+		return requeueWithError(log, "could not update the user task computing the proposal to the CruiseControlOperation status", err)
+	}
+	log.Info("requeue event as Cruise Control is still computing the proposal", "task ID", res.TaskID)
+	return requeueAfter(defaultRequeueIntervalInSeconds)
+}
+
+// updateProposal stores the proposal computed by the dry-run user task in the CruiseControlOperation status
+func (r *CruiseControlOperationReconciler) updateProposal(ctx context.Context, log logr.Logger, operation *banzaiv1alpha1.CruiseControlOperation, res *scale.Result) (ctrl.Result, error) {
+	operation.Status.Proposal = buildProposal(res.Result)
+	operation.Status.ProposalTaskID = ""
+	if err := r.Status().Update(ctx, operation); err != nil {
+		return requeueWithError(log, "could not update the proposal of the Cruise Control user task to the CruiseControlOperation status", err)
+	}
+	log.Info("proposal of Cruise Control task is waiting for approval", "name", operation.GetName(), "namespace", operation.GetNamespace(),
+		"annotation", banzaiv1alpha1.ProposalApprovedAnnotationKey, "summary", operation.Status.Proposal.Summary)
+
+	return reconciled()
+}
+
+// stopOutsideExecutionWindow stops the execution of the operation moving partitions or leaderships as the execution
+// window of the cluster closed. Its task is reset, so it is executed again in the next execution window, and
+// Cruise Control computes the remaining movements from the current state of the cluster.
+func (r *CruiseControlOperationReconciler) stopOutsideExecutionWindow(ctx context.Context, log logr.Logger, operation *banzaiv1alpha1.CruiseControlOperation) (ctrl.Result, error) {
+	log.Info("stopping Cruise Control task as the execution window closed", "name", operation.GetName(), "namespace", operation.GetNamespace(),
+		"operation", operation.CurrentTaskOperation(), "task ID", operation.CurrentTaskID())
+	if _, err := r.scaler.StopExecution(ctx); err != nil {
+		return requeueWithError(log, "could not stop the execution of the Cruise Control task outside the execution windows", err)
+	}
+
+	operation.CurrentTask().SetDefaults()
+	operation.Status.StoppedOutsideExecutionWindow = true
+	if err := r.Status().Update(ctx, operation); err != nil {
+		return requeueWithError(log, "could not update the stopped Cruise Control task to the CruiseControlOperation status", err)
+	}
+	return requeueAfter(executionWindowRequeueIntervalInSeconds)
+}
+
+// failInvalidOperation marks the operation failed when Cruise Control cannot be requested because of its invalid
+// parameters, as its retries would fail the same way
+func (r *CruiseControlOperationReconciler) failInvalidOperation(ctx context.Context, log logr.Logger, operation *banzaiv1alpha1.CruiseControlOperation, err error) (ctrl.Result, error) {
+	log.Error(err, "CruiseControlOperation custom resource is invalid, it is marked as failed", "name", operation.GetName(),
+		"namespace", operation.GetNamespace(), "operation", operation.CurrentTaskOperation(), "parameters", operation.CurrentTaskParameters())
+	task := operation.CurrentTask()
+	task.State = banzaiv1beta1.CruiseControlTaskFailed
+	task.ErrorMessage = err.Error()
+	task.Finished = &v1.Time{Time: time.Now()}
+	operation.Status.ErrorPolicy = operation.Spec.ErrorPolicy
+	if err := r.Status().Update(ctx, operation); err != nil {
+		return requeueWithError(log, "could not update the failure of the invalid CruiseControlOperation to its status", err)
+	}
+	return reconciled()
+}
+
 func sortOperations(ccOperations []*banzaiv1alpha1.CruiseControlOperation) map[string][]*banzaiv1alpha1.CruiseControlOperation {
 	ccOperationQueueMap := make(map[string][]*banzaiv1alpha1.CruiseControlOperation)
 	for _, ccOperation := range ccOperations {
 		switch {
 		case isWaitingForFinalization(ccOperation):
 			ccOperationQueueMap[ccOperationForStopExecution] = append(ccOperationQueueMap[ccOperationForStopExecution], ccOperation)
-		case ccOperation.IsWaitingForFirstExecution():
+		case ccOperation.IsWaitingForApproval():
+			continue
+		case ccOperation.IsWaitingForFirstExecution(), ccOperation.IsWaitingForExecutionWindow():
 			ccOperationQueueMap[ccOperationFirstExecution] = append(ccOperationQueueMap[ccOperationFirstExecution], ccOperation)
 		case ccOperation.IsWaitingForRetryExecution():
 			ccOperationQueueMap[ccOperationRetryExecution] = append(ccOperationQueueMap[ccOperationRetryExecution], ccOperation)
@@ -350,6 +510,7 @@ func SetupCruiseControlOperationWithManager(mgr ctrl.Manager) *ctrl.Builder {
 				if !reflect.DeepEqual(oldObj.CurrentTask(), newObj.CurrentTask()) ||
 					oldObj.GetDeletionTimestamp() != newObj.GetDeletionTimestamp() ||
 					oldObj.IsPaused() != newObj.IsPaused() ||
+					oldObj.IsProposalApproved() != newObj.IsProposalApproved() ||
 					oldObj.GetGeneration() != newObj.GetGeneration() {
 					return true
 				}
@@ -374,7 +535,8 @@ func kafkaClusterReference(operation *banzaiv1alpha1.CruiseControlOperation) (cl
 	}, nil
 }
 
-func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.CruiseControlOperation, isAfterExecution bool) error {
+func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.CruiseControlOperation,
+	defaultRetryPolicy *banzaiv1beta1.CruiseControlOperationRetryPolicy, isAfterExecution bool) error {
 	// This can happen rarely when the max cached completed user tasks is reached
 	if res == nil {
 		log.Error(missingCCResErr, "Cruise Control's max.cached.completed.user.tasks configuration value probably too small. Missing user task state is handled as completedWithError", "name", operation.GetName(), "namespace", operation.GetNamespace(), "task ID", operation.CurrentTaskID())
@@ -386,6 +548,10 @@ func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.
 	}
 
 	operation.Status.ErrorPolicy = operation.Spec.ErrorPolicy
+	operation.Status.RetryPolicy = operation.Spec.RetryPolicy
+	if operation.Status.RetryPolicy == nil {
+		operation.Status.RetryPolicy = defaultRetryPolicy.DeepCopy()
+	}
 	task := operation.CurrentTask()
 
 	if (res.State == banzaiv1beta1.CruiseControlTaskCompleted || res.State == banzaiv1beta1.CruiseControlTaskCompletedWithError) && task.Finished == nil {
@@ -404,6 +570,7 @@ func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.
 	}
 
 	if isAfterExecution {
+		operation.Status.StoppedOutsideExecutionWindow = false
 		if task.Started == nil {
 			startTime, err := time.Parse(time.RFC1123, res.StartedAt)
 			if err != nil {
@@ -412,7 +579,7 @@ func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.
 			task.Started = &v1.Time{Time: startTime}
 		}
 		task.ID = res.TaskID
-		task.Summary = formatSummary(res.Result)
+		task.Summary = formatTaskSummary(res)
 		if res.Err != nil {
 			task.ErrorMessage = res.Err.Error()
 		}
@@ -422,12 +589,39 @@ func updateResult(log logr.Logger, res *scale.Result, operation *banzaiv1alpha1.
 
 	task.State = res.State
 
+	// The failed task is not retried anymore when the retries are exhausted or its error is not retryable
+	if task.State == banzaiv1beta1.CruiseControlTaskCompletedWithError && operation.IsErrorPolicyRetry() {
+		warnInvalidRetryOnErrors(log, operation)
+	}
+	if task.State == banzaiv1beta1.CruiseControlTaskCompletedWithError && operation.IsErrorPolicyRetry() &&
+		!operation.Status.RetryPolicy.AllowsRetry(operation.Status.RetryCount, task.ErrorMessage) {
+		log.Info("Cruise Control user task failed and it is not retried anymore", "name", operation.GetName(), "namespace", operation.GetNamespace(),
+			"task ID", task.ID, "retry count", operation.Status.RetryCount, "error", task.ErrorMessage)
+		task.State = banzaiv1beta1.CruiseControlTaskFailed
+	}
+
 	return nil
 }
 
+// warnInvalidRetryOnErrors logs the retry on errors patterns of the retry policy of the operation which are not valid
+// regular expressions. Unlike the default retry policy of the KafkaCluster, it is not validated by a webhook, and
+// these patterns never match the error message of the failed task.
+func warnInvalidRetryOnErrors(log logr.Logger, operation *banzaiv1alpha1.CruiseControlOperation) {
+	if operation.Status.RetryPolicy == nil {
+		return
+	}
+	for _, pattern := range operation.Status.RetryPolicy.RetryOnErrors {
+		if _, err := regexp.Compile(pattern); err != nil {
+			log.Info("WARNING: retry on errors pattern of the CruiseControlOperation is not a valid regular expression, it never matches",
+				"name", operation.GetName(), "namespace", operation.GetNamespace(), "pattern", pattern, "error", err.Error())
+		}
+	}
+}
+
 // updateCurrentTasks the state of the CruiseControlOperation from the CruiseControlTasksAndStates instance by getting their
 // status from Cruise Control.
-func (r *CruiseControlOperationReconciler) updateCurrentTasks(ctx context.Context, ccOperations []*banzaiv1alpha1.CruiseControlOperation) error {
+func (r *CruiseControlOperationReconciler) updateCurrentTasks(ctx context.Context, ccOperations []*banzaiv1alpha1.CruiseControlOperation,
+	defaultRetryPolicy *banzaiv1beta1.CruiseControlOperationRetryPolicy) error {
 	log := logr.FromContextOrDiscard(ctx)
 
 	userTaskIDs := make([]string, 0, len(ccOperations))
@@ -452,7 +646,7 @@ func (r *CruiseControlOperationReconciler) updateCurrentTasks(ctx context.Contex
 	for i := range ccOperations {
 		ccOperation := ccOperations[i]
 		if ccOperation.CurrentTaskID() != "" && !ccOperation.IsDone() {
-			if err := updateResult(log, taskResultsByID[ccOperation.CurrentTaskID()], ccOperation, false); err != nil {
+			if err := updateResult(log, taskResultsByID[ccOperation.CurrentTaskID()], ccOperation, defaultRetryPolicy, false); err != nil {
 				return errors.WrapWithDetails(err, "could not set Cruise Control user task result to CruiseControlOperation CurrentTask", "name", ccOperations[i].GetName(), "namespace", ccOperations[i].GetNamespace())
 			}
 		}
@@ -472,6 +666,17 @@ func isWaitingForFinalization(ccOperation *banzaiv1alpha1.CruiseControlOperation
 	return ccOperation.IsCurrentTaskRunning() && !ccOperation.ObjectMeta.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(ccOperation, ccOperationFinalizerGroup)
 }
 
+// formatTaskSummary formats the result of the Cruise Control user task, the operations which do not compute
+// a proposal only return a message
+func formatTaskSummary(res *scale.Result) map[string]string {
+	if res.Result == nil && res.Message != "" {
+		return map[string]string{
+			"Message": res.Message,
+		}
+	}
+	return formatSummary(res.Result)
+}
+
 func formatSummary(res *types.OptimizationResult) map[string]string {
 	if res == nil {
 		return nil
@@ -486,3 +691,50 @@ func formatSummary(res *types.OptimizationResult) map[string]string {
 		"Provision recommendation":                 res.Summary.ProvisionRecommendation,
 	}
 }
+
+// buildProposal builds the proposal of the Cruise Control operation from the result of its dry-run execution
+func buildProposal(res *types.OptimizationResult) *banzaiv1alpha1.CruiseControlProposal {
+	proposal := &banzaiv1alpha1.CruiseControlProposal{
+		Generated: &v1.Time{Time: time.Now()},
+		Summary:   formatSummary(res),
+	}
+
+	brokerLoads := make(map[int32]*banzaiv1alpha1.CruiseControlBrokerLoadDelta)
+	brokerLoad := func(brokerID int32) *banzaiv1alpha1.CruiseControlBrokerLoadDelta {
+		if _, ok := brokerLoads[brokerID]; !ok {
+			brokerLoads[brokerID] = &banzaiv1alpha1.CruiseControlBrokerLoadDelta{BrokerID: brokerID}
+		}
+		return brokerLoads[brokerID]
+	}
+	for _, broker := range res.LoadBeforeOptimization.Brokers {
+		load := brokerLoad(broker.Broker)
+		load.ReplicasBefore = broker.Replicas
+		load.LeadersBefore = broker.Leaders
+		load.DiskMBBefore = int64(math.Round(broker.DiskMB))
+	}
+	for _, broker := range res.LoadAfterOptimization.Brokers {
+		load := brokerLoad(broker.Broker)
+		load.ReplicasAfter = broker.Replicas
+		load.LeadersAfter = broker.Leaders
+		load.DiskMBAfter = int64(math.Round(broker.DiskMB))
+	}
+	for _, load := range brokerLoads {
+		proposal.Brokers = append(proposal.Brokers, *load)
+	}
+	sort.Slice(proposal.Brokers, func(i, j int) bool {
+		return proposal.Brokers[i].BrokerID < proposal.Brokers[j].BrokerID
+	})
+
+	// The goals which were fixed by the proposal are violated before its execution
+	for _, goal := range res.GoalSummary {
+		switch goal.Status {
+		case types.GoalStatusFixed:
+			proposal.GoalsViolatedBefore = append(proposal.GoalsViolatedBefore, goal.Goal.String())
+		case types.GoalStatusViolated:
+			proposal.GoalsViolatedBefore = append(proposal.GoalsViolatedBefore, goal.Goal.String())
+			proposal.GoalsViolatedAfter = append(proposal.GoalsViolatedAfter, goal.Goal.String())
+		}
+	}
+
+	return proposal
+}
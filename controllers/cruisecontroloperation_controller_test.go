@@ -15,14 +15,24 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"emperror.dev/errors"
+	"github.com/banzaicloud/go-cruise-control/pkg/types"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/banzaicloud/koperator/api/v1alpha1"
 	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/controllers/tests/mocks"
+	"github.com/banzaicloud/koperator/pkg/scale"
 )
 
 func createCCRetryExecutionOperation(createTime time.Time, id string, operation v1alpha1.CruiseControlTaskOperation) *v1alpha1.CruiseControlOperation {
@@ -67,6 +77,23 @@ func TestSortOperations(t *testing.T) {
 				createCCRetryExecutionOperation(timeNow.Add(3*time.Second), "1", v1alpha1.OperationAddBroker),
 			},
 		},
+		{
+			testName: "operations added later",
+			ccOperations: []*v1alpha1.CruiseControlOperation{
+				createCCRetryExecutionOperation(timeNow, "1", v1alpha1.OperationPauseSampling),
+				createCCRetryExecutionOperation(timeNow, "2", v1alpha1.OperationRebalance),
+				createCCRetryExecutionOperation(timeNow, "3", v1alpha1.OperationDemoteBroker),
+				createCCRetryExecutionOperation(timeNow, "4", v1alpha1.OperationAddBroker),
+				createCCRetryExecutionOperation(timeNow, "5", v1alpha1.OperationFixOfflineReplicas),
+			},
+			expectedOutput: []*v1alpha1.CruiseControlOperation{
+				createCCRetryExecutionOperation(timeNow, "5", v1alpha1.OperationFixOfflineReplicas),
+				createCCRetryExecutionOperation(timeNow, "3", v1alpha1.OperationDemoteBroker),
+				createCCRetryExecutionOperation(timeNow, "4", v1alpha1.OperationAddBroker),
+				createCCRetryExecutionOperation(timeNow, "2", v1alpha1.OperationRebalance),
+				createCCRetryExecutionOperation(timeNow, "1", v1alpha1.OperationPauseSampling),
+			},
+		},
 		{
 			testName: "mixed",
 			ccOperations: []*v1alpha1.CruiseControlOperation{
@@ -91,3 +118,267 @@ func TestSortOperations(t *testing.T) {
 		assert.Equal(t, sortedRetryOutput, testCase.expectedOutput, "test", testCase.testName)
 	}
 }
+
+func TestSortOperationsWaitingForApproval(t *testing.T) {
+	createDryRunOperation := func(approved bool, proposal *v1alpha1.CruiseControlProposal) *v1alpha1.CruiseControlOperation {
+		operation := &v1alpha1.CruiseControlOperation{
+			Spec: v1alpha1.CruiseControlOperationSpec{
+				DryRun: true,
+			},
+			Status: v1alpha1.CruiseControlOperationStatus{
+				CurrentTask: &v1alpha1.CruiseControlTask{
+					Operation: v1alpha1.OperationRebalance,
+				},
+				Proposal: proposal,
+			},
+		}
+		if approved {
+			operation.SetAnnotations(map[string]string{v1alpha1.ProposalApprovedAnnotationKey: "true"})
+		}
+		return operation
+	}
+
+	withoutProposal := createDryRunOperation(true, nil)
+	notApproved := createDryRunOperation(false, &v1alpha1.CruiseControlProposal{})
+	approved := createDryRunOperation(true, &v1alpha1.CruiseControlProposal{})
+
+	assert.True(t, withoutProposal.IsWaitingForProposal())
+	assert.False(t, notApproved.IsWaitingForProposal())
+
+	sortedCCOperations := sortOperations([]*v1alpha1.CruiseControlOperation{withoutProposal, notApproved, approved})
+	assert.Equal(t, []*v1alpha1.CruiseControlOperation{approved}, sortedCCOperations[ccOperationFirstExecution])
+}
+
+func TestStopOutsideExecutionWindow(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	operation := createCCRetryExecutionOperation(time.Now(), "task-1", v1alpha1.OperationRemoveBroker)
+	operation.ObjectMeta.Name = "remove-broker"
+	operation.ObjectMeta.Namespace = "kafka"
+	operation.Status.RetryCount = 1
+	operation.Status.CurrentTask.State = v1beta1.CruiseControlTaskInExecution
+	operation.Status.CurrentTask.Parameters = map[string]string{scale.ParamBrokerID: "1"}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(operation).Build()
+	scaler := mocks.NewMockCruiseControlScaler(gomock.NewController(t))
+	r := CruiseControlOperationReconciler{Client: fakeClient, scaler: scaler}
+
+	// the execution of the operation in progress is stopped and its task is reset
+	scaler.EXPECT().StopExecution(ctx).Return(&scale.Result{State: v1beta1.CruiseControlTaskActive}, nil)
+	_, err := r.stopOutsideExecutionWindow(ctx, logr.Discard(), operation.DeepCopy())
+	assert.NoError(t, err)
+
+	current := &v1alpha1.CruiseControlOperation{}
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(operation), current))
+	assert.True(t, current.IsWaitingForExecutionWindow())
+	assert.Empty(t, current.CurrentTaskID())
+	assert.Equal(t, map[string]string{scale.ParamBrokerID: "1"}, current.CurrentTaskParameters())
+
+	// the stopped operation is executed again like the operations waiting for their first execution
+	sortedCCOperations := sortOperations([]*v1alpha1.CruiseControlOperation{current})
+	assert.Equal(t, []*v1alpha1.CruiseControlOperation{current}, sortedCCOperations[ccOperationFirstExecution])
+
+	// the operation is not waiting for the execution window anymore once it is executed again
+	err = updateResult(logr.Discard(), &scale.Result{TaskID: "task-2", StartedAt: time.Now().Format(time.RFC1123), State: v1beta1.CruiseControlTaskActive},
+		current, nil, true)
+	assert.NoError(t, err)
+	assert.False(t, current.IsWaitingForExecutionWindow())
+	assert.Equal(t, 1, current.Status.RetryCount)
+}
+
+func TestComputeProposal(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	operation := &v1alpha1.CruiseControlOperation{
+		ObjectMeta: v1.ObjectMeta{Name: "rebalance", Namespace: "kafka"},
+		Spec:       v1alpha1.CruiseControlOperationSpec{DryRun: true},
+		Status: v1alpha1.CruiseControlOperationStatus{
+			CurrentTask: &v1alpha1.CruiseControlTask{Operation: v1alpha1.OperationRebalance},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(operation).Build()
+	scaler := mocks.NewMockCruiseControlScaler(gomock.NewController(t))
+	r := CruiseControlOperationReconciler{Client: fakeClient, scaler: scaler}
+	getOperation := func() *v1alpha1.CruiseControlOperation {
+		current := &v1alpha1.CruiseControlOperation{}
+		assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(operation), current))
+		return current
+	}
+
+	// the dry-run user task is started and its ID is stored
+	scaler.EXPECT().RebalanceWithParams(ctx, map[string]string{scale.ParamDryRun: "true"}).
+		Return(&scale.Result{TaskID: "task-1", State: v1beta1.CruiseControlTaskActive}, nil)
+	_, err := r.computeProposal(ctx, logr.Discard(), getOperation())
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", getOperation().Status.ProposalTaskID)
+
+	// the user task is polled while it is computing the proposal
+	scaler.EXPECT().UserTaskResult(ctx, "task-1").Return(&scale.Result{TaskID: "task-1", State: v1beta1.CruiseControlTaskActive}, nil)
+	_, err = r.computeProposal(ctx, logr.Discard(), getOperation())
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", getOperation().Status.ProposalTaskID)
+	assert.Nil(t, getOperation().Status.Proposal)
+
+	// a new dry-run user task is only started when the previous one failed
+	scaler.EXPECT().UserTaskResult(ctx, "task-1").Return(&scale.Result{TaskID: "task-1", State: v1beta1.CruiseControlTaskCompletedWithError}, nil)
+	scaler.EXPECT().RebalanceWithParams(ctx, map[string]string{scale.ParamDryRun: "true"}).
+		Return(&scale.Result{TaskID: "task-2", State: v1beta1.CruiseControlTaskActive}, nil)
+	_, err = r.computeProposal(ctx, logr.Discard(), getOperation())
+	assert.NoError(t, err)
+	assert.Equal(t, "task-2", getOperation().Status.ProposalTaskID)
+
+	// the proposal of the completed user task is stored
+	scaler.EXPECT().UserTaskResult(ctx, "task-2").
+		Return(&scale.Result{TaskID: "task-2", State: v1beta1.CruiseControlTaskCompleted, Result: &types.OptimizationResult{}}, nil)
+	_, err = r.computeProposal(ctx, logr.Discard(), getOperation())
+	assert.NoError(t, err)
+	assert.Empty(t, getOperation().Status.ProposalTaskID)
+	assert.NotNil(t, getOperation().Status.Proposal)
+}
+
+func TestComputeProposalOfInvalidOperation(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	operation := &v1alpha1.CruiseControlOperation{
+		ObjectMeta: v1.ObjectMeta{Name: "topic-configuration", Namespace: "kafka"},
+		Spec:       v1alpha1.CruiseControlOperationSpec{DryRun: true, ErrorPolicy: v1alpha1.ErrorPolicyRetry},
+		Status: v1alpha1.CruiseControlOperationStatus{
+			CurrentTask: &v1alpha1.CruiseControlTask{Operation: v1alpha1.OperationTopicConfiguration},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(operation).Build()
+	scaler := mocks.NewMockCruiseControlScaler(gomock.NewController(t))
+	r := CruiseControlOperationReconciler{Client: fakeClient, scaler: scaler}
+
+	// the operation with invalid parameters is marked failed instead of being retried
+	scaler.EXPECT().TopicConfigurationWithParams(ctx, map[string]string{scale.ParamDryRun: "true"}).
+		Return(nil, errors.New("missing topic_configuration parameter: topic"))
+	_, err := r.computeProposal(ctx, logr.Discard(), operation.DeepCopy())
+	assert.NoError(t, err)
+
+	current := &v1alpha1.CruiseControlOperation{}
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(operation), current))
+	assert.True(t, current.IsFailed())
+	assert.False(t, current.IsWaitingForProposal())
+	assert.NotNil(t, current.CurrentTaskFinished())
+	assert.Equal(t, "missing topic_configuration parameter: topic", current.CurrentTask().ErrorMessage)
+}
+
+func TestBuildProposal(t *testing.T) {
+	res := &types.OptimizationResult{
+		Summary: types.OptimizerResult{
+			DataToMoveMB:        1024,
+			NumReplicaMovements: 10,
+			NumLeaderMovements:  3,
+		},
+		LoadBeforeOptimization: types.BrokerStats{
+			Brokers: []types.BrokerLoadStats{
+				{Broker: 1, Replicas: 20, Leaders: 8, DiskMB: 2048.4},
+				{Broker: 0, Replicas: 10, Leaders: 4, DiskMB: 1024.6},
+			},
+		},
+		LoadAfterOptimization: types.BrokerStats{
+			Brokers: []types.BrokerLoadStats{
+				{Broker: 0, Replicas: 15, Leaders: 6, DiskMB: 1536},
+				{Broker: 1, Replicas: 15, Leaders: 6, DiskMB: 1536},
+			},
+		},
+		GoalSummary: []types.GoalSummary{
+			{Goal: types.RackAwareGoal, Status: types.GoalStatusNoAction},
+			{Goal: types.ReplicaDistributionGoal, Status: types.GoalStatusFixed},
+			{Goal: types.DiskUsageDistributionGoal, Status: types.GoalStatusViolated},
+		},
+	}
+
+	proposal := buildProposal(res)
+	assert.NotNil(t, proposal.Generated)
+	assert.Equal(t, formatSummary(res), proposal.Summary)
+	assert.Equal(t, []v1alpha1.CruiseControlBrokerLoadDelta{
+		{BrokerID: 0, ReplicasBefore: 10, ReplicasAfter: 15, LeadersBefore: 4, LeadersAfter: 6, DiskMBBefore: 1025, DiskMBAfter: 1536},
+		{BrokerID: 1, ReplicasBefore: 20, ReplicasAfter: 15, LeadersBefore: 8, LeadersAfter: 6, DiskMBBefore: 2048, DiskMBAfter: 1536},
+	}, proposal.Brokers)
+	assert.Equal(t, []string{"ReplicaDistributionGoal", "DiskUsageDistributionGoal"}, proposal.GoalsViolatedBefore)
+	assert.Equal(t, []string{"DiskUsageDistributionGoal"}, proposal.GoalsViolatedAfter)
+}
+
+func TestFormatTaskSummary(t *testing.T) {
+	assert.Equal(t, map[string]string{"Message": "Metric sampling paused."},
+		formatTaskSummary(&scale.Result{Message: "Metric sampling paused."}))
+
+	res := &types.OptimizationResult{Summary: types.OptimizerResult{NumLeaderMovements: 12}}
+	assert.Equal(t, formatSummary(res), formatTaskSummary(&scale.Result{Result: res}))
+	assert.Nil(t, formatTaskSummary(&scale.Result{}))
+}
+
+func TestRetryBackOff(t *testing.T) {
+	operation := createCCRetryExecutionOperation(time.Now(), "1", v1alpha1.OperationRemoveBroker)
+	operation.Status.RetryPolicy = &v1beta1.CruiseControlOperationRetryPolicy{BackOffSeconds: 60, MaxBackOffSeconds: 300}
+
+	for retryCount, expectedBackOff := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		operation.Status.RetryCount = retryCount
+		backOff := operation.RetryBackOff()
+		assert.LessOrEqual(t, backOff, expectedBackOff, "retry count", retryCount)
+		assert.Greater(t, backOff, expectedBackOff/2, "retry count", retryCount)
+		assert.Equal(t, backOff, operation.RetryBackOff(), "the back off must not change between reconciliations")
+	}
+}
+
+func TestUpdateResultRetryPolicy(t *testing.T) {
+	maxRetries := int32(2)
+	defaultRetryPolicy := &v1beta1.CruiseControlOperationRetryPolicy{MaxRetries: &maxRetries}
+
+	testCases := []struct {
+		testName      string
+		retryPolicy   *v1beta1.CruiseControlOperationRetryPolicy
+		retryCount    int
+		errorMessage  string
+		expectedState v1beta1.CruiseControlUserTaskState
+	}{
+		{
+			testName:      "retried with the default retry policy",
+			retryCount:    1,
+			expectedState: v1beta1.CruiseControlTaskCompletedWithError,
+		},
+		{
+			testName:      "retries of the default retry policy exhausted",
+			retryCount:    2,
+			expectedState: v1beta1.CruiseControlTaskFailed,
+		},
+		{
+			testName:      "retry policy of the operation",
+			retryPolicy:   &v1beta1.CruiseControlOperationRetryPolicy{RetryOnErrors: []string{"NotEnoughValidWindowsException"}},
+			retryCount:    5,
+			errorMessage:  "NotEnoughValidWindowsException: there are only 1 valid windows",
+			expectedState: v1beta1.CruiseControlTaskCompletedWithError,
+		},
+		{
+			testName:      "non-retryable error",
+			retryPolicy:   &v1beta1.CruiseControlOperationRetryPolicy{RetryOnErrors: []string{"NotEnoughValidWindowsException"}},
+			errorMessage:  "NullPointerException",
+			expectedState: v1beta1.CruiseControlTaskFailed,
+		},
+		{
+			testName:      "invalid retry on errors pattern never matches",
+			retryPolicy:   &v1beta1.CruiseControlOperationRetryPolicy{RetryOnErrors: []string{"timed out(.*"}},
+			errorMessage:  "timed out",
+			expectedState: v1beta1.CruiseControlTaskFailed,
+		},
+	}
+
+	for _, testCase := range testCases {
+		operation := createCCRetryExecutionOperation(time.Now(), "1", v1alpha1.OperationRemoveBroker)
+		operation.Spec.RetryPolicy = testCase.retryPolicy
+		operation.Status.RetryCount = testCase.retryCount
+		operation.Status.CurrentTask.State = v1beta1.CruiseControlTaskActive
+		operation.Status.CurrentTask.ErrorMessage = testCase.errorMessage
+
+		err := updateResult(logr.Discard(), &scale.Result{TaskID: "1", State: v1beta1.CruiseControlTaskCompletedWithError},
+			operation, defaultRetryPolicy, false)
+		assert.NoError(t, err, testCase.testName)
+		assert.Equal(t, testCase.expectedState, operation.CurrentTaskState(), testCase.testName)
+		assert.Equal(t, testCase.expectedState == v1beta1.CruiseControlTaskFailed, operation.IsDone(), testCase.testName)
+		assert.Equal(t, testCase.expectedState == v1beta1.CruiseControlTaskFailed, operation.IsFinished(), testCase.testName)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/go-cruise-control/pkg/types"
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	banzaiv1alpha1 "github.com/banzaicloud/koperator/api/v1alpha1"
+	banzaiv1beta1 "github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/scale"
+)
+
+// CruiseControlRebalanceReconciler requests the rebalances of the kafka clusters defined by their rebalance config
+type CruiseControlRebalanceReconciler struct {
+	client.Client
+	// DirectClient here is needed because when the next reconciliation is happened instantly after status update then
+	// the changes in some cases will not be in the resource otherwise.
+	DirectClient client.Reader
+	Scheme       *runtime.Scheme
+	ScaleFactory func(ctx context.Context, kafkaCluster *banzaiv1beta1.KafkaCluster) (scale.CruiseControlScaler, error)
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=cruisecontroloperations,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=cruisecontroloperations/status,verbs=get;update;patch
+
+func (r *CruiseControlRebalanceReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	instance := &banzaiv1beta1.KafkaCluster{}
+	err := r.DirectClient.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return reconciled()
+		}
+		return requeueWithError(log, err.Error(), err)
+	}
+
+	rebalanceConfig := instance.Spec.CruiseControlConfig.RebalanceConfig
+	if rebalanceConfig == nil || !instance.GetDeletionTimestamp().IsZero() {
+		return reconciled()
+	}
+
+	status := &banzaiv1beta1.RebalanceStatus{}
+	if instance.Status.Rebalance != nil {
+		status = instance.Status.Rebalance.DeepCopy()
+	}
+
+	// a single rebalance runs at once, and the broker operations take precedence over the rebalances
+	if status.CruiseControlOperationReference != nil {
+		inProgress, finishTime, err := r.rebalanceInProgress(ctx, instance.Namespace, status.CruiseControlOperationReference.Name)
+		if err != nil {
+			return requeueWithError(log, "failed to get the CruiseControlOperation of the last rebalance", err)
+		}
+		if inProgress {
+			log.V(1).Info("rebalance is in progress", "cruiseControlOperation", status.CruiseControlOperationReference.Name)
+			return requeueAfter(DefaultRequeueAfterTimeInSec)
+		}
+		if status.LastRebalanceFinishTime == nil || status.LastRebalanceFinishTime.Before(status.LastRebalanceTime) {
+			status.LastRebalanceFinishTime = finishTime
+		}
+	}
+	if !getActiveTasksFromCluster(instance).IsEmpty() {
+		log.V(1).Info("rebalance is postponed as Cruise Control tasks of the brokers are in progress")
+		return requeueAfter(DefaultRequeueAfterTimeInSec)
+	}
+
+	now := time.Now()
+	var (
+		trigger   banzaiv1beta1.RebalanceTrigger
+		nextCheck time.Time
+	)
+
+	if rebalanceConfig.Schedule != "" {
+		schedule, err := cron.ParseStandard(rebalanceConfig.Schedule)
+		if err != nil {
+			// the schedule is validated by the webhook, it is not retried until the config is changed
+			log.Error(err, "invalid rebalance schedule", "schedule", rebalanceConfig.Schedule)
+			return reconciled()
+		}
+		// the schedule is counted from the time it is first seen, so enabling it does not start a rebalance at once
+		if status.LastScheduleTime == nil {
+			status.LastScheduleTime = &metav1.Time{Time: now}
+		}
+		if next := schedule.Next(status.LastScheduleTime.Time); !next.After(now) {
+			trigger = banzaiv1beta1.RebalanceTriggerSchedule
+			status.LastScheduleTime = &metav1.Time{Time: now}
+		}
+		nextCheck = schedule.Next(status.LastScheduleTime.Time)
+	}
+
+	if imbalanceTrigger := rebalanceConfig.ImbalanceTrigger; imbalanceTrigger != nil {
+		interval := imbalanceTrigger.GetCheckInterval()
+		if trigger == "" && (status.LastImbalanceCheckTime == nil || !status.LastImbalanceCheckTime.Add(interval).After(now)) {
+			imbalance, err := r.brokerLoadImbalance(ctx, instance)
+			if err != nil {
+				log.Error(err, "could not check the load of the brokers for imbalance")
+				return requeueAfter(DefaultRequeueAfterTimeInSec)
+			}
+			status.LastImbalanceCheckTime = &metav1.Time{Time: now}
+			status.Imbalance = &imbalance
+			switch cooldownEnd := imbalanceCooldownEnd(status, imbalanceTrigger); {
+			case imbalance <= imbalanceTrigger.ThresholdPercent:
+			case now.Before(cooldownEnd):
+				log.Info("load of the brokers is imbalanced, the rebalance is postponed until the cooldown after the last rebalance is over",
+					"imbalance", imbalance, "threshold", imbalanceTrigger.ThresholdPercent, "cooldownEnd", cooldownEnd)
+			default:
+				log.Info("load of the brokers is imbalanced", "imbalance", imbalance, "threshold", imbalanceTrigger.ThresholdPercent)
+				trigger = banzaiv1beta1.RebalanceTriggerImbalance
+			}
+		}
+		if status.LastImbalanceCheckTime != nil {
+			if next := status.LastImbalanceCheckTime.Add(interval); nextCheck.IsZero() || next.Before(nextCheck) {
+				nextCheck = next
+			}
+		}
+	}
+
+	if trigger != "" {
+		cruiseControlOpRef, err := createCruiseControlOperation(ctx, r.Client, r.Scheme, instance, banzaiv1alpha1.ErrorPolicyIgnore,
+			instance.Spec.CruiseControlConfig.CruiseControlOperationSpec.GetTTLSecondsAfterFinished(),
+			banzaiv1alpha1.OperationRebalance, rebalanceParameters(rebalanceConfig))
+		if err != nil {
+			return requeueWithError(log, "creating CruiseControlOperation for rebalance has failed", err)
+		}
+		log.Info("rebalance requested", "trigger", trigger, "cruiseControlOperation", cruiseControlOpRef.Name)
+		status.LastRebalanceTime = &metav1.Time{Time: now}
+		status.LastTrigger = trigger
+		status.CruiseControlOperationReference = &cruiseControlOpRef
+	}
+
+	if !reflect.DeepEqual(instance.Status.Rebalance, status) {
+		if err := k8sutil.UpdateRebalanceStatus(r.Client, instance, status, log); err != nil {
+			return requeueWithError(log, "failed to update Kafka Cluster status", err)
+		}
+	}
+
+	if nextCheck.IsZero() {
+		return reconciled()
+	}
+	// +1 sec is needed to be sure, because double to int conversion round down
+	return requeueAfter(int(time.Until(nextCheck).Seconds() + 1))
+}
+
+// rebalanceInProgress returns true when the CruiseControlOperation of the last rebalance has not finished yet,
+// otherwise the time it finished. The operations which are already deleted are considered finished now.
+func (r *CruiseControlRebalanceReconciler) rebalanceInProgress(ctx context.Context, namespace, name string) (bool, *metav1.Time, error) {
+	now := &metav1.Time{Time: time.Now()}
+	operation := &banzaiv1alpha1.CruiseControlOperation{}
+	if err := r.DirectClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, operation); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, now, nil
+		}
+		return false, nil, err
+	}
+	if !operation.IsFinished() {
+		return true, nil, nil
+	}
+	if finished := operation.CurrentTaskFinished(); finished != nil {
+		return false, finished, nil
+	}
+	return false, now, nil
+}
+
+// imbalanceCooldownEnd returns the time until the imbalance of the brokers does not request a rebalance
+// after the last rebalance
+func imbalanceCooldownEnd(status *banzaiv1beta1.RebalanceStatus, imbalanceTrigger *banzaiv1beta1.ImbalanceTrigger) time.Time {
+	if status.LastRebalanceFinishTime == nil {
+		return time.Time{}
+	}
+	return status.LastRebalanceFinishTime.Add(imbalanceTrigger.GetCooldown())
+}
+
+// brokerLoadImbalance returns the load imbalance of the brokers reported by Cruise Control
+func (r *CruiseControlRebalanceReconciler) brokerLoadImbalance(ctx context.Context, instance *banzaiv1beta1.KafkaCluster) (int32, error) {
+	scaler, err := r.ScaleFactory(ctx, instance)
+	if err != nil {
+		return 0, errors.WrapIf(err, "failed to create Cruise Control Scaler instance")
+	}
+	load, err := scaler.KafkaClusterLoad(ctx)
+	if err != nil {
+		return 0, errors.WrapIf(err, "failed to get the load of the brokers from Cruise Control")
+	}
+	if load == nil || load.Result == nil {
+		return 0, errors.New("Cruise Control returned no broker load")
+	}
+	return loadImbalance(load.Result.Brokers), nil
+}
+
+// loadImbalance returns how much the most loaded alive broker exceeds the average of the alive brokers in percent,
+// where the load is the disk usage and the number of partition replicas of the brokers
+func loadImbalance(brokers []types.BrokerLoadStats) int32 {
+	var diskUsages, replicas []float64
+	for _, broker := range brokers {
+		if broker.BrokerState != types.BrokerStateAlive {
+			continue
+		}
+		diskUsages = append(diskUsages, broker.DiskMB)
+		replicas = append(replicas, float64(broker.Replicas))
+	}
+	return int32(math.Round(math.Max(deviationFromAverage(diskUsages), deviationFromAverage(replicas))))
+}
+
+// deviationFromAverage returns how much the highest value exceeds the average of the values in percent
+func deviationFromAverage(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum, highest float64
+	for _, value := range values {
+		sum += value
+		highest = math.Max(highest, value)
+	}
+	average := sum / float64(len(values))
+	if average == 0 {
+		return 0
+	}
+	return (highest - average) / average * 100
+}
+
+// rebalanceParameters returns the parameters of the rebalance operation defined by the rebalance config
+func rebalanceParameters(rebalanceConfig *banzaiv1beta1.RebalanceConfig) map[string]string {
+	parameters := map[string]string{
+		scale.ParamExcludeDemoted: "true",
+		scale.ParamExcludeRemoved: "true",
+	}
+	if len(rebalanceConfig.Goals) > 0 {
+		parameters[scale.ParamGoals] = strings.Join(rebalanceConfig.Goals, ",")
+	}
+	if rebalanceConfig.ExcludedTopics != "" {
+		parameters[scale.ParamExcludedTopics] = rebalanceConfig.ExcludedTopics
+	}
+	if rebalanceConfig.ConcurrentPartitionMovementsPerBroker != nil {
+		parameters[scale.ParamConcurrentPartitionMovements] = strconv.Itoa(int(*rebalanceConfig.ConcurrentPartitionMovementsPerBroker))
+	}
+	if rebalanceConfig.ConcurrentLeaderMovements != nil {
+		parameters[scale.ParamConcurrentLeaderMovements] = strconv.Itoa(int(*rebalanceConfig.ConcurrentLeaderMovements))
+	}
+	return parameters
+}
+
+// SetupCruiseControlRebalanceWithManager registers the Cruise Control rebalance controller to the manager
+func SetupCruiseControlRebalanceWithManager(mgr ctrl.Manager) *ctrl.Builder {
+	cruiseControlOperationPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldObj := e.ObjectOld.(*banzaiv1alpha1.CruiseControlOperation)
+			newObj := e.ObjectNew.(*banzaiv1alpha1.CruiseControlOperation)
+			return oldObj.IsFinished() != newObj.IsFinished()
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+	}
+
+	kafkaClusterPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if _, ok := e.ObjectNew.(*banzaiv1beta1.KafkaCluster); ok {
+				oldObj := e.ObjectOld.(*banzaiv1beta1.KafkaCluster)
+				newObj := e.ObjectNew.(*banzaiv1beta1.KafkaCluster)
+				return !reflect.DeepEqual(oldObj.Spec.CruiseControlConfig.RebalanceConfig, newObj.Spec.CruiseControlConfig.RebalanceConfig)
+			}
+			return true
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&banzaiv1beta1.KafkaCluster{}, builder.WithPredicates(kafkaClusterPredicate)).
+		WithEventFilter(SkipClusterRegistryOwnedResourcePredicate{}).
+		Owns(&banzaiv1alpha1.CruiseControlOperation{}, builder.WithPredicates(cruiseControlOperationPredicate)).
+		Named("CruiseControlRebalance")
+}
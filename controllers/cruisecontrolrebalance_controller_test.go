@@ -0,0 +1,117 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/go-cruise-control/pkg/types"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+)
+
+func TestLoadImbalance(t *testing.T) {
+	testCases := []struct {
+		testName          string
+		brokers           []types.BrokerLoadStats
+		expectedImbalance int32
+	}{
+		{
+			testName:          "no brokers",
+			expectedImbalance: 0,
+		},
+		{
+			testName: "balanced brokers",
+			brokers: []types.BrokerLoadStats{
+				{Broker: 0, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 10},
+				{Broker: 1, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 10},
+			},
+			expectedImbalance: 0,
+		},
+		{
+			testName: "imbalanced disk usage",
+			brokers: []types.BrokerLoadStats{
+				{Broker: 0, BrokerState: types.BrokerStateAlive, DiskMB: 150, Replicas: 10},
+				{Broker: 1, BrokerState: types.BrokerStateAlive, DiskMB: 50, Replicas: 10},
+			},
+			expectedImbalance: 50,
+		},
+		{
+			testName: "imbalanced partition replicas",
+			brokers: []types.BrokerLoadStats{
+				{Broker: 0, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 20},
+				{Broker: 1, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 10},
+				{Broker: 2, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 15},
+			},
+			expectedImbalance: 33,
+		},
+		{
+			testName: "brokers which are not alive are left out",
+			brokers: []types.BrokerLoadStats{
+				{Broker: 0, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 10},
+				{Broker: 1, BrokerState: types.BrokerStateAlive, DiskMB: 100, Replicas: 10},
+				{Broker: 2, BrokerState: types.BrokerStateDead, DiskMB: 0, Replicas: 0},
+			},
+			expectedImbalance: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			assert.Equal(t, testCase.expectedImbalance, loadImbalance(testCase.brokers))
+		})
+	}
+}
+
+func TestRebalanceParameters(t *testing.T) {
+	partitionMovements := int32(5)
+	leaderMovements := int32(100)
+
+	assert.Equal(t, map[string]string{
+		"exclude_recently_demoted_brokers": "true",
+		"exclude_recently_removed_brokers": "true",
+	}, rebalanceParameters(&v1beta1.RebalanceConfig{Schedule: "0 2 * * 6"}))
+
+	assert.Equal(t, map[string]string{
+		"exclude_recently_demoted_brokers":          "true",
+		"exclude_recently_removed_brokers":          "true",
+		"goals":                                     "RackAwareGoal,DiskUsageDistributionGoal",
+		"excluded_topics":                           "__.*",
+		"concurrent_partition_movements_per_broker": "5",
+		"concurrent_leader_movements":               "100",
+	}, rebalanceParameters(&v1beta1.RebalanceConfig{
+		Goals:                                 []string{"RackAwareGoal", "DiskUsageDistributionGoal"},
+		ExcludedTopics:                        "__.*",
+		ConcurrentPartitionMovementsPerBroker: &partitionMovements,
+		ConcurrentLeaderMovements:             &leaderMovements,
+	}))
+}
+
+func TestImbalanceCooldownEnd(t *testing.T) {
+	imbalanceTrigger := &v1beta1.ImbalanceTrigger{ThresholdPercent: 20}
+	status := &v1beta1.RebalanceStatus{}
+	assert.True(t, imbalanceCooldownEnd(status, imbalanceTrigger).IsZero())
+
+	finished := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	status.LastRebalanceFinishTime = &metav1.Time{Time: finished}
+	assert.Equal(t, finished.Add(30*time.Minute), imbalanceCooldownEnd(status, imbalanceTrigger))
+
+	imbalanceTrigger.CooldownMinutes = 5
+	assert.Equal(t, finished.Add(5*time.Minute), imbalanceCooldownEnd(status, imbalanceTrigger))
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"emperror.dev/errors"
@@ -299,6 +300,35 @@ func (r *CruiseControlTaskReconciler) createCCOperation(
 	operationType banzaiv1alpha1.CruiseControlTaskOperation,
 	bokerIDs []string,
 	isJBOD bool,
+) (corev1.LocalObjectReference, error) {
+	parameters := map[string]string{
+		scale.ParamExcludeDemoted: "true",
+		scale.ParamExcludeRemoved: "true",
+	}
+
+	if operationType == banzaiv1alpha1.OperationRebalance {
+		parameters[scale.ParamDestbrokerIDs] = strings.Join(bokerIDs, ",")
+		if isJBOD {
+			parameters[scale.ParamRebalanceDisk] = "true"
+		}
+	} else {
+		parameters[scale.ParamBrokerID] = strings.Join(bokerIDs, ",")
+	}
+
+	return createCruiseControlOperation(ctx, r.Client, r.Scheme, kafkaCluster, errorPolicy, ttlSecondsAfterFinished, operationType, parameters)
+}
+
+// createCruiseControlOperation creates a CruiseControlOperation owned by the Kafka cluster which executes the operation
+// with the given parameters
+func createCruiseControlOperation(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	kafkaCluster *banzaiv1beta1.KafkaCluster,
+	errorPolicy banzaiv1alpha1.ErrorPolicyType,
+	ttlSecondsAfterFinished *int,
+	operationType banzaiv1alpha1.CruiseControlTaskOperation,
+	parameters map[string]string,
 ) (corev1.LocalObjectReference, error) {
 	operation := &banzaiv1alpha1.CruiseControlOperation{
 		ObjectMeta: metav1.ObjectMeta{
@@ -315,31 +345,30 @@ func (r *CruiseControlTaskReconciler) createCCOperation(
 		operation.Spec.TTLSecondsAfterFinished = ttlSecondsAfterFinished
 	}
 
-	if err := controllerutil.SetControllerReference(kafkaCluster, operation, r.Scheme); err != nil {
+	if err := controllerutil.SetControllerReference(kafkaCluster, operation, scheme); err != nil {
 		return corev1.LocalObjectReference{}, err
 	}
-	if err := r.Client.Create(ctx, operation); err != nil {
+	if err := c.Create(ctx, operation); err != nil {
 		return corev1.LocalObjectReference{}, err
 	}
 
-	operation.Status.CurrentTask = &banzaiv1alpha1.CruiseControlTask{
-		Operation: operationType,
-		Parameters: map[string]string{
-			"exclude_recently_demoted_brokers": "true",
-			"exclude_recently_removed_brokers": "true",
-		},
+	// The parameters of the operation take precedence over the execution policy of the cluster
+	policyParameters := executionPolicyParameters(kafkaCluster.Spec.CruiseControlConfig.ExecutionPolicy)
+	if len(policyParameters) > 0 && parameters == nil {
+		parameters = make(map[string]string, len(policyParameters))
 	}
-
-	if operationType == banzaiv1alpha1.OperationRebalance {
-		operation.Status.CurrentTask.Parameters["destination_broker_ids"] = strings.Join(bokerIDs, ",")
-		if isJBOD {
-			operation.Status.CurrentTask.Parameters["rebalance_disk"] = "true"
+	for param, value := range policyParameters {
+		if _, ok := parameters[param]; !ok {
+			parameters[param] = value
 		}
-	} else {
-		operation.Status.CurrentTask.Parameters["brokerid"] = strings.Join(bokerIDs, ",")
 	}
 
-	if err := r.Status().Update(ctx, operation); err != nil {
+	operation.Status.CurrentTask = &banzaiv1alpha1.CruiseControlTask{
+		Operation:  operationType,
+		Parameters: parameters,
+	}
+
+	if err := c.Status().Update(ctx, operation); err != nil {
 		return corev1.LocalObjectReference{}, err
 	}
 	return corev1.LocalObjectReference{
@@ -347,6 +376,25 @@ func (r *CruiseControlTaskReconciler) createCCOperation(
 	}, nil
 }
 
+// executionPolicyParameters returns the CruiseControlOperation parameters which limit the partition and leadership
+// movements according to the execution policy of the cluster
+func executionPolicyParameters(executionPolicy *banzaiv1beta1.CruiseControlExecutionPolicy) map[string]string {
+	if executionPolicy == nil {
+		return nil
+	}
+	parameters := make(map[string]string)
+	if executionPolicy.ConcurrentPartitionMovementsPerBroker != nil {
+		parameters[scale.ParamConcurrentPartitionMovements] = strconv.Itoa(int(*executionPolicy.ConcurrentPartitionMovementsPerBroker))
+	}
+	if executionPolicy.ConcurrentLeaderMovements != nil {
+		parameters[scale.ParamConcurrentLeaderMovements] = strconv.Itoa(int(*executionPolicy.ConcurrentLeaderMovements))
+	}
+	if executionPolicy.ReplicationThrottle != nil {
+		parameters[scale.ParamReplicationThrottle] = strconv.FormatInt(*executionPolicy.ReplicationThrottle, 10)
+	}
+	return parameters
+}
+
 // brokersJBODSelector filters out the JBOD and not JBOD brokers from a broker list based on the capacityConfig
 func brokersJBODSelector(brokerIDs []string, capacityConfigJSON string) (brokersJBOD []string, brokersNotJBOD []string, err error) {
 	// JBOD is generated by default
@@ -15,9 +15,17 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	banzaiv1alpha1 "github.com/banzaicloud/koperator/api/v1alpha1"
+	banzaiv1beta1 "github.com/banzaicloud/koperator/api/v1beta1"
 )
 
 func TestBrokersJBODSelector(t *testing.T) {
@@ -295,3 +303,39 @@ func TestBrokersJBODSelector(t *testing.T) {
 		assert.ElementsMatch(t, testCase.expectedBrokersNotJBOD, brokersNotJBOD, "testName", testCase.testName)
 	}
 }
+
+func TestCreateCruiseControlOperationWithExecutionPolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = banzaiv1alpha1.AddToScheme(scheme)
+	_ = banzaiv1beta1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	partitionMovements := int32(5)
+	replicationThrottle := int64(50000000)
+	kafkaCluster := &banzaiv1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka", UID: "uid"},
+		Spec: banzaiv1beta1.KafkaClusterSpec{
+			CruiseControlConfig: banzaiv1beta1.CruiseControlConfig{
+				ExecutionPolicy: &banzaiv1beta1.CruiseControlExecutionPolicy{
+					ConcurrentPartitionMovementsPerBroker: &partitionMovements,
+					ReplicationThrottle:                   &replicationThrottle,
+				},
+			},
+		},
+	}
+
+	ref, err := createCruiseControlOperation(context.Background(), fakeClient, scheme, kafkaCluster, banzaiv1alpha1.ErrorPolicyRetry, nil,
+		banzaiv1alpha1.OperationRebalance, map[string]string{
+			"destination_broker_ids":                    "1",
+			"concurrent_partition_movements_per_broker": "10",
+		})
+	assert.NoError(t, err)
+
+	operation := &banzaiv1alpha1.CruiseControlOperation{}
+	assert.NoError(t, fakeClient.Get(context.Background(), client.ObjectKey{Name: ref.Name, Namespace: "kafka"}, operation))
+	assert.Equal(t, map[string]string{
+		"destination_broker_ids":                    "1",
+		"concurrent_partition_movements_per_broker": "10",
+		"replication_throttle":                      "50000000",
+	}, operation.CurrentTaskParameters())
+}
@@ -112,7 +112,7 @@ func (t *CruiseControlTask) FromResult(operation *koperatorv1alpha1.CruiseContro
 			t.BrokerState = koperatorv1beta1.GracefulUpscaleRunning
 		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompleted:
 			t.BrokerState = koperatorv1beta1.GracefulUpscaleSucceeded
-		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError:
+		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError, operation.IsFailed():
 			t.BrokerState = koperatorv1beta1.GracefulUpscaleCompletedWithError
 		case operation.CurrentTaskState() == "":
 			t.BrokerState = koperatorv1beta1.GracefulUpscaleScheduled
@@ -129,7 +129,7 @@ func (t *CruiseControlTask) FromResult(operation *koperatorv1alpha1.CruiseContro
 			t.BrokerState = koperatorv1beta1.GracefulDownscaleRunning
 		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompleted:
 			t.BrokerState = koperatorv1beta1.GracefulDownscaleSucceeded
-		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError:
+		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError, operation.IsFailed():
 			t.BrokerState = koperatorv1beta1.GracefulDownscaleCompletedWithError
 		case operation.CurrentTaskState() == "":
 			t.BrokerState = koperatorv1beta1.GracefulDownscaleScheduled
@@ -147,7 +147,7 @@ func (t *CruiseControlTask) FromResult(operation *koperatorv1alpha1.CruiseContro
 			t.VolumeState = koperatorv1beta1.GracefulDiskRebalanceRunning
 		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompleted:
 			t.VolumeState = koperatorv1beta1.GracefulDiskRebalanceSucceeded
-		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError:
+		case operation.CurrentTaskState() == koperatorv1beta1.CruiseControlTaskCompletedWithError, operation.IsFailed():
 			t.VolumeState = koperatorv1beta1.GracefulDiskRebalanceCompletedWithError
 		case operation.CurrentTaskState() == "":
 			t.VolumeState = koperatorv1beta1.GracefulDiskRebalanceScheduled
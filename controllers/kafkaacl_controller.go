@@ -0,0 +1,287 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/util"
+	kafkautil "github.com/banzaicloud/koperator/pkg/util/kafka"
+)
+
+var aclFinalizer = "finalizer.kafkaacls.kafka.banzaicloud.io"
+
+// SetupKafkaACLWithManager registers KafkaACL controller to the manager
+func SetupKafkaACLWithManager(mgr ctrl.Manager) *ctrl.Builder {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.KafkaACL{}).
+		WithEventFilter(SkipClusterRegistryOwnedResourcePredicate{}).
+		Named("KafkaACL")
+}
+
+// blank assignment to verify that KafkaACLReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &KafkaACLReconciler{}
+
+// KafkaACLReconciler reconciles a KafkaACL object
+type KafkaACLReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaacls,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaacls/status,verbs=get;update;patch
+
+// Reconcile reconciles the ACLs of a KafkaACL with the Kafka cluster. Only the ACLs
+// recorded in the status are considered to be owned by the KafkaACL, so ACLs of the
+// same principal created by other means are left intact.
+func (r *KafkaACLReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("Reconciling KafkaACL")
+	var err error
+
+	// Fetch the KafkaACL instance
+	instance := &v1alpha1.KafkaACL{}
+	if err = r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconciled()
+		}
+		// Error reading the object - requeue the request.
+		return requeueWithError(reqLogger, err.Error(), err)
+	}
+
+	// Get the referenced kafkacluster
+	clusterNamespace := getClusterRefNamespace(instance.Namespace, instance.Spec.ClusterRef)
+	var cluster *v1beta1.KafkaCluster
+	if cluster, err = k8sutil.LookupKafkaCluster(ctx, r.Client, instance.Spec.ClusterRef.Name, clusterNamespace); err != nil {
+		// This shouldn't trigger anymore, but leaving it here as a safetybelt
+		if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+			reqLogger.Info("Cluster is already gone, there is nothing we can do")
+			if err = r.removeFinalizer(ctx, instance); err != nil {
+				return requeueWithError(reqLogger, "failed to remove finalizer", err)
+			}
+			return reconciled()
+		}
+		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
+	}
+
+	// Check if marked for deletion and if so run finalizers
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+		return r.checkFinalizers(ctx, cluster, instance)
+	}
+
+	// ensure a kafkaCluster label
+	if instance, err = r.ensureClusterLabel(ctx, cluster, instance); err != nil {
+		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on acl", err)
+	}
+
+	// Get a kafka connection
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return checkBrokerConnectionError(reqLogger, err)
+	}
+	defer close()
+
+	desiredACLs := kafkautil.ACLRulesToACLStrings(instance.Spec.Principal, instance.Spec.Rules)
+	for _, acl := range desiredACLs {
+		// CreateACL returns no error if the ACL already exists
+		if err = broker.CreateACL(acl); err != nil {
+			return requeueWithError(reqLogger, "failed to ensure ACL", err)
+		}
+	}
+
+	// remove the ACLs which were created previously but are not desired anymore
+	sharedACLs, err := r.aclsDeclaredByOthers(ctx, cluster, instance)
+	if err != nil {
+		return requeueWithError(reqLogger, "failed to list the ACLs declared by other KafkaACLs", err)
+	}
+	for _, acl := range instance.Status.ACLs {
+		if util.StringSliceContains(desiredACLs, acl) {
+			continue
+		}
+		if util.StringSliceContains(sharedACLs, acl) {
+			reqLogger.Info("Keeping ACL which is not desired anymore but declared by another KafkaACL", "acl", acl)
+			continue
+		}
+		reqLogger.Info("Removing ACL which is not desired anymore", "acl", acl)
+		if err = broker.DeleteACL(acl); err != nil {
+			return requeueWithError(reqLogger, "failed to remove ACL", err)
+		}
+	}
+
+	// ensure a finalizer for cleanup on deletion
+	if !util.StringSliceContains(instance.GetFinalizers(), aclFinalizer) {
+		reqLogger.Info("Adding Finalizer for the KafkaACL")
+		instance.SetFinalizers(append(instance.GetFinalizers(), aclFinalizer))
+		if instance, err = r.updateAndFetchLatest(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to add Finalizer to KafkaACL", err)
+		}
+	}
+
+	status := v1alpha1.KafkaACLStatus{
+		State: v1alpha1.ACLStateCreated,
+		ACLs:  desiredACLs,
+	}
+	if !reflect.DeepEqual(instance.Status, status) {
+		instance.Status = status
+		if err = r.Client.Status().Update(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to update kafkaacl status", err)
+		}
+	}
+
+	reqLogger.Info("Ensured ACLs")
+
+	return reconciled()
+}
+
+func (r *KafkaACLReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, acl *v1alpha1.KafkaACL) (*v1alpha1.KafkaACL, error) {
+	labels := applyClusterRefLabel(cluster, acl.GetLabels())
+	if !reflect.DeepEqual(labels, acl.GetLabels()) {
+		acl.SetLabels(labels)
+		return r.updateAndFetchLatest(ctx, acl)
+	}
+	return acl, nil
+}
+
+func (r *KafkaACLReconciler) updateAndFetchLatest(ctx context.Context, acl *v1alpha1.KafkaACL) (*v1alpha1.KafkaACL, error) {
+	typeMeta := acl.TypeMeta
+	err := r.Client.Update(ctx, acl)
+	if err != nil {
+		return nil, err
+	}
+	acl.TypeMeta = typeMeta
+	return acl, nil
+}
+
+func (r *KafkaACLReconciler) checkFinalizers(ctx context.Context, cluster *v1beta1.KafkaCluster, acl *v1alpha1.KafkaACL) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("KafkaACL is marked for deletion")
+	var err error
+	if util.StringSliceContains(acl.GetFinalizers(), aclFinalizer) {
+		if err = r.finalizeKafkaACL(ctx, cluster, acl); err != nil {
+			return checkBrokerConnectionError(reqLogger, err)
+		}
+		if err = r.removeFinalizer(ctx, acl); err != nil {
+			return requeueWithError(reqLogger, "failed to remove finalizer from kafkaacl", err)
+		}
+	}
+	return reconciled()
+}
+
+func (r *KafkaACLReconciler) removeFinalizer(ctx context.Context, acl *v1alpha1.KafkaACL) error {
+	acl.SetFinalizers(util.StringSliceRemove(acl.GetFinalizers(), aclFinalizer))
+	_, err := r.updateAndFetchLatest(ctx, acl)
+	return err
+}
+
+func (r *KafkaACLReconciler) finalizeKafkaACL(ctx context.Context, cluster *v1beta1.KafkaCluster, acl *v1alpha1.KafkaACL) error {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping ACL deletion")
+		return nil
+	}
+	if len(acl.Status.ACLs) == 0 {
+		return nil
+	}
+	sharedACLs, err := r.aclsDeclaredByOthers(ctx, cluster, acl)
+	if err != nil {
+		return err
+	}
+	reqLogger.Info("Deleting ACLs from kafka")
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+	for _, a := range acl.Status.ACLs {
+		if util.StringSliceContains(sharedACLs, a) {
+			reqLogger.Info("Keeping ACL which is declared by another KafkaACL", "acl", a)
+			continue
+		}
+		if err = broker.DeleteACL(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aclsDeclaredByOthers returns the ACLs declared by the KafkaACLs of the cluster other than the given one and the ACLs
+// created for the topic grants of the KafkaUsers of the cluster. As Kafka does not know which resource an ACL belongs
+// to, these must not be removed when the given KafkaACL stops declaring them.
+func (r *KafkaACLReconciler) aclsDeclaredByOthers(ctx context.Context, cluster *v1beta1.KafkaCluster, acl *v1alpha1.KafkaACL) ([]string, error) {
+	acls, err := kafkaACLsOfCluster(ctx, r.Client, cluster, acl.UID)
+	if err != nil {
+		return nil, err
+	}
+	userACLs, err := kafkaUserACLsOfCluster(ctx, r.Client, cluster, "")
+	if err != nil {
+		return nil, err
+	}
+	return append(acls, userACLs...), nil
+}
+
+// kafkaACLsOfCluster returns the ACLs declared by the KafkaACLs of the cluster, except the ones of the KafkaACL with
+// the given UID and the KafkaACLs being deleted
+func kafkaACLsOfCluster(ctx context.Context, c client.Reader, cluster *v1beta1.KafkaCluster, skipUID types.UID) ([]string, error) {
+	aclList := &v1alpha1.KafkaACLList{}
+	if err := c.List(ctx, aclList); err != nil {
+		return nil, err
+	}
+	var acls []string
+	for i := range aclList.Items {
+		other := &aclList.Items[i]
+		if other.UID == skipUID || k8sutil.IsMarkedForDeletion(other.ObjectMeta) ||
+			other.Spec.ClusterRef.Name != cluster.Name ||
+			getClusterRefNamespace(other.Namespace, other.Spec.ClusterRef) != cluster.Namespace {
+			continue
+		}
+		acls = append(acls, kafkautil.ACLRulesToACLStrings(other.Spec.Principal, other.Spec.Rules)...)
+	}
+	return acls, nil
+}
+
+// kafkaUserACLsOfCluster returns the ACLs recorded by the KafkaUsers of the cluster for their topic grants, except the
+// ones of the KafkaUser with the given UID and the KafkaUsers being deleted
+func kafkaUserACLsOfCluster(ctx context.Context, c client.Reader, cluster *v1beta1.KafkaCluster, skipUID types.UID) ([]string, error) {
+	userList := &v1alpha1.KafkaUserList{}
+	if err := c.List(ctx, userList); err != nil {
+		return nil, err
+	}
+	var acls []string
+	for i := range userList.Items {
+		user := &userList.Items[i]
+		if user.UID == skipUID || k8sutil.IsMarkedForDeletion(user.ObjectMeta) ||
+			user.Spec.ClusterRef.Name != cluster.Name ||
+			getClusterRefNamespace(user.Namespace, user.Spec.ClusterRef) != cluster.Namespace {
+			continue
+		}
+		acls = append(acls, user.Status.ACLs...)
+	}
+	return acls, nil
+}
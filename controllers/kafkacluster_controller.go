@@ -105,6 +105,10 @@ func (r *KafkaClusterReconciler) Reconcile(ctx context.Context, request ctrl.Req
 		return r.checkFinalizers(ctx, instance)
 	}
 
+	if err := kafka.ReconcileBrokerReplicas(log, r.Client, instance); err != nil {
+		return requeueWithError(log, err.Error(), err)
+	}
+
 	if instance.Status.State != v1beta1.KafkaClusterRollingUpgrading {
 		if err := k8sutil.UpdateCRStatus(r.Client, instance, v1beta1.KafkaClusterReconciling, log); err != nil {
 			return requeueWithError(log, err.Error(), err)
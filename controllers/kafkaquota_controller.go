@@ -0,0 +1,212 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+var quotaFinalizer = "finalizer.kafkaquotas.kafka.banzaicloud.io"
+
+// clientQuotaDriftCheckInterval is the interval in seconds the client quotas are checked
+// against the desired ones to revert the changes made outside of the operator
+const clientQuotaDriftCheckInterval = 300
+
+// SetupKafkaQuotaWithManager registers KafkaQuota controller to the manager
+func SetupKafkaQuotaWithManager(mgr ctrl.Manager) *ctrl.Builder {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.KafkaQuota{}).
+		WithEventFilter(SkipClusterRegistryOwnedResourcePredicate{}).
+		Named("KafkaQuota")
+}
+
+// blank assignment to verify that KafkaQuotaReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &KafkaQuotaReconciler{}
+
+// KafkaQuotaReconciler reconciles a KafkaQuota object
+type KafkaQuotaReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaquotas,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkaquotas/status,verbs=get;update;patch
+
+// Reconcile reconciles the client quotas of a KafkaQuota with the Kafka cluster
+func (r *KafkaQuotaReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("Reconciling KafkaQuota")
+	var err error
+
+	// Fetch the KafkaQuota instance
+	instance := &v1alpha1.KafkaQuota{}
+	if err = r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconciled()
+		}
+		// Error reading the object - requeue the request.
+		return requeueWithError(reqLogger, err.Error(), err)
+	}
+
+	// Get the referenced kafkacluster
+	clusterNamespace := getClusterRefNamespace(instance.Namespace, instance.Spec.ClusterRef)
+	var cluster *v1beta1.KafkaCluster
+	if cluster, err = k8sutil.LookupKafkaCluster(ctx, r.Client, instance.Spec.ClusterRef.Name, clusterNamespace); err != nil {
+		// This shouldn't trigger anymore, but leaving it here as a safetybelt
+		if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+			reqLogger.Info("Cluster is already gone, there is nothing we can do")
+			if err = r.removeFinalizer(ctx, instance); err != nil {
+				return requeueWithError(reqLogger, "failed to remove finalizer", err)
+			}
+			return reconciled()
+		}
+		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
+	}
+
+	// Check if marked for deletion and if so run finalizers
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
+		return r.checkFinalizers(ctx, cluster, instance)
+	}
+
+	// ensure a kafkaCluster label
+	if instance, err = r.ensureClusterLabel(ctx, cluster, instance); err != nil {
+		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on quota", err)
+	}
+
+	// Get a kafka connection
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return checkBrokerConnectionError(reqLogger, err)
+	}
+	defer close()
+
+	// remove the quotas from the previous entity when the entity has been changed
+	if previous := instance.Status.Entity; previous != nil && *previous != instance.Spec.Entity {
+		reqLogger.Info("Entity of the quotas has been changed, removing the quotas of the previous entity",
+			"entityType", previous.Type, "entityName", previous.Name)
+		if err = broker.DeleteClientQuotas(*previous); err != nil {
+			return requeueWithError(reqLogger, "failed to remove client quotas of the previous entity", err)
+		}
+	}
+
+	changed, err := broker.EnsureClientQuotas(instance.Spec.Entity, &instance.Spec.Quotas)
+	if err != nil {
+		return requeueWithError(reqLogger, "failed to ensure client quotas", err)
+	}
+	if changed && instance.Status.State == v1alpha1.QuotaStateCreated && instance.Status.Entity != nil &&
+		*instance.Status.Entity == instance.Spec.Entity && instance.Generation == instance.Status.ObservedGeneration {
+		reqLogger.Info("Client quotas were changed outside of the operator, restored them")
+	}
+
+	// ensure a finalizer for cleanup on deletion
+	if !util.StringSliceContains(instance.GetFinalizers(), quotaFinalizer) {
+		reqLogger.Info("Adding Finalizer for the KafkaQuota")
+		instance.SetFinalizers(append(instance.GetFinalizers(), quotaFinalizer))
+		if instance, err = r.updateAndFetchLatest(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to add Finalizer to KafkaQuota", err)
+		}
+	}
+
+	entity := instance.Spec.Entity
+	status := v1alpha1.KafkaQuotaStatus{
+		State:              v1alpha1.QuotaStateCreated,
+		Entity:             &entity,
+		ObservedGeneration: instance.Generation,
+	}
+	if !reflect.DeepEqual(instance.Status, status) {
+		instance.Status = status
+		if err = r.Client.Status().Update(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to update kafkaquota status", err)
+		}
+	}
+
+	reqLogger.Info("Ensured client quotas")
+
+	// periodically check the client quotas to revert any changes made outside of the operator
+	return requeueAfter(clientQuotaDriftCheckInterval)
+}
+
+func (r *KafkaQuotaReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, quota *v1alpha1.KafkaQuota) (*v1alpha1.KafkaQuota, error) {
+	labels := applyClusterRefLabel(cluster, quota.GetLabels())
+	if !reflect.DeepEqual(labels, quota.GetLabels()) {
+		quota.SetLabels(labels)
+		return r.updateAndFetchLatest(ctx, quota)
+	}
+	return quota, nil
+}
+
+func (r *KafkaQuotaReconciler) updateAndFetchLatest(ctx context.Context, quota *v1alpha1.KafkaQuota) (*v1alpha1.KafkaQuota, error) {
+	typeMeta := quota.TypeMeta
+	err := r.Client.Update(ctx, quota)
+	if err != nil {
+		return nil, err
+	}
+	quota.TypeMeta = typeMeta
+	return quota, nil
+}
+
+func (r *KafkaQuotaReconciler) checkFinalizers(ctx context.Context, cluster *v1beta1.KafkaCluster, quota *v1alpha1.KafkaQuota) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("KafkaQuota is marked for deletion")
+	var err error
+	if util.StringSliceContains(quota.GetFinalizers(), quotaFinalizer) {
+		if err = r.finalizeKafkaQuota(reqLogger, cluster, quota); err != nil {
+			return checkBrokerConnectionError(reqLogger, err)
+		}
+		if err = r.removeFinalizer(ctx, quota); err != nil {
+			return requeueWithError(reqLogger, "failed to remove finalizer from kafkaquota", err)
+		}
+	}
+	return reconciled()
+}
+
+func (r *KafkaQuotaReconciler) removeFinalizer(ctx context.Context, quota *v1alpha1.KafkaQuota) error {
+	quota.SetFinalizers(util.StringSliceRemove(quota.GetFinalizers(), quotaFinalizer))
+	_, err := r.updateAndFetchLatest(ctx, quota)
+	return err
+}
+
+func (r *KafkaQuotaReconciler) finalizeKafkaQuota(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, quota *v1alpha1.KafkaQuota) error {
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping client quota deletion")
+		return nil
+	}
+	if quota.Status.Entity == nil {
+		return nil
+	}
+	reqLogger.Info("Deleting client quotas from kafka")
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+	return broker.DeleteClientQuotas(*quota.Status.Entity)
+}
@@ -22,7 +22,9 @@ import (
 	"strings"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -40,6 +42,13 @@ import (
 
 var topicFinalizer = "finalizer.kafkatopics.kafka.banzaicloud.io"
 
+const (
+	// topicReassignmentCheckInterval is the interval (in seconds) of checking the progress of replication factor changes
+	topicReassignmentCheckInterval = 15
+	// topicStatusSyncInterval is the interval (in seconds) of refreshing the observed state of the topic in its status
+	topicStatusSyncInterval = 60
+)
+
 func isTopicManagedByKoperator(topic metav1.Object) bool {
 	if managedByAnnotation, hasManagedByAnnotation := topic.GetAnnotations()[webhooks.TopicManagedByAnnotationKey]; hasManagedByAnnotation {
 		return strings.ToLower(managedByAnnotation) == webhooks.TopicManagedByKoperatorAnnotationValue
@@ -119,6 +128,11 @@ func (r *KafkaTopicReconciler) Reconcile(ctx context.Context, request reconcile.
 		}
 	}
 
+	// Orphaned topics are released without connecting to the kafka cluster, so they can be deleted while it is unreachable
+	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) && instance.Spec.GetDeletionPolicy(cluster) == v1alpha1.TopicDeletionPolicyOrphan {
+		return r.checkFinalizers(ctx, nil, cluster, instance)
+	}
+
 	// Get a kafka connection
 	broker, close, err := newKafkaFromCluster(r.Client, cluster)
 	if err != nil {
@@ -128,7 +142,7 @@ func (r *KafkaTopicReconciler) Reconcile(ctx context.Context, request reconcile.
 
 	// Check if marked for deletion and if so run finalizers
 	if k8sutil.IsMarkedForDeletion(instance.ObjectMeta) {
-		return r.checkFinalizers(ctx, broker, instance)
+		return r.checkFinalizers(ctx, broker, cluster, instance)
 	}
 
 	// No need to do anything when the kafka topic is not managed by Koperator
@@ -149,20 +163,40 @@ func (r *KafkaTopicReconciler) Reconcile(ctx context.Context, request reconcile.
 		return requeueWithError(reqLogger, instance.Spec.Name, errors.New("topic is still creating"))
 	}
 
+	// the observed state of the topic, it is described again for the status when the topic has been changed
+	var observed *v1alpha1.KafkaTopicStatus
+
 	// we got a topic back
 	if existing != nil {
 		reqLogger.Info("Topic already exists, verifying configuration")
+		if observed, err = broker.DescribeTopicStatus(instance.Spec.Name); err != nil {
+			return requeueWithError(reqLogger, "failed to describe topic", err)
+		}
+		writes := requiredTopicWrites(instance, observed)
 		// Ensure partition count
-		if changed, err := broker.EnsurePartitionCount(instance.Spec.Name, instance.Spec.Partitions); err != nil {
-			return requeueWithError(reqLogger, "failed to ensure topic partition count", err)
-		} else if changed {
-			reqLogger.Info("Increased partition count for topic")
+		if writes.partitions {
+			if changed, err := broker.EnsurePartitionCount(instance.Spec.Name, instance.Spec.Partitions); err != nil {
+				return requeueWithError(reqLogger, "failed to ensure topic partition count", err)
+			} else if changed {
+				reqLogger.Info("Increased partition count for topic")
+			}
 		}
 		// Ensure topic configurations
-		if err = broker.EnsureTopicConfig(instance.Spec.Name, util.MapStringStringPointer(instance.Spec.Config)); err != nil {
-			return requeueWithError(reqLogger, "failure to ensure topic config", err)
+		if writes.config {
+			if err = broker.EnsureTopicConfig(instance.Spec.Name, desiredTopicConfig(instance)); err != nil {
+				return requeueWithError(reqLogger, "failure to ensure topic config", err)
+			}
 		}
-		reqLogger.Info("Verified partitions and configuration for topic")
+		// Ensure replication factor
+		if writes.replicationFactor {
+			if err = r.ensureReplicationFactor(ctx, broker, instance); err != nil {
+				return requeueWithError(reqLogger, "failed to ensure topic replication factor", err)
+			}
+		}
+		if writes.any() {
+			observed = nil
+		}
+		reqLogger.Info("Verified partitions, replication factor and configuration for topic")
 	} else if err = broker.CreateTopic(&kafkaclient.CreateTopicOptions{
 		// Create the topic
 		Name:              instance.Spec.Name,
@@ -195,9 +229,162 @@ func (r *KafkaTopicReconciler) Reconcile(ctx context.Context, request reconcile.
 		}
 	}
 
+	// keep the observed state of the topic up to date
+	if err = r.syncTopicStatus(ctx, broker, instance, observed); err != nil {
+		return requeueWithError(reqLogger, "failed to sync kafkatopic status", err)
+	}
+
+	if isReassignmentInProgress(instance) {
+		reqLogger.Info("Replication factor change of topic is in progress",
+			"pendingPartitions", instance.Status.Reassignment.PendingPartitions)
+		return requeueAfter(topicReassignmentCheckInterval)
+	}
+
 	reqLogger.Info("Ensured topic")
 
-	return reconciled()
+	return requeueAfter(topicStatusSyncInterval)
+}
+
+// topicWrites tells which properties of an existing topic have to be written to the Kafka cluster
+type topicWrites struct {
+	partitions        bool
+	config            bool
+	replicationFactor bool
+}
+
+func (w topicWrites) any() bool {
+	return w.partitions || w.config || w.replicationFactor
+}
+
+// requiredTopicWrites compares the KafkaTopic with the observed state of the topic, so the periodic
+// reconciliation of an unchanged topic only reads the Kafka cluster
+func requiredTopicWrites(topic *v1alpha1.KafkaTopic, observed *v1alpha1.KafkaTopicStatus) topicWrites {
+	return topicWrites{
+		partitions: observed.PartitionCount != topic.Spec.Partitions,
+		// -1 stands for the broker's default replication factor which is only used on creation
+		replicationFactor: topic.Spec.ReplicationFactor > 0 &&
+			(observed.ReplicationFactor != topic.Spec.ReplicationFactor || isReassignmentInProgress(topic)),
+		// configuration changes made outside of the KafkaTopic are left to the drift scanner, which reverts,
+		// reports or adopts them according to the drift policy of the topic and records them in its status
+		config: topic.Status.ObservedGeneration != topic.Generation,
+	}
+}
+
+// syncTopicStatus updates the status of the KafkaTopic with the observed partitions, replicas and
+// effective configuration of the topic. The topic is described when its observed state is not given.
+func (r *KafkaTopicReconciler) syncTopicStatus(ctx context.Context, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic, observed *v1alpha1.KafkaTopicStatus) error {
+	if observed == nil {
+		var err error
+		if observed, err = broker.DescribeTopicStatus(topic.Spec.Name); err != nil {
+			return err
+		}
+	}
+
+	status := topic.Status.DeepCopy()
+	status.Partitions = observed.Partitions
+	status.PartitionCount = observed.PartitionCount
+	status.ReplicationFactor = observed.ReplicationFactor
+	status.OfflinePartitions = observed.OfflinePartitions
+	status.UnderReplicatedPartitions = observed.UnderReplicatedPartitions
+	status.UnderMinISRPartitions = observed.UnderMinISRPartitions
+	status.Config = observed.Config
+	status.ObservedGeneration = topic.Generation
+
+	readyCondition := metav1.Condition{
+		Type:               v1alpha1.TopicConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: topic.Generation,
+		Reason:             "PartitionsAvailable",
+		Message:            "All partitions have a leader and enough in-sync replicas",
+	}
+	switch {
+	case observed.OfflinePartitions > 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = "OfflinePartitions"
+		readyCondition.Message = fmt.Sprintf("%d partition(s) without a leader", observed.OfflinePartitions)
+	case observed.UnderMinISRPartitions > 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = "UnderMinISRPartitions"
+		readyCondition.Message = fmt.Sprintf("%d partition(s) with less in-sync replicas than min.insync.replicas", observed.UnderMinISRPartitions)
+	}
+	apimeta.SetStatusCondition(&status.Conditions, readyCondition)
+
+	replicatedCondition := metav1.Condition{
+		Type:               v1alpha1.TopicConditionFullyReplicated,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: topic.Generation,
+		Reason:             "ReplicasInSync",
+		Message:            "All replicas are in-sync",
+	}
+	if observed.UnderReplicatedPartitions > 0 {
+		replicatedCondition.Status = metav1.ConditionFalse
+		replicatedCondition.Reason = "UnderReplicatedPartitions"
+		replicatedCondition.Message = fmt.Sprintf("%d partition(s) with out of sync replicas", observed.UnderReplicatedPartitions)
+	}
+	apimeta.SetStatusCondition(&status.Conditions, replicatedCondition)
+
+	// semantic equality treats empty and nil slices and maps as equal, as they are after a round-trip
+	if equality.Semantic.DeepEqual(*status, topic.Status) {
+		return nil
+	}
+	topic.Status = *status
+	return r.Client.Status().Update(ctx, topic)
+}
+
+// desiredTopicConfig returns the configuration to be pushed to the topic
+func desiredTopicConfig(topic *v1alpha1.KafkaTopic) map[string]*string {
+	return util.MapStringStringPointer(topic.Spec.Config)
+}
+
+func isReassignmentInProgress(topic *v1alpha1.KafkaTopic) bool {
+	return topic.Status.Reassignment != nil && topic.Status.Reassignment.State == v1alpha1.TopicReassignmentStateInProgress
+}
+
+// ensureReplicationFactor changes the replication factor of the existing topic and tracks the progress
+// of the reassignment in the status of the KafkaTopic. The replication throttle is removed once the
+// reassignment started by the operator has been finished.
+func (r *KafkaTopicReconciler) ensureReplicationFactor(ctx context.Context, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic) error {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	progress, err := broker.EnsureReplicationFactor(topic.Spec.Name, int16(topic.Spec.ReplicationFactor), topic.Spec.GetReplicationThrottleRate())
+	if err != nil {
+		return err
+	}
+
+	status := topic.Status.Reassignment.DeepCopy()
+	now := metav1.Now()
+	switch {
+	case progress.Submitted:
+		reqLogger.Info("Submitted reassignment to change the replication factor of topic",
+			"replicationFactor", topic.Spec.ReplicationFactor, "partitions", progress.PendingPartitions)
+		status = &v1alpha1.TopicReassignmentStatus{
+			State:             v1alpha1.TopicReassignmentStateInProgress,
+			ReplicationFactor: topic.Spec.ReplicationFactor,
+			Partitions:        progress.PendingPartitions,
+			PendingPartitions: progress.PendingPartitions,
+			StartTime:         &now,
+		}
+	case progress.PendingPartitions > 0:
+		// reassignments which were not started by the operator are not tracked
+		if isReassignmentInProgress(topic) {
+			status.PendingPartitions = progress.PendingPartitions
+		}
+	case isReassignmentInProgress(topic):
+		if err = broker.RemoveReplicationThrottle(topic.Spec.Name); err != nil {
+			return err
+		}
+		reqLogger.Info("Replication factor of topic has been changed", "replicationFactor", status.ReplicationFactor)
+		status.State = v1alpha1.TopicReassignmentStateCompleted
+		status.PendingPartitions = 0
+		status.CompletionTime = &now
+	}
+
+	if !reflect.DeepEqual(status, topic.Status.Reassignment) {
+		topic.Status.Reassignment = status
+		if err = r.Client.Status().Update(ctx, topic); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *KafkaTopicReconciler) ensureClusterLabel(ctx context.Context, cluster *v1beta1.KafkaCluster, topic *v1alpha1.KafkaTopic) (*v1alpha1.KafkaTopic, error) {
@@ -219,14 +406,22 @@ func (r *KafkaTopicReconciler) updateAndFetchLatest(ctx context.Context, topic *
 	return topic, nil
 }
 
-func (r *KafkaTopicReconciler) checkFinalizers(ctx context.Context, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic) (reconcile.Result, error) {
+func (r *KafkaTopicReconciler) checkFinalizers(ctx context.Context, broker kafkaclient.KafkaClient, cluster *v1beta1.KafkaCluster, topic *v1alpha1.KafkaTopic) (reconcile.Result, error) {
 	reqLogger := logr.FromContextOrDiscard(ctx)
 	reqLogger.Info("Kafka topic is marked for deletion")
 	var err error
 	if util.StringSliceContains(topic.GetFinalizers(), topicFinalizer) {
-		// Remove topic from Kafka cluster when it is managed by Koperator
+		// Remove topic from Kafka cluster when it is managed by Koperator, according to its deletion policy
 		if isTopicManagedByKoperator(topic) {
-			if err = r.finalizeKafkaTopic(reqLogger, broker, topic); err != nil {
+			switch topic.Spec.GetDeletionPolicy(cluster) {
+			case v1alpha1.TopicDeletionPolicyDelete:
+				err = r.finalizeKafkaTopic(reqLogger, broker, topic)
+			case v1alpha1.TopicDeletionPolicyRetain:
+				err = r.retainKafkaTopic(reqLogger, broker, topic)
+			case v1alpha1.TopicDeletionPolicyOrphan:
+				reqLogger.Info("Orphaned topic")
+			}
+			if err != nil {
 				return requeueWithError(reqLogger, "failed to finalize kafkatopic", err)
 			}
 		}
@@ -262,3 +457,19 @@ func (r *KafkaTopicReconciler) finalizeKafkaTopic(reqLogger logr.Logger, broker
 	}
 	return nil
 }
+
+// retainKafkaTopic keeps the topic and its data on the Kafka cluster and removes the replication throttling
+// which may have been left behind by an interrupted replication factor change
+func (r *KafkaTopicReconciler) retainKafkaTopic(reqLogger logr.Logger, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic) error {
+	exists, err := broker.GetTopic(topic.Spec.Name)
+	if err != nil {
+		return err
+	}
+	if exists != nil && isReassignmentInProgress(topic) {
+		if err = broker.RemoveReplicationThrottle(topic.Spec.Name); err != nil {
+			return err
+		}
+	}
+	reqLogger.Info("Retained topic")
+	return nil
+}
@@ -68,3 +68,49 @@ func TestIsTopicManagedByKoperator(t *testing.T) {
 		})
 	}
 }
+
+func TestRequiredTopicWrites(t *testing.T) {
+	observed := &v1alpha1.KafkaTopicStatus{
+		PartitionCount:    3,
+		ReplicationFactor: 2,
+		Config:            map[string]string{"cleanup.policy": "delete", "retention.ms": "3600000"},
+	}
+	newTopic := func() *v1alpha1.KafkaTopic {
+		return &v1alpha1.KafkaTopic{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec: v1alpha1.KafkaTopicSpec{
+				Partitions:        3,
+				ReplicationFactor: 2,
+				Config:            map[string]string{"retention.ms": "3600000"},
+			},
+			Status: v1alpha1.KafkaTopicStatus{ObservedGeneration: 2},
+		}
+	}
+
+	topic := newTopic()
+	assert.Equal(t, topicWrites{}, requiredTopicWrites(topic, observed))
+	assert.False(t, requiredTopicWrites(topic, observed).any())
+
+	topic = newTopic()
+	topic.Generation = 3
+	assert.Equal(t, topicWrites{config: true}, requiredTopicWrites(topic, observed))
+
+	topic = newTopic()
+	topic.Spec.Partitions = 6
+	topic.Spec.ReplicationFactor = 3
+	assert.Equal(t, topicWrites{partitions: true, replicationFactor: true}, requiredTopicWrites(topic, observed))
+
+	topic = newTopic()
+	topic.Spec.ReplicationFactor = -1
+	assert.Equal(t, topicWrites{}, requiredTopicWrites(topic, observed))
+
+	topic = newTopic()
+	topic.Status.Reassignment = &v1alpha1.TopicReassignmentStatus{State: v1alpha1.TopicReassignmentStateInProgress}
+	assert.Equal(t, topicWrites{replicationFactor: true}, requiredTopicWrites(topic, observed))
+
+	topic = newTopic()
+	topic.Spec.Config["retention.ms"] = "7200000"
+	topic.Spec.DriftPolicy = v1alpha1.TopicDriftPolicyEnforce
+	// drifted configuration is reverted by the drift scanner
+	assert.Equal(t, topicWrites{}, requiredTopicWrites(topic, observed))
+}
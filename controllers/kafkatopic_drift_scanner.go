@@ -0,0 +1,312 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+const (
+	topicDriftReverted = "TopicDriftReverted"
+	topicDriftDetected = "TopicDriftDetected"
+	topicDriftAdopted  = "TopicDriftAdopted"
+)
+
+// KafkaTopicDriftScanner periodically compares the topics of the Kafka clusters with their KafkaTopics
+// and handles the changes made outside of the KafkaTopics according to their drift policy
+type KafkaTopicDriftScanner struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	Interval time.Duration
+}
+
+// SetupKafkaTopicDriftScannerWithManager adds the KafkaTopic drift scanner to the manager
+func SetupKafkaTopicDriftScannerWithManager(mgr manager.Manager, interval time.Duration) error {
+	return mgr.Add(&KafkaTopicDriftScanner{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("kafkatopic-drift-scanner"),
+		Interval: interval,
+	})
+}
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Start runs the drift scan periodically until the context is done
+func (s *KafkaTopicDriftScanner) Start(ctx context.Context) error {
+	log := logf.Log.WithName("kafkatopic-drift-scanner")
+	ctx = logr.NewContext(ctx, log)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Scan(ctx); err != nil {
+				log.Error(err, "failed to scan topics for drift")
+			}
+		}
+	}
+}
+
+// Scan checks the KafkaTopics of every Kafka cluster for drift
+func (s *KafkaTopicDriftScanner) Scan(ctx context.Context) error {
+	log := logr.FromContextOrDiscard(ctx)
+
+	topicList := &v1alpha1.KafkaTopicList{}
+	if err := s.Client.List(ctx, topicList); err != nil {
+		return errors.WrapIf(err, "failed to list kafkatopics")
+	}
+
+	topicsByCluster := make(map[types.NamespacedName][]*v1alpha1.KafkaTopic)
+	for i := range topicList.Items {
+		topic := &topicList.Items[i]
+		if util.ObjectManagedByClusterRegistry(topic) {
+			continue
+		}
+		cluster := types.NamespacedName{
+			Name:      topic.Spec.ClusterRef.Name,
+			Namespace: getClusterRefNamespace(topic.Namespace, topic.Spec.ClusterRef),
+		}
+		topicsByCluster[cluster] = append(topicsByCluster[cluster], topic)
+	}
+
+	for cluster, topics := range topicsByCluster {
+		if err := s.scanCluster(ctx, cluster, topics); err != nil {
+			log.Error(err, "failed to scan topics of kafka cluster for drift", "kafkaCluster", cluster)
+		}
+	}
+	return nil
+}
+
+func (s *KafkaTopicDriftScanner) scanCluster(ctx context.Context, clusterName types.NamespacedName, topics []*v1alpha1.KafkaTopic) error {
+	cluster, err := k8sutil.LookupKafkaCluster(ctx, s.Client, clusterName.Name, clusterName.Namespace)
+	if err != nil {
+		return errors.WrapIf(err, "failed to lookup referenced cluster")
+	}
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) || util.ObjectManagedByClusterRegistry(cluster) {
+		return nil
+	}
+
+	broker, close, err := newKafkaFromCluster(s.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	existing, err := broker.ListTopics()
+	if err != nil {
+		return errors.WrapIf(err, "failed to list topics")
+	}
+
+	for _, topic := range topics {
+		// changes of the KafkaTopic which have not been pushed to Kafka yet are not drift
+		if k8sutil.IsMarkedForDeletion(topic.ObjectMeta) || !isTopicManagedByKoperator(topic) ||
+			topic.Status.State != v1alpha1.TopicStateCreated || topic.Status.ObservedGeneration != topic.Generation {
+			continue
+		}
+		log := logr.FromContextOrDiscard(ctx).WithValues("kafkaTopic", types.NamespacedName{Name: topic.Name, Namespace: topic.Namespace})
+
+		var overrides map[string]string
+		_, exists := existing[topic.Spec.Name]
+		if exists {
+			// the config entries of ListTopics include the values inherited from the brokers as well,
+			// only the configs set on the topic are compared with the KafkaTopic
+			if overrides, err = broker.DescribeTopicConfigOverrides(topic.Spec.Name); err != nil {
+				log.Error(err, "failed to describe topic config")
+				continue
+			}
+		}
+		drift := detectTopicDrift(topic, exists, overrides)
+		if err = s.handleDrift(ctx, broker, topic, drift); err != nil {
+			log.Error(err, "failed to handle topic drift")
+		}
+	}
+	return nil
+}
+
+// detectTopicDrift returns the difference between the topic on the Kafka cluster and the KafkaTopic,
+// or nil if they match. The overrides are the DYNAMIC_TOPIC_CONFIG entries of the topic.
+func detectTopicDrift(topic *v1alpha1.KafkaTopic, exists bool, overrides map[string]string) *v1alpha1.TopicDriftStatus {
+	if !exists {
+		return &v1alpha1.TopicDriftStatus{Missing: true}
+	}
+
+	actual := make(map[string]string, len(overrides))
+	for name, value := range overrides {
+		if kafkaclient.IsReplicationThrottleConfig(name) {
+			continue
+		}
+		actual[name] = value
+	}
+
+	names := make([]string, 0, len(actual)+len(topic.Spec.Config))
+	for name := range actual {
+		names = append(names, name)
+	}
+	for name := range topic.Spec.Config {
+		if _, ok := actual[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var configDrift []v1alpha1.TopicConfigDrift
+	for _, name := range names {
+		desired, inSpec := topic.Spec.Config[name]
+		value, inKafka := actual[name]
+		if inSpec == inKafka && desired == value {
+			continue
+		}
+		configDrift = append(configDrift, v1alpha1.TopicConfigDrift{Name: name, Desired: desired, Actual: value})
+	}
+	if len(configDrift) == 0 {
+		return nil
+	}
+	return &v1alpha1.TopicDriftStatus{Config: configDrift}
+}
+
+// handleDrift reverts, reports or adopts the drift according to the drift policy of the topic
+// and records it in the status of the KafkaTopic
+func (s *KafkaTopicDriftScanner) handleDrift(ctx context.Context, broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic, drift *v1alpha1.TopicDriftStatus) error {
+	log := logr.FromContextOrDiscard(ctx).WithValues("kafkaTopic", types.NamespacedName{Name: topic.Name, Namespace: topic.Namespace})
+	status := topic.Status.DeepCopy()
+	condition := metav1.Condition{
+		Type:               v1alpha1.TopicConditionInSync,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: topic.Generation,
+		Reason:             "InSync",
+		Message:            "The topic matches the KafkaTopic",
+	}
+
+	if drift != nil {
+		description := describeTopicDrift(drift)
+		policy := topic.Spec.GetDriftPolicy()
+		switch {
+		case policy == v1alpha1.TopicDriftPolicyEnforce:
+			if err := revertTopicDrift(broker, topic, drift); err != nil {
+				return err
+			}
+			log.Info("Reverted topic drift", "drift", description)
+			s.Recorder.Event(topic, corev1.EventTypeWarning, topicDriftReverted, "Reverted "+description)
+			drift.Action = v1alpha1.TopicDriftActionReverted
+			condition.Reason = topicDriftReverted
+			condition.Message = "Reverted " + description
+		case policy == v1alpha1.TopicDriftPolicyAdopt && !drift.Missing:
+			topic.Spec.Config = adoptedTopicConfig(topic.Spec.Config, drift)
+			if err := s.Client.Update(ctx, topic); err != nil {
+				return errors.WrapIf(err, "failed to adopt topic config")
+			}
+			log.Info("Adopted topic drift", "drift", description)
+			s.Recorder.Event(topic, corev1.EventTypeNormal, topicDriftAdopted, "Adopted "+description)
+			drift.Action = v1alpha1.TopicDriftActionAdopted
+			condition.ObservedGeneration = topic.Generation
+			condition.Reason = topicDriftAdopted
+			condition.Message = "Adopted " + description
+		default:
+			// report only, deleted topics can't be adopted either
+			drift.Action = v1alpha1.TopicDriftActionReported
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = topicDriftDetected
+			condition.Message = "Detected " + description
+			if !sameTopicDrift(topic.Status.Drift, drift) {
+				log.Info("Detected topic drift", "drift", description)
+				s.Recorder.Event(topic, corev1.EventTypeWarning, topicDriftDetected, "Detected "+description)
+			}
+		}
+
+		drift.DetectedTime = metav1.Now()
+		if sameTopicDrift(topic.Status.Drift, drift) {
+			// keep the time when the same drift has been detected first
+			drift.DetectedTime = topic.Status.Drift.DetectedTime
+		}
+		status.Drift = drift
+	}
+	apimeta.SetStatusCondition(&status.Conditions, condition)
+
+	if equality.Semantic.DeepEqual(*status, topic.Status) {
+		return nil
+	}
+	topic.Status = *status
+	return s.Client.Status().Update(ctx, topic)
+}
+
+func revertTopicDrift(broker kafkaclient.KafkaClient, topic *v1alpha1.KafkaTopic, drift *v1alpha1.TopicDriftStatus) error {
+	if drift.Missing {
+		return broker.CreateTopic(&kafkaclient.CreateTopicOptions{
+			Name:              topic.Spec.Name,
+			Partitions:        topic.Spec.Partitions,
+			ReplicationFactor: int16(topic.Spec.ReplicationFactor),
+			Config:            util.MapStringStringPointer(topic.Spec.Config),
+		})
+	}
+	return broker.EnsureTopicConfig(topic.Spec.Name, desiredTopicConfig(topic))
+}
+
+// adoptedTopicConfig returns the topic config with the drifted values of the Kafka cluster
+func adoptedTopicConfig(config map[string]string, drift *v1alpha1.TopicDriftStatus) map[string]string {
+	adopted := make(map[string]string, len(config))
+	for name, value := range config {
+		adopted[name] = value
+	}
+	for _, c := range drift.Config {
+		if c.Actual == "" {
+			delete(adopted, c.Name)
+			continue
+		}
+		adopted[c.Name] = c.Actual
+	}
+	return adopted
+}
+
+func sameTopicDrift(a, b *v1alpha1.TopicDriftStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Missing == b.Missing && a.Action == b.Action && equality.Semantic.DeepEqual(a.Config, b.Config)
+}
+
+func describeTopicDrift(drift *v1alpha1.TopicDriftStatus) string {
+	if drift.Missing {
+		return "deletion of the topic from the Kafka cluster"
+	}
+	changes := make([]string, 0, len(drift.Config))
+	for _, c := range drift.Config {
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", c.Name, c.Desired, c.Actual))
+	}
+	return "topic config changes (" + strings.Join(changes, ", ") + ")"
+}
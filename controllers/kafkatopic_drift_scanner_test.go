@@ -0,0 +1,145 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+)
+
+func TestDetectTopicDrift(t *testing.T) {
+	overrides := map[string]string{
+		"retention.ms":                          "1000",
+		"cleanup.policy":                        "compact",
+		"leader.replication.throttled.replicas": "0:0",
+	}
+
+	testCases := []struct {
+		testName string
+		topic    v1alpha1.KafkaTopicSpec
+		expected *v1alpha1.TopicDriftStatus
+	}{
+		{
+			testName: "topic is in sync",
+			topic: v1alpha1.KafkaTopicSpec{
+				Name:   "test-topic",
+				Config: map[string]string{"retention.ms": "1000", "cleanup.policy": "compact"},
+			},
+		},
+		{
+			testName: "topic is missing",
+			topic: v1alpha1.KafkaTopicSpec{
+				Name: "missing-topic",
+			},
+			expected: &v1alpha1.TopicDriftStatus{Missing: true},
+		},
+		{
+			testName: "topic config changed, added and removed",
+			topic: v1alpha1.KafkaTopicSpec{
+				Name:   "test-topic",
+				Config: map[string]string{"retention.ms": "2000", "max.message.bytes": "1024"},
+			},
+			expected: &v1alpha1.TopicDriftStatus{
+				Config: []v1alpha1.TopicConfigDrift{
+					{Name: "cleanup.policy", Actual: "compact"},
+					{Name: "max.message.bytes", Desired: "1024"},
+					{Name: "retention.ms", Desired: "2000", Actual: "1000"},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			t.Parallel()
+			topic := &v1alpha1.KafkaTopic{Spec: testCase.topic}
+			exists := testCase.topic.Name == "test-topic"
+			assert.Equal(t, testCase.expected, detectTopicDrift(topic, exists, overrides))
+		})
+	}
+}
+
+func TestAdoptedTopicConfig(t *testing.T) {
+	adopted := adoptedTopicConfig(map[string]string{"retention.ms": "2000", "max.message.bytes": "1024"}, &v1alpha1.TopicDriftStatus{
+		Config: []v1alpha1.TopicConfigDrift{
+			{Name: "cleanup.policy", Actual: "compact"},
+			{Name: "max.message.bytes", Desired: "1024"},
+			{Name: "retention.ms", Desired: "2000", Actual: "1000"},
+		},
+	})
+	assert.Equal(t, map[string]string{"retention.ms": "1000", "cleanup.policy": "compact"}, adopted)
+}
+
+func TestHandleTopicDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	drift := func() *v1alpha1.TopicDriftStatus {
+		return &v1alpha1.TopicDriftStatus{
+			Config: []v1alpha1.TopicConfigDrift{{Name: "retention.ms", Desired: "2000", Actual: "1000"}},
+		}
+	}
+
+	testCases := []struct {
+		policy            v1alpha1.TopicDriftPolicy
+		expectedAction    v1alpha1.TopicDriftAction
+		expectedInSync    bool
+		expectedRetention string
+	}{
+		{policy: v1alpha1.TopicDriftPolicyEnforce, expectedAction: v1alpha1.TopicDriftActionReverted, expectedInSync: true, expectedRetention: "2000"},
+		{policy: v1alpha1.TopicDriftPolicyReportOnly, expectedAction: v1alpha1.TopicDriftActionReported, expectedInSync: false, expectedRetention: "2000"},
+		{policy: v1alpha1.TopicDriftPolicyAdopt, expectedAction: v1alpha1.TopicDriftActionAdopted, expectedInSync: true, expectedRetention: "1000"},
+	}
+
+	for _, testCase := range testCases {
+		topic := &v1alpha1.KafkaTopic{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-topic", Namespace: "kafka"},
+			Spec: v1alpha1.KafkaTopicSpec{
+				Name:        "test-topic",
+				Config:      map[string]string{"retention.ms": "2000"},
+				DriftPolicy: testCase.policy,
+			},
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(topic).Build()
+		recorder := record.NewFakeRecorder(10)
+		scanner := &KafkaTopicDriftScanner{Client: k8sClient, Recorder: recorder}
+		broker, closeBroker, _ := kafkaclient.NewMockFromCluster(k8sClient, nil)
+
+		err := scanner.handleDrift(context.Background(), broker, topic, drift())
+		closeBroker()
+		require.NoError(t, err, testCase.policy)
+
+		actual := &v1alpha1.KafkaTopic{}
+		require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "test-topic", Namespace: "kafka"}, actual))
+		require.NotNil(t, actual.Status.Drift, testCase.policy)
+		assert.Equal(t, testCase.expectedAction, actual.Status.Drift.Action, testCase.policy)
+		assert.Equal(t, testCase.expectedInSync, apimeta.IsStatusConditionTrue(actual.Status.Conditions, v1alpha1.TopicConditionInSync), testCase.policy)
+		assert.Equal(t, testCase.expectedRetention, actual.Spec.Config["retention.ms"], testCase.policy)
+		assert.Len(t, recorder.Events, 1, testCase.policy)
+	}
+}
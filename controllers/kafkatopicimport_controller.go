@@ -0,0 +1,287 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+	"github.com/banzaicloud/koperator/pkg/webhooks"
+)
+
+// SetupKafkaTopicImportWithManager registers KafkaTopicImport controller to the manager
+func SetupKafkaTopicImportWithManager(mgr ctrl.Manager) *ctrl.Builder {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.KafkaTopicImport{}).
+		WithEventFilter(SkipClusterRegistryOwnedResourcePredicate{}).
+		Named("KafkaTopicImport")
+}
+
+// blank assignment to verify that KafkaTopicImportReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &KafkaTopicImportReconciler{}
+
+// KafkaTopicImportReconciler reconciles a KafkaTopicImport object
+type KafkaTopicImportReconciler struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkatopicimports,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=kafka.banzaicloud.io,resources=kafkatopicimports/status,verbs=get;update;patch
+
+// Reconcile creates a KafkaTopic for each existing topic of the Kafka cluster which has no KafkaTopic yet.
+// The KafkaTopics are created in the namespace of the KafkaTopicImport and are not owned by it, so
+// deleting the KafkaTopicImport leaves them (and the topics) intact. The import is carried out only once.
+func (r *KafkaTopicImportReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("Reconciling KafkaTopicImport")
+	var err error
+
+	// Fetch the KafkaTopicImport instance
+	instance := &v1alpha1.KafkaTopicImport{}
+	if err = r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			return reconciled()
+		}
+		// Error reading the object - requeue the request.
+		return requeueWithError(reqLogger, err.Error(), err)
+	}
+
+	if instance.Status.State == v1alpha1.TopicImportStateCompleted || instance.Status.State == v1alpha1.TopicImportStateFailed {
+		return reconciled()
+	}
+
+	includes, err := compilePatterns(instance.Spec.IncludePatterns)
+	if err != nil {
+		return r.failImport(ctx, instance, err)
+	}
+	excludes, err := compilePatterns(instance.Spec.GetExcludePatterns())
+	if err != nil {
+		return r.failImport(ctx, instance, err)
+	}
+
+	// Get the referenced kafkacluster
+	clusterNamespace := getClusterRefNamespace(instance.Namespace, instance.Spec.ClusterRef)
+	var cluster *v1beta1.KafkaCluster
+	if cluster, err = k8sutil.LookupKafkaCluster(ctx, r.Client, instance.Spec.ClusterRef.Name, clusterNamespace); err != nil {
+		return requeueWithError(reqLogger, "failed to lookup referenced cluster", err)
+	}
+	if util.ObjectManagedByClusterRegistry(cluster) {
+		return r.failImport(ctx, instance, errors.New("topics of remote kafka clusters can not be imported"))
+	}
+
+	// Get a kafka connection
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return checkBrokerConnectionError(reqLogger, err)
+	}
+	defer close()
+
+	existing, err := broker.ListTopics()
+	if err != nil {
+		return requeueWithError(reqLogger, "failed to list topics", err)
+	}
+
+	managed, err := r.managedTopics(ctx, cluster)
+	if err != nil {
+		return requeueWithError(reqLogger, "failed to list kafkatopics", err)
+	}
+
+	topicNames := make([]string, 0, len(existing))
+	for name := range existing {
+		topicNames = append(topicNames, name)
+	}
+	sort.Strings(topicNames)
+
+	status := instance.Status.DeepCopy()
+	status.State = v1alpha1.TopicImportStateInProgress
+	status.FailedTopics = nil
+	for _, name := range topicNames {
+		if !matchesAny(includes, name, true) || matchesAny(excludes, name, false) {
+			continue
+		}
+		if managed[name] {
+			if !util.StringSliceContains(status.ImportedTopics, name) && !util.StringSliceContains(status.SkippedTopics, name) {
+				status.SkippedTopics = append(status.SkippedTopics, name)
+			}
+			continue
+		}
+		// the config entries of ListTopics include the values inherited from the brokers as well
+		overrides, err := broker.DescribeTopicConfigOverrides(name)
+		if err != nil {
+			reqLogger.Info("failed to describe topic config", "topic", name, "error", err.Error())
+			status.FailedTopics = append(status.FailedTopics, v1alpha1.TopicImportFailure{Topic: name, Reason: err.Error()})
+			continue
+		}
+		topic := importedKafkaTopic(instance, cluster, name, existing[name], overrides)
+		if err = r.Client.Create(ctx, topic); err != nil {
+			reqLogger.Info("failed to import topic", "topic", name, "error", err.Error())
+			status.FailedTopics = append(status.FailedTopics, v1alpha1.TopicImportFailure{Topic: name, Reason: err.Error()})
+			continue
+		}
+		reqLogger.Info("Imported topic", "topic", name, "kafkaTopic", topic.Name)
+		status.ImportedTopics = append(status.ImportedTopics, name)
+	}
+
+	if len(status.FailedTopics) == 0 {
+		now := metav1.Now()
+		status.State = v1alpha1.TopicImportStateCompleted
+		status.CompletionTime = &now
+	}
+	if !reflect.DeepEqual(*status, instance.Status) {
+		instance.Status = *status
+		if err = r.Client.Status().Update(ctx, instance); err != nil {
+			return requeueWithError(reqLogger, "failed to update kafkatopicimport status", err)
+		}
+	}
+
+	if len(status.FailedTopics) > 0 {
+		return requeueWithError(reqLogger, "failed to import topics", errors.Errorf("%d topic(s) could not be imported", len(status.FailedTopics)))
+	}
+
+	reqLogger.Info("Imported topics", "imported", len(status.ImportedTopics), "skipped", len(status.SkippedTopics))
+
+	return reconciled()
+}
+
+func (r *KafkaTopicImportReconciler) failImport(ctx context.Context, instance *v1alpha1.KafkaTopicImport, reason error) (reconcile.Result, error) {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+	reqLogger.Info("KafkaTopicImport can not be carried out", "reason", reason.Error())
+	instance.Status.State = v1alpha1.TopicImportStateFailed
+	instance.Status.Message = reason.Error()
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return requeueWithError(reqLogger, "failed to update kafkatopicimport status", err)
+	}
+	return reconciled()
+}
+
+// managedTopics returns the names of the topics of the cluster which already have a KafkaTopic
+func (r *KafkaTopicImportReconciler) managedTopics(ctx context.Context, cluster *v1beta1.KafkaCluster) (map[string]bool, error) {
+	topicList := &v1alpha1.KafkaTopicList{}
+	if err := r.Client.List(ctx, topicList); err != nil {
+		return nil, err
+	}
+	managed := make(map[string]bool, len(topicList.Items))
+	for _, topic := range topicList.Items {
+		if topic.Spec.ClusterRef.Name == cluster.Name && getClusterRefNamespace(topic.Namespace, topic.Spec.ClusterRef) == cluster.Namespace {
+			managed[topic.Spec.Name] = true
+		}
+	}
+	return managed, nil
+}
+
+// importedKafkaTopic returns the KafkaTopic of an existing topic. Its config consists of the
+// DYNAMIC_TOPIC_CONFIG entries of the topic, as the KafkaTopic webhook expects for existing topics.
+// The replication throttle of an ongoing reassignment is not part of the desired config.
+func importedKafkaTopic(instance *v1alpha1.KafkaTopicImport, cluster *v1beta1.KafkaCluster, name string, detail sarama.TopicDetail, overrides map[string]string) *v1alpha1.KafkaTopic {
+	var config map[string]string
+	for key, value := range overrides {
+		if kafkaclient.IsReplicationThrottleConfig(key) {
+			continue
+		}
+		if config == nil {
+			config = make(map[string]string, len(overrides))
+		}
+		config[key] = value
+	}
+	return &v1alpha1.KafkaTopic{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        kafkaTopicNameForTopic(name),
+			Namespace:   instance.Namespace,
+			Labels:      applyClusterRefLabel(cluster, nil),
+			Annotations: map[string]string{webhooks.TopicManagedByAnnotationKey: webhooks.TopicManagedByKoperatorAnnotationValue},
+		},
+		Spec: v1alpha1.KafkaTopicSpec{
+			Name:              name,
+			Partitions:        detail.NumPartitions,
+			ReplicationFactor: int32(detail.ReplicationFactor),
+			Config:            config,
+			ClusterRef: v1alpha1.ClusterReference{
+				Name:      cluster.Name,
+				Namespace: cluster.Namespace,
+			},
+		},
+	}
+}
+
+var invalidKafkaTopicNameChars = regexp.MustCompile(`[^a-z0-9.-]`)
+
+// kafkaTopicNameForTopic returns a valid Kubernetes object name for the topic. Kafka topic names may contain
+// upper case letters and underscores, such names are sanitized and suffixed with the hash of the topic name
+// to keep them unique.
+func kafkaTopicNameForTopic(topic string) string {
+	name := strings.Trim(invalidKafkaTopicNameChars.ReplaceAllString(strings.ToLower(topic), "-"), ".-")
+	if name == topic && len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+	hash := sha256.Sum256([]byte(topic))
+	suffix := hex.EncodeToString(hash[:])[:8]
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], ".-")
+	}
+	if name == "" {
+		return "topic-" + suffix
+	}
+	return name + "-" + suffix
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.WrapIff(err, "invalid topic name pattern %q", pattern)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchesAny returns true if the name matches any of the patterns, or the given default when there are no patterns
+func matchesAny(patterns []*regexp.Regexp, name string, defaultValue bool) bool {
+	if len(patterns) == 0 {
+		return defaultValue
+	}
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
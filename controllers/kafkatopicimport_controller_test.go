@@ -0,0 +1,98 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+)
+
+func TestKafkaTopicNameForTopic(t *testing.T) {
+	testCases := []struct {
+		topic    string
+		expected string
+	}{
+		{topic: "orders", expected: "orders"},
+		{topic: "orders.v1", expected: "orders.v1"},
+		{topic: "Payments_v1", expected: "payments-v1-"},
+		{topic: "payments_v1", expected: "payments-v1-"},
+		{topic: "___", expected: "topic-"},
+	}
+
+	names := make(map[string]bool)
+	for _, testCase := range testCases {
+		name := kafkaTopicNameForTopic(testCase.topic)
+		assert.Empty(t, validation.IsDNS1123Subdomain(name), testCase.topic)
+		assert.False(t, names[name], "name of %s is not unique", testCase.topic)
+		names[name] = true
+		if testCase.expected == testCase.topic {
+			assert.Equal(t, testCase.expected, name)
+		} else {
+			assert.Regexp(t, "^"+testCase.expected+"[0-9a-f]{8}$", name)
+		}
+	}
+}
+
+func TestTopicImportPatterns(t *testing.T) {
+	spec := v1alpha1.KafkaTopicImportSpec{
+		IncludePatterns: []string{"^orders", "^payments"},
+		ExcludePatterns: []string{`\.tmp$`},
+	}
+	includes, err := compilePatterns(spec.IncludePatterns)
+	require.NoError(t, err)
+	excludes, err := compilePatterns(spec.GetExcludePatterns())
+	require.NoError(t, err)
+
+	imported := func(name string) bool {
+		return matchesAny(includes, name, true) && !matchesAny(excludes, name, false)
+	}
+	assert.True(t, imported("orders.v1"))
+	assert.True(t, imported("payments"))
+	assert.False(t, imported("orders.tmp"))
+	assert.False(t, imported("users"))
+	assert.False(t, matchesAny(excludes, "orders", false))
+	assert.True(t, matchesAny(excludes, "__consumer_offsets", false))
+	assert.True(t, matchesAny(excludes, "__CruiseControlMetrics", false))
+	assert.True(t, matchesAny(nil, "users", true))
+
+	_, err = compilePatterns([]string{"("})
+	assert.Error(t, err)
+}
+
+func TestImportedKafkaTopic(t *testing.T) {
+	instance := &v1alpha1.KafkaTopicImport{ObjectMeta: metav1.ObjectMeta{Name: "import", Namespace: "kafka"}}
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	detail := sarama.TopicDetail{NumPartitions: 3, ReplicationFactor: 2}
+
+	topic := importedKafkaTopic(instance, cluster, "orders", detail, map[string]string{
+		"retention.ms":                          "1000",
+		"leader.replication.throttled.replicas": "0:0",
+	})
+	assert.Equal(t, "orders", topic.Spec.Name)
+	assert.Equal(t, int32(3), topic.Spec.Partitions)
+	assert.Equal(t, int32(2), topic.Spec.ReplicationFactor)
+	assert.Equal(t, map[string]string{"retention.ms": "1000"}, topic.Spec.Config)
+
+	topic = importedKafkaTopic(instance, cluster, "orders", detail, map[string]string{})
+	assert.Nil(t, topic.Spec.Config)
+}
@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"reflect"
@@ -28,12 +29,15 @@ import (
 	certv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/go-logr/logr"
 	certsigningreqv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlBuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -57,8 +61,17 @@ var userFinalizer = "finalizer.kafkausers.kafka.banzaicloud.io"
 // SetupKafkaUserWithManager registers KafkaUser controller to the manager
 func SetupKafkaUserWithManager(mgr ctrl.Manager, certSigningEnabled bool, certManagerEnabled bool) *ctrl.Builder {
 	log := mgr.GetLogger()
+	passwordSecretMapper := passwordSecretMapper{
+		client: mgr.GetClient(),
+		log:    log,
+	}
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.KafkaUser{}).
+		// the secrets of the SCRAM users which hold their generated credentials
+		Owns(&corev1.Secret{}).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(passwordSecretMapper.mapToKafkaUsers)).
 		WithEventFilter(SkipClusterRegistryOwnedResourcePredicate{}).
 		Named("KafkaUser")
 	if certSigningEnabled {
@@ -141,6 +154,40 @@ func (m *csrMapper) mapToKafkaUser(obj client.Object) []ctrl.Request {
 	}}
 }
 
+type passwordSecretMapper struct {
+	client client.Reader
+	log    logr.Logger
+}
+
+// mapToKafkaUsers maps Secret events to reconcile events of the SCRAM KafkaUsers which take their password from the Secret
+func (m *passwordSecretMapper) mapToKafkaUsers(obj client.Object) []ctrl.Request {
+	var kafkaUsers v1alpha1.KafkaUserList
+	if err := m.client.List(context.Background(), &kafkaUsers, client.InNamespace(obj.GetNamespace())); err != nil {
+		m.log.Error(err, "couldn't list KafkaUsers", "namespace", obj.GetNamespace())
+		return []ctrl.Request{}
+	}
+
+	requests := []ctrl.Request{}
+	for i := range kafkaUsers.Items {
+		kafkaUser := &kafkaUsers.Items[i]
+		if !kafkaUser.Spec.IsScramAuthentication() || kafkaUser.Spec.Authentication.PasswordSecretRef == nil ||
+			kafkaUser.Spec.Authentication.PasswordSecretRef.Name != obj.GetName() {
+			continue
+		}
+		// skip reconciling KafkaUser if owned by Cluster Registry
+		if util.ObjectManagedByClusterRegistry(kafkaUser) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: kafkaUser.Namespace,
+				Name:      kafkaUser.Name,
+			},
+		})
+	}
+	return requests
+}
+
 // blank assignment to verify that KafkaUserReconciler implements reconcile.kafkaUserReconciler
 var _ reconcile.Reconciler = &KafkaUserReconciler{}
 
@@ -252,6 +299,9 @@ func (r *KafkaUserReconciler) Reconcile(ctx context.Context, request reconcile.R
 				return requeueWithError(reqLogger, "failed to finalize user certificate", err)
 			}
 		}
+	} else if instance.Spec.IsScramAuthentication() {
+		// SCRAM users are identified by their name
+		kafkaUser = instance.Name
 	} else {
 		kafkaUser = fmt.Sprintf("CN=%s", instance.Name)
 	}
@@ -266,15 +316,31 @@ func (r *KafkaUserReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return requeueWithError(reqLogger, "failed to ensure kafkacluster label on user", err)
 	}
 
-	// If topic grants supplied, grab a broker connection and set ACLs
-	if len(instance.Spec.TopicGrants) > 0 {
+	if instance.Spec.IsScramAuthentication() {
+		if err = r.reconcileScramCredentials(ctx, cluster, instance); err != nil {
+			return checkBrokerConnectionError(reqLogger, err)
+		}
+	} else if instance.Status.ScramCredentials {
+		// the user does not authenticate with SCRAM anymore
+		if err = r.finalizeKafkaUserScramCredentials(reqLogger, cluster, instance.Name); err != nil {
+			return checkBrokerConnectionError(reqLogger, err)
+		}
+	}
+
+	// If topic grants supplied or there were ACLs set previously, grab a broker connection and converge the ACLs
+	var addedACLs, removedACLs []string
+	if len(instance.Spec.TopicGrants) > 0 || len(instance.Status.ACLs) > 0 {
 		broker, close, err := newKafkaFromCluster(r.Client, cluster)
 		if err != nil {
 			return checkBrokerConnectionError(reqLogger, err)
 		}
 		defer close()
 
-		// TODO (tinyzimmer): Should probably take this opportunity to see if we are removing any ACLs
+		currentACLs, err := broker.ListUserACLsForPrincipal(kafkaUser)
+		if err != nil {
+			return requeueWithError(reqLogger, "failed to list ACLs of kafkauser", err)
+		}
+
 		for _, grant := range instance.Spec.TopicGrants {
 			reqLogger.Info(fmt.Sprintf("Ensuring %s ACLs for User: %s -> Topic: %s", grant.AccessType, kafkaUser, grant.TopicName))
 			// CreateUserACLs returns no error if the ACLs already exist
@@ -282,6 +348,57 @@ func (r *KafkaUserReconciler) Reconcile(ctx context.Context, request reconcile.R
 				return requeueWithError(reqLogger, "failed to ensure ACLs for kafkauser", err)
 			}
 		}
+
+		desiredACLs := kafkautil.GrantsToACLStrings(kafkaUser, instance.Spec.TopicGrants)
+		for _, acl := range desiredACLs {
+			if !util.StringSliceContains(currentACLs, acl) {
+				addedACLs = append(addedACLs, acl)
+			}
+		}
+
+		// remove the ACLs which were created for the topic grants of the user earlier but are not backed by any of
+		// them anymore, the ones declared by KafkaACLs or other KafkaUsers are kept as Kafka does not know which
+		// resource an ACL belongs to
+		sharedACLs, err := r.aclsDeclaredByOthers(ctx, cluster, instance)
+		if err != nil {
+			return requeueWithError(reqLogger, "failed to list the ACLs declared by KafkaACLs and other KafkaUsers", err)
+		}
+		for _, acl := range instance.Status.ACLs {
+			if util.StringSliceContains(desiredACLs, acl) {
+				continue
+			}
+			if util.StringSliceContains(sharedACLs, acl) {
+				reqLogger.Info("Keeping stale ACL of User which is declared by another resource", "user", kafkaUser, "acl", acl)
+				continue
+			}
+			// the ACLs of a former principal of the user (e.g. before switching from TLS to SCRAM authentication)
+			// are not listed for the current one
+			if isACLOfPrincipal(acl, kafkaUser) && !util.StringSliceContains(currentACLs, acl) {
+				continue
+			}
+			reqLogger.Info("Removing stale ACL of User", "user", kafkaUser, "acl", acl)
+			if err = broker.DeleteACL(acl); err != nil {
+				return requeueWithError(reqLogger, "failed to remove stale ACL of kafkauser", err)
+			}
+			removedACLs = append(removedACLs, acl)
+		}
+	}
+
+	// ensure the client quotas of the user, and remove them when they are not desired anymore
+	appliedQuotas := instance.Spec.Quotas
+	if instance.Spec.Quotas != nil || instance.Status.Quotas != nil {
+		quota, err := kafkaQuotaOfUser(ctx, r.Client, cluster, kafkaUser)
+		if err != nil {
+			return requeueWithError(reqLogger, "failed to list the KafkaQuotas of the cluster", err)
+		}
+		if quota != nil {
+			// the resources would overwrite the client quotas of each other
+			reqLogger.Info("Client quotas of the user are managed by a KafkaQuota, ignoring the quotas of the user",
+				"user", kafkaUser, "kafkaQuota", types.NamespacedName{Namespace: quota.Namespace, Name: quota.Name})
+			appliedQuotas = nil
+		} else if err = r.reconcileUserQuotas(ctx, cluster, instance, kafkaUser); err != nil {
+			return checkBrokerConnectionError(reqLogger, err)
+		}
 	}
 
 	// ensure a finalizer for cleanup on deletion
@@ -292,9 +409,16 @@ func (r *KafkaUserReconciler) Reconcile(ctx context.Context, request reconcile.R
 		}
 	}
 
-	// set user status
+	// set user status, the ACL changes are kept until the ACLs of the user change again
+	if len(addedACLs) == 0 && len(removedACLs) == 0 {
+		addedACLs, removedACLs = instance.Status.AddedACLs, instance.Status.RemovedACLs
+	}
 	instance.Status = v1alpha1.KafkaUserStatus{
-		State: v1alpha1.UserStateCreated,
+		State:            v1alpha1.UserStateCreated,
+		AddedACLs:        addedACLs,
+		RemovedACLs:      removedACLs,
+		Quotas:           appliedQuotas,
+		ScramCredentials: instance.Spec.IsScramAuthentication(),
 	}
 	if len(instance.Spec.TopicGrants) > 0 {
 		instance.Status.ACLs = kafkautil.GrantsToACLStrings(kafkaUser, instance.Spec.TopicGrants)
@@ -303,6 +427,12 @@ func (r *KafkaUserReconciler) Reconcile(ctx context.Context, request reconcile.R
 		return requeueWithError(reqLogger, "failed to update kafkauser status", err)
 	}
 
+	// periodically check the client quotas and the SCRAM credentials of the user to revert any changes made outside
+	// of the operator
+	if appliedQuotas != nil || instance.Spec.IsScramAuthentication() {
+		return requeueAfter(clientQuotaDriftCheckInterval)
+	}
+
 	return reconciled()
 }
 
@@ -330,11 +460,21 @@ func (r *KafkaUserReconciler) checkFinalizers(ctx context.Context, cluster *v1be
 	// run finalizers
 	var err error
 	if util.StringSliceContains(instance.GetFinalizers(), userFinalizer) {
-		if len(instance.Spec.TopicGrants) > 0 {
-			if err = r.finalizeKafkaUserACLs(reqLogger, cluster, user); err != nil {
+		if len(instance.Spec.TopicGrants) > 0 || len(instance.Status.ACLs) > 0 {
+			if err = r.finalizeKafkaUserACLs(ctx, cluster, instance, user); err != nil {
 				return requeueWithError(reqLogger, "failed to finalize kafkauser", err)
 			}
 		}
+		if instance.Status.Quotas != nil {
+			if err = r.finalizeKafkaUserQuotas(reqLogger, cluster, user); err != nil {
+				return requeueWithError(reqLogger, "failed to finalize kafkauser quotas", err)
+			}
+		}
+		if instance.Spec.IsScramAuthentication() || instance.Status.ScramCredentials {
+			if err = r.finalizeKafkaUserScramCredentials(reqLogger, cluster, instance.Name); err != nil {
+				return requeueWithError(reqLogger, "failed to finalize kafkauser SCRAM credentials", err)
+			}
+		}
 		// remove finalizer
 		if err = r.removeFinalizer(ctx, instance); err != nil {
 			return requeueWithError(reqLogger, "failed to remove finalizer from kafkauser", err)
@@ -349,25 +489,235 @@ func (r *KafkaUserReconciler) removeFinalizer(ctx context.Context, user *v1alpha
 	return err
 }
 
-func (r *KafkaUserReconciler) finalizeKafkaUserACLs(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string) error {
+func (r *KafkaUserReconciler) finalizeKafkaUserACLs(ctx context.Context, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser, user string) error {
+	reqLogger := logr.FromContextOrDiscard(ctx)
 	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
 		reqLogger.Info("Cluster is being deleted, skipping ACL deletion")
 		return nil
 	}
-	var err error
+	sharedACLs, err := r.aclsDeclaredByOthers(ctx, cluster, instance)
+	if err != nil {
+		return err
+	}
 	reqLogger.Info("Deleting user ACLs from kafka")
 	broker, close, err := newKafkaFromCluster(r.Client, cluster)
 	if err != nil {
 		return err
 	}
 	defer close()
-	if err = broker.DeleteUserACLs(user); err != nil {
+
+	userACLs := kafkautil.GrantsToACLStrings(user, instance.Spec.TopicGrants)
+	for _, acl := range instance.Status.ACLs {
+		if !util.StringSliceContains(userACLs, acl) {
+			userACLs = append(userACLs, acl)
+		}
+	}
+	keptACLs := false
+	for _, acl := range userACLs {
+		if util.StringSliceContains(sharedACLs, acl) {
+			reqLogger.Info("Keeping ACL of User which is declared by another resource", "user", user, "acl", acl)
+			keptACLs = true
+		}
+	}
+	// without ACLs declared by other resources all the ACLs of the principal can be removed at once
+	if !keptACLs {
+		return broker.DeleteUserACLs(user)
+	}
+	for _, acl := range userACLs {
+		if util.StringSliceContains(sharedACLs, acl) {
+			continue
+		}
+		if err = broker.DeleteACL(acl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aclsDeclaredByOthers returns the ACLs declared by the KafkaACLs of the cluster and the ACLs created for the topic
+// grants of the other KafkaUsers of the cluster
+func (r *KafkaUserReconciler) aclsDeclaredByOthers(ctx context.Context, cluster *v1beta1.KafkaCluster, user *v1alpha1.KafkaUser) ([]string, error) {
+	acls, err := kafkaACLsOfCluster(ctx, r.Client, cluster, "")
+	if err != nil {
+		return nil, err
+	}
+	userACLs, err := kafkaUserACLsOfCluster(ctx, r.Client, cluster, user.UID)
+	if err != nil {
+		return nil, err
+	}
+	return append(acls, userACLs...), nil
+}
+
+// isACLOfPrincipal returns true if the raw ACL string belongs to the given user principal
+func isACLOfPrincipal(acl string, user string) bool {
+	return strings.HasPrefix(acl, "User:"+user+",")
+}
+
+func (r *KafkaUserReconciler) finalizeKafkaUserScramCredentials(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string) error {
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping SCRAM credentials deletion")
+		return nil
+	}
+	reqLogger.Info("Deleting user SCRAM credentials from kafka")
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
 		return err
 	}
+	defer close()
+	return broker.DeleteUserScramCredentials(user)
+}
+
+// reconcileScramCredentials ensures the SCRAM-SHA-512 credentials of the user in Kafka and the
+// user secret which holds the credentials and the JAAS configuration for the clients
+func (r *KafkaUserReconciler) reconcileScramCredentials(ctx context.Context, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser) error {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+
+	userSecret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.Namespace}, userSecret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.WrapIfWithDetails(err, "failed to get user secret", "secretName", instance.Spec.SecretName)
+	}
+	userSecretExists := err == nil
+
+	var password []byte
+	if ref := instance.Spec.Authentication.PasswordSecretRef; ref != nil {
+		passwordSecret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: instance.Namespace}, passwordSecret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return errorfactory.New(errorfactory.ResourceNotReady{}, err, "password secret not found", "secretName", ref.Name)
+			}
+			return errors.WrapIfWithDetails(err, "failed to get password secret", "secretName", ref.Name)
+		}
+		var ok bool
+		if password, ok = passwordSecret.Data[ref.Key]; !ok || len(password) == 0 {
+			return errorfactory.New(errorfactory.ResourceNotReady{}, errors.New("password not found in secret"),
+				"password secret is not ready", "secretName", ref.Name, "key", ref.Key)
+		}
+	} else if userSecretExists && len(userSecret.Data[v1alpha1.PasswordKey]) > 0 {
+		// keep the already generated password
+		password = userSecret.Data[v1alpha1.PasswordKey]
+	} else {
+		generated, err := util.GetRandomString(32)
+		if err != nil {
+			return errors.WrapIf(err, "failed to generate password")
+		}
+		password = []byte(generated)
+	}
+
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	exists, err := broker.UserScramCredentialsExist(instance.Name)
+	if err != nil {
+		return err
+	}
+	passwordChanged := !userSecretExists || !bytes.Equal(userSecret.Data[v1alpha1.PasswordKey], password)
+	if !exists || passwordChanged {
+		reqLogger.Info("Ensuring SCRAM credentials", "user", instance.Name)
+		if err = broker.EnsureUserScramCredentials(instance.Name, password); err != nil {
+			return err
+		}
+	}
+
+	desiredData := map[string][]byte{
+		v1alpha1.UsernameKey:       []byte(instance.Name),
+		v1alpha1.PasswordKey:       password,
+		v1alpha1.SaslMechanismKey:  []byte("SCRAM-SHA-512"),
+		v1alpha1.SaslJaasConfigKey: []byte(scramJaasConfig(instance.Name, password)),
+	}
+
+	if !userSecretExists {
+		userSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance.Spec.SecretName,
+				Namespace: instance.Namespace,
+			},
+			Data: desiredData,
+		}
+		if err = controllerutil.SetControllerReference(instance, userSecret, r.Scheme); err != nil {
+			return errors.WrapIf(err, "failed to set controller reference on user secret")
+		}
+		return r.Client.Create(ctx, userSecret)
+	}
+
+	if !reflect.DeepEqual(userSecret.Data, desiredData) {
+		userSecret.Data = desiredData
+		return r.Client.Update(ctx, userSecret)
+	}
 	return nil
 }
 
+// jaasValueEscaper escapes the characters which have special meaning in the quoted values of a JAAS configuration
+var jaasValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// scramJaasConfig returns the JAAS configuration of the SCRAM login module for the given credentials
+func scramJaasConfig(username string, password []byte) string {
+	return fmt.Sprintf("org.apache.kafka.common.security.scram.ScramLoginModule required username=\"%s\" password=\"%s\";",
+		jaasValueEscaper.Replace(username), jaasValueEscaper.Replace(string(password)))
+}
+
 func (r *KafkaUserReconciler) addFinalizer(reqLogger logr.Logger, user *v1alpha1.KafkaUser) {
 	reqLogger.Info("Adding Finalizer for the KafkaUser")
 	user.SetFinalizers(append(user.GetFinalizers(), userFinalizer))
 }
+
+// reconcileUserQuotas ensures the client quotas of the user principal are the same as the desired ones
+func (r *KafkaUserReconciler) reconcileUserQuotas(ctx context.Context, cluster *v1beta1.KafkaCluster, instance *v1alpha1.KafkaUser, user string) error {
+	reqLogger := logr.FromContextOrDiscard(ctx)
+
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	entity := v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: user}
+	changed, err := broker.EnsureClientQuotas(entity, instance.Spec.Quotas)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "failed to ensure client quotas", "user", user)
+	}
+	if changed {
+		if reflect.DeepEqual(instance.Spec.Quotas, instance.Status.Quotas) {
+			reqLogger.Info("Client quotas of the user were changed outside of the operator, restored them", "user", user)
+		} else {
+			reqLogger.Info("Updated client quotas of the user", "user", user)
+		}
+	}
+	return nil
+}
+
+// kafkaQuotaOfUser returns the KafkaQuota of the cluster which manages the client quotas of the user principal, if any
+func kafkaQuotaOfUser(ctx context.Context, c client.Reader, cluster *v1beta1.KafkaCluster, user string) (*v1alpha1.KafkaQuota, error) {
+	quotaList := &v1alpha1.KafkaQuotaList{}
+	if err := c.List(ctx, quotaList); err != nil {
+		return nil, err
+	}
+	entity := v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: user}
+	for i := range quotaList.Items {
+		quota := &quotaList.Items[i]
+		if quota.Spec.Entity != entity || k8sutil.IsMarkedForDeletion(quota.ObjectMeta) ||
+			quota.Spec.ClusterRef.Name != cluster.Name ||
+			getClusterRefNamespace(quota.Namespace, quota.Spec.ClusterRef) != cluster.Namespace {
+			continue
+		}
+		return quota, nil
+	}
+	return nil, nil
+}
+
+func (r *KafkaUserReconciler) finalizeKafkaUserQuotas(reqLogger logr.Logger, cluster *v1beta1.KafkaCluster, user string) error {
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		reqLogger.Info("Cluster is being deleted, skipping client quota deletion")
+		return nil
+	}
+	reqLogger.Info("Deleting user client quotas from kafka")
+	broker, close, err := newKafkaFromCluster(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+	defer close()
+	return broker.DeleteClientQuotas(v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: user})
+}
@@ -0,0 +1,199 @@
+// Copyright © 2022 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+)
+
+func TestScramJaasConfig(t *testing.T) {
+	assert.Equal(t,
+		`org.apache.kafka.common.security.scram.ScramLoginModule required username="alice" password="s3cret";`,
+		scramJaasConfig("alice", []byte("s3cret")))
+	assert.Equal(t,
+		`org.apache.kafka.common.security.scram.ScramLoginModule required username="alice" password="a\"b\\c";`,
+		scramJaasConfig("alice", []byte(`a"b\c`)))
+}
+
+func TestPasswordSecretMapper(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	scramUser := func(name, namespace, secretName string) *v1alpha1.KafkaUser {
+		user := &v1alpha1.KafkaUser{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: v1alpha1.KafkaUserSpec{
+				Authentication: &v1alpha1.UserAuthentication{Type: v1alpha1.UserAuthenticationTypeScramSha512},
+			},
+		}
+		if secretName != "" {
+			user.Spec.Authentication.PasswordSecretRef = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  "password",
+			}
+		}
+		return user
+	}
+
+	mapper := passwordSecretMapper{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			scramUser("alice", "kafka", "passwords"),
+			scramUser("bob", "kafka", "other-passwords"),
+			scramUser("carol", "kafka", ""),
+			scramUser("dave", "other", "passwords"),
+			&v1alpha1.KafkaUser{ObjectMeta: metav1.ObjectMeta{Name: "eve", Namespace: "kafka"}},
+		).Build(),
+		log: logr.Discard(),
+	}
+
+	requests := mapper.mapToKafkaUsers(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "passwords", Namespace: "kafka"}})
+	assert.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "kafka", Name: "alice"}}}, requests)
+
+	requests = mapper.mapToKafkaUsers(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "kafka"}})
+	assert.Empty(t, requests)
+}
+
+func TestIsACLOfPrincipal(t *testing.T) {
+	assert.True(t, isACLOfPrincipal("User:CN=alice,Topic,LITERAL,orders,Read,Allow,*", "CN=alice"))
+	assert.False(t, isACLOfPrincipal("User:CN=alice,Topic,LITERAL,orders,Read,Allow,*", "alice"))
+	assert.False(t, isACLOfPrincipal("User:CN=alice-admin,Topic,LITERAL,orders,Read,Allow,*", "CN=alice"))
+}
+
+func TestKafkaACLsOfCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	kafkaACL := func(name, namespace, clusterName string) *v1alpha1.KafkaACL {
+		return &v1alpha1.KafkaACL{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(name)},
+			Spec: v1alpha1.KafkaACLSpec{
+				ClusterRef: v1alpha1.ClusterReference{Name: clusterName, Namespace: "kafka"},
+				Principal:  "User:alice",
+				Rules: []v1alpha1.KafkaACLRule{{
+					Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: name},
+					Operation: v1alpha1.KafkaACLOperationRead,
+				}},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		kafkaACL("orders", "default", "kafka"),
+		kafkaACL("payments", "kafka", "kafka"),
+		kafkaACL("other", "kafka", "other"),
+	).Build()
+
+	acls, err := kafkaACLsOfCluster(context.Background(), c, cluster, "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"User:alice,Topic,LITERAL,orders,Read,Allow,*",
+		"User:alice,Topic,LITERAL,payments,Read,Allow,*",
+	}, acls)
+
+	acls, err = kafkaACLsOfCluster(context.Background(), c, cluster, "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"User:alice,Topic,LITERAL,payments,Read,Allow,*"}, acls)
+}
+
+func TestKafkaUserACLsOfCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	kafkaUser := func(name, clusterName string, acls ...string) *v1alpha1.KafkaUser {
+		return &v1alpha1.KafkaUser{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kafka", UID: types.UID(name)},
+			Spec:       v1alpha1.KafkaUserSpec{ClusterRef: v1alpha1.ClusterReference{Name: clusterName}},
+			Status:     v1alpha1.KafkaUserStatus{ACLs: acls},
+		}
+	}
+	kafkaACL := &v1alpha1.KafkaACL{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "kafka", UID: "alice-acl"},
+		Spec: v1alpha1.KafkaACLSpec{
+			ClusterRef: v1alpha1.ClusterReference{Name: "kafka"},
+			Principal:  "User:CN=alice",
+			Rules: []v1alpha1.KafkaACLRule{{
+				Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "orders"},
+				Operation: v1alpha1.KafkaACLOperationRead,
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		kafkaUser("alice", "kafka", "User:CN=alice,Topic,LITERAL,orders,Describe,Allow,*"),
+		kafkaUser("bob", "kafka", "User:CN=bob,Topic,LITERAL,orders,Describe,Allow,*"),
+		kafkaUser("carol", "other", "User:CN=carol,Topic,LITERAL,orders,Describe,Allow,*"),
+		kafkaACL,
+	).Build()
+
+	acls, err := kafkaUserACLsOfCluster(context.Background(), c, cluster, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"User:CN=alice,Topic,LITERAL,orders,Describe,Allow,*"}, acls)
+
+	// the KafkaACLs keep the ACLs recorded by the KafkaUsers
+	r := KafkaACLReconciler{Client: c}
+	acls, err = r.aclsDeclaredByOthers(context.Background(), cluster, kafkaACL)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"User:CN=alice,Topic,LITERAL,orders,Describe,Allow,*",
+		"User:CN=bob,Topic,LITERAL,orders,Describe,Allow,*",
+	}, acls)
+}
+
+func TestKafkaQuotaOfUser(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1alpha1.KafkaQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "kafka"},
+			Spec: v1alpha1.KafkaQuotaSpec{
+				ClusterRef: v1alpha1.ClusterReference{Name: "kafka"},
+				Entity:     v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: "CN=alice"},
+			},
+		},
+		&v1alpha1.KafkaQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "bob", Namespace: "kafka"},
+			Spec: v1alpha1.KafkaQuotaSpec{
+				ClusterRef: v1alpha1.ClusterReference{Name: "other"},
+				Entity:     v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: "CN=bob"},
+			},
+		},
+	).Build()
+
+	quota, err := kafkaQuotaOfUser(context.Background(), c, cluster, "CN=alice")
+	assert.NoError(t, err)
+	if assert.NotNil(t, quota) {
+		assert.Equal(t, "alice", quota.Name)
+	}
+
+	quota, err = kafkaQuotaOfUser(context.Background(), c, cluster, "CN=bob")
+	assert.NoError(t, err)
+	assert.Nil(t, quota)
+}
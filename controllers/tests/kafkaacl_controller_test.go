@@ -0,0 +1,238 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+)
+
+var _ = Describe("KafkaACL", func() {
+	var (
+		count              uint64 = 0
+		namespace          string
+		namespaceObj       *corev1.Namespace
+		kafkaClusterCRName string
+		kafkaCluster       *v1beta1.KafkaCluster
+	)
+
+	BeforeEach(func() {
+		atomic.AddUint64(&count, 1)
+
+		namespace = fmt.Sprintf("kafka-acl-%v", count)
+		namespaceObj = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		}
+
+		kafkaClusterCRName = fmt.Sprintf("kafkacluster-%d", count)
+		kafkaCluster = createMinimalKafkaClusterCR(kafkaClusterCRName, namespace)
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		By("creating namespace " + namespace)
+		err := k8sClient.Create(ctx, namespaceObj)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err = k8sClient.Create(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		waitForClusterRunningState(ctx, kafkaCluster, namespace)
+	})
+
+	JustAfterEach(func(ctx SpecContext) {
+		resetMockKafkaClient(kafkaCluster)
+
+		By("deleting Kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err := k8sClient.Delete(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+		kafkaCluster = nil
+	})
+
+	It("converges ACLs and removes them on deletion", func(ctx SpecContext) {
+		aclCRName := fmt.Sprintf("kafkaacl-%v", count)
+		acl := v1alpha1.KafkaACL{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      aclCRName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaACLSpec{
+				ClusterRef: v1alpha1.ClusterReference{
+					Namespace: namespace,
+					Name:      kafkaClusterCRName,
+				},
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource: v1alpha1.KafkaACLResource{
+							Type:        v1alpha1.KafkaACLResourceTypeTopic,
+							Name:        "orders-",
+							PatternType: v1alpha1.KafkaPatternTypePrefixed,
+						},
+						Operation: v1alpha1.KafkaACLOperationRead,
+					},
+					{
+						Resource: v1alpha1.KafkaACLResource{
+							Type: v1alpha1.KafkaACLResourceTypeGroup,
+							Name: "orders-consumer",
+						},
+						Operation: v1alpha1.KafkaACLOperationRead,
+					},
+					{
+						Resource: v1alpha1.KafkaACLResource{
+							Type: v1alpha1.KafkaACLResourceTypeTopic,
+							Name: "orders-secret",
+						},
+						Operation:      v1alpha1.KafkaACLOperationAll,
+						PermissionType: v1alpha1.KafkaACLPermissionTypeDeny,
+					},
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, &acl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (v1alpha1.ACLState, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      aclCRName,
+			}, &acl)
+			if err != nil {
+				return "", err
+			}
+			return acl.Status.State, nil
+		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.ACLStateCreated))
+
+		Expect(acl.Labels).To(HaveKeyWithValue("kafkaCluster", fmt.Sprintf("%s.%s", kafkaClusterCRName, namespace)))
+
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		acls, err := mockKafkaClient.ListUserACLsForPrincipal("CN=alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acls).To(ConsistOf(
+			"User:CN=alice,Topic,PREFIXED,orders-,Read,Allow,*",
+			"User:CN=alice,Group,LITERAL,orders-consumer,Read,Allow,*",
+			"User:CN=alice,Topic,LITERAL,orders-secret,All,Deny,*",
+		))
+
+		By("removing the deny rule")
+		acl.Spec.Rules = acl.Spec.Rules[:2]
+		err = k8sClient.Update(ctx, &acl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() ([]string, error) {
+			return mockKafkaClient.ListUserACLsForPrincipal("CN=alice")
+		}, 5*time.Second, 100*time.Millisecond).Should(ConsistOf(
+			"User:CN=alice,Topic,PREFIXED,orders-,Read,Allow,*",
+			"User:CN=alice,Group,LITERAL,orders-consumer,Read,Allow,*",
+		))
+
+		By("deleting the KafkaACL")
+		err = k8sClient.Delete(ctx, &acl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      aclCRName,
+			}, &acl)
+			return apierrors.IsNotFound(err)
+		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		acls, err = mockKafkaClient.ListUserACLsForPrincipal("CN=alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acls).To(BeEmpty())
+	})
+	It("keeps the ACLs which are declared by another KafkaACL on deletion", func(ctx SpecContext) {
+		newACL := func(name string) v1alpha1.KafkaACL {
+			return v1alpha1.KafkaACL{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: v1alpha1.KafkaACLSpec{
+					ClusterRef: v1alpha1.ClusterReference{
+						Namespace: namespace,
+						Name:      kafkaClusterCRName,
+					},
+					Principal: "User:CN=bob",
+					Rules: []v1alpha1.KafkaACLRule{
+						{
+							Resource: v1alpha1.KafkaACLResource{
+								Type: v1alpha1.KafkaACLResourceTypeTopic,
+								Name: "payments",
+							},
+							Operation: v1alpha1.KafkaACLOperationRead,
+						},
+					},
+				},
+			}
+		}
+		first := newACL(fmt.Sprintf("kafkaacl-%v-first", count))
+		second := newACL(fmt.Sprintf("kafkaacl-%v-second", count))
+		for _, acl := range []*v1alpha1.KafkaACL{&first, &second} {
+			err := k8sClient.Create(ctx, acl)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(ctx, func() (v1alpha1.ACLState, error) {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      acl.Name,
+				}, acl)
+				if err != nil {
+					return "", err
+				}
+				return acl.Status.State, nil
+			}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.ACLStateCreated))
+		}
+
+		By("deleting the first KafkaACL")
+		err := k8sClient.Delete(ctx, &first)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      first.Name,
+			}, &first)
+			return apierrors.IsNotFound(err)
+		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		acls, err := mockKafkaClient.ListUserACLsForPrincipal("CN=bob")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acls).To(ConsistOf("User:CN=bob,Topic,LITERAL,payments,Read,Allow,*"))
+
+		By("deleting the second KafkaACL")
+		err = k8sClient.Delete(ctx, &second)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() ([]string, error) {
+			return mockKafkaClient.ListUserACLsForPrincipal("CN=bob")
+		}, 5*time.Second, 100*time.Millisecond).Should(BeEmpty())
+	})
+})
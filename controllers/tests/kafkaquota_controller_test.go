@@ -0,0 +1,152 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+var _ = Describe("KafkaQuota", func() {
+	var (
+		count              uint64 = 0
+		namespace          string
+		namespaceObj       *corev1.Namespace
+		kafkaClusterCRName string
+		kafkaCluster       *v1beta1.KafkaCluster
+	)
+
+	BeforeEach(func() {
+		atomic.AddUint64(&count, 1)
+
+		namespace = fmt.Sprintf("kafka-quota-%v", count)
+		namespaceObj = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		}
+
+		kafkaClusterCRName = fmt.Sprintf("kafkacluster-%d", count)
+		kafkaCluster = createMinimalKafkaClusterCR(kafkaClusterCRName, namespace)
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		By("creating namespace " + namespace)
+		err := k8sClient.Create(ctx, namespaceObj)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err = k8sClient.Create(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		waitForClusterRunningState(ctx, kafkaCluster, namespace)
+	})
+
+	JustAfterEach(func(ctx SpecContext) {
+		resetMockKafkaClient(kafkaCluster)
+
+		By("deleting Kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err := k8sClient.Delete(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+		kafkaCluster = nil
+	})
+
+	It("applies client quotas and removes them on deletion", func(ctx SpecContext) {
+		quotaCRName := fmt.Sprintf("kafkaquota-%v", count)
+		quota := v1alpha1.KafkaQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      quotaCRName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaQuotaSpec{
+				ClusterRef: v1alpha1.ClusterReference{
+					Namespace: namespace,
+					Name:      kafkaClusterCRName,
+				},
+				Entity: v1alpha1.KafkaQuotaEntity{
+					Type: v1alpha1.KafkaQuotaEntityTypeClientID,
+					Name: "noisy-client",
+				},
+				Quotas: v1alpha1.KafkaQuotas{
+					ProducerByteRate:  util.Int64Pointer(1048576),
+					RequestPercentage: util.Int32Pointer(25),
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, &quota)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (v1alpha1.QuotaState, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      quotaCRName,
+			}, &quota)
+			if err != nil {
+				return "", err
+			}
+			return quota.Status.State, nil
+		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.QuotaStateCreated))
+
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		values, err := mockKafkaClient.DescribeClientQuotas(quota.Spec.Entity)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]float64{
+			kafkaclient.QuotaProducerByteRate:  1048576,
+			kafkaclient.QuotaRequestPercentage: 25,
+		}))
+
+		By("moving the quotas to the default client id entity")
+		previousEntity := quota.Spec.Entity
+		quota.Spec.Entity = v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID}
+		err = k8sClient.Update(ctx, &quota)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (map[string]float64, error) {
+			return mockKafkaClient.DescribeClientQuotas(v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID})
+		}, 5*time.Second, 100*time.Millisecond).Should(HaveLen(2))
+		values, err = mockKafkaClient.DescribeClientQuotas(previousEntity)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(BeEmpty())
+
+		By("deleting the KafkaQuota")
+		err = k8sClient.Delete(ctx, &quota)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      quotaCRName,
+			}, &quota)
+			return apierrors.IsNotFound(err)
+		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		values, err = mockKafkaClient.DescribeClientQuotas(v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(BeEmpty())
+	})
+})
@@ -24,6 +24,8 @@ import (
 
 	"github.com/Shopify/sarama"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -133,6 +135,24 @@ var _ = Describe("KafkaTopic", func() {
 			}, &topic)
 			Expect(err).NotTo(HaveOccurred())
 
+			// the observed state of the topic is synced after it has been created
+			Eventually(ctx, func() (int32, error) {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      topic.Name,
+					Namespace: topic.Namespace,
+				}, &topic)
+				return topic.Status.PartitionCount, err
+			}, 5*time.Second, 100*time.Millisecond).Should(Equal(int32(17)))
+			Expect(topic.Status.ReplicationFactor).To(Equal(int32(19)))
+			Expect(topic.Status.Partitions).To(HaveLen(17))
+			Expect(topic.Status.UnderReplicatedPartitions).To(BeZero())
+			Expect(topic.Status.Config).To(Equal(map[string]string{
+				"key1": "value1",
+				"key2": "value2",
+			}))
+			Expect(apimeta.IsStatusConditionTrue(topic.Status.Conditions, v1alpha1.TopicConditionReady)).To(BeTrue())
+			Expect(apimeta.IsStatusConditionTrue(topic.Status.Conditions, v1alpha1.TopicConditionFullyReplicated)).To(BeTrue())
+
 			err = k8sClient.Delete(ctx, &topic)
 			Expect(err).NotTo(HaveOccurred())
 		})
@@ -166,7 +186,7 @@ var _ = Describe("KafkaTopic", func() {
 				Spec: v1alpha1.KafkaTopicSpec{
 					Name:              topicName,
 					Partitions:        17,
-					ReplicationFactor: 19,
+					ReplicationFactor: 13,
 					Config: map[string]string{
 						"key1": "value1",
 						"key2": "value2",
@@ -213,5 +233,113 @@ var _ = Describe("KafkaTopic", func() {
 			err = k8sClient.Delete(ctx, &topic)
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("changes the replication factor", func(ctx SpecContext) {
+			crTopicName := fmt.Sprintf("kafkatopic-%v", count)
+
+			topic := v1alpha1.KafkaTopic{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      crTopicName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha1.KafkaTopicSpec{
+					Name:              topicName,
+					Partitions:        11,
+					ReplicationFactor: 1,
+					Config: map[string]string{
+						"key": "value",
+					},
+					ClusterRef: v1alpha1.ClusterReference{
+						Name:      kafkaCluster.Name,
+						Namespace: namespace,
+					},
+				},
+			}
+
+			err := k8sClient.Create(ctx, &topic)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(ctx, func() (*v1alpha1.TopicReassignmentStatus, error) {
+				topic := v1alpha1.KafkaTopic{}
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: kafkaCluster.Namespace,
+					Name:      crTopicName,
+				}, &topic)
+				if err != nil {
+					return nil, err
+				}
+				return topic.Status.Reassignment, nil
+			}, 5*time.Second, 100*time.Millisecond).Should(And(
+				Not(BeNil()),
+				HaveField("State", v1alpha1.TopicReassignmentStateCompleted),
+				HaveField("ReplicationFactor", int32(1)),
+				HaveField("Partitions", int32(11)),
+				HaveField("PendingPartitions", int32(0)),
+			))
+
+			mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+			detail, err := mockKafkaClient.GetTopic(topicName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detail.ReplicationFactor).To(Equal(int16(1)))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      topic.Name,
+				Namespace: topic.Namespace,
+			}, &topic)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, &topic)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the topic when the deletion policy is Retain", func(ctx SpecContext) {
+			crTopicName := fmt.Sprintf("kafkatopic-%v", count)
+
+			topic := v1alpha1.KafkaTopic{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      crTopicName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha1.KafkaTopicSpec{
+					Name:              topicName,
+					Partitions:        11,
+					ReplicationFactor: 13,
+					Config: map[string]string{
+						"key": "value",
+					},
+					ClusterRef: v1alpha1.ClusterReference{
+						Name:      kafkaCluster.Name,
+						Namespace: namespace,
+					},
+					DeletionPolicy: v1alpha1.TopicDeletionPolicyRetain,
+				},
+			}
+
+			err := k8sClient.Create(ctx, &topic)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(ctx, func() ([]string, error) {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: kafkaCluster.Namespace,
+					Name:      crTopicName,
+				}, &topic)
+				return topic.GetFinalizers(), err
+			}, 5*time.Second, 100*time.Millisecond).ShouldNot(BeEmpty())
+
+			err = k8sClient.Delete(ctx, &topic)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(ctx, func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: kafkaCluster.Namespace,
+					Name:      crTopicName,
+				}, &v1alpha1.KafkaTopic{})
+			}, 5*time.Second, 100*time.Millisecond).Should(Satisfy(apierrors.IsNotFound))
+
+			mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+			detail, err := mockKafkaClient.GetTopic(topicName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detail).NotTo(BeNil())
+		})
 	})
 })
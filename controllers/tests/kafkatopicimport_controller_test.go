@@ -0,0 +1,129 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+	"github.com/banzaicloud/koperator/pkg/webhooks"
+)
+
+var _ = Describe("KafkaTopicImport", func() {
+	var (
+		count        uint64 = 0
+		namespace    string
+		namespaceObj *corev1.Namespace
+		kafkaCluster *v1beta1.KafkaCluster
+	)
+
+	BeforeEach(func() {
+		atomic.AddUint64(&count, 1)
+
+		namespace = fmt.Sprintf("kafka-topic-import-%v", count)
+		namespaceObj = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		}
+
+		kafkaCluster = createMinimalKafkaClusterCR(fmt.Sprintf("kafkacluster-%v", count), namespace)
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		By("creating namespace " + namespace)
+		err := k8sClient.Create(ctx, namespaceObj)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err = k8sClient.Create(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		waitForClusterRunningState(ctx, kafkaCluster, namespace)
+
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		for _, topicName := range []string{"orders", "Payments_v1", "__consumer_offsets"} {
+			err = mockKafkaClient.CreateTopic(&kafkaclient.CreateTopicOptions{
+				Name:              topicName,
+				Partitions:        3,
+				ReplicationFactor: 1,
+				Config: map[string]*string{
+					"retention.ms": util.StringPointer("1000"),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	JustAfterEach(func(ctx SpecContext) {
+		resetMockKafkaClient(kafkaCluster)
+
+		By("deleting Kafka cluster object " + kafkaCluster.Name + " in namespace " + namespace)
+		err := k8sClient.Delete(ctx, kafkaCluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		kafkaCluster = nil
+	})
+
+	It("creates KafkaTopics for the existing topics", func(ctx SpecContext) {
+		topicImport := v1alpha1.KafkaTopicImport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("kafkatopicimport-%v", count),
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaTopicImportSpec{
+				ClusterRef: v1alpha1.ClusterReference{
+					Name:      kafkaCluster.Name,
+					Namespace: namespace,
+				},
+			},
+		}
+
+		err := k8sClient.Create(ctx, &topicImport)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (v1alpha1.TopicImportState, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: topicImport.Name}, &topicImport)
+			return topicImport.Status.State, err
+		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.TopicImportStateCompleted))
+
+		Expect(topicImport.Status.ImportedTopics).To(ConsistOf("orders", "Payments_v1"))
+
+		topicList := v1alpha1.KafkaTopicList{}
+		err = k8sClient.List(ctx, &topicList, client.InNamespace(namespace))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topicList.Items).To(HaveLen(2))
+		for _, topic := range topicList.Items {
+			Expect(topic.Spec.Name).NotTo(Equal("__consumer_offsets"))
+			Expect(topic.Spec.Partitions).To(Equal(int32(3)))
+			Expect(topic.Spec.ReplicationFactor).To(Equal(int32(1)))
+			Expect(topic.Spec.Config).To(Equal(map[string]string{"retention.ms": "1000"}))
+			Expect(topic.Annotations).To(HaveKeyWithValue(webhooks.TopicManagedByAnnotationKey, webhooks.TopicManagedByKoperatorAnnotationValue))
+		}
+	})
+})
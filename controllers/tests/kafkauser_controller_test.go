@@ -34,6 +34,7 @@ import (
 
 	"github.com/banzaicloud/koperator/api/v1alpha1"
 	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
 	"github.com/banzaicloud/koperator/pkg/util"
 )
 
@@ -213,9 +214,11 @@ var _ = Describe("KafkaTopic", func() {
 
 		Expect(user.Status.ACLs).To(ConsistOf(
 			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,Describe,Allow,*",
+			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,DescribeConfigs,Allow,*",
 			"User:CN=kafkauser-1,Topic,ANY,test-topic-1,Read,Allow,*",
 			"User:CN=kafkauser-1,Group,LITERAL,*,Read,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Describe,Allow,*",
+			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,DescribeConfigs,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Create,Allow,*",
 			"User:CN=kafkauser-1,Topic,LITERAL,test-topic-2,Write,Allow,*",
 		))
@@ -361,4 +364,176 @@ x0eepFeUNacaeg7O1ftIrzNlYsSLi2Qm+tnu7odyxafZ65GJ9lcSLUqXuNDCrNOl
 			return user.Status.State, nil
 		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.UserStateCreated))
 	})
+	It("creates SCRAM credentials and the belonging secret correctly", func(ctx SpecContext) {
+		userCRName := fmt.Sprintf("kafkauser-%v", count)
+		user := v1alpha1.KafkaUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      userCRName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaUserSpec{
+				SecretName: userCRName,
+				ClusterRef: v1alpha1.ClusterReference{
+					Namespace: namespace,
+					Name:      kafkaClusterCRName,
+				},
+				Authentication: &v1alpha1.UserAuthentication{
+					Type: v1alpha1.UserAuthenticationTypeScramSha512,
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, &user)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (v1alpha1.UserState, error) {
+			user := v1alpha1.KafkaUser{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: kafkaCluster.Namespace,
+				Name:      userCRName,
+			}, &user)
+			if err != nil {
+				return "", err
+			}
+			return user.Status.State, nil
+		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.UserStateCreated))
+
+		secret := &corev1.Secret{}
+		err = k8sClient.Get(ctx, types.NamespacedName{
+			Name:      user.Spec.SecretName,
+			Namespace: user.Namespace}, secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Data).To(HaveKeyWithValue(v1alpha1.UsernameKey, []byte(userCRName)))
+		Expect(secret.Data).To(HaveKeyWithValue(v1alpha1.SaslMechanismKey, []byte("SCRAM-SHA-512")))
+		Expect(secret.Data[v1alpha1.PasswordKey]).NotTo(BeEmpty())
+		Expect(string(secret.Data[v1alpha1.SaslJaasConfigKey])).To(ContainSubstring(string(secret.Data[v1alpha1.PasswordKey])))
+
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		exists, err := mockKafkaClient.UserScramCredentialsExist(userCRName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+	It("removes stale ACLs when topic grants change", func(ctx SpecContext) {
+		userCRName := fmt.Sprintf("kafkauser-%v", count)
+		user := v1alpha1.KafkaUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      userCRName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaUserSpec{
+				ClusterRef: v1alpha1.ClusterReference{
+					Namespace: namespace,
+					Name:      kafkaClusterCRName,
+				},
+				TopicGrants: []v1alpha1.UserTopicGrant{
+					{
+						TopicName:  "test-topic-1",
+						AccessType: v1alpha1.KafkaAccessTypeRead,
+					},
+					{
+						TopicName:  "test-topic-2",
+						AccessType: v1alpha1.KafkaAccessTypeWrite,
+					},
+				},
+				CreateCert: util.BoolPointer(false),
+			},
+		}
+		err := k8sClient.Create(ctx, &user)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (v1alpha1.UserState, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: kafkaCluster.Namespace,
+				Name:      userCRName,
+			}, &user)
+			if err != nil {
+				return "", err
+			}
+			return user.Status.State, nil
+		}, 5*time.Second, 100*time.Millisecond).Should(Equal(v1alpha1.UserStateCreated))
+
+		// an ACL of the principal which is not managed through the topic grants of the user
+		principal := fmt.Sprintf("CN=%s", userCRName)
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		unmanagedACL := "User:" + principal + ",Topic,LITERAL,test-topic-3,Read,Allow,*"
+		Expect(mockKafkaClient.CreateACL(unmanagedACL)).To(Succeed())
+
+		// revoke the write grant
+		user.Spec.TopicGrants = user.Spec.TopicGrants[:1]
+		err = k8sClient.Update(ctx, &user)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() ([]string, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: kafkaCluster.Namespace,
+				Name:      userCRName,
+			}, &user)
+			if err != nil {
+				return nil, err
+			}
+			return user.Status.RemovedACLs, nil
+		}, 5*time.Second, 100*time.Millisecond).Should(ConsistOf(
+			"User:"+principal+",Topic,LITERAL,test-topic-2,Describe,Allow,*",
+			"User:"+principal+",Topic,LITERAL,test-topic-2,DescribeConfigs,Allow,*",
+			"User:"+principal+",Topic,LITERAL,test-topic-2,Create,Allow,*",
+			"User:"+principal+",Topic,LITERAL,test-topic-2,Write,Allow,*",
+		))
+
+		acls, err := mockKafkaClient.ListUserACLsForPrincipal(principal)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acls).To(ConsistOf(
+			"User:"+principal+",Topic,LITERAL,test-topic-1,Describe,Allow,*",
+			"User:"+principal+",Topic,LITERAL,test-topic-1,DescribeConfigs,Allow,*",
+			"User:"+principal+",Topic,LITERAL,test-topic-1,Read,Allow,*",
+			"User:"+principal+",Group,LITERAL,*,Read,Allow,*",
+			unmanagedACL,
+		))
+	})
+
+	It("applies the client quotas of the user", func(ctx SpecContext) {
+		userCRName := fmt.Sprintf("kafkauser-%v", count)
+		user := v1alpha1.KafkaUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      userCRName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.KafkaUserSpec{
+				ClusterRef: v1alpha1.ClusterReference{
+					Namespace: namespace,
+					Name:      kafkaClusterCRName,
+				},
+				CreateCert: util.BoolPointer(false),
+				Quotas: &v1alpha1.KafkaQuotas{
+					ConsumerByteRate: util.Int64Pointer(2097152),
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, &user)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (*v1alpha1.KafkaQuotas, error) {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: kafkaCluster.Namespace,
+				Name:      userCRName,
+			}, &user)
+			if err != nil {
+				return nil, err
+			}
+			return user.Status.Quotas, nil
+		}, 5*time.Second, 100*time.Millisecond).ShouldNot(BeNil())
+
+		entity := v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: fmt.Sprintf("CN=%s", userCRName)}
+		mockKafkaClient, _ := getMockedKafkaClientForCluster(kafkaCluster)
+		values, err := mockKafkaClient.DescribeClientQuotas(entity)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]float64{kafkaclient.QuotaConsumerByteRate: 2097152}))
+
+		By("removing the quotas of the user")
+		user.Spec.Quotas = nil
+		err = k8sClient.Update(ctx, &user)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(ctx, func() (map[string]float64, error) {
+			return mockKafkaClient.DescribeClientQuotas(entity)
+		}, 5*time.Second, 100*time.Millisecond).Should(BeEmpty())
+	})
 })
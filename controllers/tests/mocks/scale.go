@@ -122,6 +122,76 @@ func (mr *MockCruiseControlScalerMockRecorder) BrokersWithState(ctx interface{},
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BrokersWithState", reflect.TypeOf((*MockCruiseControlScaler)(nil).BrokersWithState), varargs...)
 }
 
+// DemoteBrokers mocks base method.
+func (m *MockCruiseControlScaler) DemoteBrokers(ctx context.Context, brokerIDs ...string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range brokerIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DemoteBrokers", varargs...)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DemoteBrokers indicates an expected call of DemoteBrokers.
+func (mr *MockCruiseControlScalerMockRecorder) DemoteBrokers(ctx interface{}, brokerIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, brokerIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DemoteBrokers", reflect.TypeOf((*MockCruiseControlScaler)(nil).DemoteBrokers), varargs...)
+}
+
+// DemoteBrokersWithParams mocks base method.
+func (m *MockCruiseControlScaler) DemoteBrokersWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DemoteBrokersWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DemoteBrokersWithParams indicates an expected call of DemoteBrokersWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) DemoteBrokersWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DemoteBrokersWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).DemoteBrokersWithParams), ctx, params)
+}
+
+// ElectPreferredLeaders mocks base method.
+func (m *MockCruiseControlScaler) ElectPreferredLeaders(ctx context.Context, brokerIDs ...string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range brokerIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ElectPreferredLeaders", varargs...)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ElectPreferredLeaders indicates an expected call of ElectPreferredLeaders.
+func (mr *MockCruiseControlScalerMockRecorder) ElectPreferredLeaders(ctx interface{}, brokerIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, brokerIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ElectPreferredLeaders", reflect.TypeOf((*MockCruiseControlScaler)(nil).ElectPreferredLeaders), varargs...)
+}
+
+// FixOfflineReplicasWithParams mocks base method.
+func (m *MockCruiseControlScaler) FixOfflineReplicasWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FixOfflineReplicasWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FixOfflineReplicasWithParams indicates an expected call of FixOfflineReplicasWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) FixOfflineReplicasWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FixOfflineReplicasWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).FixOfflineReplicasWithParams), ctx, params)
+}
+
 // IsReady mocks base method.
 func (m *MockCruiseControlScaler) IsReady(ctx context.Context) bool {
 	m.ctrl.T.Helper()
@@ -210,6 +280,21 @@ func (mr *MockCruiseControlScalerMockRecorder) PartitionReplicasByBroker(ctx int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PartitionReplicasByBroker", reflect.TypeOf((*MockCruiseControlScaler)(nil).PartitionReplicasByBroker), ctx)
 }
 
+// PauseSamplingWithParams mocks base method.
+func (m *MockCruiseControlScaler) PauseSamplingWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseSamplingWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PauseSamplingWithParams indicates an expected call of PauseSamplingWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) PauseSamplingWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseSamplingWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).PauseSamplingWithParams), ctx, params)
+}
+
 // RebalanceDisks mocks base method.
 func (m *MockCruiseControlScaler) RebalanceDisks(ctx context.Context, brokerIDs ...string) (*scale.Result, error) {
 	m.ctrl.T.Helper()
@@ -280,6 +365,36 @@ func (mr *MockCruiseControlScalerMockRecorder) RemoveBrokersWithParams(ctx, para
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveBrokersWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).RemoveBrokersWithParams), ctx, params)
 }
 
+// RemoveDisksWithParams mocks base method.
+func (m *MockCruiseControlScaler) RemoveDisksWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDisksWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveDisksWithParams indicates an expected call of RemoveDisksWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) RemoveDisksWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDisksWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).RemoveDisksWithParams), ctx, params)
+}
+
+// ResumeSamplingWithParams mocks base method.
+func (m *MockCruiseControlScaler) ResumeSamplingWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeSamplingWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeSamplingWithParams indicates an expected call of ResumeSamplingWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) ResumeSamplingWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeSamplingWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).ResumeSamplingWithParams), ctx, params)
+}
+
 // Status mocks base method.
 func (m *MockCruiseControlScaler) Status(ctx context.Context) (scale.CruiseControlStatus, error) {
 	m.ctrl.T.Helper()
@@ -310,6 +425,36 @@ func (mr *MockCruiseControlScalerMockRecorder) StopExecution(ctx interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopExecution", reflect.TypeOf((*MockCruiseControlScaler)(nil).StopExecution), ctx)
 }
 
+// TopicConfigurationWithParams mocks base method.
+func (m *MockCruiseControlScaler) TopicConfigurationWithParams(ctx context.Context, params map[string]string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopicConfigurationWithParams", ctx, params)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopicConfigurationWithParams indicates an expected call of TopicConfigurationWithParams.
+func (mr *MockCruiseControlScalerMockRecorder) TopicConfigurationWithParams(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopicConfigurationWithParams", reflect.TypeOf((*MockCruiseControlScaler)(nil).TopicConfigurationWithParams), ctx, params)
+}
+
+// UserTaskResult mocks base method.
+func (m *MockCruiseControlScaler) UserTaskResult(ctx context.Context, taskID string) (*scale.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserTaskResult", ctx, taskID)
+	ret0, _ := ret[0].(*scale.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserTaskResult indicates an expected call of UserTaskResult.
+func (mr *MockCruiseControlScalerMockRecorder) UserTaskResult(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserTaskResult", reflect.TypeOf((*MockCruiseControlScaler)(nil).UserTaskResult), ctx, taskID)
+}
+
 // UserTasks mocks base method.
 func (m *MockCruiseControlScaler) UserTasks(ctx context.Context, taskIDs ...string) ([]*scale.Result, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +168,14 @@ var _ = BeforeSuite(func(ctx SpecContext) {
 	err = controllers.SetupKafkaTopicWithManager(mgr, 10).Complete(kafkaTopicReconciler)
 	Expect(err).NotTo(HaveOccurred())
 
+	kafkaTopicImportReconciler := &controllers.KafkaTopicImportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	err = controllers.SetupKafkaTopicImportWithManager(mgr).Complete(kafkaTopicImportReconciler)
+	Expect(err).NotTo(HaveOccurred())
+
 	// Create a new  kafka user reconciler
 	kafkaUserReconciler := controllers.KafkaUserReconciler{
 		Client: mgr.GetClient(),
@@ -177,6 +185,22 @@ var _ = BeforeSuite(func(ctx SpecContext) {
 	err = controllers.SetupKafkaUserWithManager(mgr, true, true).Complete(&kafkaUserReconciler)
 	Expect(err).NotTo(HaveOccurred())
 
+	kafkaACLReconciler := controllers.KafkaACLReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	err = controllers.SetupKafkaACLWithManager(mgr).Complete(&kafkaACLReconciler)
+	Expect(err).NotTo(HaveOccurred())
+
+	kafkaQuotaReconciler := controllers.KafkaQuotaReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	err = controllers.SetupKafkaQuotaWithManager(mgr).Complete(&kafkaQuotaReconciler)
+	Expect(err).NotTo(HaveOccurred())
+
 	kafkaClusterCCReconciler = controllers.CruiseControlTaskReconciler{
 		Client:       mgr.GetClient(),
 		DirectClient: mgr.GetAPIReader(),
@@ -22,6 +22,7 @@ require (
 	github.com/onsi/gomega v1.27.2
 	github.com/pavlo-v-chernykh/keystore-go/v4 v4.4.1
 	github.com/prometheus/common v0.37.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/stretchr/testify v1.8.0
 	go.uber.org/zap v1.23.0
 	golang.org/x/exp v0.0.0-20220827204233-334a2380cb91
@@ -139,6 +140,9 @@ require (
 	sigs.k8s.io/yaml v1.3.0 // indirect
 )
 
+// the operator is built against the API types of this repository until the api module is tagged
+replace github.com/banzaicloud/koperator/api => ./api
+
 replace (
 	github.com/gogo/protobuf => github.com/waynz0r/protobuf v1.3.3-0.20210811122234-64636cae0910
 	github.com/golang/protobuf => github.com/luciferinlove/protobuf v0.0.0-20220913214010-c63936d75066
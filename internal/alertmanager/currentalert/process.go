@@ -352,12 +352,8 @@ func upScale(log logr.Logger, labels model.LabelSet, annotations model.LabelSet,
 		return nil
 	}
 
-	biggestId := int32(0)
-	for _, broker := range cr.Spec.Brokers {
-		if broker.Id > biggestId {
-			biggestId = broker.Id
-		}
-	}
+	// broker IDs used by the cluster before are never reused
+	biggestId := kafka.LastBrokerID(cr)
 
 	var broker v1beta1.Broker
 
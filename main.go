@@ -34,6 +34,7 @@ import (
 	"flag"
 	"os"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 
@@ -89,6 +90,7 @@ func main() {
 		certSigningDisabled               bool
 		certManagerEnabled                bool
 		maxKafkaTopicConcurrentReconciles int
+		kafkaTopicDriftScanInterval       time.Duration
 	)
 
 	flag.StringVar(&namespaces, "namespaces", "", "Comma separated list of namespaces where operator listens for resources")
@@ -103,6 +105,7 @@ func main() {
 	flag.BoolVar(&certManagerEnabled, "cert-manager-enabled", false, "Enable cert-manager integration")
 	flag.BoolVar(&certSigningDisabled, "disable-cert-signing-support", false, "Disable native certificate signing integration")
 	flag.IntVar(&maxKafkaTopicConcurrentReconciles, "max-kafka-topic-concurrent-reconciles", 10, "Define max amount of concurrent KafkaTopic reconciles")
+	flag.DurationVar(&kafkaTopicDriftScanInterval, "kafka-topic-drift-scan-interval", 5*time.Minute, "Interval of scanning the Kafka topics for changes made outside of the KafkaTopics, 0 disables the scan")
 	flag.Parse()
 	ctrl.SetLogger(util.CreateLogger(verboseLogging, developmentLogging))
 
@@ -171,6 +174,23 @@ func main() {
 		os.Exit(1)
 	}
 
+	if kafkaTopicDriftScanInterval > 0 {
+		if err = controllers.SetupKafkaTopicDriftScannerWithManager(mgr, kafkaTopicDriftScanInterval); err != nil {
+			setupLog.Error(err, "unable to create drift scanner", "controller", "KafkaTopic")
+			os.Exit(1)
+		}
+	}
+
+	kafkaTopicImportReconciler := &controllers.KafkaTopicImportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = controllers.SetupKafkaTopicImportWithManager(mgr).Complete(kafkaTopicImportReconciler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaTopicImport")
+		os.Exit(1)
+	}
+
 	// Create a new  kafka user reconciler
 	kafkaUserReconciler := &controllers.KafkaUserReconciler{
 		Client: mgr.GetClient(),
@@ -182,6 +202,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	kafkaACLReconciler := &controllers.KafkaACLReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = controllers.SetupKafkaACLWithManager(mgr).Complete(kafkaACLReconciler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaACL")
+		os.Exit(1)
+	}
+
+	kafkaQuotaReconciler := &controllers.KafkaQuotaReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = controllers.SetupKafkaQuotaWithManager(mgr).Complete(kafkaQuotaReconciler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaQuota")
+		os.Exit(1)
+	}
+
 	kafkaClusterCCReconciler := &controllers.CruiseControlTaskReconciler{
 		Client:       mgr.GetClient(),
 		DirectClient: mgr.GetAPIReader(),
@@ -216,6 +256,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	cruiseControlRebalanceReconciler := &controllers.CruiseControlRebalanceReconciler{
+		Client:       mgr.GetClient(),
+		DirectClient: mgr.GetAPIReader(),
+		Scheme:       mgr.GetScheme(),
+		ScaleFactory: scale.ScaleFactoryFn(),
+	}
+
+	if err = controllers.SetupCruiseControlRebalanceWithManager(mgr).Complete(cruiseControlRebalanceReconciler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CruiseControlRebalance")
+		os.Exit(1)
+	}
+
 	if !webhookDisabled {
 		err = ctrl.NewWebhookManagedBy(mgr).For(&banzaicloudv1beta1.KafkaCluster{}).
 			WithValidator(webhooks.KafkaClusterValidator{
@@ -237,6 +289,26 @@ func main() {
 			setupLog.Error(err, "unable to create validating webhook", "Kind", "KafkaTopic")
 			os.Exit(1)
 		}
+		err = ctrl.NewWebhookManagedBy(mgr).For(&banzaicloudv1alpha1.KafkaACL{}).
+			WithValidator(webhooks.KafkaACLValidator{
+				Client: mgr.GetClient(),
+				Log:    mgr.GetLogger().WithName("webhooks").WithName("KafkaACL"),
+			}).
+			Complete()
+		if err != nil {
+			setupLog.Error(err, "unable to create validating webhook", "Kind", "KafkaACL")
+			os.Exit(1)
+		}
+		err = ctrl.NewWebhookManagedBy(mgr).For(&banzaicloudv1alpha1.KafkaQuota{}).
+			WithValidator(webhooks.KafkaQuotaValidator{
+				Client: mgr.GetClient(),
+				Log:    mgr.GetLogger().WithName("webhooks").WithName("KafkaQuota"),
+			}).
+			Complete()
+		if err != nil {
+			setupLog.Error(err, "unable to create validating webhook", "Kind", "KafkaQuota")
+			os.Exit(1)
+		}
 	}
 
 	// +kubebuilder:scaffold:builder
@@ -150,6 +150,8 @@ func generateBrokerState(brokerIDs []string, cluster *banzaicloudv1beta1.KafkaCl
 		case map[string]banzaicloudv1beta1.GracefulActionState:
 			state := s[brokerID]
 			brokerState.GracefulActionState = state
+		case banzaicloudv1beta1.BrokerReplacementState:
+			brokerState.GracefulActionState.ReplacementState = s
 		case banzaicloudv1beta1.ConfigurationState:
 			brokerState.ConfigurationState = s
 		case banzaicloudv1beta1.PerBrokerConfigurationState:
@@ -275,7 +277,7 @@ func UpdateRollingUpgradeState(c client.Client, cluster *banzaicloudv1beta1.Kafk
 	typeMeta := cluster.TypeMeta
 
 	timeStamp := time.Format("2006-01-02 15:04:05")
-	cluster.Status.RollingUpgrade.LastSuccess = timeStamp
+	finishRollingUpgrade(&cluster.Status.RollingUpgrade, timeStamp)
 
 	err := c.Status().Update(context.Background(), cluster)
 	if apierrors.IsNotFound(err) {
@@ -293,7 +295,7 @@ func UpdateRollingUpgradeState(c client.Client, cluster *banzaicloudv1beta1.Kafk
 			return errors.WrapIf(err, "could not get config for updating status")
 		}
 
-		cluster.Status.RollingUpgrade.LastSuccess = timeStamp
+		finishRollingUpgrade(&cluster.Status.RollingUpgrade, timeStamp)
 
 		err = c.Status().Update(context.Background(), cluster)
 		if apierrors.IsNotFound(err) {
@@ -309,6 +311,229 @@ func UpdateRollingUpgradeState(c client.Client, cluster *banzaicloudv1beta1.Kafk
 	return nil
 }
 
+// UpdateLeaderMigratedBrokers updates the brokers waiting for the preferred leader election in the rolling upgrade status
+func UpdateLeaderMigratedBrokers(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, brokerIDs []string, logger logr.Logger) error {
+	err := UpdateRollingUpgradeStatus(c, cluster, func(status *banzaicloudv1beta1.RollingUpgradeStatus) {
+		status.LeaderMigratedBrokers = brokerIDs
+	})
+	if err != nil {
+		return errors.WrapIf(err, "could not update leader migrated brokers")
+	}
+	logger.Info("Leader migrated brokers updated", "brokers", brokerIDs)
+	return nil
+}
+
+// UpdateRollingUpgradeStatus applies the update to the rolling upgrade status of the cluster
+func UpdateRollingUpgradeStatus(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, update func(status *banzaicloudv1beta1.RollingUpgradeStatus)) error {
+	typeMeta := cluster.TypeMeta
+
+	update(&cluster.Status.RollingUpgrade)
+
+	err := c.Status().Update(context.Background(), cluster)
+	if apierrors.IsNotFound(err) {
+		err = c.Update(context.Background(), cluster)
+	}
+	if err != nil {
+		if !apierrors.IsConflict(err) {
+			return errors.WrapIf(err, "could not update rolling upgrade status")
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		}, cluster)
+		if err != nil {
+			return errors.WrapIf(err, "could not get config for updating status")
+		}
+
+		update(&cluster.Status.RollingUpgrade)
+
+		err = c.Status().Update(context.Background(), cluster)
+		if apierrors.IsNotFound(err) {
+			err = c.Update(context.Background(), cluster)
+		}
+		if err != nil {
+			return errors.WrapIf(err, "could not update rolling upgrade status")
+		}
+	}
+	// update loses the typeMeta of the config that's used later when setting ownerrefs
+	cluster.TypeMeta = typeMeta
+	return nil
+}
+
+// finishRollingUpgrade records the successful rolling upgrade, the restarted brokers are kept until the next one starts
+func finishRollingUpgrade(status *banzaicloudv1beta1.RollingUpgradeStatus, timeStamp string) {
+	status.LastSuccess = timeStamp
+	status.PendingBrokers = nil
+	status.CurrentBrokers = nil
+	status.PausedAt = nil
+}
+
+// UpdateClusterID updates the cluster ID of the Kafka cluster in the status
+func UpdateClusterID(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, clusterID string, logger logr.Logger) error {
+	typeMeta := cluster.TypeMeta
+
+	cluster.Status.ClusterID = clusterID
+
+	err := c.Status().Update(context.Background(), cluster)
+	if apierrors.IsNotFound(err) {
+		err = c.Update(context.Background(), cluster)
+	}
+	if err != nil {
+		if !apierrors.IsConflict(err) {
+			return errors.WrapIf(err, "could not update cluster ID")
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		}, cluster)
+		if err != nil {
+			return errors.WrapIf(err, "could not get config for updating status")
+		}
+		// the cluster ID must never be changed once it has been set
+		if cluster.Status.ClusterID != "" {
+			cluster.TypeMeta = typeMeta
+			return nil
+		}
+
+		cluster.Status.ClusterID = clusterID
+
+		err = c.Status().Update(context.Background(), cluster)
+		if apierrors.IsNotFound(err) {
+			err = c.Update(context.Background(), cluster)
+		}
+		if err != nil {
+			return errors.WrapIf(err, "could not update cluster ID")
+		}
+	}
+	// update loses the typeMeta of the config that's used later when setting ownerrefs
+	cluster.TypeMeta = typeMeta
+	logger.Info("Cluster ID updated", "clusterID", clusterID)
+	return nil
+}
+
+// UpdateKafkaVersionUpgradeStatus updates the status of the upgrade of the brokers to the Kafka version set in the spec
+func UpdateKafkaVersionUpgradeStatus(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, status *banzaicloudv1beta1.KafkaVersionUpgradeStatus, logger logr.Logger) error {
+	typeMeta := cluster.TypeMeta
+
+	cluster.Status.KafkaVersionUpgrade = status
+
+	err := c.Status().Update(context.Background(), cluster)
+	if apierrors.IsNotFound(err) {
+		err = c.Update(context.Background(), cluster)
+	}
+	if err != nil {
+		if !apierrors.IsConflict(err) {
+			return errors.WrapIf(err, "could not update Kafka version upgrade status")
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		}, cluster)
+		if err != nil {
+			return errors.WrapIf(err, "could not get config for updating status")
+		}
+
+		cluster.Status.KafkaVersionUpgrade = status
+
+		err = c.Status().Update(context.Background(), cluster)
+		if apierrors.IsNotFound(err) {
+			err = c.Update(context.Background(), cluster)
+		}
+		if err != nil {
+			return errors.WrapIf(err, "could not update Kafka version upgrade status")
+		}
+	}
+	// update loses the typeMeta of the config that's used later when setting ownerrefs
+	cluster.TypeMeta = typeMeta
+	logger.Info("Kafka version upgrade status updated", "state", status.State,
+		"targetVersion", status.TargetVersion, "protocolVersion", status.ProtocolVersion)
+	return nil
+}
+
+// UpdateRebalanceStatus updates the status of the rebalances requested by the rebalance config of Cruise Control
+func UpdateRebalanceStatus(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, status *banzaicloudv1beta1.RebalanceStatus, logger logr.Logger) error {
+	typeMeta := cluster.TypeMeta
+
+	cluster.Status.Rebalance = status
+
+	err := c.Status().Update(context.Background(), cluster)
+	if apierrors.IsNotFound(err) {
+		err = c.Update(context.Background(), cluster)
+	}
+	if err != nil {
+		if !apierrors.IsConflict(err) {
+			return errors.WrapIf(err, "could not update rebalance status")
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		}, cluster)
+		if err != nil {
+			return errors.WrapIf(err, "could not get config for updating status")
+		}
+
+		cluster.Status.Rebalance = status
+
+		err = c.Status().Update(context.Background(), cluster)
+		if apierrors.IsNotFound(err) {
+			err = c.Update(context.Background(), cluster)
+		}
+		if err != nil {
+			return errors.WrapIf(err, "could not update rebalance status")
+		}
+	}
+	// update loses the typeMeta of the config that's used later when setting ownerrefs
+	cluster.TypeMeta = typeMeta
+	logger.V(1).Info("rebalance status updated")
+	return nil
+}
+
+// UpdateBrokerReplicasStatus updates the highest broker ID used by the cluster and the status of its /scale subresource
+func UpdateBrokerReplicasStatus(c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, lastBrokerID, replicas int32, selector string, logger logr.Logger) error {
+	typeMeta := cluster.TypeMeta
+
+	updateStatus := func() {
+		cluster.Status.LastBrokerID = &lastBrokerID
+		cluster.Status.Replicas = replicas
+		cluster.Status.Selector = selector
+	}
+	updateStatus()
+
+	err := c.Status().Update(context.Background(), cluster)
+	if apierrors.IsNotFound(err) {
+		err = c.Update(context.Background(), cluster)
+	}
+	if err != nil {
+		if !apierrors.IsConflict(err) {
+			return errors.WrapIf(err, "could not update broker replicas status")
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		}, cluster)
+		if err != nil {
+			return errors.WrapIf(err, "could not get config for updating status")
+		}
+		// the highest broker ID must never decrease
+		if cluster.Status.LastBrokerID != nil && *cluster.Status.LastBrokerID > lastBrokerID {
+			lastBrokerID = *cluster.Status.LastBrokerID
+		}
+		updateStatus()
+
+		err = c.Status().Update(context.Background(), cluster)
+		if apierrors.IsNotFound(err) {
+			err = c.Update(context.Background(), cluster)
+		}
+		if err != nil {
+			return errors.WrapIf(err, "could not update broker replicas status")
+		}
+	}
+	// update loses the typeMeta of the config that's used later when setting ownerrefs
+	cluster.TypeMeta = typeMeta
+	logger.V(1).Info("broker replicas status updated", "lastBrokerID", lastBrokerID, "replicas", replicas)
+	return nil
+}
+
 func UpdateListenerStatuses(ctx context.Context, c client.Client, cluster *banzaicloudv1beta1.KafkaCluster, intListenerStatuses, extListenerStatuses map[string]banzaicloudv1beta1.ListenerStatusList) error {
 	logger := logr.FromContextOrDiscard(ctx)
 
@@ -0,0 +1,105 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+)
+
+// CreateACL creates the ACL described by the given raw string representation
+// (Principal,ResourceType,PatternType,ResourceName,Operation,PermissionType,Host).
+// It returns no error if the ACL already exists.
+func (k *kafkaClient) CreateACL(acl string) error {
+	resource, a, err := parseACLString(acl)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "could not parse ACL", "acl", acl)
+	}
+	return k.admin.CreateACL(resource, a)
+}
+
+// DeleteACL removes exactly the one ACL described by the given raw string representation
+func (k *kafkaClient) DeleteACL(acl string) error {
+	resource, a, err := parseACLString(acl)
+	if err != nil {
+		return errorfactory.New(errorfactory.InternalError{}, err, "could not parse ACL", "acl", acl)
+	}
+	matches, err := k.admin.DeleteACL(sarama.AclFilter{
+		ResourceType:              resource.ResourceType,
+		ResourceName:              &resource.ResourceName,
+		ResourcePatternTypeFilter: resource.ResourcePatternType,
+		Principal:                 &a.Principal,
+		Host:                      &a.Host,
+		Operation:                 a.Operation,
+		PermissionType:            a.PermissionType,
+	}, false)
+	if err != nil {
+		return err
+	}
+	for _, x := range matches {
+		if x.Err != sarama.ErrNoError {
+			return x.Err
+		}
+	}
+	return nil
+}
+
+// aclToString converts an ACL to its raw string representation in the form of
+// Principal,ResourceType,PatternType,ResourceName,Operation,PermissionType,Host
+func aclToString(resource sarama.Resource, acl sarama.Acl) string {
+	return strings.Join([]string{
+		acl.Principal,
+		resource.ResourceType.String(),
+		strings.ToUpper(resource.ResourcePatternType.String()),
+		resource.ResourceName,
+		acl.Operation.String(),
+		acl.PermissionType.String(),
+		acl.Host,
+	}, ",")
+}
+
+// parseACLString parses the raw string representation of an ACL
+func parseACLString(acl string) (sarama.Resource, sarama.Acl, error) {
+	resource := sarama.Resource{}
+	a := sarama.Acl{}
+	fields := strings.Split(acl, ",")
+	// the principal (e.g. a distinguished name) may contain commas itself, so the
+	// fields are parsed from the end of the string
+	if len(fields) < 7 {
+		return resource, a, errors.Errorf("invalid ACL %q: expected at least 7 comma separated fields", acl)
+	}
+	n := len(fields)
+
+	if err := resource.ResourceType.UnmarshalText([]byte(fields[n-6])); err != nil {
+		return resource, a, errors.WrapIf(err, "invalid resource type")
+	}
+	if err := resource.ResourcePatternType.UnmarshalText([]byte(fields[n-5])); err != nil {
+		return resource, a, errors.WrapIf(err, "invalid pattern type")
+	}
+	if err := a.Operation.UnmarshalText([]byte(fields[n-3])); err != nil {
+		return resource, a, errors.WrapIf(err, "invalid operation")
+	}
+	if err := a.PermissionType.UnmarshalText([]byte(fields[n-2])); err != nil {
+		return resource, a, errors.WrapIf(err, "invalid permission type")
+	}
+	resource.ResourceName = fields[n-4]
+	a.Principal = strings.Join(fields[:n-6], ",")
+	a.Host = fields[n-1]
+	return resource, a, nil
+}
@@ -0,0 +1,105 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+)
+
+func TestCreateACL(t *testing.T) {
+	client := newOpenedMockClient()
+
+	acls := []string{
+		"User:CN=test-user,Group,PREFIXED,test-group-,Read,Allow,*",
+		"User:CN=test-user,Cluster,LITERAL,kafka-cluster,IdempotentWrite,Allow,*",
+		"User:CN=test-user,TransactionalID,LITERAL,test-tx,Write,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,secret-topic,All,Deny,10.0.0.1",
+	}
+	for _, acl := range acls {
+		if err := client.CreateACL(acl); err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+	}
+	// creating an existing ACL is a no-op
+	if err := client.CreateACL(acls[0]); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	current, err := client.ListUserACLsForPrincipal("CN=test-user")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	sort.Strings(current)
+	sort.Strings(acls)
+	if !reflect.DeepEqual(current, acls) {
+		t.Errorf("Expected %v, got %v", acls, current)
+	}
+
+	if err := client.CreateACL("User:CN=test-user,Topic,LITERAL,test-topic,Read,Allow"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.CreateACL(acls[0]); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestDeleteACL(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.CreateUserACLs(v1alpha1.KafkaAccessTypeWrite, "", "CN=test-user,O=test", "test-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	if err := client.DeleteACL("User:CN=test-user,O=test,Topic,LITERAL,test-topic,Write,Allow,*"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	acls, err := client.ListUserACLsForPrincipal("CN=test-user,O=test")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := []string{
+		"User:CN=test-user,O=test,Topic,LITERAL,test-topic,Create,Allow,*",
+		"User:CN=test-user,O=test,Topic,LITERAL,test-topic,Describe,Allow,*",
+		"User:CN=test-user,O=test,Topic,LITERAL,test-topic,DescribeConfigs,Allow,*",
+	}
+	sort.Strings(acls)
+	if !reflect.DeepEqual(acls, expected) {
+		t.Errorf("Expected %v, got %v", expected, acls)
+	}
+
+	for _, acl := range []string{
+		"User:CN=test-user,Topic,LITERAL,test-topic",
+		"User:CN=test-user,Topic,LITERAL,test-topic,Write,Allow",
+		"User:CN=test-user,Unknown-Type,LITERAL,test-topic,Write,Allow,*",
+		"User:CN=test-user,Topic,LITERAL,test-topic,Fly,Allow,*",
+	} {
+		if err := client.DeleteACL(acl); err == nil {
+			t.Errorf("Expected error for %q, got nil", acl)
+		}
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.DeleteACL("User:CN=test-user,O=test,Topic,LITERAL,test-topic,Create,Allow,*"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -37,12 +37,27 @@ type KafkaClient interface {
 	CreateTopic(*CreateTopicOptions) error
 	EnsurePartitionCount(string, int32) (bool, error)
 	EnsureTopicConfig(string, map[string]*string) error
+	EnsureReplicationFactor(string, int16, int64) (*ReplicationFactorProgress, error)
+	RemoveReplicationThrottle(string) error
 	DeleteTopic(string, bool) error
 	GetTopic(string) (*sarama.TopicDetail, error)
 	DescribeTopic(string) (*sarama.TopicMetadata, error)
+	DescribeTopicConfig(string) (map[string]string, error)
+	DescribeTopicConfigOverrides(string) (map[string]string, error)
+	DescribeTopicStatus(string) (*v1alpha1.KafkaTopicStatus, error)
+	ListActiveConsumerGroups(string) ([]string, error)
 	CreateUserACLs(v1alpha1.KafkaAccessType, v1alpha1.KafkaPatternType, string, string) error
 	ListUserACLs() ([]sarama.ResourceAcls, error)
+	ListUserACLsForPrincipal(string) ([]string, error)
+	CreateACL(string) error
+	DeleteACL(string) error
 	DeleteUserACLs(string) error
+	EnsureUserScramCredentials(string, []byte) error
+	UserScramCredentialsExist(string) (bool, error)
+	DeleteUserScramCredentials(string) error
+	DescribeClientQuotas(v1alpha1.KafkaQuotaEntity) (map[string]float64, error)
+	EnsureClientQuotas(v1alpha1.KafkaQuotaEntity, *v1alpha1.KafkaQuotas) (bool, error)
+	DeleteClientQuotas(v1alpha1.KafkaQuotaEntity) error
 
 	Brokers() map[int32]string
 	DescribeCluster() ([]*sarama.Broker, int32, error)
@@ -53,6 +68,21 @@ type KafkaClient interface {
 	// OutOfSyncReplicas returns the list of unique out of sync replica (broker) ids
 	OutOfSyncReplicas() ([]int32, error)
 
+	// PartitionsInSyncOnlyOn returns the partitions whose in-sync replicas are all placed on the given brokers
+	PartitionsInSyncOnlyOn([]int32) ([]string, error)
+
+	// PartitionsBlockingRestart returns the partitions which would go below min.insync.replicas while the given brokers are restarted
+	PartitionsBlockingRestart([]int32) ([]BlockingPartition, error)
+
+	// LeadersOn returns the partitions led by the given brokers which have an in-sync replica on another broker
+	LeadersOn([]int32) ([]string, error)
+
+	// ReplicasOn returns the partitions which have a replica on any of the given brokers
+	ReplicasOn([]int32) ([]string, error)
+
+	// ReplicasOnLogDir returns the partitions which have a replica in the given log dir of the broker
+	ReplicasOnLogDir(int32, string) ([]string, error)
+
 	AlterPerBrokerConfig(int32, map[string]*string, bool) error
 	DescribePerBrokerConfig(int32, []string) ([]*sarama.ConfigEntry, error)
 
@@ -76,6 +106,8 @@ type kafkaClient struct {
 	// client funcs for mocking
 	newClusterAdmin func([]string, *sarama.Config) (sarama.ClusterAdmin, error)
 	newClient       func([]string, *sarama.Config) (sarama.Client, error)
+
+	alterPartitionReassignments func(sarama.ClusterAdmin, string, map[int32][]int32) error
 }
 
 func New(opts *KafkaConfig) KafkaClient {
@@ -85,6 +117,7 @@ func New(opts *KafkaConfig) KafkaClient {
 	}
 	kclient.newClusterAdmin = sarama.NewClusterAdmin
 	kclient.newClient = sarama.NewClient
+	kclient.alterPartitionReassignments = alterPartitionReassignments
 	return kclient
 }
 
@@ -0,0 +1,101 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+)
+
+const (
+	consumerGroupStateEmpty = "Empty"
+	consumerGroupStateDead  = "Dead"
+
+	consumerGroupProtocolType = "consumer"
+)
+
+// ListActiveConsumerGroups returns the consumer groups which have members and committed offsets on the topic.
+// The offsets are only fetched for the groups whose members are assigned partitions of the topic, so that the
+// number of requests does not grow with the number of consumer groups of the cluster.
+func (k *kafkaClient) ListActiveConsumerGroups(topic string) ([]string, error) {
+	groups, err := k.admin.ListConsumerGroups()
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to list consumer groups")
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for name, protocolType := range groups {
+		// the member assignments of other protocols, like the one of Kafka Connect, are not topic partitions
+		if protocolType == consumerGroupProtocolType {
+			groupNames = append(groupNames, name)
+		}
+	}
+	if len(groupNames) == 0 {
+		return nil, nil
+	}
+	descriptions, err := k.admin.DescribeConsumerGroups(groupNames)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to describe consumer groups")
+	}
+
+	meta, err := k.DescribeTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	partitions := make([]int32, 0, len(meta.Partitions))
+	for _, partition := range meta.Partitions {
+		partitions = append(partitions, partition.ID)
+	}
+
+	var active []string
+	for _, description := range descriptions {
+		if description.State == consumerGroupStateEmpty || description.State == consumerGroupStateDead ||
+			!isTopicAssigned(description, topic) {
+			continue
+		}
+		offsets, err := k.admin.ListConsumerGroupOffsets(description.GroupId, map[string][]int32{topic: partitions})
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "failed to list consumer group offsets", "group", description.GroupId)
+		}
+		for _, block := range offsets.Blocks[topic] {
+			// offset -1 means that the group has not committed any offset on the partition
+			if block != nil && block.Offset >= 0 {
+				active = append(active, description.GroupId)
+				break
+			}
+		}
+	}
+	sort.Strings(active)
+	return active, nil
+}
+
+// isTopicAssigned tells whether partitions of the topic are assigned to a member of the consumer group
+func isTopicAssigned(description *sarama.GroupDescription, topic string) bool {
+	for _, member := range description.Members {
+		assignment, err := member.GetMemberAssignment()
+		if err != nil || assignment == nil {
+			continue
+		}
+		if len(assignment.Topics[topic]) > 0 {
+			return true
+		}
+	}
+	return false
+}
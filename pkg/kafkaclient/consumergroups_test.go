@@ -0,0 +1,93 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestListActiveConsumerGroups(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+
+	if err := admin.CreateTopic("group-topic", &sarama.TopicDetail{NumPartitions: 2, ReplicationFactor: 1}, false); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	member := func(topics map[string][]int32) map[string]*sarama.GroupMemberDescription {
+		return map[string]*sarama.GroupMemberDescription{"member-1": {MemberAssignment: encodeMemberAssignment(topics)}}
+	}
+	admin.mockConsumerGroups = map[string]*sarama.GroupDescription{
+		"active-group": {GroupId: "active-group", State: "Stable", ProtocolType: "consumer",
+			Members: member(map[string][]int32{"group-topic": {0, 1}})},
+		"empty-group": {GroupId: "empty-group", State: "Empty", ProtocolType: "consumer"},
+		"other-topic-group": {GroupId: "other-topic-group", State: "Stable", ProtocolType: "consumer",
+			Members: member(map[string][]int32{"other-topic": {0}})},
+		"no-offsets-group": {GroupId: "no-offsets-group", State: "Stable", ProtocolType: "consumer",
+			Members: member(map[string][]int32{"group-topic": {0, 1}})},
+		// committed offsets of a group whose members are not assigned the topic are not looked up
+		"unassigned-group": {GroupId: "unassigned-group", State: "Stable", ProtocolType: "consumer",
+			Members: member(map[string][]int32{"other-topic": {0}})},
+		"connect-group": {GroupId: "connect-group", State: "Stable", ProtocolType: "connect",
+			Members: map[string]*sarama.GroupMemberDescription{"worker-1": {MemberAssignment: []byte{0, 1}}}},
+	}
+	admin.mockConsumerGroupOffsets = map[string]map[string]map[int32]int64{
+		"active-group":      {"group-topic": {1: 42}},
+		"empty-group":       {"group-topic": {0: 42}},
+		"other-topic-group": {"other-topic": {0: 42}},
+		"unassigned-group":  {"group-topic": {0: 42}},
+		"connect-group":     {"group-topic": {0: 42}},
+	}
+
+	groups, err := client.ListActiveConsumerGroups("group-topic")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if expected := []string{"active-group"}; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected %v, got: %v", expected, groups)
+	}
+
+	admin.failOps = true
+	if _, err = client.ListActiveConsumerGroups("group-topic"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+// encodeMemberAssignment encodes the topic partitions assigned to a consumer group member in the consumer protocol
+func encodeMemberAssignment(topics map[string][]int32) []byte {
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := binary.BigEndian.AppendUint16(nil, 0)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(names)))
+	for _, name := range names {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(name)))
+		buf = append(buf, name...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(topics[name])))
+		for _, partition := range topics[name] {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(partition))
+		}
+	}
+	// no user data
+	return binary.BigEndian.AppendUint32(buf, 0xffffffff)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"fmt"
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+)
+
+// ReplicasOn returns the partitions which have a replica on any of the given brokers
+func (k *kafkaClient) ReplicasOn(brokerIDs []int32) ([]string, error) {
+	brokers := make(map[int32]struct{}, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		brokers[brokerID] = struct{}{}
+	}
+
+	_, metadata, err := k.describeAllTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []string
+	for _, meta := range metadata {
+		for _, partition := range meta.Partitions {
+			for _, brokerID := range partition.Replicas {
+				if _, ok := brokers[brokerID]; ok {
+					partitions = append(partitions, fmt.Sprintf("%s-%d", meta.Name, partition.ID))
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(partitions)
+	return partitions, nil
+}
+
+// ReplicasOnLogDir returns the partitions which have a replica in the given log dir of the broker
+func (k *kafkaClient) ReplicasOnLogDir(brokerID int32, logDir string) ([]string, error) {
+	logDirs, err := k.admin.DescribeLogDirs([]int32{brokerID})
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range logDirs[brokerID] {
+		if dir.Path != logDir {
+			continue
+		}
+		if dir.ErrorCode != sarama.ErrNoError {
+			return nil, errors.WrapIfWithDetails(dir.ErrorCode, "could not describe log dir", "broker", brokerID, "logDir", logDir)
+		}
+		var partitions []string
+		for _, topic := range dir.Topics {
+			for _, partition := range topic.Partitions {
+				partitions = append(partitions, fmt.Sprintf("%s-%d", topic.Topic, partition.PartitionID))
+			}
+		}
+		sort.Strings(partitions)
+		return partitions, nil
+	}
+	return nil, errors.NewWithDetails("log dir not found", "broker", brokerID, "logDir", logDir)
+}
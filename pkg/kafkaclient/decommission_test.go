@@ -0,0 +1,92 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestReplicasOn(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+
+	err := admin.CreateTopic("replica-topic", &sarama.TopicDetail{
+		NumPartitions:     3,
+		ReplicationFactor: 2,
+		ReplicaAssignment: map[int32][]int32{0: {0, 1}, 1: {1, 2}, 2: {0, 2}},
+	}, false)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	testCases := []struct {
+		brokerIDs []int32
+		expected  []string
+	}{
+		{brokerIDs: []int32{3}},
+		{brokerIDs: []int32{0}, expected: []string{"replica-topic-0", "replica-topic-2"}},
+		{brokerIDs: []int32{1, 2}, expected: []string{"replica-topic-0", "replica-topic-1", "replica-topic-2"}},
+	}
+	for _, testCase := range testCases {
+		partitions, err := client.ReplicasOn(testCase.brokerIDs)
+		if err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+		if !reflect.DeepEqual(partitions, testCase.expected) {
+			t.Errorf("Expected %v for brokers %v, got: %v", testCase.expected, testCase.brokerIDs, partitions)
+		}
+	}
+}
+
+func TestReplicasOnLogDir(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+	admin.mockLogDirs[0] = []sarama.DescribeLogDirsResponseDirMetadata{
+		{
+			Path: "/kafka-logs/kafka",
+			Topics: []sarama.DescribeLogDirsResponseTopic{
+				{Topic: "topic", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 1}, {PartitionID: 0}}},
+			},
+		},
+		{Path: "/kafka-logs-2/kafka"},
+		{Path: "/kafka-logs-3/kafka", ErrorCode: sarama.ErrKafkaStorageError},
+	}
+
+	partitions, err := client.ReplicasOnLogDir(0, "/kafka-logs/kafka")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if expected := []string{"topic-0", "topic-1"}; !reflect.DeepEqual(partitions, expected) {
+		t.Errorf("Expected %v, got: %v", expected, partitions)
+	}
+
+	partitions, err = client.ReplicasOnLogDir(0, "/kafka-logs-2/kafka")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if len(partitions) != 0 {
+		t.Error("Expected no partitions, got:", partitions)
+	}
+
+	if _, err = client.ReplicasOnLogDir(0, "/kafka-logs-3/kafka"); err == nil {
+		t.Error("Expected error for offline log dir, got nil")
+	}
+	if _, err = client.ReplicasOnLogDir(1, "/kafka-logs/kafka"); err == nil {
+		t.Error("Expected error for missing log dir, got nil")
+	}
+}
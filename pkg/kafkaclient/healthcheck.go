@@ -15,6 +15,9 @@
 package kafkaclient
 
 import (
+	"fmt"
+	"sort"
+
 	"emperror.dev/errors"
 )
 
@@ -91,3 +94,42 @@ func (k *kafkaClient) OutOfSyncReplicas() ([]int32, error) {
 	}
 	return brokerIDs, nil
 }
+
+// PartitionsInSyncOnlyOn returns the partitions (in topic-partition form) whose in-sync replicas are all placed
+// on the given brokers, these partitions go offline when the given brokers are restarted at the same time
+func (k *kafkaClient) PartitionsInSyncOnlyOn(brokerIDs []int32) ([]string, error) {
+	brokers := make(map[int32]struct{}, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		brokers[brokerID] = struct{}{}
+	}
+
+	availableTopics, err := k.client.Topics()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not fetch topics")
+	}
+	var affectedPartitions []string
+	for _, topic := range availableTopics {
+		partitions, err := k.client.Partitions(topic)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not fetch partition", "topic", topic)
+		}
+		for _, partition := range partitions {
+			isrReplicas, err := k.client.InSyncReplicas(topic, partition)
+			if err != nil {
+				return nil, errors.WrapIfWithDetails(err, "could not fetch isr replicas", "topic", topic, "partition", partition)
+			}
+			inSyncElsewhere := false
+			for _, brokerID := range isrReplicas {
+				if _, ok := brokers[brokerID]; !ok {
+					inSyncElsewhere = true
+					break
+				}
+			}
+			if !inSyncElsewhere {
+				affectedPartitions = append(affectedPartitions, fmt.Sprintf("%s-%d", topic, partition))
+			}
+		}
+	}
+	sort.Strings(affectedPartitions)
+	return affectedPartitions, nil
+}
@@ -0,0 +1,60 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestPartitionsInSyncOnlyOn(t *testing.T) {
+	client := newOpenedMockClient()
+	// the metadata is fetched by the sarama client instead of the cluster admin
+	metadataClient := client.client.(*mockClusterAdmin)
+
+	err := metadataClient.CreateTopic("placement-topic", &sarama.TopicDetail{
+		NumPartitions:     3,
+		ReplicationFactor: 2,
+		ReplicaAssignment: map[int32][]int32{
+			0: {0, 1},
+			1: {1, 2},
+			2: {2, 3},
+		},
+	}, false)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	testCases := []struct {
+		brokerIDs []int32
+		expected  []string
+	}{
+		{brokerIDs: []int32{0}},
+		{brokerIDs: []int32{0, 2}},
+		{brokerIDs: []int32{0, 1}, expected: []string{"placement-topic-0"}},
+		{brokerIDs: []int32{1, 2, 3}, expected: []string{"placement-topic-1", "placement-topic-2"}},
+	}
+	for _, testCase := range testCases {
+		partitions, err := client.PartitionsInSyncOnlyOn(testCase.brokerIDs)
+		if err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+		if !reflect.DeepEqual(partitions, testCase.expected) {
+			t.Errorf("Expected %v for brokers %v, got: %v", testCase.expected, testCase.brokerIDs, partitions)
+		}
+	}
+}
@@ -31,6 +31,26 @@ type mockClusterAdmin struct {
 	failOps    bool
 	mockTopics map[string]sarama.TopicDetail
 	mockACLs   map[sarama.Resource]*sarama.ResourceAcls
+	mockScram  map[string][]byte
+	mockQuotas map[sarama.QuotaEntityComponent]map[string]float64
+	// mockConfigs holds the configs set by incremental config changes
+	mockConfigs map[mockConfigResource]map[string]string
+	// mockReassignments holds the ongoing partition reassignments
+	mockReassignments map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus
+	// mockConsumerGroups holds the consumer groups and mockConsumerGroupOffsets their committed offsets by topic and partition
+	mockConsumerGroups       map[string]*sarama.GroupDescription
+	mockConsumerGroupOffsets map[string]map[string]map[int32]int64
+	// mockISRs overrides the in-sync replicas of the partitions, which are all replicas otherwise
+	mockISRs map[string]map[int32][]int32
+	// mockLogDirs holds the log dirs of the brokers
+	mockLogDirs map[int32][]sarama.DescribeLogDirsResponseDirMetadata
+	// mockTopicDefaults holds the configs inherited by every topic from the brokers
+	mockTopicDefaults []sarama.ConfigEntry
+}
+
+type mockConfigResource struct {
+	resourceType sarama.ConfigResourceType
+	name         string
 }
 
 func NewMockFromCluster(client client.Client, cluster *v1beta1.KafkaCluster) (KafkaClient, func(), error) {
@@ -40,9 +60,18 @@ func NewMockFromCluster(client client.Client, cluster *v1beta1.KafkaCluster) (Ka
 
 func newEmptyMockClusterAdmin(failOps bool) *mockClusterAdmin {
 	return &mockClusterAdmin{
-		mockTopics: make(map[string]sarama.TopicDetail, 0),
-		mockACLs:   make(map[sarama.Resource]*sarama.ResourceAcls, 0),
-		failOps:    failOps,
+		mockTopics:        make(map[string]sarama.TopicDetail, 0),
+		mockACLs:          make(map[sarama.Resource]*sarama.ResourceAcls, 0),
+		mockScram:         make(map[string][]byte, 0),
+		mockQuotas:        make(map[sarama.QuotaEntityComponent]map[string]float64, 0),
+		mockConfigs:       make(map[mockConfigResource]map[string]string, 0),
+		mockReassignments: make(map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, 0),
+		failOps:           failOps,
+
+		mockConsumerGroups:       make(map[string]*sarama.GroupDescription, 0),
+		mockConsumerGroupOffsets: make(map[string]map[string]map[int32]int64, 0),
+		mockISRs:                 make(map[string]map[int32][]int32, 0),
+		mockLogDirs:              make(map[int32][]sarama.DescribeLogDirsResponseDirMetadata, 0),
 	}
 }
 
@@ -74,7 +103,37 @@ func newMockClient() *kafkaClient {
 		timeout:         time.Duration(kafkaDefaultTimeout) * time.Second,
 		newClusterAdmin: newMockClusterAdmin,
 		newClient:       newMockKafkaClient,
+
+		alterPartitionReassignments: mockAlterPartitionReassignments,
+	}
+}
+
+// mockAlterPartitionReassignments completes the reassignment of the given partitions of the mock immediately
+func mockAlterPartitionReassignments(admin sarama.ClusterAdmin, topic string, assignment map[int32][]int32) error {
+	m := admin.(*mockClusterAdmin)
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return errors.New("bad alter partition reassignments")
 	}
+	detail, ok := m.mockTopics[topic]
+	if !ok {
+		return sarama.ErrUnknownTopicOrPartition
+	}
+	replicaAssignment := make(map[int32][]int32, len(detail.ReplicaAssignment))
+	for id, replicas := range detail.ReplicaAssignment {
+		replicaAssignment[id] = replicas
+	}
+	for id, replicas := range assignment {
+		replicaAssignment[id] = replicas
+	}
+	detail.ReplicaAssignment = replicaAssignment
+	for _, replicas := range replicaAssignment {
+		detail.ReplicationFactor = int16(len(replicas))
+	}
+	m.mockTopics[topic] = detail
+	return nil
 }
 
 func newOpenedMockClient() *kafkaClient {
@@ -99,7 +158,25 @@ func (m *mockClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
 	if m.failOps {
 		return map[string]sarama.TopicDetail{}, errors.New("bad list topics")
 	}
-	return shallowCopy(m.mockTopics), nil
+	topics := shallowCopy(m.mockTopics)
+	// the dynamic topic configs altered incrementally are listed along with the config entries of the topic
+	for resource, configs := range m.mockConfigs {
+		detail, ok := topics[resource.name]
+		if resource.resourceType != sarama.TopicResource || !ok || len(configs) == 0 {
+			continue
+		}
+		entries := make(map[string]*string, len(detail.ConfigEntries)+len(configs))
+		for name, value := range detail.ConfigEntries {
+			entries[name] = value
+		}
+		for name, value := range configs {
+			value := value
+			entries[name] = &value
+		}
+		detail.ConfigEntries = entries
+		topics[resource.name] = detail
+	}
+	return topics, nil
 }
 
 func (m *mockClusterAdmin) Topics() ([]string, error) {
@@ -120,6 +197,23 @@ func (m *mockClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetad
 	if m.failOps {
 		return []*sarama.TopicMetadata{}, errors.New("bad describe topics")
 	}
+	m.Lock()
+	metadata := make([]*sarama.TopicMetadata, 0, len(topics))
+	for _, topic := range topics {
+		if detail, ok := m.mockTopics[topic]; ok {
+			meta := topicMetadataFromDetail(topic, detail)
+			for _, partition := range meta.Partitions {
+				if isr, ok := m.mockISRs[topic][partition.ID]; ok {
+					partition.Isr = isr
+				}
+			}
+			metadata = append(metadata, meta)
+		}
+	}
+	m.Unlock()
+	if len(metadata) > 0 {
+		return metadata, nil
+	}
 	switch topics[0] {
 	case "test-topic", "already-created-topic":
 		return []*sarama.TopicMetadata{
@@ -144,6 +238,32 @@ func (m *mockClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetad
 	}
 }
 
+// topicMetadataFromDetail describes a topic by its replica assignment, topics created without an explicit
+// assignment have their replicas on the first brokers
+func topicMetadataFromDetail(topic string, detail sarama.TopicDetail) *sarama.TopicMetadata {
+	meta := &sarama.TopicMetadata{Name: topic, Err: sarama.ErrNoError}
+	for id := int32(0); id < detail.NumPartitions; id++ {
+		replicas, ok := detail.ReplicaAssignment[id]
+		if !ok {
+			replicas = make([]int32, 0, detail.ReplicationFactor)
+			for replica := int32(0); replica < int32(detail.ReplicationFactor); replica++ {
+				replicas = append(replicas, replica)
+			}
+		}
+		leader := int32(-1)
+		if len(replicas) > 0 {
+			leader = replicas[0]
+		}
+		meta.Partitions = append(meta.Partitions, &sarama.PartitionMetadata{
+			ID:       id,
+			Leader:   leader,
+			Replicas: replicas,
+			Isr:      replicas,
+		})
+	}
+	return meta
+}
+
 func (m *mockClusterAdmin) CreateTopic(name string, detail *sarama.TopicDetail, validateOnly bool) error {
 	m.Lock()
 	defer m.Unlock()
@@ -174,12 +294,64 @@ func (m *mockClusterAdmin) DeleteTopic(name string) error {
 }
 
 func (m *mockClusterAdmin) AlterConfig(resource sarama.ConfigResourceType, topic string, conf map[string]*string, validateOnly bool) error {
+	m.Lock()
+	defer m.Unlock()
+
 	if m.failOps {
 		return errors.New("bad alter config")
 	}
+	if validateOnly {
+		return nil
+	}
+	// altering the config replaces every dynamic config of the resource
+	configs := make(map[string]string, len(conf))
+	for key, value := range conf {
+		if value != nil {
+			configs[key] = *value
+		}
+	}
+	m.mockConfigs[mockConfigResource{resourceType: resource, name: topic}] = configs
+	return nil
+}
+
+func (m *mockClusterAdmin) IncrementalAlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return errors.New("bad incremental alter config")
+	}
+	resource := mockConfigResource{resourceType: resourceType, name: name}
+	configs, ok := m.mockConfigs[resource]
+	if !ok {
+		configs = make(map[string]string)
+		m.mockConfigs[resource] = configs
+	}
+	for key, entry := range entries {
+		switch entry.Operation {
+		case sarama.IncrementalAlterConfigsOperationSet:
+			configs[key] = *entry.Value
+		case sarama.IncrementalAlterConfigsOperationDelete:
+			delete(configs, key)
+		}
+	}
 	return nil
 }
 
+func (m *mockClusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad list partition reassignments")
+	}
+	status := make(map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus)
+	if ongoing, ok := m.mockReassignments[topic]; ok && len(ongoing) > 0 {
+		status[topic] = ongoing
+	}
+	return status, nil
+}
+
 func (m *mockClusterAdmin) CreatePartitions(topic string, count int32, assn [][]int32, validateOnly bool) error {
 	return nil
 }
@@ -215,7 +387,19 @@ func (m *mockClusterAdmin) ListAcls(filter sarama.AclFilter) ([]sarama.ResourceA
 
 	acls := make([]sarama.ResourceAcls, len(m.mockACLs))
 	for _, acl := range m.mockACLs {
-		acls = append(acls, *acl)
+		if filter.Principal == nil {
+			acls = append(acls, *acl)
+			continue
+		}
+		filtered := sarama.ResourceAcls{Resource: acl.Resource}
+		for _, a := range acl.Acls {
+			if a.Principal == *filter.Principal {
+				filtered.Acls = append(filtered.Acls, a)
+			}
+		}
+		if len(filtered.Acls) > 0 {
+			acls = append(acls, filtered)
+		}
 	}
 	return acls, nil
 }
@@ -227,6 +411,10 @@ func (m *mockClusterAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool)
 	if m.failOps {
 		return []sarama.MatchingAcl{}, errors.New("bad create acl")
 	}
+	// precise filters only remove the matching ACL
+	if filter.ResourceName != nil {
+		return m.deleteMatchingACL(filter), nil
+	}
 	switch *filter.Principal {
 	case "test-user":
 		return []sarama.MatchingAcl{{}}, nil
@@ -239,8 +427,112 @@ func (m *mockClusterAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool)
 	}
 }
 
+func (m *mockClusterAdmin) deleteMatchingACL(filter sarama.AclFilter) []sarama.MatchingAcl {
+	matches := make([]sarama.MatchingAcl, 0)
+	for resource, resourceAcls := range m.mockACLs {
+		if resource.ResourceType != filter.ResourceType || resource.ResourceName != *filter.ResourceName ||
+			resource.ResourcePatternType != filter.ResourcePatternTypeFilter {
+			continue
+		}
+		remaining := make([]*sarama.Acl, 0, len(resourceAcls.Acls))
+		for _, acl := range resourceAcls.Acls {
+			if acl.Principal == *filter.Principal && acl.Host == *filter.Host &&
+				acl.Operation == filter.Operation && acl.PermissionType == filter.PermissionType {
+				matches = append(matches, sarama.MatchingAcl{Resource: resource, Acl: *acl})
+				continue
+			}
+			remaining = append(remaining, acl)
+		}
+		if len(remaining) == 0 {
+			delete(m.mockACLs, resource)
+		} else {
+			resourceAcls.Acls = remaining
+		}
+	}
+	return matches
+}
+
+func (m *mockClusterAdmin) UpsertUserScramCredentials(upsert []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad upsert scram credentials")
+	}
+	results := make([]*sarama.AlterUserScramCredentialsResult, 0, len(upsert))
+	for _, u := range upsert {
+		m.mockScram[u.Name] = u.Password
+		results = append(results, &sarama.AlterUserScramCredentialsResult{User: u.Name})
+	}
+	return results, nil
+}
+
+func (m *mockClusterAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe scram credentials")
+	}
+	results := make([]*sarama.DescribeUserScramCredentialsResult, 0, len(users))
+	for _, user := range users {
+		result := &sarama.DescribeUserScramCredentialsResult{User: user}
+		if _, ok := m.mockScram[user]; ok {
+			result.CredentialInfos = []*sarama.UserScramCredentialsResponseInfo{
+				{Mechanism: sarama.SCRAM_MECHANISM_SHA_512, Iterations: scramIterations},
+			}
+		} else {
+			result.ErrorCode = errResourceNotFound
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (m *mockClusterAdmin) DeleteUserScramCredentials(del []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad delete scram credentials")
+	}
+	results := make([]*sarama.AlterUserScramCredentialsResult, 0, len(del))
+	for _, d := range del {
+		result := &sarama.AlterUserScramCredentialsResult{User: d.Name}
+		if _, ok := m.mockScram[d.Name]; ok {
+			delete(m.mockScram, d.Name)
+		} else {
+			result.ErrorCode = errResourceNotFound
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 func (m *mockClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
-	return []sarama.ConfigEntry{}, nil
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe config")
+	}
+	entries := []sarama.ConfigEntry{}
+	source := sarama.SourceUnknown
+	if resource.Type == sarama.TopicResource {
+		source = sarama.SourceTopic
+		for name, value := range m.mockTopics[resource.Name].ConfigEntries {
+			if value != nil {
+				entries = append(entries, sarama.ConfigEntry{Name: name, Value: *value, Source: source})
+			}
+		}
+	}
+	for name, value := range m.mockConfigs[mockConfigResource{resourceType: resource.Type, name: resource.Name}] {
+		entries = append(entries, sarama.ConfigEntry{Name: name, Value: value, Source: source})
+	}
+	if resource.Type == sarama.TopicResource {
+		entries = append(entries, m.mockTopicDefaults...)
+	}
+	return entries, nil
 }
 
 func (m *mockClusterAdmin) Controller() (*sarama.Broker, error) {
@@ -254,3 +546,155 @@ func shallowCopy(original map[string]sarama.TopicDetail) map[string]sarama.Topic
 	}
 	return returnMap
 }
+
+func (m *mockClusterAdmin) DescribeClientQuotas(components []sarama.QuotaFilterComponent, strict bool) ([]sarama.DescribeClientQuotasEntry, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return nil, errors.New("bad describe client quotas")
+	}
+	entries := make([]sarama.DescribeClientQuotasEntry, 0)
+	for entity, values := range m.mockQuotas {
+		for _, c := range components {
+			if c.EntityType == entity.EntityType && c.MatchType == entity.MatchType && c.Match == entity.Name {
+				copied := make(map[string]float64, len(values))
+				for k, v := range values {
+					copied[k] = v
+				}
+				entries = append(entries, sarama.DescribeClientQuotasEntry{
+					Entity: []sarama.QuotaEntityComponent{entity},
+					Values: copied,
+				})
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockClusterAdmin) AlterClientQuotas(entity []sarama.QuotaEntityComponent, op sarama.ClientQuotasOp, validateOnly bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failOps {
+		return errors.New("bad alter client quotas")
+	}
+	if len(entity) != 1 {
+		return errors.New("only single component entities are supported by the mock")
+	}
+	values, ok := m.mockQuotas[entity[0]]
+	if !ok {
+		values = make(map[string]float64)
+		m.mockQuotas[entity[0]] = values
+	}
+	if op.Remove {
+		delete(values, op.Key)
+		if len(values) == 0 {
+			delete(m.mockQuotas, entity[0])
+		}
+		return nil
+	}
+	values[op.Key] = op.Value
+	return nil
+}
+
+func (m *mockClusterAdmin) ListConsumerGroups() (map[string]string, error) {
+	if m.failOps {
+		return nil, errors.New("bad list consumer groups")
+	}
+	groups := make(map[string]string, len(m.mockConsumerGroups))
+	for name, group := range m.mockConsumerGroups {
+		groups[name] = group.ProtocolType
+	}
+	return groups, nil
+}
+
+func (m *mockClusterAdmin) DescribeConsumerGroups(groups []string) ([]*sarama.GroupDescription, error) {
+	descriptions := make([]*sarama.GroupDescription, 0, len(groups))
+	for _, name := range groups {
+		if group, ok := m.mockConsumerGroups[name]; ok {
+			descriptions = append(descriptions, group)
+			continue
+		}
+		descriptions = append(descriptions, &sarama.GroupDescription{GroupId: name, State: "Dead"})
+	}
+	return descriptions, nil
+}
+
+func (m *mockClusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	response := &sarama.OffsetFetchResponse{}
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			offset, ok := m.mockConsumerGroupOffsets[group][topic][partition]
+			if !ok {
+				offset = -1
+			}
+			response.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		}
+	}
+	return response, nil
+}
+
+func (m *mockClusterAdmin) DescribeLogDirs(brokerIDs []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+	if m.failOps {
+		return nil, errors.New("bad describe log dirs")
+	}
+	logDirs := make(map[int32][]sarama.DescribeLogDirsResponseDirMetadata, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		logDirs[brokerID] = m.mockLogDirs[brokerID]
+	}
+	return logDirs, nil
+}
+
+func (m *mockClusterAdmin) partitionMetadata(topic string, partitionID int32) (*sarama.PartitionMetadata, error) {
+	m.Lock()
+	detail, ok := m.mockTopics[topic]
+	m.Unlock()
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	for _, partition := range topicMetadataFromDetail(topic, detail).Partitions {
+		if partition.ID == partitionID {
+			return partition, nil
+		}
+	}
+	return nil, sarama.ErrUnknownTopicOrPartition
+}
+
+func (m *mockClusterAdmin) Partitions(topic string) ([]int32, error) {
+	m.Lock()
+	detail, ok := m.mockTopics[topic]
+	m.Unlock()
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	partitions := make([]int32, 0, detail.NumPartitions)
+	for id := int32(0); id < detail.NumPartitions; id++ {
+		partitions = append(partitions, id)
+	}
+	return partitions, nil
+}
+
+func (m *mockClusterAdmin) Replicas(topic string, partitionID int32) ([]int32, error) {
+	partition, err := m.partitionMetadata(topic, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	return partition.Replicas, nil
+}
+
+func (m *mockClusterAdmin) InSyncReplicas(topic string, partitionID int32) ([]int32, error) {
+	partition, err := m.partitionMetadata(topic, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	return partition.Isr, nil
+}
+
+func (m *mockClusterAdmin) OfflineReplicas(topic string, partitionID int32) ([]int32, error) {
+	partition, err := m.partitionMetadata(topic, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	return partition.OfflineReplicas, nil
+}
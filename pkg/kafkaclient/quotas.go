@@ -0,0 +1,128 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+)
+
+const (
+	QuotaProducerByteRate  = "producer_byte_rate"
+	QuotaConsumerByteRate  = "consumer_byte_rate"
+	QuotaRequestPercentage = "request_percentage"
+)
+
+// QuotasToValues converts the quotas to the client quota configuration keys and values
+func QuotasToValues(quotas *v1alpha1.KafkaQuotas) map[string]float64 {
+	values := make(map[string]float64)
+	if quotas == nil {
+		return values
+	}
+	if quotas.ProducerByteRate != nil {
+		values[QuotaProducerByteRate] = float64(*quotas.ProducerByteRate)
+	}
+	if quotas.ConsumerByteRate != nil {
+		values[QuotaConsumerByteRate] = float64(*quotas.ConsumerByteRate)
+	}
+	if quotas.RequestPercentage != nil {
+		values[QuotaRequestPercentage] = float64(*quotas.RequestPercentage)
+	}
+	return values
+}
+
+// DescribeClientQuotas returns the client quotas which are set exactly on the given entity
+func (k *kafkaClient) DescribeClientQuotas(entity v1alpha1.KafkaQuotaEntity) (map[string]float64, error) {
+	component := quotaEntityComponent(entity)
+	filter := sarama.QuotaFilterComponent{
+		EntityType: component.EntityType,
+		MatchType:  component.MatchType,
+		Match:      component.Name,
+	}
+	entries, err := k.admin.DescribeClientQuotas([]sarama.QuotaFilterComponent{filter}, true)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not describe client quotas", "entityType", entity.Type, "entityName", entity.Name)
+	}
+	for _, entry := range entries {
+		if len(entry.Entity) == 1 && entry.Entity[0] == component {
+			return entry.Values, nil
+		}
+	}
+	return map[string]float64{}, nil
+}
+
+// EnsureClientQuotas makes sure that exactly the given quotas are set on the entity. Quotas which are
+// not part of the desired quotas are removed. It returns true if any of the quotas had to be altered.
+func (k *kafkaClient) EnsureClientQuotas(entity v1alpha1.KafkaQuotaEntity, quotas *v1alpha1.KafkaQuotas) (bool, error) {
+	current, err := k.DescribeClientQuotas(entity)
+	if err != nil {
+		return false, err
+	}
+	desired := QuotasToValues(quotas)
+
+	var ops []sarama.ClientQuotasOp
+	for key, value := range desired {
+		if currentValue, ok := current[key]; !ok || currentValue != value {
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Value: value})
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Remove: true})
+		}
+	}
+	if err = k.alterClientQuotas(entity, ops); err != nil {
+		return false, err
+	}
+	return len(ops) > 0, nil
+}
+
+// DeleteClientQuotas removes every client quota set on the entity
+func (k *kafkaClient) DeleteClientQuotas(entity v1alpha1.KafkaQuotaEntity) error {
+	_, err := k.EnsureClientQuotas(entity, nil)
+	return err
+}
+
+func (k *kafkaClient) alterClientQuotas(entity v1alpha1.KafkaQuotaEntity, ops []sarama.ClientQuotasOp) error {
+	// keep the order of the operations stable
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Key < ops[j].Key
+	})
+	component := quotaEntityComponent(entity)
+	for _, op := range ops {
+		if err := k.admin.AlterClientQuotas([]sarama.QuotaEntityComponent{component}, op, false); err != nil {
+			return errors.WrapIfWithDetails(err, "could not alter client quota", "entityType", entity.Type,
+				"entityName", entity.Name, "key", op.Key)
+		}
+	}
+	return nil
+}
+
+func quotaEntityComponent(entity v1alpha1.KafkaQuotaEntity) sarama.QuotaEntityComponent {
+	component := sarama.QuotaEntityComponent{
+		EntityType: sarama.QuotaEntityType(entity.Type),
+	}
+	if entity.IsDefault() {
+		component.MatchType = sarama.QuotaMatchDefault
+	} else {
+		component.MatchType = sarama.QuotaMatchExact
+		component.Name = entity.Name
+	}
+	return component
+}
@@ -0,0 +1,122 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+func TestQuotasToValues(t *testing.T) {
+	if values := QuotasToValues(nil); len(values) != 0 {
+		t.Error("Expected no quota values, got:", values)
+	}
+
+	values := QuotasToValues(&v1alpha1.KafkaQuotas{
+		ProducerByteRate:  util.Int64Pointer(1048576),
+		RequestPercentage: util.Int32Pointer(50),
+	})
+	expected := map[string]float64{
+		QuotaProducerByteRate:  1048576,
+		QuotaRequestPercentage: 50,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestEnsureClientQuotas(t *testing.T) {
+	client := newOpenedMockClient()
+
+	user := v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: "CN=test-user"}
+	defaultClientID := v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID}
+
+	changed, err := client.EnsureClientQuotas(user, &v1alpha1.KafkaQuotas{
+		ProducerByteRate: util.Int64Pointer(1024),
+		ConsumerByteRate: util.Int64Pointer(2048),
+	})
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if !changed {
+		t.Error("Expected quotas to be changed")
+	}
+
+	if _, err = client.EnsureClientQuotas(defaultClientID, &v1alpha1.KafkaQuotas{
+		RequestPercentage: util.Int32Pointer(100),
+	}); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	// ensuring the same quotas again is a no-op
+	changed, err = client.EnsureClientQuotas(user, &v1alpha1.KafkaQuotas{
+		ProducerByteRate: util.Int64Pointer(1024),
+		ConsumerByteRate: util.Int64Pointer(2048),
+	})
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if changed {
+		t.Error("Expected quotas not to be changed")
+	}
+
+	// quotas which are not desired anymore are removed
+	changed, err = client.EnsureClientQuotas(user, &v1alpha1.KafkaQuotas{
+		ProducerByteRate: util.Int64Pointer(4096),
+	})
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if !changed {
+		t.Error("Expected quotas to be changed")
+	}
+	values, err := client.DescribeClientQuotas(user)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if expected := map[string]float64{QuotaProducerByteRate: 4096}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	// the default entity is independent of the other entities
+	values, err = client.DescribeClientQuotas(defaultClientID)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if expected := map[string]float64{QuotaRequestPercentage: 100}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	if err = client.DeleteClientQuotas(user); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	values, err = client.DescribeClientQuotas(user)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if len(values) != 0 {
+		t.Error("Expected no quotas, got:", values)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err = client.EnsureClientQuotas(user, &v1alpha1.KafkaQuotas{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
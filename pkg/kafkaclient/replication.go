@@ -0,0 +1,375 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+)
+
+const (
+	leaderReplicationThrottledRate       = "leader.replication.throttled.rate"
+	followerReplicationThrottledRate     = "follower.replication.throttled.rate"
+	leaderReplicationThrottledReplicas   = "leader.replication.throttled.replicas"
+	followerReplicationThrottledReplicas = "follower.replication.throttled.replicas"
+)
+
+// ReplicationFactorProgress describes the progress of a replication factor change of a topic
+type ReplicationFactorProgress struct {
+	// Submitted is true when a new reassignment has been submitted to change the replication factor
+	Submitted bool
+	// PendingPartitions is the number of partitions whose reassignment is still in progress
+	PendingPartitions int32
+}
+
+// EnsureReplicationFactor reassigns the replicas of the topic when its replication factor differs from the
+// desired one. New replicas are placed rack aware, preferring brokers from racks which do not hold any
+// replica of the partition yet, while the replicas to be removed are picked from the most crowded racks.
+// Only the partitions whose replicas change are reassigned. The replication traffic of the moving replicas is
+// throttled by the given rate (bytes/sec) on every broker until the throttle is removed by RemoveReplicationThrottle.
+func (k *kafkaClient) EnsureReplicationFactor(topic string, desired int16, throttleRate int64) (*ReplicationFactorProgress, error) {
+	meta, err := k.DescribeTopic(topic)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, fmt.Sprintf("could not describe topic %s", topic))
+	}
+
+	partitionIDs := make([]int32, 0, len(meta.Partitions))
+	for _, partition := range meta.Partitions {
+		partitionIDs = append(partitionIDs, partition.ID)
+	}
+
+	ongoing, err := k.admin.ListPartitionReassignments(topic, partitionIDs)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list partition reassignments")
+	}
+	if pending := len(ongoing[topic]); pending > 0 {
+		return &ReplicationFactorProgress{PendingPartitions: int32(pending)}, nil
+	}
+
+	brokers, _, err := k.DescribeCluster()
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not describe kafka cluster")
+	}
+	brokerRacks := make(map[int32]string, len(brokers))
+	for _, broker := range brokers {
+		brokerRacks[broker.ID()] = broker.Rack()
+	}
+
+	assignment, changed := planReplicationFactorChange(meta.Partitions, brokerRacks, int(desired))
+	if changed == 0 {
+		return &ReplicationFactorProgress{}, nil
+	}
+	if int(desired) > len(brokers) {
+		return nil, errorfactory.New(errorfactory.InternalError{},
+			errors.Errorf("replication factor %d is larger than the number of brokers %d", desired, len(brokers)),
+			"could not change the replication factor", "topic", topic)
+	}
+
+	moving := make(map[int32][]int32, changed)
+	for _, partition := range meta.Partitions {
+		if replicas := assignment[partition.ID]; !sameBrokerIDs(replicas, partition.Replicas) {
+			moving[partition.ID] = replicas
+		}
+	}
+
+	leaderReplicas, followerReplicas := throttledReplicas(meta.Partitions, moving)
+	if followerReplicas != "" {
+		if err = k.setReplicationThrottle(topic, leaderReplicas, followerReplicas, throttleRate); err != nil {
+			return nil, err
+		}
+	}
+	if err = k.alterPartitionReassignments(k.admin, topic, moving); err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not reassign partitions", "topic", topic)
+	}
+	return &ReplicationFactorProgress{Submitted: true, PendingPartitions: int32(len(moving))}, nil
+}
+
+// alterPartitionReassignments submits the reassignment of the given partitions of the topic to the controller.
+// Unlike sarama.ClusterAdmin.AlterPartitionReassignments, which submits every partition up to the highest partition
+// id, it leaves the ongoing reassignments of the other partitions (e.g. the ones of Cruise Control) intact. The errors
+// of the single partitions are not exposed by sarama, the partitions which were not reassigned are planned again by
+// the next EnsureReplicationFactor call.
+func alterPartitionReassignments(admin sarama.ClusterAdmin, topic string, assignment map[int32][]int32) error {
+	request := &sarama.AlterPartitionReassignmentsRequest{
+		TimeoutMs: int32(60000),
+		Version:   int16(0),
+	}
+	for partition, replicas := range assignment {
+		request.AddBlock(topic, partition, replicas)
+	}
+	controller, err := admin.Controller()
+	if err != nil {
+		return err
+	}
+	response, err := controller.AlterPartitionReassignments(request)
+	if err != nil {
+		return err
+	}
+	if response.ErrorCode != sarama.ErrNoError {
+		return response.ErrorCode
+	}
+	return nil
+}
+
+// RemoveReplicationThrottle removes the replication throttle set by EnsureReplicationFactor. The throttled replicas
+// of the topic are always removed, while the broker-wide replication rate is only removed when there is no other
+// throttled reassignment in progress (e.g. a replication factor change of another topic, or a Cruise Control
+// execution), as that would lift the throttle of those too.
+func (k *kafkaClient) RemoveReplicationThrottle(topic string) error {
+	err := k.admin.IncrementalAlterConfig(sarama.TopicResource, topic, map[string]sarama.IncrementalAlterConfigsEntry{
+		leaderReplicationThrottledReplicas:   {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+		followerReplicationThrottledReplicas: {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+	}, false)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not remove replication throttle of topic", "topic", topic)
+	}
+	throttled, err := k.hasThrottledReassignment()
+	if err != nil {
+		return err
+	}
+	if throttled {
+		return nil
+	}
+	for _, broker := range k.brokers {
+		err = k.admin.IncrementalAlterConfig(sarama.BrokerResource, strconv.Itoa(int(broker.ID())), map[string]sarama.IncrementalAlterConfigsEntry{
+			leaderReplicationThrottledRate:   {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+			followerReplicationThrottledRate: {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+		}, false)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not remove replication throttle of broker", "brokerId", broker.ID())
+		}
+	}
+	return nil
+}
+
+// hasThrottledReassignment returns true if there is a topic whose replication is throttled and which has
+// partition reassignments in progress
+func (k *kafkaClient) hasThrottledReassignment() (bool, error) {
+	topics, err := k.admin.ListTopics()
+	if err != nil {
+		return false, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list topics")
+	}
+	for name, detail := range topics {
+		if detail.ConfigEntries[leaderReplicationThrottledReplicas] == nil &&
+			detail.ConfigEntries[followerReplicationThrottledReplicas] == nil {
+			continue
+		}
+		partitionIDs := make([]int32, 0, detail.NumPartitions)
+		for id := int32(0); id < detail.NumPartitions; id++ {
+			partitionIDs = append(partitionIDs, id)
+		}
+		ongoing, err := k.admin.ListPartitionReassignments(name, partitionIDs)
+		if err != nil {
+			return false, errorfactory.New(errorfactory.BrokersRequestError{}, err, "could not list partition reassignments", "topic", name)
+		}
+		if len(ongoing[name]) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// throttledReplicas returns the leader and follower throttled replicas of the reassignment in the
+// partition:broker form. The leader throttle covers the current replicas of the moving partitions, which serve as
+// the source of the replication, while the follower throttle covers the replicas being added.
+func throttledReplicas(partitions []*sarama.PartitionMetadata, moving map[int32][]int32) (string, string) {
+	var leaders, followers []string
+	for _, partition := range partitions {
+		replicas, ok := moving[partition.ID]
+		if !ok {
+			continue
+		}
+		for _, replica := range partition.Replicas {
+			leaders = append(leaders, fmt.Sprintf("%d:%d", partition.ID, replica))
+		}
+		for _, replica := range replicas {
+			if !containsBrokerID(partition.Replicas, replica) {
+				followers = append(followers, fmt.Sprintf("%d:%d", partition.ID, replica))
+			}
+		}
+	}
+	return strings.Join(leaders, ","), strings.Join(followers, ",")
+}
+
+// setReplicationThrottle throttles the replication of the given replicas of the topic. Incremental config
+// changes are used so the rest of the dynamic topic and broker configurations are left intact.
+func (k *kafkaClient) setReplicationThrottle(topic string, leaderReplicas, followerReplicas string, rate int64) error {
+	err := k.admin.IncrementalAlterConfig(sarama.TopicResource, topic, map[string]sarama.IncrementalAlterConfigsEntry{
+		leaderReplicationThrottledReplicas:   {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &leaderReplicas},
+		followerReplicationThrottledReplicas: {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &followerReplicas},
+	}, false)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not set replication throttle of topic", "topic", topic)
+	}
+	rateValue := strconv.FormatInt(rate, 10)
+	for _, broker := range k.brokers {
+		err = k.admin.IncrementalAlterConfig(sarama.BrokerResource, strconv.Itoa(int(broker.ID())), map[string]sarama.IncrementalAlterConfigsEntry{
+			leaderReplicationThrottledRate:   {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &rateValue},
+			followerReplicationThrottledRate: {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &rateValue},
+		}, false)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not set replication throttle of broker", "brokerId", broker.ID())
+		}
+	}
+	return nil
+}
+
+// planReplicationFactorChange returns the replica assignment of every partition (indexed by the partition id)
+// with the desired number of replicas, and the number of partitions whose replicas have been changed.
+// Partitions which already have the desired number of replicas keep their current replicas.
+func planReplicationFactorChange(partitions []*sarama.PartitionMetadata, brokerRacks map[int32]string, desired int) ([][]int32, int) {
+	// the number of replicas hosted by each broker is used to spread the replicas of the topic evenly
+	load := make(map[int32]int, len(brokerRacks))
+	maxID := int32(-1)
+	for _, partition := range partitions {
+		for _, replica := range partition.Replicas {
+			load[replica]++
+		}
+		if partition.ID > maxID {
+			maxID = partition.ID
+		}
+	}
+
+	assignment := make([][]int32, maxID+1)
+	changed := 0
+	for _, partition := range partitions {
+		replicas := append([]int32{}, partition.Replicas...)
+		switch {
+		case len(replicas) < desired:
+			replicas = addReplicas(replicas, brokerRacks, load, desired)
+			changed++
+		case len(replicas) > desired:
+			replicas = removeReplicas(replicas, partition.Leader, brokerRacks, load, desired)
+			changed++
+		}
+		assignment[partition.ID] = replicas
+	}
+	return assignment, changed
+}
+
+// addReplicas extends the replicas with brokers from the least used racks, then with the least loaded brokers
+func addReplicas(replicas []int32, brokerRacks map[int32]string, load map[int32]int, desired int) []int32 {
+	usedRacks := make(map[string]int)
+	for _, replica := range replicas {
+		usedRacks[brokerRacks[replica]]++
+	}
+
+	candidates := make([]int32, 0, len(brokerRacks))
+	for id := range brokerRacks {
+		if !containsBrokerID(replicas, id) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	for len(replicas) < desired && len(candidates) > 0 {
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if usedRacks[brokerRacks[a]] != usedRacks[brokerRacks[b]] {
+				return usedRacks[brokerRacks[a]] < usedRacks[brokerRacks[b]]
+			}
+			if load[a] != load[b] {
+				return load[a] < load[b]
+			}
+			return a < b
+		})
+		picked := candidates[0]
+		candidates = candidates[1:]
+		replicas = append(replicas, picked)
+		usedRacks[brokerRacks[picked]]++
+		load[picked]++
+	}
+	return replicas
+}
+
+// removeReplicas removes replicas from the most crowded racks, then from the most loaded brokers.
+// The leader of the partition is never removed, nor is the preferred leader unless only a single replica is kept.
+func removeReplicas(replicas []int32, leader int32, brokerRacks map[int32]string, load map[int32]int, desired int) []int32 {
+	if !containsBrokerID(replicas, leader) {
+		leader = replicas[0]
+	}
+	usedRacks := make(map[string]int)
+	for _, replica := range replicas {
+		usedRacks[brokerRacks[replica]]++
+	}
+
+	for len(replicas) > desired {
+		removable := make([]int32, 0, len(replicas))
+		for i, replica := range replicas {
+			if replica == leader || (i == 0 && desired > 1) {
+				continue
+			}
+			removable = append(removable, replica)
+		}
+		if len(removable) == 0 {
+			break
+		}
+		sort.Slice(removable, func(i, j int) bool {
+			a, b := removable[i], removable[j]
+			if usedRacks[brokerRacks[a]] != usedRacks[brokerRacks[b]] {
+				return usedRacks[brokerRacks[a]] > usedRacks[brokerRacks[b]]
+			}
+			if load[a] != load[b] {
+				return load[a] > load[b]
+			}
+			return a > b
+		})
+		picked := removable[0]
+		remaining := make([]int32, 0, len(replicas)-1)
+		for _, replica := range replicas {
+			if replica != picked {
+				remaining = append(remaining, replica)
+			}
+		}
+		replicas = remaining
+		usedRacks[brokerRacks[picked]]--
+		load[picked]--
+	}
+	return replicas
+}
+
+// sameBrokerIDs returns true if the broker ids are the same in the same order, as the order of the replicas
+// determines the preferred leader of the partition
+func sameBrokerIDs(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsBrokerID(ids []int32, id int32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReplicationThrottleConfig returns true if the topic config is managed by the replication throttle
+func IsReplicationThrottleConfig(name string) bool {
+	return name == leaderReplicationThrottledReplicas || name == followerReplicationThrottledReplicas
+}
@@ -0,0 +1,257 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestPlanReplicationFactorChange(t *testing.T) {
+	brokerRacks := map[int32]string{
+		0: "rack-a",
+		1: "rack-a",
+		2: "rack-b",
+		3: "rack-b",
+		4: "rack-c",
+	}
+
+	testCases := []struct {
+		testName           string
+		partitions         []*sarama.PartitionMetadata
+		desired            int
+		expectedAssignment [][]int32
+		expectedChanged    int
+	}{
+		{
+			testName: "increase prefers brokers from racks without replicas",
+			partitions: []*sarama.PartitionMetadata{
+				{ID: 0, Leader: 0, Replicas: []int32{0, 1}},
+				{ID: 1, Leader: 2, Replicas: []int32{2, 3}},
+			},
+			desired: 3,
+			expectedAssignment: [][]int32{
+				// rack-b and rack-c are both unused, broker 4 has the lowest load
+				{0, 1, 4},
+				// rack-a and rack-c are both unused, broker 0, 1 and 4 host a single replica of the topic each
+				{2, 3, 0},
+			},
+			expectedChanged: 2,
+		},
+		{
+			testName: "increase spreads the replicas over the brokers of the least used racks",
+			partitions: []*sarama.PartitionMetadata{
+				{ID: 0, Leader: 0, Replicas: []int32{0}},
+				{ID: 1, Leader: 1, Replicas: []int32{1}},
+			},
+			desired: 3,
+			expectedAssignment: [][]int32{
+				{0, 2, 4},
+				{1, 3, 4},
+			},
+			expectedChanged: 2,
+		},
+		{
+			testName: "decrease removes replicas from the most crowded racks",
+			partitions: []*sarama.PartitionMetadata{
+				{ID: 0, Leader: 0, Replicas: []int32{0, 1, 2, 4}},
+				{ID: 1, Leader: 4, Replicas: []int32{4, 2, 3}},
+			},
+			desired: 3,
+			expectedAssignment: [][]int32{
+				{0, 2, 4},
+				{4, 2, 3},
+			},
+			expectedChanged: 1,
+		},
+		{
+			testName: "decrease keeps the current leader",
+			partitions: []*sarama.PartitionMetadata{
+				{ID: 0, Leader: 3, Replicas: []int32{0, 2, 3}},
+			},
+			desired: 1,
+			expectedAssignment: [][]int32{
+				{3},
+			},
+			expectedChanged: 1,
+		},
+		{
+			testName: "unchanged replication factor",
+			partitions: []*sarama.PartitionMetadata{
+				{ID: 0, Leader: 0, Replicas: []int32{0, 2}},
+				{ID: 1, Leader: 2, Replicas: []int32{2, 4}},
+			},
+			desired: 2,
+			expectedAssignment: [][]int32{
+				{0, 2},
+				{2, 4},
+			},
+			expectedChanged: 0,
+		},
+	}
+
+	for _, test := range testCases {
+		assignment, changed := planReplicationFactorChange(test.partitions, brokerRacks, test.desired)
+		if changed != test.expectedChanged {
+			t.Errorf("%s: expected %d changed partitions, got %d", test.testName, test.expectedChanged, changed)
+		}
+		if !reflect.DeepEqual(assignment, test.expectedAssignment) {
+			t.Errorf("%s: expected assignment %v, got %v", test.testName, test.expectedAssignment, assignment)
+		}
+	}
+}
+
+func TestEnsureReplicationFactor(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+
+	var submitted map[int32][]int32
+	client.alterPartitionReassignments = func(admin sarama.ClusterAdmin, topic string, assignment map[int32][]int32) error {
+		submitted = assignment
+		return mockAlterPartitionReassignments(admin, topic, assignment)
+	}
+
+	err := admin.CreateTopic("rf-topic", &sarama.TopicDetail{
+		NumPartitions:     3,
+		ReplicationFactor: 2,
+		ReplicaAssignment: map[int32][]int32{0: {0, 1}, 1: {0, 2}, 2: {0}},
+	}, false)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	err = admin.CreateTopic("stable-topic", &sarama.TopicDetail{
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ReplicaAssignment: map[int32][]int32{0: {0}},
+	}, false)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	// the mock cluster has a single broker
+	if _, err = client.EnsureReplicationFactor("stable-topic", 1, 1024); err != nil {
+		t.Error("Expected no error for unchanged replication factor, got:", err)
+	}
+	if submitted != nil {
+		t.Error("Expected no reassignment to be submitted for unchanged replication factor, got:", submitted)
+	}
+	if _, err = client.EnsureReplicationFactor("rf-topic", 3, 1024); err == nil {
+		t.Error("Expected error for replication factor larger than the number of brokers")
+	}
+
+	progress, err := client.EnsureReplicationFactor("rf-topic", 1, 1024)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if !progress.Submitted || progress.PendingPartitions != 2 {
+		t.Error("Expected reassignment of 2 partitions to be submitted, got:", progress)
+	}
+	// only the partitions whose replicas change are submitted
+	if expected := map[int32][]int32{0: {0}, 1: {0}}; !reflect.DeepEqual(submitted, expected) {
+		t.Errorf("Expected submitted assignment %v, got %v", expected, submitted)
+	}
+	expected := map[int32][]int32{0: {0}, 1: {0}, 2: {0}}
+	if assignment := admin.mockTopics["rf-topic"].ReplicaAssignment; !reflect.DeepEqual(assignment, expected) {
+		t.Errorf("Expected assignment %v, got %v", expected, assignment)
+	}
+	// removing replicas does not move any data, there is nothing to throttle
+	topicConfigs := admin.mockConfigs[mockConfigResource{resourceType: sarama.TopicResource, name: "rf-topic"}]
+	if len(topicConfigs) != 0 {
+		t.Error("Expected replication of the topic not to be throttled, got:", topicConfigs)
+	}
+
+	// ongoing reassignments are reported as pending
+	admin.mockReassignments["rf-topic"] = map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+		0: {Replicas: []int32{0, 1}, RemovingReplicas: []int32{1}},
+	}
+	progress, err = client.EnsureReplicationFactor("rf-topic", 1, 1024)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if progress.Submitted || progress.PendingPartitions != 1 {
+		t.Error("Expected a single pending partition, got:", progress)
+	}
+	delete(admin.mockReassignments, "rf-topic")
+
+	// the broker-wide rate is kept while the throttled reassignment of another topic is in progress
+	err = admin.CreateTopic("other-topic", &sarama.TopicDetail{
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ReplicaAssignment: map[int32][]int32{0: {0}},
+	}, false)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if err = client.setReplicationThrottle("rf-topic", "0:0", "0:1", 1024); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if err = client.setReplicationThrottle("other-topic", "0:0", "0:1", 1024); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	topicConfigs = admin.mockConfigs[mockConfigResource{resourceType: sarama.TopicResource, name: "rf-topic"}]
+	if topicConfigs[leaderReplicationThrottledReplicas] != "0:0" || topicConfigs[followerReplicationThrottledReplicas] != "0:1" {
+		t.Error("Expected the given replicas of the topic to be throttled, got:", topicConfigs)
+	}
+	brokerConfigs := admin.mockConfigs[mockConfigResource{resourceType: sarama.BrokerResource, name: "0"}]
+	if brokerConfigs[leaderReplicationThrottledRate] != "1024" || brokerConfigs[followerReplicationThrottledRate] != "1024" {
+		t.Error("Expected replication rate of the broker to be throttled, got:", brokerConfigs)
+	}
+	admin.mockReassignments["other-topic"] = map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+		0: {Replicas: []int32{0, 1}, AddingReplicas: []int32{1}},
+	}
+	if err = client.RemoveReplicationThrottle("rf-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if len(topicConfigs) != 0 || brokerConfigs[leaderReplicationThrottledRate] != "1024" || brokerConfigs[followerReplicationThrottledRate] != "1024" {
+		t.Error("Expected only the throttled replicas of the topic to be removed, got:", topicConfigs, brokerConfigs)
+	}
+	delete(admin.mockReassignments, "other-topic")
+
+	if err = client.RemoveReplicationThrottle("other-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	otherTopicConfigs := admin.mockConfigs[mockConfigResource{resourceType: sarama.TopicResource, name: "other-topic"}]
+	if len(otherTopicConfigs) != 0 || len(brokerConfigs) != 0 {
+		t.Error("Expected replication throttle to be removed, got:", otherTopicConfigs, brokerConfigs)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err = client.EnsureReplicationFactor("rf-topic", 1, 1024); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestThrottledReplicas(t *testing.T) {
+	partitions := []*sarama.PartitionMetadata{
+		{ID: 0, Replicas: []int32{0, 1}},
+		{ID: 1, Replicas: []int32{1, 2}},
+		{ID: 2, Replicas: []int32{2, 0}},
+	}
+	moving := map[int32][]int32{
+		0: {0, 1, 2},
+		2: {2},
+	}
+
+	leaders, followers := throttledReplicas(partitions, moving)
+	if expected := "0:0,0:1,2:2,2:0"; leaders != expected {
+		t.Errorf("Expected leader throttled replicas %s, got %s", expected, leaders)
+	}
+	if expected := "0:2"; followers != expected {
+		t.Errorf("Expected follower throttled replicas %s, got %s", expected, followers)
+	}
+}
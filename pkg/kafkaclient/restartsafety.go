@@ -0,0 +1,170 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+)
+
+// BlockingPartition is a partition which would have less in-sync replicas than its min.insync.replicas
+// while the brokers are restarted, so producers with acks=all would fail
+type BlockingPartition struct {
+	Topic     string
+	Partition int32
+	ISR       []int32
+	MinISR    int
+}
+
+func (p BlockingPartition) String() string {
+	return fmt.Sprintf("%s-%d (isr: %v, min.insync.replicas: %d)", p.Topic, p.Partition, p.ISR, p.MinISR)
+}
+
+// PartitionsBlockingRestart returns the partitions which would have less in-sync replicas than their effective
+// min.insync.replicas while the given brokers are restarted. The brokers can be restarted safely when it is empty.
+// Partitions whose replication factor is not larger than their min.insync.replicas can not tolerate the loss of any
+// replica, so they are blocking whenever one of their in-sync replicas is restarted.
+func (k *kafkaClient) PartitionsBlockingRestart(brokerIDs []int32) ([]BlockingPartition, error) {
+	restarted := make(map[int32]struct{}, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		restarted[brokerID] = struct{}{}
+	}
+
+	defaultMinISR, err := k.defaultMinInSyncReplicas()
+	if err != nil {
+		return nil, err
+	}
+
+	topics, metadata, err := k.describeAllTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocking []BlockingPartition
+	for _, meta := range metadata {
+		minISR := defaultMinISR
+		if value, ok := topics[meta.Name].ConfigEntries[minInSyncReplicasConfig]; ok && value != nil {
+			if minISR, err = strconv.Atoi(*value); err != nil {
+				return nil, errors.WrapIfWithDetails(err, "invalid min.insync.replicas", "topic", meta.Name)
+			}
+		}
+		for _, partition := range meta.Partitions {
+			remaining := 0
+			for _, brokerID := range partition.Isr {
+				if _, ok := restarted[brokerID]; !ok {
+					remaining++
+				}
+			}
+			// the partition is only affected when one of its in-sync replicas is restarted
+			if remaining < len(partition.Isr) && remaining < minISR {
+				blocking = append(blocking, BlockingPartition{
+					Topic:     meta.Name,
+					Partition: partition.ID,
+					ISR:       partition.Isr,
+					MinISR:    minISR,
+				})
+			}
+		}
+	}
+	sort.SliceStable(blocking, func(i, j int) bool {
+		if blocking[i].Topic != blocking[j].Topic {
+			return blocking[i].Topic < blocking[j].Topic
+		}
+		return blocking[i].Partition < blocking[j].Partition
+	})
+	return blocking, nil
+}
+
+// LeadersOn returns the partitions led by the given brokers whose leadership can be taken over by an in-sync
+// replica on another broker
+func (k *kafkaClient) LeadersOn(brokerIDs []int32) ([]string, error) {
+	brokers := make(map[int32]struct{}, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		brokers[brokerID] = struct{}{}
+	}
+
+	_, metadata, err := k.describeAllTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []string
+	for _, meta := range metadata {
+		for _, partition := range meta.Partitions {
+			if _, ok := brokers[partition.Leader]; !ok {
+				continue
+			}
+			for _, brokerID := range partition.Isr {
+				if _, ok := brokers[brokerID]; !ok {
+					partitions = append(partitions, fmt.Sprintf("%s-%d", meta.Name, partition.ID))
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(partitions)
+	return partitions, nil
+}
+
+// describeAllTopics returns the details and the metadata of every topic, the details contain the non-default
+// configs of the topics
+func (k *kafkaClient) describeAllTopics() (map[string]sarama.TopicDetail, []*sarama.TopicMetadata, error) {
+	topics, err := k.admin.ListTopics()
+	if err != nil {
+		return nil, nil, errors.WrapIf(err, "could not list topics")
+	}
+	if len(topics) == 0 {
+		return topics, nil, nil
+	}
+	topicNames := make([]string, 0, len(topics))
+	for name := range topics {
+		topicNames = append(topicNames, name)
+	}
+	sort.Strings(topicNames)
+	metadata, err := k.admin.DescribeTopics(topicNames)
+	if err != nil {
+		return nil, nil, errors.WrapIf(err, "could not describe topics")
+	}
+	return topics, metadata, nil
+}
+
+// defaultMinInSyncReplicas returns the min.insync.replicas of the brokers which applies to the topics without override
+func (k *kafkaClient) defaultMinInSyncReplicas() (int, error) {
+	if len(k.brokers) == 0 {
+		return defaultMinInSyncReplicas, nil
+	}
+	entries, err := k.admin.DescribeConfig(sarama.ConfigResource{
+		Type:        sarama.BrokerResource,
+		Name:        strconv.Itoa(int(k.brokers[0].ID())),
+		ConfigNames: []string{minInSyncReplicasConfig},
+	})
+	if err != nil {
+		return 0, errors.WrapIf(err, "could not describe broker config")
+	}
+	for _, entry := range entries {
+		if entry.Name == minInSyncReplicasConfig {
+			minISR, err := strconv.Atoi(entry.Value)
+			if err != nil {
+				return 0, errors.WrapIf(err, "invalid min.insync.replicas broker config")
+			}
+			return minISR, nil
+		}
+	}
+	return defaultMinInSyncReplicas, nil
+}
@@ -0,0 +1,139 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestPartitionsBlockingRestart(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+
+	two := "2"
+	topics := map[string]*sarama.TopicDetail{
+		// min.insync.replicas is inherited from the brokers
+		"default-topic": {
+			NumPartitions:     2,
+			ReplicationFactor: 3,
+			ReplicaAssignment: map[int32][]int32{0: {0, 1, 2}, 1: {1, 2, 3}},
+		},
+		"override-topic": {
+			NumPartitions:     2,
+			ReplicationFactor: 3,
+			ReplicaAssignment: map[int32][]int32{0: {0, 1, 2}, 1: {1, 2, 3}},
+			ConfigEntries:     map[string]*string{minInSyncReplicasConfig: &two},
+		},
+		// can not tolerate the loss of any replica
+		"strict-topic": {
+			NumPartitions:     1,
+			ReplicationFactor: 2,
+			ReplicaAssignment: map[int32][]int32{0: {0, 1}},
+			ConfigEntries:     map[string]*string{minInSyncReplicasConfig: &two},
+		},
+	}
+	for name, detail := range topics {
+		if err := admin.CreateTopic(name, detail, false); err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+	}
+	admin.mockISRs["override-topic"] = map[int32][]int32{0: {0, 1}}
+	admin.mockISRs["default-topic"] = map[int32][]int32{1: {1}}
+
+	testCases := []struct {
+		brokerIDs []int32
+		minISR    string
+		expected  []BlockingPartition
+	}{
+		{brokerIDs: []int32{2}},
+		{brokerIDs: []int32{3}},
+		{
+			brokerIDs: []int32{0},
+			expected: []BlockingPartition{
+				{Topic: "override-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+				{Topic: "strict-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+			},
+		},
+		{
+			brokerIDs: []int32{1},
+			expected: []BlockingPartition{
+				{Topic: "default-topic", Partition: 1, ISR: []int32{1}, MinISR: 1},
+				{Topic: "override-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+				{Topic: "strict-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+			},
+		},
+		{
+			brokerIDs: []int32{0, 2},
+			minISR:    "2",
+			expected: []BlockingPartition{
+				{Topic: "default-topic", Partition: 0, ISR: []int32{0, 1, 2}, MinISR: 2},
+				{Topic: "override-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+				{Topic: "strict-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		delete(admin.mockConfigs, mockConfigResource{resourceType: sarama.BrokerResource, name: "0"})
+		if testCase.minISR != "" {
+			admin.mockConfigs[mockConfigResource{resourceType: sarama.BrokerResource, name: "0"}] = map[string]string{
+				minInSyncReplicasConfig: testCase.minISR,
+			}
+		}
+		partitions, err := client.PartitionsBlockingRestart(testCase.brokerIDs)
+		if err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+		if !reflect.DeepEqual(partitions, testCase.expected) {
+			t.Errorf("Expected %v for brokers %v, got: %v", testCase.expected, testCase.brokerIDs, partitions)
+		}
+	}
+}
+
+func TestLeadersOn(t *testing.T) {
+	client := newOpenedMockClient()
+	admin := client.admin.(*mockClusterAdmin)
+
+	err := admin.CreateTopic("leader-topic", &sarama.TopicDetail{
+		NumPartitions:     3,
+		ReplicationFactor: 2,
+		ReplicaAssignment: map[int32][]int32{0: {0, 1}, 1: {1, 2}, 2: {0, 2}},
+	}, false)
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	// the leadership of partition 2 can not be moved as broker 2 is out of sync
+	admin.mockISRs["leader-topic"] = map[int32][]int32{2: {0}}
+
+	testCases := []struct {
+		brokerIDs []int32
+		expected  []string
+	}{
+		{brokerIDs: []int32{2}},
+		{brokerIDs: []int32{0}, expected: []string{"leader-topic-0"}},
+		{brokerIDs: []int32{0, 1}, expected: []string{"leader-topic-1"}},
+	}
+	for _, testCase := range testCases {
+		partitions, err := client.LeadersOn(testCase.brokerIDs)
+		if err != nil {
+			t.Error("Expected no error, got:", err)
+		}
+		if !reflect.DeepEqual(partitions, testCase.expected) {
+			t.Errorf("Expected %v for brokers %v, got: %v", testCase.expected, testCase.brokerIDs, partitions)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"crypto/rand"
+
+	"emperror.dev/errors"
+	"github.com/Shopify/sarama"
+)
+
+const (
+	// scramIterations is the iteration count of the SCRAM-SHA-512 credentials, 4096 is the minimum accepted by Kafka
+	scramIterations = 4096
+	scramSaltLength = 32
+
+	// errResourceNotFound is the RESOURCE_NOT_FOUND Kafka error code which is not defined by sarama
+	errResourceNotFound sarama.KError = 91
+)
+
+// EnsureUserScramCredentials creates or updates the SCRAM-SHA-512 credentials of the given user
+func (k *kafkaClient) EnsureUserScramCredentials(user string, password []byte) error {
+	salt := make([]byte, scramSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.WrapIf(err, "could not generate salt for SCRAM credentials")
+	}
+
+	results, err := k.admin.UpsertUserScramCredentials([]sarama.AlterUserScramCredentialsUpsert{
+		{
+			Name:       user,
+			Mechanism:  sarama.SCRAM_MECHANISM_SHA_512,
+			Iterations: scramIterations,
+			Salt:       salt,
+			Password:   password,
+		},
+	})
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not upsert SCRAM credentials", "user", user)
+	}
+	return scramResultsError(results, false)
+}
+
+// UserScramCredentialsExist returns true when the given user has SCRAM-SHA-512 credentials
+func (k *kafkaClient) UserScramCredentialsExist(user string) (bool, error) {
+	results, err := k.admin.DescribeUserScramCredentials([]string{user})
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not describe SCRAM credentials", "user", user)
+	}
+	for _, result := range results {
+		if result.ErrorCode == errResourceNotFound {
+			return false, nil
+		}
+		if result.ErrorCode != sarama.ErrNoError {
+			return false, errors.WrapIfWithDetails(result.ErrorCode, "could not describe SCRAM credentials", "user", user)
+		}
+		for _, info := range result.CredentialInfos {
+			if info.Mechanism == sarama.SCRAM_MECHANISM_SHA_512 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// DeleteUserScramCredentials removes the SCRAM-SHA-512 credentials of the given user
+func (k *kafkaClient) DeleteUserScramCredentials(user string) error {
+	results, err := k.admin.DeleteUserScramCredentials([]sarama.AlterUserScramCredentialsDelete{
+		{
+			Name:      user,
+			Mechanism: sarama.SCRAM_MECHANISM_SHA_512,
+		},
+	})
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not delete SCRAM credentials", "user", user)
+	}
+	// credentials which are already gone are not considered as an error
+	return scramResultsError(results, true)
+}
+
+func scramResultsError(results []*sarama.AlterUserScramCredentialsResult, ignoreNotFound bool) error {
+	for _, result := range results {
+		if result.ErrorCode == sarama.ErrNoError || (ignoreNotFound && result.ErrorCode == errResourceNotFound) {
+			continue
+		}
+		var msg string
+		if result.ErrorMessage != nil {
+			msg = *result.ErrorMessage
+		}
+		return errors.WrapIfWithDetails(result.ErrorCode, "could not alter SCRAM credentials", "user", result.User, "message", msg)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafkaclient
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestUserScramCredentials(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if exists, err := client.UserScramCredentialsExist("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	} else if exists {
+		t.Error("Expected credentials to not exist")
+	}
+
+	if err := client.EnsureUserScramCredentials("test-user", []byte("password")); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	if exists, err := client.UserScramCredentialsExist("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	} else if !exists {
+		t.Error("Expected credentials to exist")
+	}
+
+	if err := client.DeleteUserScramCredentials("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	// deleting credentials which are already gone is not an error
+	if err := client.DeleteUserScramCredentials("test-user"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if err := client.EnsureUserScramCredentials("test-user", []byte("password")); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if _, err := client.UserScramCredentialsExist("test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := client.DeleteUserScramCredentials("test-user"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -17,12 +17,20 @@ package kafkaclient
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/banzaicloud/koperator/api/v1alpha1"
 	"github.com/banzaicloud/koperator/pkg/errorfactory"
 )
 
+const (
+	minInSyncReplicasConfig  = "min.insync.replicas"
+	defaultMinInSyncReplicas = 1
+)
+
 // CreateTopicOptions holds info about topic configuration
 type CreateTopicOptions struct {
 	Name              string
@@ -132,7 +140,124 @@ func (k *kafkaClient) EnsurePartitionCount(topic string, desired int32) (changed
 	return
 }
 
-// EnsureTopicConfig is an idempotent call to ensure topic configuration overrides
+// EnsureTopicConfig is an idempotent call to ensure topic configuration overrides. The throttled replicas of an
+// ongoing reassignment are kept, as they would be removed otherwise.
 func (k *kafkaClient) EnsureTopicConfig(topic string, desiredConf map[string]*string) error {
-	return k.admin.AlterConfig(sarama.TopicResource, topic, desiredConf, false)
+	current, err := k.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return err
+	}
+	conf := make(map[string]*string, len(desiredConf))
+	for key, value := range desiredConf {
+		conf[key] = value
+	}
+	for _, entry := range current {
+		if _, ok := conf[entry.Name]; !ok && IsReplicationThrottleConfig(entry.Name) {
+			value := entry.Value
+			conf[entry.Name] = &value
+		}
+	}
+	return k.admin.AlterConfig(sarama.TopicResource, topic, conf, false)
+}
+
+// TopicMetaToStatus converts the topic metadata to the partition and replica health fields of a KafkaTopicStatus
+func (k *kafkaClient) TopicMetaToStatus(meta *sarama.TopicMetadata) *v1alpha1.KafkaTopicStatus {
+	status := &v1alpha1.KafkaTopicStatus{
+		Partitions:     make([]v1alpha1.TopicPartitionStatus, 0, len(meta.Partitions)),
+		PartitionCount: int32(len(meta.Partitions)),
+	}
+	for _, partition := range meta.Partitions {
+		status.Partitions = append(status.Partitions, v1alpha1.TopicPartitionStatus{
+			ID:              partition.ID,
+			Leader:          partition.Leader,
+			Replicas:        partition.Replicas,
+			ISR:             partition.Isr,
+			OfflineReplicas: partition.OfflineReplicas,
+		})
+		if int32(len(partition.Replicas)) > status.ReplicationFactor {
+			status.ReplicationFactor = int32(len(partition.Replicas))
+		}
+		if partition.Leader < 0 {
+			status.OfflinePartitions++
+		}
+		if len(partition.Isr) < len(partition.Replicas) {
+			status.UnderReplicatedPartitions++
+		}
+	}
+	sort.Slice(status.Partitions, func(i, j int) bool {
+		return status.Partitions[i].ID < status.Partitions[j].ID
+	})
+	return status
+}
+
+// DescribeTopicConfig returns the effective configuration of the topic including the broker defaults.
+// Sensitive configs are left out.
+func (k *kafkaClient) DescribeTopicConfig(topic string) (map[string]string, error) {
+	entries, err := k.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, fmt.Sprintf("could not describe config of topic %s", topic))
+	}
+	config := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.Sensitive {
+			continue
+		}
+		config[entry.Name] = entry.Value
+	}
+	return config, nil
+}
+
+// DescribeTopicConfigOverrides returns the configs set on the topic itself, the configs inherited from the
+// static or dynamic broker configs and the defaults are left out
+func (k *kafkaClient) DescribeTopicConfigOverrides(topic string) (map[string]string, error) {
+	entries, err := k.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, fmt.Sprintf("could not describe config of topic %s", topic))
+	}
+	config := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if !isTopicConfigOverride(entry) {
+			continue
+		}
+		config[entry.Name] = entry.Value
+	}
+	return config, nil
+}
+
+// isTopicConfigOverride tells whether the config entry is a DYNAMIC_TOPIC_CONFIG. The source of the entries is
+// not sent by the brokers for DescribeConfigs v0 requests, where only the defaults are flagged.
+func isTopicConfigOverride(entry sarama.ConfigEntry) bool {
+	if entry.Source == sarama.SourceUnknown {
+		return !entry.Default
+	}
+	return entry.Source == sarama.SourceTopic
+}
+
+// DescribeTopicStatus returns the observed state of the topic: the replicas of its partitions,
+// the number of unhealthy partitions and its effective configuration
+func (k *kafkaClient) DescribeTopicStatus(topic string) (*v1alpha1.KafkaTopicStatus, error) {
+	meta, err := k.DescribeTopic(topic)
+	if err != nil {
+		return nil, errorfactory.New(errorfactory.BrokersRequestError{}, err, fmt.Sprintf("could not describe topic %s", topic))
+	}
+	config, err := k.DescribeTopicConfig(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	status := k.TopicMetaToStatus(meta)
+	status.Config = config
+
+	minISR := defaultMinInSyncReplicas
+	if value, ok := config[minInSyncReplicasConfig]; ok {
+		if minISR, err = strconv.Atoi(value); err != nil {
+			return nil, errorfactory.New(errorfactory.InternalError{}, err, fmt.Sprintf("invalid %s config of topic %s", minInSyncReplicasConfig, topic))
+		}
+	}
+	for _, partition := range status.Partitions {
+		if len(partition.ISR) < minISR {
+			status.UnderMinISRPartitions++
+		}
+	}
+	return status, nil
 }
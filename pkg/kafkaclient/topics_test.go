@@ -15,6 +15,7 @@
 package kafkaclient
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/Shopify/sarama"
@@ -126,6 +127,23 @@ func TestEnsureTopicConfig(t *testing.T) {
 	if err := client.EnsureTopicConfig("test-topic", map[string]*string{}); err != nil {
 		t.Error("Expected no error, got:", err)
 	}
+
+	// the throttled replicas of an ongoing reassignment are kept
+	admin := client.admin.(*mockClusterAdmin)
+	resource := mockConfigResource{resourceType: sarama.TopicResource, name: "test-topic"}
+	admin.mockConfigs[resource] = map[string]string{
+		leaderReplicationThrottledReplicas: "0:0",
+		"retention.ms":                     "1000",
+	}
+	retention := "2000"
+	if err := client.EnsureTopicConfig("test-topic", map[string]*string{"retention.ms": &retention}); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := map[string]string{leaderReplicationThrottledReplicas: "0:0", "retention.ms": "2000"}
+	if configs := admin.mockConfigs[resource]; !reflect.DeepEqual(configs, expected) {
+		t.Errorf("Expected topic configs %v, got %v", expected, configs)
+	}
+
 	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
 	if err := client.EnsureTopicConfig("test-topic", map[string]*string{}); err == nil {
 		t.Error("Expected error, got nil")
@@ -151,3 +169,106 @@ func TestEnsurePartitionCount(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 }
+
+func TestTopicMetaToStatus(t *testing.T) {
+	client := newOpenedMockClient()
+
+	status := client.TopicMetaToStatus(&sarama.TopicMetadata{
+		Name: "test-topic",
+		Partitions: []*sarama.PartitionMetadata{
+			{ID: 1, Leader: -1, Replicas: []int32{1, 2}, Isr: []int32{}, OfflineReplicas: []int32{1, 2}},
+			{ID: 0, Leader: 0, Replicas: []int32{0, 1}, Isr: []int32{0}},
+		},
+	})
+	if status.PartitionCount != 2 || status.ReplicationFactor != 2 {
+		t.Error("Expected 2 partitions with replication factor 2, got:", status.PartitionCount, status.ReplicationFactor)
+	}
+	if status.OfflinePartitions != 1 || status.UnderReplicatedPartitions != 2 {
+		t.Error("Expected 1 offline and 2 under replicated partitions, got:", status.OfflinePartitions, status.UnderReplicatedPartitions)
+	}
+	if status.Partitions[0].ID != 0 || status.Partitions[1].ID != 1 {
+		t.Error("Expected partitions to be sorted by their id, got:", status.Partitions)
+	}
+}
+
+func TestDescribeTopicConfigOverrides(t *testing.T) {
+	client := newOpenedMockClient()
+	retention := "1000"
+	err := client.admin.CreateTopic("overrides-topic", &sarama.TopicDetail{
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ConfigEntries:     map[string]*string{"retention.ms": &retention},
+	}, false)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	admin := client.admin.(*mockClusterAdmin)
+	admin.mockTopicDefaults = []sarama.ConfigEntry{
+		{Name: "cleanup.policy", Value: "delete", Source: sarama.SourceDefault, Default: true},
+		{Name: "min.insync.replicas", Value: "2", Source: sarama.SourceStaticBroker},
+		{Name: "max.message.bytes", Value: "2048", Source: sarama.SourceDynamicDefaultBroker},
+	}
+
+	config, err := client.DescribeTopicConfigOverrides("overrides-topic")
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if expected := map[string]string{"retention.ms": "1000"}; !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expected topic config overrides %v, got %v", expected, config)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err = client.DescribeTopicConfigOverrides("overrides-topic"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestIsTopicConfigOverride(t *testing.T) {
+	testCases := []struct {
+		entry    sarama.ConfigEntry
+		expected bool
+	}{
+		{entry: sarama.ConfigEntry{Source: sarama.SourceTopic}, expected: true},
+		{entry: sarama.ConfigEntry{Source: sarama.SourceDynamicBroker}, expected: false},
+		{entry: sarama.ConfigEntry{Source: sarama.SourceStaticBroker}, expected: false},
+		{entry: sarama.ConfigEntry{Source: sarama.SourceDefault, Default: true}, expected: false},
+		// DescribeConfigs v0 responses
+		{entry: sarama.ConfigEntry{Source: sarama.SourceUnknown}, expected: true},
+		{entry: sarama.ConfigEntry{Source: sarama.SourceUnknown, Default: true}, expected: false},
+	}
+	for _, testCase := range testCases {
+		if actual := isTopicConfigOverride(testCase.entry); actual != testCase.expected {
+			t.Errorf("Expected %v for config entry %+v, got %v", testCase.expected, testCase.entry, actual)
+		}
+	}
+}
+
+func TestDescribeTopicStatus(t *testing.T) {
+	client := newOpenedMockClient()
+	minISR := "2"
+	err := client.admin.CreateTopic("status-topic", &sarama.TopicDetail{
+		NumPartitions:     2,
+		ReplicationFactor: 2,
+		ReplicaAssignment: map[int32][]int32{0: {0, 1}, 1: {1}},
+		ConfigEntries:     map[string]*string{"min.insync.replicas": &minISR},
+	}, false)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	status, err := client.DescribeTopicStatus("status-topic")
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if status.UnderMinISRPartitions != 1 {
+		t.Error("Expected 1 partition under min ISR, got:", status.UnderMinISRPartitions)
+	}
+	if status.Config["min.insync.replicas"] != "2" {
+		t.Error("Expected effective config of the topic, got:", status.Config)
+	}
+
+	client.admin, _ = newMockClusterAdminFailOps([]string{}, sarama.NewConfig())
+	if _, err = client.DescribeTopicStatus("status-topic"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
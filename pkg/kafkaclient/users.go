@@ -67,6 +67,33 @@ func (k *kafkaClient) ListUserACLs() ([]sarama.ResourceAcls, error) {
 	return acls, nil
 }
 
+// ListUserACLsForPrincipal returns the ACLs bound to the given user in the same raw
+// string representation which is used in the KafkaUser status
+func (k *kafkaClient) ListUserACLsForPrincipal(dn string) ([]string, error) {
+	principal := fmt.Sprintf("User:%s", dn)
+	resourceAcls, err := k.admin.ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Principal:                 &principal,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
+	})
+	if err != nil {
+		return nil, err
+	}
+	acls := make([]string, 0)
+	for _, resourceAcl := range resourceAcls {
+		for _, acl := range resourceAcl.Acls {
+			// the principal filter is applied on the broker side, however better safe than sorry
+			if acl == nil || acl.Principal != principal {
+				continue
+			}
+			acls = append(acls, aclToString(resourceAcl.Resource, *acl))
+		}
+	}
+	return acls, nil
+}
+
 // DeleteUserACLs removes all ACLs for a given user
 func (k *kafkaClient) DeleteUserACLs(dn string) (err error) {
 	matches, err := k.admin.DeleteACL(sarama.AclFilter{
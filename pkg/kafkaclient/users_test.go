@@ -15,6 +15,8 @@
 package kafkaclient
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/Shopify/sarama"
@@ -94,3 +96,30 @@ func TestDeleteUserACLs(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 }
+
+func TestListUserACLsForPrincipal(t *testing.T) {
+	client := newOpenedMockClient()
+
+	if err := client.CreateUserACLs(v1alpha1.KafkaAccessTypeRead, v1alpha1.KafkaPatternTypePrefixed, "CN=test-user", "test-"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := client.CreateUserACLs(v1alpha1.KafkaAccessTypeWrite, "", "CN=other-user", "test-topic"); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	acls, err := client.ListUserACLsForPrincipal("CN=test-user")
+	if err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	expected := []string{
+		"User:CN=test-user,Topic,PREFIXED,test-,Describe,Allow,*",
+		"User:CN=test-user,Topic,PREFIXED,test-,DescribeConfigs,Allow,*",
+		"User:CN=test-user,Topic,PREFIXED,test-,Read,Allow,*",
+		"User:CN=test-user,Group,LITERAL,*,Read,Allow,*",
+	}
+	sort.Strings(acls)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(acls, expected) {
+		t.Errorf("Expected %v, got %v", expected, acls)
+	}
+}
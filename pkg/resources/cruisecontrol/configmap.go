@@ -60,10 +60,23 @@ func (r *Reconciler) configMap(clientPass string, capacityConfig string, log log
 		log.Error(err, fmt.Sprintf("setting '%s' in Cruise Control configuration failed", kafkautils.KafkaConfigBoostrapServers), "config", bootstrapServers)
 	}
 
-	// Add Zookeeper configuration
-	zkConnect := zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath())
-	if err = ccConfig.Set(kafkautils.KafkaConfigZooKeeperConnect, zkConnect); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' in Cruise Control configuration failed", kafkautils.KafkaConfigZooKeeperConnect), "config", zkConnect)
+	// Add Zookeeper configuration, there is no ZooKeeper to connect to in KRaft mode
+	if !r.KafkaCluster.Spec.KRaftMode {
+		zkConnect := zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath())
+		if err = ccConfig.Set(kafkautils.KafkaConfigZooKeeperConnect, zkConnect); err != nil {
+			log.Error(err, fmt.Sprintf("setting '%s' in Cruise Control configuration failed", kafkautils.KafkaConfigZooKeeperConnect), "config", zkConnect)
+		}
+	} else {
+		// Cruise Control has to detect broker failures and read the topic configs through the Kafka Admin client
+		kraftConfig := map[string]string{
+			kafkautils.CruiseControlConfigBrokerFailureDetection:   "true",
+			kafkautils.CruiseControlConfigTopicConfigProviderClass: kafkautils.CruiseControlKafkaAdminTopicConfigProvider,
+		}
+		for k, v := range kraftConfig {
+			if err = ccConfig.Set(k, v); err != nil {
+				log.Error(err, fmt.Sprintf("setting '%s' in Cruise Control configuration failed", k), "config", v)
+			}
+		}
 	}
 
 	// Add SSL configuration
@@ -227,7 +240,7 @@ func appendGeneratedBrokerCapacities(kafkaCluster *v1beta1.KafkaCluster, log log
 		for _, broker := range kafkaCluster.Spec.Brokers {
 			if brokerId == strconv.Itoa(int(broker.Id)) {
 				brokerFoundInSpec = true
-				brokerDisks, err := generateBrokerDisks(broker, kafkaCluster.Spec, log)
+				brokerDisks, err := generateBrokerDisks(broker, kafkaCluster.Spec, kafkaCluster.Status.BrokersState[brokerId].GracefulActionState.VolumeStates, log)
 				if err != nil {
 					return nil, errors.WrapIfWithDetails(err, "could not generate broker disks config for broker", v1beta1.BrokerIdLabelKey, broker.Id)
 				}
@@ -312,7 +325,10 @@ func generateBrokerCPU(broker v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClus
 	return strconv.Itoa(int(brokerConfig.GetResources().Limits.Cpu().ScaledValue(-2)))
 }
 
-func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec, log logr.Logger) (map[string]string, error) {
+// generateBrokerDisks generates the capacity of the log dirs of the broker. The log dirs being drained have no capacity,
+// so Cruise Control moves their partition replicas to the other log dirs of the broker, while the drained ones are left out.
+func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.KafkaClusterSpec,
+	volumeStates map[string]v1beta1.VolumeState, log logr.Logger) (map[string]string, error) {
 	storageConfigs := make(map[string]v1beta1.StorageConfig)
 
 	// Get disks from the BrokerConfigGroup if it's in use
@@ -334,6 +350,15 @@ func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.Ka
 	// Generate log dir configuration
 	logDirs := make(map[string]string, len(storageConfigs))
 	for path, conf := range storageConfigs {
+		logDir := util.StorageConfigKafkaMountPath(path)
+		if conf.Drain {
+			if volumeStates[path].CruiseControlVolumeState == v1beta1.GracefulDiskDrained {
+				continue
+			}
+			logDirs[logDir] = "0"
+			continue
+		}
+
 		size := parseMountPathWithSize(conf)
 		log.V(1).Info(fmt.Sprintf("broker log.dir %s size in MB: %d", path, size), v1beta1.BrokerIdLabelKey, brokerState.Id)
 
@@ -342,7 +367,6 @@ func generateBrokerDisks(brokerState v1beta1.Broker, kafkaClusterSpec v1beta1.Ka
 				path, size, MinLogDirSizeInMB)
 		}
 
-		logDir := util.StorageConfigKafkaMountPath(path)
 		logDirs[logDir] = fmt.Sprintf("%d", size)
 	}
 
@@ -24,6 +24,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/resources"
+	kafkautils "github.com/banzaicloud/koperator/pkg/util/kafka"
+	properties "github.com/banzaicloud/koperator/properties/pkg"
 )
 
 //nolint:funlen
@@ -433,6 +436,98 @@ func TestGenerateCapacityConfig_JBOD(t *testing.T) {
 					]
                   }`,
 		},
+		{
+			testName: "generate no capacity for the log dirs being drained and leave out the drained ones",
+			kafkaCluster: v1beta1.KafkaCluster{
+				Spec: v1beta1.KafkaClusterSpec{
+					BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+						"default": {
+							StorageConfigs: []v1beta1.StorageConfig{
+								{
+									MountPath: "/path1",
+									PvcSpec: &v1.PersistentVolumeClaimSpec{
+										Resources: v1.ResourceRequirements{
+											Requests: v1.ResourceList{
+												v1.ResourceStorage: quantity,
+											},
+										},
+									},
+								},
+								{
+									MountPath: "/path2",
+									Drain:     true,
+									PvcSpec: &v1.PersistentVolumeClaimSpec{
+										Resources: v1.ResourceRequirements{
+											Requests: v1.ResourceList{
+												v1.ResourceStorage: quantity,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Brokers: []v1beta1.Broker{
+						{
+							Id:                0,
+							BrokerConfigGroup: "default",
+						},
+						{
+							Id:                1,
+							BrokerConfigGroup: "default",
+						},
+					},
+				},
+				Status: v1beta1.KafkaClusterStatus{
+					BrokersState: map[string]v1beta1.BrokerState{
+						"0": {
+							GracefulActionState: v1beta1.GracefulActionState{
+								VolumeStates: map[string]v1beta1.VolumeState{
+									"/path2": {CruiseControlVolumeState: v1beta1.GracefulDiskRebalanceRunning},
+								},
+							},
+						},
+						"1": {
+							GracefulActionState: v1beta1.GracefulActionState{
+								VolumeStates: map[string]v1beta1.VolumeState{
+									"/path2": {CruiseControlVolumeState: v1beta1.GracefulDiskDrained},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedConfiguration: `
+				  {
+					"brokerCapacities": [
+                      {
+					  "brokerId": "0",
+					  "capacity": {
+					   "DISK": {
+						"/path1/kafka": "10737",
+						"/path2/kafka": "0"
+					   },
+					   "CPU": "150",
+					   "NW_IN": "125000",
+					   "NW_OUT": "125000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 },
+                      {
+					  "brokerId": "1",
+					  "capacity": {
+					   "DISK": {
+						"/path1/kafka": "10737"
+					   },
+					   "CPU": "150",
+					   "NW_IN": "125000",
+					   "NW_OUT": "125000"
+					  },
+					  "doc": "Capacity unit used for disk is in MB, cpu is in percentage, network throughput is in KB."
+					 }
+					]
+                  }`,
+		},
 		{
 			testName: "generate correct capacity config when there is no broker config group on last broker",
 			kafkaCluster: v1beta1.KafkaCluster{
@@ -1017,3 +1112,62 @@ func TestGenerateCapacityConfigWithUserProvidedInput(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigMapKRaftMode(t *testing.T) {
+	testCases := []struct {
+		testName string
+		kraft    bool
+		expected map[string]string
+	}{
+		{
+			testName: "ZooKeeper mode",
+			kraft:    false,
+			expected: map[string]string{
+				kafkautils.KafkaConfigZooKeeperConnect: "zk:2181/kafka",
+			},
+		},
+		{
+			testName: "KRaft mode",
+			kraft:    true,
+			expected: map[string]string{
+				kafkautils.CruiseControlConfigBrokerFailureDetection:   "true",
+				kafkautils.CruiseControlConfigTopicConfigProviderClass: kafkautils.CruiseControlKafkaAdminTopicConfigProvider,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.testName, func(t *testing.T) {
+			r := Reconciler{
+				Reconciler: resources.Reconciler{
+					KafkaCluster: &v1beta1.KafkaCluster{
+						Spec: v1beta1.KafkaClusterSpec{
+							KRaftMode:   test.kraft,
+							ZKAddresses: []string{"zk:2181"},
+							ZKPath:      "/kafka",
+							CruiseControlConfig: v1beta1.CruiseControlConfig{
+								Config: "topic.config.provider.class=com.linkedin.kafka.cruisecontrol.config.KafkaTopicConfigProvider",
+							},
+						},
+					},
+				},
+			}
+			configMap := r.configMap("", "", logr.Discard()).(*v1.ConfigMap)
+			ccConfig, err := properties.NewFromString(configMap.Data["cruisecontrol.properties"])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for key, value := range test.expected {
+				property, found := ccConfig.Get(key)
+				if !found || property.Value() != value {
+					t.Errorf("expected %s=%s, got %v", key, value, property)
+				}
+			}
+			if _, found := ccConfig.Get(kafkautils.KafkaConfigZooKeeperConnect); found == test.kraft {
+				t.Errorf("unexpected presence of %s in KRaft mode: %v", kafkautils.KafkaConfigZooKeeperConnect, test.kraft)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+)
+
+// ReconcileBrokerReplicas adds and removes the brokers of the broker config groups with replicas in spec.brokers.
+// New brokers get broker IDs higher than any broker ID used by the cluster so far, and the removed brokers are
+// downscaled gracefully by the kafka reconciler. The cluster is not scaled while Cruise Control tasks are pending
+// or running for its brokers.
+func ReconcileBrokerReplicas(log logr.Logger, c client.Client, cluster *v1beta1.KafkaCluster) error {
+	lastBrokerID := LastBrokerID(cluster)
+	brokers := cluster.Spec.Brokers
+	if ids := GetBrokersWithPendingOrRunningCCTask(cluster); len(ids) > 0 {
+		log.V(1).Info("scaling the broker config groups is skipped as there are brokers with pending or running CC tasks", "brokers", ids)
+	} else {
+		brokers, lastBrokerID = scaleBrokerConfigGroups(&cluster.Spec, lastBrokerID)
+	}
+
+	// the allocated broker IDs are persisted before they are added so they are never reused
+	replicas, selector := scaleSubresourceStatus(cluster, brokers)
+	if cluster.Status.LastBrokerID == nil || *cluster.Status.LastBrokerID != lastBrokerID ||
+		cluster.Status.Replicas != replicas || cluster.Status.Selector != selector {
+		if err := k8sutil.UpdateBrokerReplicasStatus(c, cluster, lastBrokerID, replicas, selector, log); err != nil {
+			return errors.WrapIf(err, "could not update broker replicas status")
+		}
+	}
+
+	if len(brokers) == len(cluster.Spec.Brokers) && (len(brokers) == 0 || reflect.DeepEqual(brokers, cluster.Spec.Brokers)) {
+		return nil
+	}
+	log.Info("scaling the broker config groups", "brokers", brokerIDsOf(brokers), "previousBrokers", brokerIDsOf(cluster.Spec.Brokers))
+	cluster.Spec.Brokers = brokers
+	if err := k8sutil.UpdateCr(cluster, c); err != nil {
+		return errors.WrapIf(err, "could not update the brokers of the cluster")
+	}
+	return nil
+}
+
+// LastBrokerID returns the highest broker ID used by the cluster so far, or -1 when the cluster has no brokers yet
+func LastBrokerID(cluster *v1beta1.KafkaCluster) int32 {
+	lastBrokerID := int32(-1)
+	if cluster.Status.LastBrokerID != nil {
+		lastBrokerID = *cluster.Status.LastBrokerID
+	}
+	for _, broker := range cluster.Spec.Brokers {
+		if broker.Id > lastBrokerID {
+			lastBrokerID = broker.Id
+		}
+	}
+	// brokers which are being downscaled are only present in the status
+	for brokerID := range cluster.Status.BrokersState {
+		if id, err := strconv.ParseInt(brokerID, 10, 32); err == nil && int32(id) > lastBrokerID {
+			lastBrokerID = int32(id)
+		}
+	}
+	return lastBrokerID
+}
+
+// scaleBrokerConfigGroups returns the brokers of the cluster with the number of brokers of each broker config group
+// matching its replicas, together with the highest broker ID used so far
+func scaleBrokerConfigGroups(spec *v1beta1.KafkaClusterSpec, lastBrokerID int32) ([]v1beta1.Broker, int32) {
+	groupNames := make([]string, 0, len(spec.BrokerConfigGroups))
+	for groupName := range spec.BrokerConfigGroups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+
+	brokers := append([]v1beta1.Broker(nil), spec.Brokers...)
+	for _, groupName := range groupNames {
+		replicas, ok := spec.GetBrokerConfigGroupReplicas(groupName)
+		if !ok {
+			continue
+		}
+		var groupBrokerIDs []int32
+		for _, broker := range brokers {
+			if broker.BrokerConfigGroup == groupName {
+				groupBrokerIDs = append(groupBrokerIDs, broker.Id)
+			}
+		}
+
+		switch count := int32(len(groupBrokerIDs)); {
+		case count < replicas:
+			for i := count; i < replicas; i++ {
+				lastBrokerID++
+				brokers = append(brokers, v1beta1.Broker{Id: lastBrokerID, BrokerConfigGroup: groupName})
+			}
+		case count > replicas:
+			// the brokers with the highest IDs are removed
+			sort.Slice(groupBrokerIDs, func(i, j int) bool { return groupBrokerIDs[i] > groupBrokerIDs[j] })
+			removed := groupBrokerIDs[:count-replicas]
+			remaining := brokers[:0]
+			for _, broker := range brokers {
+				if broker.BrokerConfigGroup != groupName || !containsBrokerID(removed, broker.Id) {
+					remaining = append(remaining, broker)
+				}
+			}
+			brokers = remaining
+		}
+	}
+	return brokers, lastBrokerID
+}
+
+// scaleSubresourceStatus returns the number of brokers and the label selector of the broker pods of the broker
+// config group which is scaled through the /scale subresource
+func scaleSubresourceStatus(cluster *v1beta1.KafkaCluster, brokers []v1beta1.Broker) (int32, string) {
+	if _, ok := cluster.Spec.BrokerConfigGroups[cluster.Spec.ScaleBrokerConfigGroup]; !ok {
+		return 0, ""
+	}
+	var replicas int32
+	for _, broker := range brokers {
+		if broker.BrokerConfigGroup == cluster.Spec.ScaleBrokerConfigGroup {
+			replicas++
+		}
+	}
+	selector := labels.SelectorFromSet(apiutil.MergeLabels(
+		apiutil.LabelsForKafka(cluster.Name),
+		map[string]string{v1beta1.BrokerConfigGroupLabelKey: cluster.Spec.ScaleBrokerConfigGroup},
+	))
+	return replicas, selector.String()
+}
+
+func containsBrokerID(brokerIDs []int32, brokerID int32) bool {
+	for _, id := range brokerIDs {
+		if id == brokerID {
+			return true
+		}
+	}
+	return false
+}
+
+func brokerIDsOf(brokers []v1beta1.Broker) []int32 {
+	ids := make([]int32, 0, len(brokers))
+	for _, broker := range brokers {
+		ids = append(ids, broker.Id)
+	}
+	return ids
+}
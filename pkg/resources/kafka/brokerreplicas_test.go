@@ -0,0 +1,104 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+func TestLastBrokerID(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{}
+	assert.Equal(t, int32(-1), LastBrokerID(cluster))
+
+	cluster.Spec.Brokers = []v1beta1.Broker{{Id: 0}, {Id: 2}}
+	assert.Equal(t, int32(2), LastBrokerID(cluster))
+
+	cluster.Status.BrokersState = map[string]v1beta1.BrokerState{"0": {}, "2": {}, "5": {}}
+	assert.Equal(t, int32(5), LastBrokerID(cluster))
+
+	cluster.Status.LastBrokerID = util.Int32Pointer(7)
+	assert.Equal(t, int32(7), LastBrokerID(cluster))
+}
+
+func TestScaleBrokerConfigGroups(t *testing.T) {
+	spec := &v1beta1.KafkaClusterSpec{
+		BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+			"default": {Replicas: util.Int32Pointer(3)},
+			"fixed":   {},
+		},
+		Brokers: []v1beta1.Broker{
+			{Id: 0, BrokerConfigGroup: "default"},
+			{Id: 1, BrokerConfigGroup: "fixed"},
+		},
+	}
+
+	brokers, lastBrokerID := scaleBrokerConfigGroups(spec, 4)
+	assert.Equal(t, int32(6), lastBrokerID)
+	assert.Equal(t, []v1beta1.Broker{
+		{Id: 0, BrokerConfigGroup: "default"},
+		{Id: 1, BrokerConfigGroup: "fixed"},
+		{Id: 5, BrokerConfigGroup: "default"},
+		{Id: 6, BrokerConfigGroup: "default"},
+	}, brokers)
+
+	spec.Brokers = brokers
+	spec.BrokerConfigGroups["default"] = v1beta1.BrokerConfig{Replicas: util.Int32Pointer(1)}
+	brokers, lastBrokerID = scaleBrokerConfigGroups(spec, 6)
+	assert.Equal(t, int32(6), lastBrokerID)
+	assert.Equal(t, []v1beta1.Broker{
+		{Id: 0, BrokerConfigGroup: "default"},
+		{Id: 1, BrokerConfigGroup: "fixed"},
+	}, brokers)
+	// the brokers of the spec are left untouched
+	assert.Len(t, spec.Brokers, 4)
+
+	// the replicas of the spec take precedence for the scaled broker config group
+	spec.ScaleBrokerConfigGroup = "default"
+	spec.Replicas = util.Int32Pointer(2)
+	brokers, lastBrokerID = scaleBrokerConfigGroups(spec, 6)
+	assert.Equal(t, int32(6), lastBrokerID)
+	assert.Equal(t, []v1beta1.Broker{
+		{Id: 0, BrokerConfigGroup: "default"},
+		{Id: 1, BrokerConfigGroup: "fixed"},
+		{Id: 5, BrokerConfigGroup: "default"},
+	}, brokers)
+}
+
+func TestScaleSubresourceStatus(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+				"default": {BrokerLabels: map[string]string{"group": "default", "app": "custom"}},
+			},
+		},
+	}
+	brokers := []v1beta1.Broker{{Id: 0, BrokerConfigGroup: "default"}, {Id: 1}, {Id: 2, BrokerConfigGroup: "default"}}
+
+	replicas, selector := scaleSubresourceStatus(cluster, brokers)
+	assert.Equal(t, int32(0), replicas)
+	assert.Equal(t, "", selector)
+
+	cluster.Spec.ScaleBrokerConfigGroup = "default"
+	replicas, selector = scaleSubresourceStatus(cluster, brokers)
+	assert.Equal(t, int32(2), replicas)
+	assert.Equal(t, "app=kafka,brokerConfigGroup=default,kafka_cr=kafka", selector)
+}
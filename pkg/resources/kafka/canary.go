@@ -0,0 +1,267 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+const kafkaContainerName = "kafka"
+
+// reconcileCanaryUpgrade drives the canary upgrade of the broker images. It returns the images the brokers have to
+// keep by broker ID: the brokers which are not canaries keep their previous image until the canaries stayed healthy
+// for the soak period, and every upgraded broker gets its previous image back once the canary upgrade is rolled back.
+func (r *Reconciler) reconcileCanaryUpgrade(log logr.Logger, brokerPods []corev1.Pod) (map[int32]string, error) {
+	canary := r.KafkaCluster.Spec.RollingUpgradeConfig.Canary
+	if canary == nil {
+		return nil, nil
+	}
+
+	pods := make(map[string]*corev1.Pod, len(brokerPods))
+	currentImages := make(map[string]string, len(brokerPods))
+	for i := range brokerPods {
+		brokerID := brokerPods[i].Labels[v1beta1.BrokerIdLabelKey]
+		pods[brokerID] = &brokerPods[i]
+		if image := kafkaContainerImage(&brokerPods[i]); image != "" {
+			currentImages[brokerID] = image
+		}
+	}
+	desiredImages := make(map[string]string, len(r.KafkaCluster.Spec.Brokers))
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerConfig, err := broker.GetBrokerConfig(r.KafkaCluster.Spec)
+		if err != nil {
+			return nil, errors.WrapIf(err, "failed to reconcile resource")
+		}
+		desiredImages[strconv.Itoa(int(broker.Id))] = util.GetBrokerImage(brokerConfig, r.KafkaCluster.Spec.GetClusterImage())
+	}
+
+	status := r.KafkaCluster.Status.RollingUpgrade.Canary.DeepCopy()
+	if status == nil || !canaryTargetsDesired(status, desiredImages) {
+		status = newCanaryUpgradeStatus(canary, currentImages, desiredImages)
+		if status == nil {
+			// the brokers already run the desired images, a previous canary upgrade must not hold them back
+			if r.KafkaCluster.Status.RollingUpgrade.Canary != nil {
+				err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(rollingUpgradeStatus *v1beta1.RollingUpgradeStatus) {
+					rollingUpgradeStatus.Canary = nil
+				})
+				if err != nil {
+					return nil, errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update canary upgrade status")
+				}
+			}
+			return nil, nil
+		}
+		log.Info("canary upgrade started", "canaries", status.Brokers, "images", status.TargetImages)
+	}
+	if status.StartedAt == nil {
+		now := metav1.Now()
+		status.StartedAt = &now
+	}
+
+	switch status.State {
+	case v1beta1.CanaryUpgradeInProgress:
+		reason := ""
+		for _, brokerID := range status.Brokers {
+			pod, ok := pods[brokerID]
+			if !ok || kafkaContainerImage(pod) != status.TargetImages[brokerID] || !isPodReady(pod) {
+				reason = fmt.Sprintf("canary broker %s is not ready with the target image", brokerID)
+				break
+			}
+		}
+		if reason == "" {
+			var err error
+			if reason, err = r.canaryUnhealthyReason(status); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case reason == "":
+			now := metav1.Now()
+			status.State = v1beta1.CanaryUpgradeSoaking
+			status.SoakStartedAt = &now
+			status.Message = ""
+			log.Info("canary brokers upgraded, soak period started", "canaries", status.Brokers, "soakPeriod", canary.GetSoakPeriod())
+		case time.Since(status.StartedAt.Time) >= canary.GetReadinessTimeout():
+			status.State = v1beta1.CanaryUpgradeRolledBack
+			status.Message = fmt.Sprintf("canary brokers did not become healthy within %s: %s", canary.GetReadinessTimeout(), reason)
+			log.Info("canary brokers did not become healthy in time, rolling them back", "canaries", status.Brokers, "reason", reason)
+		default:
+			status.Message = reason
+		}
+	case v1beta1.CanaryUpgradeSoaking:
+		reason, err := r.canaryUnhealthyReason(status)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case reason != "":
+			status.State = v1beta1.CanaryUpgradeRolledBack
+			status.Message = reason
+			log.Info("health degraded during the soak period, rolling back the canary brokers", "canaries", status.Brokers, "reason", reason)
+		case time.Since(status.SoakStartedAt.Time) >= canary.GetSoakPeriod():
+			status.State = v1beta1.CanaryUpgradeSucceeded
+			status.Message = ""
+			log.Info("canary brokers stayed healthy, upgrading the other brokers", "canaries", status.Brokers)
+		}
+	}
+
+	if !reflect.DeepEqual(status, r.KafkaCluster.Status.RollingUpgrade.Canary) {
+		err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(rollingUpgradeStatus *v1beta1.RollingUpgradeStatus) {
+			rollingUpgradeStatus.Canary = status
+		})
+		if err != nil {
+			return nil, errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update canary upgrade status")
+		}
+	}
+
+	return canaryImageOverrides(status, currentImages), nil
+}
+
+// newCanaryUpgradeStatus returns the status of a new canary upgrade of the brokers whose image changed,
+// or nil when no image changed
+func newCanaryUpgradeStatus(canary *v1beta1.CanaryUpgradeConfig, currentImages, desiredImages map[string]string) *v1beta1.CanaryUpgradeStatus {
+	status := &v1beta1.CanaryUpgradeStatus{
+		State:          v1beta1.CanaryUpgradeInProgress,
+		PreviousImages: make(map[string]string),
+		TargetImages:   make(map[string]string),
+	}
+	for brokerID, currentImage := range currentImages {
+		if desiredImage, ok := desiredImages[brokerID]; ok && desiredImage != currentImage {
+			status.PreviousImages[brokerID] = currentImage
+			status.TargetImages[brokerID] = desiredImage
+		}
+	}
+	if len(status.TargetImages) == 0 {
+		return nil
+	}
+	for _, brokerID := range canary.BrokerIDs {
+		if id := strconv.Itoa(int(brokerID)); status.TargetImages[id] != "" {
+			status.Brokers = append(status.Brokers, id)
+		}
+	}
+	sort.Strings(status.Brokers)
+	if len(status.Brokers) == 0 {
+		status.State = v1beta1.CanaryUpgradeSucceeded
+		status.Message = "none of the canary brokers is affected by the upgrade"
+	}
+	return status
+}
+
+// canaryTargetsDesired returns true when the canary upgrade upgrades the brokers to the desired images
+func canaryTargetsDesired(status *v1beta1.CanaryUpgradeStatus, desiredImages map[string]string) bool {
+	for brokerID, targetImage := range status.TargetImages {
+		if desiredImage, ok := desiredImages[brokerID]; ok && desiredImage != targetImage {
+			return false
+		}
+	}
+	return true
+}
+
+// canaryImageOverrides returns the previous images of the brokers which must not be upgraded yet
+func canaryImageOverrides(status *v1beta1.CanaryUpgradeStatus, currentImages map[string]string) map[int32]string {
+	overrides := make(map[int32]string)
+	for brokerID, previousImage := range status.PreviousImages {
+		switch status.State {
+		case v1beta1.CanaryUpgradeSucceeded:
+			continue
+		case v1beta1.CanaryUpgradeInProgress, v1beta1.CanaryUpgradeSoaking:
+			if util.StringSliceContains(status.Brokers, brokerID) {
+				continue
+			}
+		}
+		// brokers without a pod get the desired image
+		if _, ok := currentImages[brokerID]; !ok {
+			continue
+		}
+		id, err := strconv.ParseInt(brokerID, 10, 32)
+		if err != nil {
+			continue
+		}
+		overrides[int32(id)] = previousImage
+	}
+	return overrides
+}
+
+// canaryUnhealthyReason returns why the cluster is not healthy with the upgraded canaries, or an empty string
+// when it is healthy: there are no offline replicas nor under-replicated partitions, and every canary reports
+// the Kafka version of its target image
+func (r *Reconciler) canaryUnhealthyReason(status *v1beta1.CanaryUpgradeStatus) (string, error) {
+	kClient, close, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		return "", errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+	}
+	defer close()
+
+	offlineReplicas, err := kClient.AllOfflineReplicas()
+	if err != nil {
+		return "", errors.WrapIf(err, "health check failed")
+	}
+	if len(offlineReplicas) > 0 {
+		return fmt.Sprintf("brokers %v have offline replicas", offlineReplicas), nil
+	}
+	outOfSyncReplicas, err := kClient.OutOfSyncReplicas()
+	if err != nil {
+		return "", errors.WrapIf(err, "health check failed")
+	}
+	if len(outOfSyncReplicas) > 0 {
+		return fmt.Sprintf("brokers %v have under-replicated partitions", outOfSyncReplicas), nil
+	}
+
+	return canaryVersionMismatch(status, r.KafkaCluster.Status.BrokersState, r.KafkaCluster.Spec.KafkaVersion), nil
+}
+
+// canaryVersionMismatch returns why the canaries do not run the target Kafka version, or an empty string when they
+// do: every canary reports its Kafka version with its target image, the same version as the other canaries, and the
+// desired Kafka version of the cluster when it is set
+func canaryVersionMismatch(status *v1beta1.CanaryUpgradeStatus, brokersState map[string]v1beta1.BrokerState, kafkaVersion string) string {
+	version := ""
+	for _, brokerID := range status.Brokers {
+		brokerState := brokersState[brokerID]
+		switch {
+		case brokerState.Version == "" || brokerState.Image != status.TargetImages[brokerID]:
+			return fmt.Sprintf("broker %s does not report its Kafka version with the target image", brokerID)
+		case kafkaVersion != "" && brokerState.Version != kafkaVersion:
+			return fmt.Sprintf("broker %s reports Kafka version %s instead of %s", brokerID, brokerState.Version, kafkaVersion)
+		case version == "":
+			version = brokerState.Version
+		case version != brokerState.Version:
+			return fmt.Sprintf("canary brokers report different Kafka versions: %s and %s", version, brokerState.Version)
+		}
+	}
+	return ""
+}
+
+// kafkaContainerImage returns the image of the kafka container of the broker pod
+func kafkaContainerImage(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == kafkaContainerName {
+			return container.Image
+		}
+	}
+	return ""
+}
@@ -0,0 +1,265 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/resources"
+)
+
+func TestNewCanaryUpgradeStatus(t *testing.T) {
+	canary := &v1beta1.CanaryUpgradeConfig{BrokerIDs: []int32{2, 0}}
+	currentImages := map[string]string{"0": "kafka:1", "1": "kafka:1", "2": "kafka:1"}
+
+	assert.Nil(t, newCanaryUpgradeStatus(canary, currentImages, currentImages))
+
+	status := newCanaryUpgradeStatus(canary, currentImages, map[string]string{"0": "kafka:2", "1": "kafka:2", "2": "kafka:1"})
+	assert.Equal(t, v1beta1.CanaryUpgradeInProgress, status.State)
+	assert.Equal(t, []string{"0"}, status.Brokers)
+	assert.Equal(t, map[string]string{"0": "kafka:1", "1": "kafka:1"}, status.PreviousImages)
+	assert.Equal(t, map[string]string{"0": "kafka:2", "1": "kafka:2"}, status.TargetImages)
+
+	status = newCanaryUpgradeStatus(canary, currentImages, map[string]string{"0": "kafka:1", "1": "kafka:2", "2": "kafka:1"})
+	assert.Equal(t, v1beta1.CanaryUpgradeSucceeded, status.State)
+	assert.Empty(t, status.Brokers)
+}
+
+func TestCanaryVersionMismatch(t *testing.T) {
+	status := &v1beta1.CanaryUpgradeStatus{
+		Brokers:      []string{"0", "2"},
+		TargetImages: map[string]string{"0": "kafka:3.4.0", "1": "kafka:3.4.0", "2": "kafka:3.4.0"},
+	}
+
+	brokersState := map[string]v1beta1.BrokerState{
+		"0": {Version: "3.4.0", Image: "kafka:3.4.0"},
+		"2": {Version: "3.4.0", Image: "kafka:3.4.0"},
+	}
+	assert.Empty(t, canaryVersionMismatch(status, brokersState, ""))
+	assert.Empty(t, canaryVersionMismatch(status, brokersState, "3.4.0"))
+	assert.Contains(t, canaryVersionMismatch(status, brokersState, "3.5.0"), "reports Kafka version 3.4.0 instead of 3.5.0")
+
+	// the version reported with the previous image does not count
+	brokersState["2"] = v1beta1.BrokerState{Version: "3.3.2", Image: "kafka:3.3.2"}
+	assert.Contains(t, canaryVersionMismatch(status, brokersState, ""), "broker 2 does not report its Kafka version with the target image")
+
+	brokersState["2"] = v1beta1.BrokerState{Version: "3.4.1", Image: "kafka:3.4.0"}
+	assert.Contains(t, canaryVersionMismatch(status, brokersState, ""), "different Kafka versions")
+
+	// a single canary is checked against its target image too
+	status.Brokers = []string{"0"}
+	brokersState["0"] = v1beta1.BrokerState{Version: "3.3.2", Image: "kafka:3.3.2"}
+	assert.NotEmpty(t, canaryVersionMismatch(status, brokersState, ""))
+}
+
+func TestCanaryTargetsDesired(t *testing.T) {
+	status := &v1beta1.CanaryUpgradeStatus{TargetImages: map[string]string{"0": "kafka:2", "1": "kafka:2"}}
+
+	assert.True(t, canaryTargetsDesired(status, map[string]string{"0": "kafka:2", "1": "kafka:2", "2": "kafka:2"}))
+	// removed brokers don't change the targets
+	assert.True(t, canaryTargetsDesired(status, map[string]string{"0": "kafka:2"}))
+	assert.False(t, canaryTargetsDesired(status, map[string]string{"0": "kafka:2", "1": "kafka:3"}))
+}
+
+func TestCanaryImageOverrides(t *testing.T) {
+	status := &v1beta1.CanaryUpgradeStatus{
+		State:          v1beta1.CanaryUpgradeInProgress,
+		Brokers:        []string{"0"},
+		PreviousImages: map[string]string{"0": "kafka:1", "1": "kafka:1", "2": "kafka:1"},
+		TargetImages:   map[string]string{"0": "kafka:2", "1": "kafka:2", "2": "kafka:2"},
+	}
+	currentImages := map[string]string{"0": "kafka:2", "1": "kafka:1"}
+
+	assert.Equal(t, map[int32]string{1: "kafka:1"}, canaryImageOverrides(status, currentImages))
+
+	status.State = v1beta1.CanaryUpgradeSoaking
+	assert.Equal(t, map[int32]string{1: "kafka:1"}, canaryImageOverrides(status, currentImages))
+
+	status.State = v1beta1.CanaryUpgradeRolledBack
+	assert.Equal(t, map[int32]string{0: "kafka:1", 1: "kafka:1"}, canaryImageOverrides(status, currentImages))
+
+	status.State = v1beta1.CanaryUpgradeSucceeded
+	assert.Empty(t, canaryImageOverrides(status, currentImages))
+}
+
+func TestKafkaContainerImage(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "sidecar", Image: "sidecar:1"},
+				{Name: kafkaContainerName, Image: "kafka:1"},
+			},
+		},
+	}
+	assert.Equal(t, "kafka:1", kafkaContainerImage(pod))
+
+	pod.Spec.Containers = pod.Spec.Containers[:1]
+	assert.Equal(t, "", kafkaContainerImage(pod))
+}
+
+func newCanaryReconciler(kClient *kafkaClientStub) *Reconciler {
+	scheme := runtime.NewScheme()
+	_ = v1beta1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			ClusterImage: "kafka:2",
+			RollingUpgradeConfig: v1beta1.RollingUpgradeConfig{
+				FailureThreshold: 1,
+				Canary:           &v1beta1.CanaryUpgradeConfig{BrokerIDs: []int32{0}},
+			},
+			Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{}}, {Id: 1, BrokerConfig: &v1beta1.BrokerConfig{}}},
+		},
+	}
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+			KafkaCluster: cluster,
+		},
+		kafkaClientProvider: kafkaClientProviderStub{client: kClient},
+	}
+}
+
+// canaryBrokerPods returns the pods of the brokers running the given images, the pods are ready
+func canaryBrokerPods(images ...string) []corev1.Pod {
+	pods := make([]corev1.Pod, 0, len(images))
+	for brokerID, image := range images {
+		pod := newReadyBrokerPod(strconv.Itoa(brokerID))
+		pod.Spec.Containers[0].Image = image
+		pods = append(pods, *pod)
+	}
+	return pods
+}
+
+func TestReconcileCanaryUpgrade(t *testing.T) {
+	kClient := &kafkaClientStub{}
+	r := newCanaryReconciler(kClient)
+	canaryStatus := func() *v1beta1.CanaryUpgradeStatus {
+		return r.KafkaCluster.Status.RollingUpgrade.Canary
+	}
+
+	// InProgress: only the canary gets the new image
+	overrides, err := r.reconcileCanaryUpgrade(logr.Discard(), canaryBrokerPods("kafka:1", "kafka:1"))
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]string{1: "kafka:1"}, overrides)
+	require.NotNil(t, canaryStatus())
+	assert.Equal(t, v1beta1.CanaryUpgradeInProgress, canaryStatus().State)
+	assert.Equal(t, []string{"0"}, canaryStatus().Brokers)
+	assert.Contains(t, canaryStatus().Message, "canary broker 0 is not ready with the target image")
+
+	// the canary runs the new image but does not report its Kafka version yet
+	pods := canaryBrokerPods("kafka:2", "kafka:1")
+	_, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.CanaryUpgradeInProgress, canaryStatus().State)
+
+	// InProgress -> Soaking
+	r.KafkaCluster.Status.BrokersState = map[string]v1beta1.BrokerState{"0": {Version: "3.4.0", Image: "kafka:2"}}
+	overrides, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]string{1: "kafka:1"}, overrides)
+	assert.Equal(t, v1beta1.CanaryUpgradeSoaking, canaryStatus().State)
+	require.NotNil(t, canaryStatus().SoakStartedAt)
+
+	// the soak period is not over yet
+	_, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.CanaryUpgradeSoaking, canaryStatus().State)
+
+	// Soaking -> Succeeded: the other brokers are upgraded
+	soakStartedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	canaryStatus().SoakStartedAt = &soakStartedAt
+	overrides, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+	assert.Equal(t, v1beta1.CanaryUpgradeSucceeded, canaryStatus().State)
+}
+
+func TestReconcileCanaryUpgradeRollback(t *testing.T) {
+	kClient := &kafkaClientStub{}
+	r := newCanaryReconciler(kClient)
+	canaryStatus := func() *v1beta1.CanaryUpgradeStatus {
+		return r.KafkaCluster.Status.RollingUpgrade.Canary
+	}
+	_, err := r.reconcileCanaryUpgrade(logr.Discard(), canaryBrokerPods("kafka:1", "kafka:1"))
+	require.NoError(t, err)
+	pods := canaryBrokerPods("kafka:2", "kafka:1")
+	r.KafkaCluster.Status.BrokersState = map[string]v1beta1.BrokerState{"0": {Version: "3.4.0", Image: "kafka:2"}}
+
+	// unhealthy canaries are not soaked
+	kClient.outOfSyncReplicas = []int32{0}
+	_, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.CanaryUpgradeInProgress, canaryStatus().State)
+	assert.Contains(t, canaryStatus().Message, "under-replicated partitions")
+
+	kClient.outOfSyncReplicas = nil
+	_, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.CanaryUpgradeSoaking, canaryStatus().State)
+
+	// Soaking -> RolledBack: every upgraded broker gets its previous image back
+	kClient.offlineReplicas = []int32{0}
+	overrides, err := r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]string{0: "kafka:1", 1: "kafka:1"}, overrides)
+	assert.Equal(t, v1beta1.CanaryUpgradeRolledBack, canaryStatus().State)
+	assert.Contains(t, canaryStatus().Message, "offline replicas")
+
+	// the rolled back canary upgrade stays rolled back until the desired image changes
+	kClient.offlineReplicas = nil
+	overrides, err = r.reconcileCanaryUpgrade(logr.Discard(), canaryBrokerPods("kafka:1", "kafka:1"))
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]string{0: "kafka:1", 1: "kafka:1"}, overrides)
+	assert.Equal(t, v1beta1.CanaryUpgradeRolledBack, canaryStatus().State)
+}
+
+func TestReconcileCanaryUpgradeReadinessTimeout(t *testing.T) {
+	r := newCanaryReconciler(&kafkaClientStub{})
+	canaryStatus := func() *v1beta1.CanaryUpgradeStatus {
+		return r.KafkaCluster.Status.RollingUpgrade.Canary
+	}
+
+	_, err := r.reconcileCanaryUpgrade(logr.Discard(), canaryBrokerPods("kafka:1", "kafka:1"))
+	require.NoError(t, err)
+
+	// the canary does not become ready with the new image
+	pods := canaryBrokerPods("kafka:2", "kafka:1")
+	pods[0].Status.Conditions = nil
+	_, err = r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.CanaryUpgradeInProgress, canaryStatus().State)
+
+	// InProgress -> RolledBack once the readiness timeout is exceeded
+	startedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	canaryStatus().StartedAt = &startedAt
+	overrides, err := r.reconcileCanaryUpgrade(logr.Discard(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]string{0: "kafka:1", 1: "kafka:1"}, overrides)
+	assert.Equal(t, v1beta1.CanaryUpgradeRolledBack, canaryStatus().State)
+	assert.Contains(t, canaryStatus().Message, "did not become healthy within 15m0s")
+}
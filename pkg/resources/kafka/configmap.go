@@ -47,74 +47,65 @@ func (r *Reconciler) getConfigProperties(bConfig *v1beta1.BrokerConfig, id int32
 	listenerConf := generateListenerSpecificConfig(&r.KafkaCluster.Spec.ListenersConfig, serverPasses, log)
 	config.Merge(listenerConf)
 
-	// Add listener configuration
-	advertisedListenerConf := generateAdvertisedListenerConfig(id, r.KafkaCluster.Spec.ListenersConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses)
-	if len(advertisedListenerConf) > 0 {
-		if err := config.Set(kafkautils.KafkaConfigAdvertisedListeners, advertisedListenerConf); err != nil {
-			log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigAdvertisedListeners))
+	if r.KafkaCluster.Spec.KRaftMode {
+		// Add KRaft configuration
+		config.Merge(generateKRaftConfig(bConfig, id, r.KafkaCluster, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses, log))
+	} else {
+		// Add listener configuration
+		advertisedListenerConf := generateAdvertisedListenerConfig(id, r.KafkaCluster.Spec.ListenersConfig, extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses)
+		if len(advertisedListenerConf) > 0 {
+			if err := config.Set(kafkautils.KafkaConfigAdvertisedListeners, advertisedListenerConf); err != nil {
+				log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigAdvertisedListeners))
+			}
 		}
-	}
 
-	// Add control plane listener
-	cclConf := generateControlPlaneListener(r.KafkaCluster.Spec.ListenersConfig.InternalListeners)
-	if cclConf != "" {
-		if err := config.Set(kafkautils.KafkaConfigControlPlaneListener, cclConf); err != nil {
-			log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigControlPlaneListener))
+		// Add control plane listener
+		cclConf := generateControlPlaneListener(r.KafkaCluster.Spec.ListenersConfig.InternalListeners)
+		if cclConf != "" {
+			if err := config.Set(kafkautils.KafkaConfigControlPlaneListener, cclConf); err != nil {
+				log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigControlPlaneListener))
+			}
 		}
-	}
-
-	// Add Zookeeper configuration
-	if err := config.Set(kafkautils.KafkaConfigZooKeeperConnect, zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath())); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigZooKeeperConnect))
-	}
 
-	// Add Cruise Control Metrics Reporter SSL configuration
-	if util.IsSSLEnabledForInternalCommunication(r.KafkaCluster.Spec.ListenersConfig.InternalListeners) {
-		if !r.KafkaCluster.Spec.IsClientSSLSecretPresent() {
-			log.Error(errors.New("cruise control metrics reporter needs ssl but client certificate hasn't specified"), "")
+		// Add Zookeeper configuration
+		if err := config.Set(kafkautils.KafkaConfigZooKeeperConnect, zookeeperutils.PrepareConnectionAddress(r.KafkaCluster.Spec.ZKAddresses, r.KafkaCluster.Spec.GetZkPath())); err != nil {
+			log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigZooKeeperConnect))
 		}
-		keyStoreLoc := clientKeystorePath + "/" + v1alpha1.TLSJKSKeyStore
-		trustStoreLoc := clientKeystorePath + "/" + v1alpha1.TLSJKSTrustStore
 
-		sslConfig := map[string]string{
-			kafkautils.KafkaConfigSecurityProtocol:      "SSL",
-			kafkautils.KafkaConfigSSLTrustStoreLocation: trustStoreLoc,
-			kafkautils.KafkaConfigSSLKeyStoreLocation:   keyStoreLoc,
-			kafkautils.KafkaConfigSSLKeyStorePassword:   clientPass,
-			kafkautils.KafkaConfigSSLTrustStorePassword: clientPass,
+		// Kafka Broker configuration
+		if err := config.Set(kafkautils.KafkaConfigBrokerId, id); err != nil {
+			log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigBrokerId))
 		}
 
-		for k, v := range sslConfig {
-			if err := config.Set(fmt.Sprintf("cruise.control.metrics.reporter.%s", k), v); err != nil {
-				log.Error(err, fmt.Sprintf("setting cruise.control.metrics.reporter.%s parameter in broker configuration resulted in an error", k))
+		// Add the protocol version managed by the Kafka version upgrade. When spec.kafkaVersion is removed the brokers
+		// keep the protocol version of the last upgrade until it is set in the readOnlyConfig.
+		if versionUpgrade := r.KafkaCluster.Status.KafkaVersionUpgrade; versionUpgrade != nil {
+			readOnlyConfig := properties.NewProperties()
+			if r.KafkaCluster.Spec.KafkaVersion == "" {
+				readOnlyConfig = getBrokerReadOnlyConfig(id, r.KafkaCluster, log)
+			}
+			if _, ok := readOnlyConfig.Get(kafkautils.KafkaConfigInterBrokerProtocolVersion); !ok {
+				if err := config.Set(kafkautils.KafkaConfigInterBrokerProtocolVersion, versionUpgrade.ProtocolVersion); err != nil {
+					log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigInterBrokerProtocolVersion))
+				}
+			}
+			if _, ok := readOnlyConfig.Get(kafkautils.KafkaConfigLogMessageFormatVersion); !ok && versionUpgrade.MessageFormatVersion != "" {
+				if err := config.Set(kafkautils.KafkaConfigLogMessageFormatVersion, versionUpgrade.MessageFormatVersion); err != nil {
+					log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigLogMessageFormatVersion))
+				}
 			}
 		}
 	}
 
-	// Add Cruise Control Metrics Reporter configuration
-	if err := config.Set(kafkautils.CruiseControlConfigMetricsReporters, "com.linkedin.kafka.cruisecontrol.metricsreporter.CruiseControlMetricsReporter"); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReporters))
-	}
-	bootstrapServers, err := kafkautils.GetBootstrapServersService(r.KafkaCluster)
-	if err != nil {
-		log.Error(err, "getting Kafka bootstrap servers for Cruise Control failed")
-	}
-	if err := config.Set(kafkautils.CruiseControlConfigMetricsReportersBootstrapServers, bootstrapServers); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReportersBootstrapServers))
-	}
-	if err := config.Set(kafkautils.CruiseControlConfigMetricsReporterK8sMode, true); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReporterK8sMode))
-	}
-
-	// Kafka Broker configuration
-	if err := config.Set(kafkautils.KafkaConfigBrokerId, id); err != nil {
-		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigBrokerId))
+	// Controller only nodes don't serve client requests so the Cruise Control Metrics Reporter is not needed there
+	if bConfig.IsBrokerNode() {
+		r.addCruiseControlMetricsReporterConfig(config, clientPass, log)
 	}
 
 	// This logic prevents the removal of the mountPath from the broker configmap
 	brokerConfigMapName := fmt.Sprintf(brokerConfigTemplate+"-%d", r.KafkaCluster.Name, id)
 	var brokerConfigMapOld v1.ConfigMap
-	err = r.Client.Get(context.Background(), client.ObjectKey{Name: brokerConfigMapName, Namespace: r.KafkaCluster.GetNamespace()}, &brokerConfigMapOld)
+	err := r.Client.Get(context.Background(), client.ObjectKey{Name: brokerConfigMapName, Namespace: r.KafkaCluster.GetNamespace()}, &brokerConfigMapOld)
 	if err != nil && !apierrors.IsNotFound(err) {
 		log.Error(err, "getting broker configmap from the Kubernetes API server resulted an error")
 	}
@@ -123,6 +114,10 @@ func (r *Reconciler) getConfigProperties(bConfig *v1beta1.BrokerConfig, id int32
 	if err != nil {
 		log.Error(err, "could not get mountPaths from broker configmap", v1beta1.BrokerIdLabelKey, id)
 	}
+	// the log dirs of the drained storages are the only ones which can be removed
+	for _, mountPath := range drainedMountPaths(r.KafkaCluster, id) {
+		mountPathsOld = util.StringSliceRemove(mountPathsOld, util.StorageConfigKafkaMountPath(mountPath))
+	}
 	mountPathsNew := generateStorageConfig(bConfig.StorageConfigs)
 	mountPathsMerged, isMountPathRemoved := mergeMountPaths(mountPathsOld, mountPathsNew)
 
@@ -146,6 +141,46 @@ func (r *Reconciler) getConfigProperties(bConfig *v1beta1.BrokerConfig, id int32
 	return config
 }
 
+func (r *Reconciler) addCruiseControlMetricsReporterConfig(config *properties.Properties, clientPass string, log logr.Logger) {
+	// Add Cruise Control Metrics Reporter SSL configuration
+	if util.IsSSLEnabledForInternalCommunication(r.KafkaCluster.Spec.ListenersConfig.InternalListeners) {
+		if !r.KafkaCluster.Spec.IsClientSSLSecretPresent() {
+			log.Error(errors.New("cruise control metrics reporter needs ssl but client certificate hasn't specified"), "")
+		}
+		keyStoreLoc := clientKeystorePath + "/" + v1alpha1.TLSJKSKeyStore
+		trustStoreLoc := clientKeystorePath + "/" + v1alpha1.TLSJKSTrustStore
+
+		sslConfig := map[string]string{
+			kafkautils.KafkaConfigSecurityProtocol:      "SSL",
+			kafkautils.KafkaConfigSSLTrustStoreLocation: trustStoreLoc,
+			kafkautils.KafkaConfigSSLKeyStoreLocation:   keyStoreLoc,
+			kafkautils.KafkaConfigSSLKeyStorePassword:   clientPass,
+			kafkautils.KafkaConfigSSLTrustStorePassword: clientPass,
+		}
+
+		for k, v := range sslConfig {
+			if err := config.Set(fmt.Sprintf("cruise.control.metrics.reporter.%s", k), v); err != nil {
+				log.Error(err, fmt.Sprintf("setting cruise.control.metrics.reporter.%s parameter in broker configuration resulted in an error", k))
+			}
+		}
+	}
+
+	// Add Cruise Control Metrics Reporter configuration
+	if err := config.Set(kafkautils.CruiseControlConfigMetricsReporters, "com.linkedin.kafka.cruisecontrol.metricsreporter.CruiseControlMetricsReporter"); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReporters))
+	}
+	bootstrapServers, err := kafkautils.GetBootstrapServersService(r.KafkaCluster)
+	if err != nil {
+		log.Error(err, "getting Kafka bootstrap servers for Cruise Control failed")
+	}
+	if err := config.Set(kafkautils.CruiseControlConfigMetricsReportersBootstrapServers, bootstrapServers); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReportersBootstrapServers))
+	}
+	if err := config.Set(kafkautils.CruiseControlConfigMetricsReporterK8sMode, true); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.CruiseControlConfigMetricsReporterK8sMode))
+	}
+}
+
 // mergeMountPaths is merges the new mountPaths with the old.
 // It returns the merged []string and a bool which true or false depend on mountPathsNew contains or not all of the elements of the mountPathsOld
 func mergeMountPaths(mountPathsOld, mountPathsNew []string) ([]string, bool) {
@@ -264,6 +299,89 @@ func generateControlPlaneListener(iListeners []v1beta1.InternalListenerConfig) s
 	return controlPlaneListener
 }
 
+// generateKRaftConfig generates the configuration which is needed to run the broker in KRaft mode based on its process roles
+func generateKRaftConfig(bConfig *v1beta1.BrokerConfig, id int32, kafkaCluster *v1beta1.KafkaCluster,
+	extListenerStatuses, intListenerStatuses, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList, log logr.Logger) *properties.Properties {
+	config := properties.NewProperties()
+
+	if err := config.Set(kafkautils.KafkaConfigProcessRoles, bConfig.ProcessRoles); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigProcessRoles))
+	}
+
+	if err := config.Set(kafkautils.KafkaConfigNodeId, id); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigNodeId))
+	}
+
+	controllerListenerName := generateControlPlaneListener(kafkaCluster.Spec.ListenersConfig.InternalListeners)
+	if err := config.Set(kafkautils.KafkaConfigControllerListenerName, controllerListenerName); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigControllerListenerName))
+	}
+
+	quorumVoters, err := generateQuorumVoters(kafkaCluster, controllerIntListenerStatuses)
+	if err != nil {
+		log.Error(err, "could not generate the controller quorum voters")
+	}
+	if err := config.Set(kafkautils.KafkaConfigControllerQuorumVoters, quorumVoters); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigControllerQuorumVoters))
+	}
+
+	// Only those listeners are opened which are needed by the roles of the broker
+	listenerConfig := make([]string, 0, len(kafkaCluster.Spec.ListenersConfig.InternalListeners)+len(kafkaCluster.Spec.ListenersConfig.ExternalListeners))
+	for _, iListener := range kafkaCluster.Spec.ListenersConfig.InternalListeners {
+		if (iListener.UsedForControllerCommunication && bConfig.IsControllerNode()) ||
+			(!iListener.UsedForControllerCommunication && bConfig.IsBrokerNode()) {
+			listenerConfig = append(listenerConfig, fmt.Sprintf("%s://:%d", strings.ToUpper(iListener.Name), iListener.ContainerPort))
+		}
+	}
+	if bConfig.IsBrokerNode() {
+		for _, eListener := range kafkaCluster.Spec.ListenersConfig.ExternalListeners {
+			listenerConfig = append(listenerConfig, fmt.Sprintf("%s://:%d", strings.ToUpper(eListener.Name), eListener.ContainerPort))
+		}
+	}
+	if err := config.Set(kafkautils.KafkaConfigListeners, listenerConfig); err != nil {
+		log.Error(err, fmt.Sprintf("setting '%s' parameter in broker configuration resulted an error", kafkautils.KafkaConfigListeners))
+	}
+
+	// The controller listener must not be advertised, and controller only nodes have nothing to advertise
+	if bConfig.IsBrokerNode() {
+		advertisedListenerConf := generateAdvertisedListenerConfig(id, kafkaCluster.Spec.ListenersConfig, extListenerStatuses, intListenerStatuses, nil)
+		if len(advertisedListenerConf) > 0 {
+			if err := config.Set(kafkautils.KafkaConfigAdvertisedListeners, advertisedListenerConf); err != nil {
+				log.Error(err, fmt.Sprintf("setting '%s' in broker configuration resulted an error", kafkautils.KafkaConfigAdvertisedListeners))
+			}
+		}
+	}
+
+	return config
+}
+
+// generateQuorumVoters generates the value of the controller.quorum.voters property in the
+// <node id>@<host>:<port> format from the addresses of the controller listener of the controller nodes
+func generateQuorumVoters(kafkaCluster *v1beta1.KafkaCluster, controllerIntListenerStatuses map[string]v1beta1.ListenerStatusList) ([]string, error) {
+	quorumVoters := make([]string, 0)
+	for _, broker := range kafkaCluster.Spec.Brokers {
+		bConfig, err := broker.GetBrokerConfig(kafkaCluster.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if bConfig == nil || !bConfig.IsControllerNode() {
+			continue
+		}
+		for _, statuses := range controllerIntListenerStatuses {
+			for _, status := range statuses {
+				if status.Name == fmt.Sprintf("broker-%d", broker.Id) {
+					quorumVoters = append(quorumVoters, fmt.Sprintf("%d@%s", broker.Id, status.Address))
+					break
+				}
+			}
+		}
+	}
+	if len(quorumVoters) == 0 {
+		return nil, errors.New("no controller node found")
+	}
+	return quorumVoters, nil
+}
+
 func generateListenerSpecificConfig(l *v1beta1.ListenersConfig, serverPasses map[string]string, log logr.Logger) *properties.Properties {
 	var (
 		interBrokerListenerName   string
@@ -701,3 +701,118 @@ zookeeper.connect=example.zk:2181/`,
 		})
 	}
 }
+
+func TestGenerateKRaftConfig(t *testing.T) {
+	kafkaCluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kafka",
+			Namespace: "kafka",
+		},
+		Spec: v1beta1.KafkaClusterSpec{
+			KRaftMode: true,
+			ListenersConfig: v1beta1.ListenersConfig{
+				InternalListeners: []v1beta1.InternalListenerConfig{
+					{
+						CommonListenerSpec: v1beta1.CommonListenerSpec{
+							Type:          "plaintext",
+							Name:          "internal",
+							ContainerPort: 9092,
+						},
+						UsedForInnerBrokerCommunication: true,
+					},
+					{
+						CommonListenerSpec: v1beta1.CommonListenerSpec{
+							Type:          "plaintext",
+							Name:          "controller",
+							ContainerPort: 9093,
+						},
+						UsedForControllerCommunication: true,
+					},
+				},
+			},
+			BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+				"controller": {ProcessRoles: []string{v1beta1.ProcessRoleController}},
+				"broker":     {ProcessRoles: []string{v1beta1.ProcessRoleBroker}},
+			},
+			Brokers: []v1beta1.Broker{
+				{Id: 0, BrokerConfigGroup: "controller"},
+				{Id: 1, BrokerConfigGroup: "broker"},
+				{Id: 2, BrokerConfigGroup: "broker", BrokerConfig: &v1beta1.BrokerConfig{
+					ProcessRoles: []string{v1beta1.ProcessRoleBroker, v1beta1.ProcessRoleController},
+				}},
+			},
+		},
+	}
+	intListenerStatuses := map[string]v1beta1.ListenerStatusList{
+		"internal": {
+			{Name: "broker-0", Address: "kafka-0.kafka.svc.cluster.local:9092"},
+			{Name: "broker-1", Address: "kafka-1.kafka.svc.cluster.local:9092"},
+			{Name: "broker-2", Address: "kafka-2.kafka.svc.cluster.local:9092"},
+		},
+	}
+	controllerIntListenerStatuses := map[string]v1beta1.ListenerStatusList{
+		"controller": {
+			{Name: "broker-0", Address: "kafka-0.kafka.svc.cluster.local:9093"},
+			{Name: "broker-1", Address: "kafka-1.kafka.svc.cluster.local:9093"},
+			{Name: "broker-2", Address: "kafka-2.kafka.svc.cluster.local:9093"},
+		},
+	}
+
+	tests := []struct {
+		testName       string
+		brokerID       int32
+		expectedConfig string
+	}{
+		{
+			testName: "controller only node",
+			brokerID: 0,
+			expectedConfig: `controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:9093,2@kafka-2.kafka.svc.cluster.local:9093
+listeners=CONTROLLER://:9093
+node.id=0
+process.roles=controller`,
+		},
+		{
+			testName: "broker only node",
+			brokerID: 1,
+			expectedConfig: `advertised.listeners=INTERNAL://kafka-1.kafka.svc.cluster.local:9092
+controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:9093,2@kafka-2.kafka.svc.cluster.local:9093
+listeners=INTERNAL://:9092
+node.id=1
+process.roles=broker`,
+		},
+		{
+			testName: "combined node",
+			brokerID: 2,
+			expectedConfig: `advertised.listeners=INTERNAL://kafka-2.kafka.svc.cluster.local:9092
+controller.listener.names=CONTROLLER
+controller.quorum.voters=0@kafka-0.kafka.svc.cluster.local:9093,2@kafka-2.kafka.svc.cluster.local:9093
+listeners=INTERNAL://:9092,CONTROLLER://:9093
+node.id=2
+process.roles=broker,controller`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.testName, func(t *testing.T) {
+			broker := kafkaCluster.Spec.Brokers[test.brokerID]
+			bConfig, err := broker.GetBrokerConfig(kafkaCluster.Spec)
+			if err != nil {
+				t.Fatalf("could not get broker config: %s", err)
+			}
+
+			generated := generateKRaftConfig(bConfig, test.brokerID, kafkaCluster, nil, intListenerStatuses, controllerIntListenerStatuses, logr.Discard())
+
+			expected, err := properties.NewFromString(test.expectedConfig)
+			if err != nil {
+				t.Fatalf("failed parsing expected configuration as Properties: %s", test.expectedConfig)
+			}
+
+			if !generated.Equal(expected) {
+				t.Errorf("the expected config is:\n%s\nreceived:\n%s\n", expected, generated)
+			}
+		})
+	}
+}
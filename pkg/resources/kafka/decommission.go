@@ -0,0 +1,193 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+)
+
+// maxReportedPartitions limits the number of partitions listed in the status of a broker which could not be evacuated
+const maxReportedPartitions = 10
+
+// verifyBrokerEvacuated returns true when the removed broker holds no partition replicas. Otherwise the graceful
+// downscale of the broker is requested again, and the partitions left on it are recorded in its GracefulActionState.
+// Once the evacuation attempts are used up the broker is kept and the failure is reported in its GracefulActionState.
+func (r *Reconciler) verifyBrokerEvacuated(log logr.Logger, kClient kafkaclient.KafkaClient, brokerID string) (bool, error) {
+	id, err := strconv.ParseInt(brokerID, 10, 32)
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "invalid broker id", "id", brokerID)
+	}
+	partitions, err := kClient.ReplicasOn([]int32{int32(id)})
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not verify that the broker holds no partition replicas", "id", brokerID)
+	}
+	if len(partitions) == 0 {
+		return true, nil
+	}
+
+	current := r.KafkaCluster.Status.BrokersState[brokerID].GracefulActionState
+	state, retry := evacuationGracefulActionState(current, partitions, r.KafkaCluster.Spec.DecommissionConfig.GetMaxEvacuationAttempts())
+	if reflect.DeepEqual(state, current) {
+		return false, nil
+	}
+	if retry {
+		log.Info("broker still holds partition replicas, requesting its graceful downscale again",
+			v1beta1.BrokerIdLabelKey, brokerID, "partitions", partitions, "attempt", state.EvacuationAttempts)
+	} else {
+		log.Error(errors.New(state.ErrorMessage), "broker could not be evacuated, its pod is kept",
+			v1beta1.BrokerIdLabelKey, brokerID)
+	}
+	if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerID}, r.KafkaCluster, state, log); err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not update status for broker", "id", brokerID)
+	}
+	return false, nil
+}
+
+// evacuationGracefulActionState returns the GracefulActionState of a removed broker which still holds the partition
+// replicas, and whether its graceful downscale is requested again
+func evacuationGracefulActionState(current v1beta1.GracefulActionState, partitions []string, maxAttempts int) (v1beta1.GracefulActionState, bool) {
+	if current.EvacuationAttempts >= maxAttempts {
+		state := current
+		state.ErrorMessage = fmt.Sprintf("giving up the evacuation after %d attempt(s), %s",
+			current.EvacuationAttempts, evacuationErrorMessage(partitions))
+		return state, false
+	}
+	return v1beta1.GracefulActionState{
+		CruiseControlState: v1beta1.GracefulDownscaleRequired,
+		ErrorMessage:       evacuationErrorMessage(partitions),
+		EvacuationAttempts: current.EvacuationAttempts + 1,
+		ReplacementState:   current.ReplacementState,
+	}, true
+}
+
+// evacuationErrorMessage returns the error message of a broker which still holds the partition replicas
+func evacuationErrorMessage(partitions []string) string {
+	reported := partitions
+	if len(reported) > maxReportedPartitions {
+		reported = reported[:maxReportedPartitions]
+	}
+	message := fmt.Sprintf("the broker still holds %d partition replica(s) after the graceful downscale: %s",
+		len(partitions), strings.Join(reported, ", "))
+	if len(reported) < len(partitions) {
+		message += ", ..."
+	}
+	return message
+}
+
+// deleteBrokerPVC deletes the PVC of a removed broker, or marks it to be deleted once the PVC retention
+// period is over
+func (r *Reconciler) deleteBrokerPVC(ctx context.Context, log logr.Logger, brokerID, claimName string) error {
+	retention := r.KafkaCluster.Spec.DecommissionConfig.GetPVCRetention()
+	if retention == 0 {
+		err := r.Client.Delete(ctx, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:      claimName,
+			Namespace: r.KafkaCluster.Namespace,
+		}})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// can happen when broker was not fully initialized and now is deleted
+				log.Info(fmt.Sprintf("PVC for Broker %s not found. Continue", brokerID))
+			}
+			return errors.WrapIfWithDetails(err, "could not delete pvc for broker", "id", brokerID)
+		}
+		log.V(1).Info("pvc for broker deleted", "pvc name", claimName, v1beta1.BrokerIdLabelKey, brokerID)
+		return nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: claimName, Namespace: r.KafkaCluster.Namespace}, pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info(fmt.Sprintf("PVC for Broker %s not found. Continue", brokerID))
+			return nil
+		}
+		return errors.WrapIfWithDetails(err, "could not get pvc for broker", "id", brokerID)
+	}
+	if _, ok := pvc.Annotations[v1beta1.PVCDeleteAfterAnnotationKey]; ok {
+		return nil
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = make(map[string]string)
+	}
+	deleteAfter := time.Now().Add(retention).UTC().Format(time.RFC3339)
+	pvc.Annotations[v1beta1.PVCDeleteAfterAnnotationKey] = deleteAfter
+	if err := r.Client.Update(ctx, pvc); err != nil {
+		return errors.WrapIfWithDetails(err, "could not mark pvc of broker for deletion", "id", brokerID)
+	}
+	log.Info("pvc of the removed broker is retained", "pvc name", claimName, v1beta1.BrokerIdLabelKey, brokerID, "deleteAfter", deleteAfter)
+	return nil
+}
+
+// reconcileRetainedPVCs deletes the retained PVCs of the removed brokers once their retention period is over.
+// The PVCs of the brokers which were added back to the cluster are kept.
+func (r *Reconciler) reconcileRetainedPVCs(ctx context.Context, log logr.Logger) error {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err := r.Client.List(ctx, pvcList,
+		client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(apiutil.LabelsForKafka(r.KafkaCluster.Name)),
+	)
+	if err != nil {
+		return errors.WrapIf(err, "failed to list PVCs")
+	}
+
+	brokerIDsFromSpec := make(map[string]struct{}, len(r.KafkaCluster.Spec.Brokers))
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerIDsFromSpec[strconv.Itoa(int(broker.Id))] = struct{}{}
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		deleteAfter, ok := pvc.Annotations[v1beta1.PVCDeleteAfterAnnotationKey]
+		if !ok || k8sutil.IsMarkedForDeletion(pvc.ObjectMeta) {
+			continue
+		}
+		brokerID := pvc.Labels[v1beta1.BrokerIdLabelKey]
+		if _, ok := brokerIDsFromSpec[brokerID]; ok {
+			delete(pvc.Annotations, v1beta1.PVCDeleteAfterAnnotationKey)
+			if err := r.Client.Update(ctx, pvc); err != nil {
+				return errors.WrapIfWithDetails(err, "could not keep the pvc of the broker", "id", brokerID)
+			}
+			log.Info("retained pvc is kept as its broker was added back", "pvc name", pvc.Name, v1beta1.BrokerIdLabelKey, brokerID)
+			continue
+		}
+		deadline, err := time.Parse(time.RFC3339, deleteAfter)
+		if err != nil {
+			log.Error(err, "invalid pvc deletion time, the pvc is deleted", "pvc name", pvc.Name, "deleteAfter", deleteAfter)
+		} else if time.Now().Before(deadline) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+			return errors.WrapIfWithDetails(err, "could not delete pvc for broker", "id", brokerID)
+		}
+		log.Info("retained pvc of the removed broker deleted", "pvc name", pvc.Name, v1beta1.BrokerIdLabelKey, brokerID)
+	}
+	return nil
+}
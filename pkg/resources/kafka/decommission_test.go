@@ -0,0 +1,270 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/controllers/tests/mocks"
+	"github.com/banzaicloud/koperator/pkg/resources"
+	"github.com/banzaicloud/koperator/pkg/scale"
+)
+
+func TestEvacuationErrorMessage(t *testing.T) {
+	assert.Equal(t, "the broker still holds 2 partition replica(s) after the graceful downscale: topic-0, topic-1",
+		evacuationErrorMessage([]string{"topic-0", "topic-1"}))
+
+	partitions := make([]string, 0, maxReportedPartitions+2)
+	for i := 0; i < maxReportedPartitions+2; i++ {
+		partitions = append(partitions, fmt.Sprintf("topic-%d", i))
+	}
+	message := evacuationErrorMessage(partitions)
+	assert.Contains(t, message, "holds 12 partition replica(s)")
+	assert.Contains(t, message, "topic-9, ...")
+	assert.NotContains(t, message, "topic-10")
+}
+
+func TestEvacuationGracefulActionState(t *testing.T) {
+	partitions := []string{"topic-0"}
+	current := v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulDownscaleSucceeded}
+
+	// the graceful downscale is requested again until the attempts are used up
+	for attempt := 1; attempt <= 2; attempt++ {
+		state, retry := evacuationGracefulActionState(current, partitions, 2)
+		assert.True(t, retry)
+		assert.Equal(t, v1beta1.GracefulDownscaleRequired, state.CruiseControlState)
+		assert.Equal(t, attempt, state.EvacuationAttempts)
+		current = state
+		current.CruiseControlState = v1beta1.GracefulDownscaleSucceeded
+	}
+
+	state, retry := evacuationGracefulActionState(current, partitions, 2)
+	assert.False(t, retry)
+	assert.Equal(t, v1beta1.GracefulDownscaleSucceeded, state.CruiseControlState)
+	assert.Equal(t, 2, state.EvacuationAttempts)
+	assert.Equal(t, "giving up the evacuation after 2 attempt(s), "+evacuationErrorMessage(partitions), state.ErrorMessage)
+}
+
+func newDecommissionReconciler(t *testing.T, kClient *kafkaClientStub, objects ...client.Object) (*Reconciler, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			HeadlessServiceEnabled: true,
+			Brokers:                []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{}}},
+			DecommissionConfig:     v1beta1.DecommissionConfig{MaxEvacuationAttempts: 2},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}},
+				"1": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulDownscaleSucceeded}},
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, cluster)...).Build()
+
+	scaler := mocks.NewMockCruiseControlScaler(gomock.NewController(t))
+	scaler.EXPECT().BrokersWithState(gomock.Any(), gomock.Any()).Return([]string{"0", "1"}, nil).AnyTimes()
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fakeClient,
+			KafkaCluster: cluster,
+		},
+		kafkaClientProvider: kafkaClientProviderStub{client: kClient},
+		scaleFactory: func(context.Context, *v1beta1.KafkaCluster) (scale.CruiseControlScaler, error) {
+			return scaler, nil
+		},
+	}, fakeClient
+}
+
+func TestVerifyBrokerEvacuated(t *testing.T) {
+	kClient := &kafkaClientStub{}
+	r, fakeClient := newDecommissionReconciler(t, kClient)
+
+	evacuated, err := r.verifyBrokerEvacuated(logr.Discard(), kClient, "1")
+	assert.NoError(t, err)
+	assert.True(t, evacuated)
+	assert.Equal(t, v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulDownscaleSucceeded},
+		r.KafkaCluster.Status.BrokersState["1"].GracefulActionState)
+
+	// the graceful downscale of the broker which still holds partition replicas is requested again
+	kClient.replicasOn = []string{"topic-0", "topic-1"}
+	evacuated, err = r.verifyBrokerEvacuated(logr.Discard(), kClient, "1")
+	assert.NoError(t, err)
+	assert.False(t, evacuated)
+	expected := v1beta1.GracefulActionState{
+		CruiseControlState: v1beta1.GracefulDownscaleRequired,
+		ErrorMessage:       evacuationErrorMessage(kClient.replicasOn),
+		EvacuationAttempts: 1,
+	}
+	cluster := &v1beta1.KafkaCluster{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(r.KafkaCluster), cluster))
+	assert.Equal(t, expected, cluster.Status.BrokersState["1"].GracefulActionState)
+
+	_, err = r.verifyBrokerEvacuated(logr.Discard(), kClient, "invalid")
+	assert.Error(t, err)
+}
+
+func TestReconcileKafkaPodDeleteEvacuation(t *testing.T) {
+	ctx := context.Background()
+	kClient := &kafkaClientStub{replicasOn: []string{"topic-0"}}
+	removedPod := newBrokerPod("1")
+	removedPod.Spec.Volumes = []corev1.Volume{{
+		Name: kafkaDataVolumeMount + "-0",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "kafka-1-storage-0"},
+		},
+	}}
+	removedPVC := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "kafka-1-storage-0", Namespace: "kafka"}}
+	r, fakeClient := newDecommissionReconciler(t, kClient, newBrokerPod("0"), removedPod, removedPVC)
+
+	brokerState := func() v1beta1.GracefulActionState {
+		cluster := &v1beta1.KafkaCluster{}
+		require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(r.KafkaCluster), cluster))
+		return cluster.Status.BrokersState["1"].GracefulActionState
+	}
+	podExists := func() bool {
+		err := fakeClient.Get(ctx, client.ObjectKeyFromObject(removedPod), &corev1.Pod{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+	// finishGracefulDownscale marks the graceful downscale requested again as succeeded, like Cruise Control does
+	finishGracefulDownscale := func() {
+		state := r.KafkaCluster.Status.BrokersState["1"]
+		require.Equal(t, v1beta1.GracefulDownscaleRequired, state.GracefulActionState.CruiseControlState)
+		state.GracefulActionState.CruiseControlState = v1beta1.GracefulDownscaleSucceeded
+		r.KafkaCluster.Status.BrokersState["1"] = state
+	}
+
+	// the pod of the broker which still holds partition replicas is kept and its graceful downscale is requested again
+	for attempt := 1; attempt <= 2; attempt++ {
+		require.NoError(t, r.reconcileKafkaPodDelete(ctx, logr.Discard()))
+		assert.True(t, podExists())
+		state := brokerState()
+		assert.Equal(t, v1beta1.GracefulDownscaleRequired, state.CruiseControlState)
+		assert.Equal(t, attempt, state.EvacuationAttempts)
+		assert.Equal(t, evacuationErrorMessage(kClient.replicasOn), state.ErrorMessage)
+
+		// the pod is not deleted while the graceful downscale is pending
+		require.NoError(t, r.reconcileKafkaPodDelete(ctx, logr.Discard()))
+		assert.True(t, podExists())
+		assert.Equal(t, state, brokerState())
+
+		finishGracefulDownscale()
+	}
+
+	// once the evacuation attempts are used up the pod is kept and the failure is reported
+	require.NoError(t, r.reconcileKafkaPodDelete(ctx, logr.Discard()))
+	assert.True(t, podExists())
+	state := brokerState()
+	assert.Equal(t, v1beta1.GracefulDownscaleSucceeded, state.CruiseControlState)
+	assert.Equal(t, 2, state.EvacuationAttempts)
+	assert.Equal(t, "giving up the evacuation after 2 attempt(s), "+evacuationErrorMessage(kClient.replicasOn), state.ErrorMessage)
+
+	require.NoError(t, r.reconcileKafkaPodDelete(ctx, logr.Discard()))
+	assert.True(t, podExists())
+	assert.Equal(t, state, brokerState())
+
+	// the broker is removed once it holds no partition replicas
+	kClient.replicasOn = nil
+	require.NoError(t, r.reconcileKafkaPodDelete(ctx, logr.Discard()))
+	assert.False(t, podExists())
+	err := fakeClient.Get(ctx, client.ObjectKeyFromObject(removedPVC), &corev1.PersistentVolumeClaim{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NotContains(t, r.KafkaCluster.Status.BrokersState, "1")
+	assert.Contains(t, r.KafkaCluster.Status.BrokersState, "0")
+}
+
+func TestRetainedBrokerPVCs(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	pvc := func(name, brokerID string, annotations map[string]string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "kafka",
+				Labels:      apiutil.MergeLabels(apiutil.LabelsForKafka("kafka"), map[string]string{v1beta1.BrokerIdLabelKey: brokerID}),
+				Annotations: annotations,
+			},
+		}
+	}
+	expired := map[string]string{v1beta1.PVCDeleteAfterAnnotationKey: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pvc("removed", "1", nil),
+		pvc("expired", "2", expired),
+		pvc("readded", "3", expired),
+	).Build()
+
+	r := Reconciler{
+		Reconciler: resources.Reconciler{
+			Client: fakeClient,
+			KafkaCluster: &v1beta1.KafkaCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+				Spec: v1beta1.KafkaClusterSpec{
+					Brokers:            []v1beta1.Broker{{Id: 3}},
+					DecommissionConfig: v1beta1.DecommissionConfig{PVCRetentionMinutes: 60},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, r.deleteBrokerPVC(ctx, logr.Discard(), "1", "removed"))
+	assert.NoError(t, r.reconcileRetainedPVCs(ctx, logr.Discard()))
+
+	// the PVC of the removed broker is retained until its retention period is over
+	removed := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "removed", Namespace: "kafka"}, removed))
+	deleteAfter, err := time.Parse(time.RFC3339, removed.Annotations[v1beta1.PVCDeleteAfterAnnotationKey])
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), deleteAfter, time.Minute)
+
+	err = fakeClient.Get(ctx, client.ObjectKey{Name: "expired", Namespace: "kafka"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// the PVC of the broker which was added back is kept
+	readded := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "readded", Namespace: "kafka"}, readded))
+	assert.NotContains(t, readded.Annotations, v1beta1.PVCDeleteAfterAnnotationKey)
+
+	// without retention the PVC is deleted right away
+	r.KafkaCluster.Spec.DecommissionConfig.PVCRetentionMinutes = 0
+	assert.NoError(t, r.deleteBrokerPVC(ctx, logr.Discard(), "1", "removed"))
+	err = fakeClient.Get(ctx, client.ObjectKey{Name: "removed", Namespace: "kafka"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
@@ -16,6 +16,8 @@ package kafka
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"reflect"
 	"sort"
@@ -26,7 +28,6 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -92,6 +93,8 @@ const (
 type Reconciler struct {
 	resources.Reconciler
 	kafkaClientProvider kafkaclient.Provider
+	// scaleFactory creates the Cruise Control clients of the leadership migrations and the broker removals
+	scaleFactory func(ctx context.Context, kafkaCluster *v1beta1.KafkaCluster) (scale.CruiseControlScaler, error)
 }
 
 // New creates a new reconciler for Kafka
@@ -103,6 +106,7 @@ func New(client client.Client, directClient client.Reader, cluster *v1beta1.Kafk
 			KafkaCluster: cluster,
 		},
 		kafkaClientProvider: kafkaClientProvider,
+		scaleFactory:        scale.ScaleFactoryFn(),
 	}
 }
 func getCreatedPvcForBroker(
@@ -123,6 +127,15 @@ func getCreatedPvcForBroker(
 		return nil, err
 	}
 
+	// the PVCs being deleted can not be mounted anymore, e.g. the ones of the drained storages
+	pvcs := foundPvcList.Items[:0]
+	for _, pvc := range foundPvcList.Items {
+		if !k8sutil.IsMarkedForDeletion(pvc.ObjectMeta) {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	foundPvcList.Items = pvcs
+
 	var missing []string
 	for i := range storageConfigs {
 		if storageConfigs[i].PvcSpec == nil {
@@ -150,6 +163,15 @@ func getCreatedPvcForBroker(
 	return foundPvcList.Items, nil
 }
 
+// generateClusterID generates a random cluster ID in the same format as the kafka-storage.sh random-uuid command does
+func generateClusterID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
 func getLoadBalancerIP(foundLBService *corev1.Service) (string, error) {
 	if len(foundLBService.Status.LoadBalancer.Ingress) == 0 {
 		return "", errorfactory.New(errorfactory.ResourceNotReady{}, errors.New("loadbalancer ingress is not created waiting"), "trying")
@@ -215,6 +237,10 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return errors.WrapIf(err, "failed to reconcile resource")
 	}
 
+	if err := r.reconcileBrokerReplacement(ctx, log); err != nil {
+		return err
+	}
+
 	extListenerStatuses, err := r.createExternalListenerStatuses(log)
 	if err != nil {
 		return errors.WrapIf(err, "could not update status for external listeners")
@@ -233,6 +259,17 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		}
 	}
 
+	// The storage of the brokers is formatted with the cluster ID in KRaft mode thus it has to be generated before any broker is created
+	if r.KafkaCluster.Spec.KRaftMode && r.KafkaCluster.Status.ClusterID == "" {
+		clusterID, err := generateClusterID()
+		if err != nil {
+			return errors.WrapIf(err, "could not generate cluster ID")
+		}
+		if err := k8sutil.UpdateClusterID(r.Client, r.KafkaCluster, clusterID, log); err != nil {
+			return errors.WrapIf(err, "could not update cluster ID")
+		}
+	}
+
 	// We need to grab names for servers and client in case user is enabling ACLs
 	// That way we can continue to manage topics and users
 	clientPass, serverPasses, superUsers, err := r.getPasswordKeysAndSuperUsers()
@@ -246,6 +283,7 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		if err != nil {
 			return errors.WrapIf(err, "failed to reconcile resource")
 		}
+		brokerConfig = withoutDrainedStorages(r.KafkaCluster, broker.Id, brokerConfig)
 
 		var brokerVolumes []*corev1.PersistentVolumeClaim
 		for index, storage := range brokerConfig.StorageConfigs {
@@ -307,12 +345,29 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 	}
 
 	reorderedBrokers := reorderBrokers(runningBrokers, boundPersistentVolumeClaims, r.KafkaCluster.Spec.Brokers, r.KafkaCluster.Status.BrokersState, controllerID, log)
+	if concurrentRackRestart(r.KafkaCluster) {
+		reorderedBrokers = groupBrokersByRack(reorderedBrokers, runningBrokers, r.KafkaCluster)
+	}
+	canaryImages, err := r.reconcileCanaryUpgrade(log, brokerPods.Items)
+	if err != nil {
+		return err
+	}
+	if err := r.reconcileKafkaVersionUpgrade(ctx, log); err != nil {
+		return err
+	}
+
 	allBrokerDynamicConfigSucceeded := true
 	for _, broker := range reorderedBrokers {
 		brokerConfig, err := broker.GetBrokerConfig(r.KafkaCluster.Spec)
 		if err != nil {
 			return errors.WrapIf(err, "failed to reconcile resource")
 		}
+		brokerConfig = withoutDrainedStorages(r.KafkaCluster, broker.Id, brokerConfig)
+		// the broker keeps its previous image while the canary upgrade holds it back
+		if image, ok := canaryImages[broker.Id]; ok && brokerConfig != nil {
+			brokerConfig = brokerConfig.DeepCopy()
+			brokerConfig.Image = image
+		}
 
 		var configMap *corev1.ConfigMap
 		if r.KafkaCluster.Spec.RackAwareness == nil {
@@ -373,6 +428,10 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		return err
 	}
 
+	if err := r.reconcileLogDirDrains(ctx, log); err != nil {
+		return err
+	}
+
 	// in case HeadlessServiceEnabled is changed, delete the service that was created by the previous
 	// reconcile flow. The services must be deleted at the end of the reconcile flow after the new services
 	// were created and broker configurations reflecting the new services otherwise the Kafka brokers
@@ -397,6 +456,30 @@ func (r *Reconciler) Reconcile(log logr.Logger) error {
 		}
 	}
 
+	if canary := r.KafkaCluster.Status.RollingUpgrade.Canary; r.KafkaCluster.Spec.RollingUpgradeConfig.Canary != nil && canary != nil &&
+		(canary.State == v1beta1.CanaryUpgradeInProgress || canary.State == v1beta1.CanaryUpgradeSoaking) {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			errors.NewWithDetails("canary upgrade in progress", "state", canary.State, "canaries", canary.Brokers), "rolling upgrade in progress")
+	}
+
+	if versionUpgrade := r.KafkaCluster.Status.KafkaVersionUpgrade; r.KafkaCluster.Spec.KafkaVersion != "" && !r.KafkaCluster.Spec.KRaftMode &&
+		versionUpgrade != nil && versionUpgrade.State != v1beta1.KafkaVersionUpgradeCompleted {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			errors.NewWithDetails("Kafka version upgrade in progress", "state", versionUpgrade.State,
+				"kafkaVersion", versionUpgrade.TargetVersion, "protocolVersion", versionUpgrade.ProtocolVersion), "rolling upgrade in progress")
+	}
+
+	if len(r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers) > 0 {
+		kClient, close, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+		if err != nil {
+			return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+		}
+		defer close()
+		if err := r.reconcilePreferredLeaderElection(ctx, log, kClient, nil); err != nil {
+			return err
+		}
+	}
+
 	log.V(1).Info("Reconciled")
 
 	return nil
@@ -434,7 +517,7 @@ func (r *Reconciler) reconcileKafkaPodDelete(ctx context.Context, log logr.Logge
 		if !arePodsAlreadyDeleted(podsDeletedFromSpec, log) {
 			cruiseControlURL := scale.CruiseControlURLFromKafkaCluster(r.KafkaCluster)
 			// FIXME: we should reuse the context of the Kafka Controller
-			cc, err := scale.NewCruiseControlScaler(context.TODO(), scale.CruiseControlURLFromKafkaCluster(r.KafkaCluster))
+			cc, err := r.scaleFactory(context.TODO(), r.KafkaCluster)
 			if err != nil {
 				return errorfactory.New(errorfactory.CruiseControlNotReady{}, err,
 					"failed to initialize Cruise Control Scaler", "cruise control url", cruiseControlURL)
@@ -487,6 +570,7 @@ func (r *Reconciler) reconcileKafkaPodDelete(ctx context.Context, log logr.Logge
 			}
 		}
 
+		var kClient kafkaclient.KafkaClient
 		for _, broker := range podsDeletedFromSpec {
 			broker := broker
 			if broker.ObjectMeta.DeletionTimestamp != nil {
@@ -503,6 +587,23 @@ func (r *Reconciler) reconcileKafkaPodDelete(ctx context.Context, log logr.Logge
 				continue
 			}
 
+			// the pod is only deleted once Kafka confirms that the broker holds no partition replicas
+			if kClient == nil {
+				var close func()
+				kClient, close, err = r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+				if err != nil {
+					return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+				}
+				defer close()
+			}
+			evacuated, err := r.verifyBrokerEvacuated(log, kClient, broker.Labels[v1beta1.BrokerIdLabelKey])
+			if err != nil {
+				return err
+			}
+			if !evacuated {
+				continue
+			}
+
 			err = r.Client.Delete(context.TODO(), &broker)
 			if err != nil {
 				return errors.WrapIfWithDetails(err, "could not delete broker", "id", broker.Labels[v1beta1.BrokerIdLabelKey])
@@ -534,18 +635,10 @@ func (r *Reconciler) reconcileKafkaPodDelete(ctx context.Context, log logr.Logge
 			}
 			for _, volume := range broker.Spec.Volumes {
 				if strings.HasPrefix(volume.Name, kafkaDataVolumeMount) {
-					err = r.Client.Delete(context.TODO(), &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
-						Name:      volume.PersistentVolumeClaim.ClaimName,
-						Namespace: r.KafkaCluster.Namespace,
-					}})
+					err = r.deleteBrokerPVC(ctx, log, broker.Labels[v1beta1.BrokerIdLabelKey], volume.PersistentVolumeClaim.ClaimName)
 					if err != nil {
-						if apierrors.IsNotFound(err) {
-							// can happen when broker was not fully initialized and now is deleted
-							log.Info(fmt.Sprintf("PVC for Broker %s not found. Continue", broker.Labels[v1beta1.BrokerIdLabelKey]))
-						}
-						return errors.WrapIfWithDetails(err, "could not delete pvc for broker", "id", broker.Labels[v1beta1.BrokerIdLabelKey])
+						return err
 					}
-					log.V(1).Info("pvc for broker deleted", "pvc name", volume.PersistentVolumeClaim.ClaimName, v1beta1.BrokerIdLabelKey, broker.Labels[v1beta1.BrokerIdLabelKey])
 				}
 			}
 			err = k8sutil.DeleteStatus(r.Client, broker.Labels[v1beta1.BrokerIdLabelKey], r.KafkaCluster, log)
@@ -554,7 +647,7 @@ func (r *Reconciler) reconcileKafkaPodDelete(ctx context.Context, log logr.Logge
 			}
 		}
 	}
-	return nil
+	return r.reconcileRetainedPVCs(ctx, log)
 }
 
 func arePodsAlreadyDeleted(pods []corev1.Pod, log logr.Logger) bool {
@@ -758,11 +851,16 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod,
 
 		if val, hasBrokerState := r.KafkaCluster.Status.BrokersState[desiredPod.Labels[v1beta1.BrokerIdLabelKey]]; hasBrokerState {
 			ccState := val.GracefulActionState.CruiseControlState
-			if ccState != v1beta1.GracefulUpscaleSucceeded && !ccState.IsDownscale() {
-				gracefulActionState := v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}
+			// the controller-only KRaft nodes host no partitions, there is nothing to rebalance onto them
+			if ccState != v1beta1.GracefulUpscaleSucceeded && !ccState.IsDownscale() && !bConfig.IsControllerOnlyNode() {
+				// the replaced brokers are re-replicated by the same Cruise Control operation as the new ones
+				gracefulActionState := v1beta1.GracefulActionState{
+					CruiseControlState: v1beta1.GracefulUpscaleSucceeded,
+					ReplacementState:   val.GracefulActionState.ReplacementState,
+				}
 
 				if r.KafkaCluster.Status.CruiseControlTopicStatus == v1beta1.CruiseControlTopicReady {
-					gracefulActionState = v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleRequired}
+					gracefulActionState.CruiseControlState = v1beta1.GracefulUpscaleRequired
 				}
 				statusErr = k8sutil.UpdateBrokerStatus(r.Client, []string{desiredPod.Labels[v1beta1.BrokerIdLabelKey]}, r.KafkaCluster, gracefulActionState, log)
 				if statusErr != nil {
@@ -774,6 +872,9 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod,
 		return nil
 	case len(podList.Items) == 1:
 		currentPod = podList.Items[0].DeepCopy()
+		if err := r.reconcileBrokerConfigGroupLabel(currentPod, desiredPod); err != nil {
+			return err
+		}
 		brokerId := currentPod.Labels[v1beta1.BrokerIdLabelKey]
 		if _, ok := r.KafkaCluster.Status.BrokersState[brokerId]; ok {
 			if currentPod.Spec.NodeName == "" {
@@ -801,6 +902,27 @@ func (r *Reconciler) reconcileKafkaPod(log logr.Logger, desiredPod *corev1.Pod,
 	return nil
 }
 
+// reconcileBrokerConfigGroupLabel updates the broker config group label of the running broker pod in place since
+// the label does not require the restart of the broker
+func (r *Reconciler) reconcileBrokerConfigGroupLabel(currentPod, desiredPod *corev1.Pod) error {
+	group, ok := desiredPod.Labels[v1beta1.BrokerConfigGroupLabelKey]
+	currentGroup, currentOk := currentPod.Labels[v1beta1.BrokerConfigGroupLabelKey]
+	if ok == currentOk && group == currentGroup {
+		return nil
+	}
+	patchFrom := client.MergeFrom(currentPod.DeepCopy())
+	if ok {
+		currentPod.Labels[v1beta1.BrokerConfigGroupLabelKey] = group
+	} else {
+		delete(currentPod.Labels, v1beta1.BrokerConfigGroupLabelKey)
+	}
+	if err := r.Client.Patch(context.TODO(), currentPod, patchFrom); err != nil {
+		return errorfactory.New(errorfactory.APIFailure{}, err, "could not update broker config group label of the broker pod",
+			v1beta1.BrokerIdLabelKey, currentPod.Labels[v1beta1.BrokerIdLabelKey])
+	}
+	return nil
+}
+
 func (r *Reconciler) updateStatusWithDockerImageAndVersion(brokerId int32, brokerConfig *v1beta1.BrokerConfig,
 	log logr.Logger) error {
 	jmxExp := jmxextractor.NewJMXExtractor(r.KafkaCluster.GetNamespace(),
@@ -845,7 +967,7 @@ func (r *Reconciler) handleRollingUpgrade(log logr.Logger, desiredPod, currentPo
 			!k8sutil.IsPodContainsEvictedContainer(currentPod) &&
 			!k8sutil.IsPodContainsShutdownContainer(currentPod) {
 			log.V(1).Info("resource is in sync")
-			return nil
+			return r.markBrokerUpToDate(log, currentPod.Labels[v1beta1.BrokerIdLabelKey])
 		}
 	default:
 		log.V(1).Info("kafka pod resource diffs",
@@ -864,6 +986,9 @@ func (r *Reconciler) handleRollingUpgrade(log logr.Logger, desiredPod, currentPo
 			if err := k8sutil.UpdateCRStatus(r.Client, r.KafkaCluster, v1beta1.KafkaClusterRollingUpgrading, log); err != nil {
 				return errorfactory.New(errorfactory.StatusUpdateError{}, err, "setting state to rolling upgrade failed")
 			}
+			if err := r.startRollingUpgradeProgress(log); err != nil {
+				return err
+			}
 		}
 
 		if r.KafkaCluster.Status.State == v1beta1.KafkaClusterRollingUpgrading {
@@ -874,8 +999,21 @@ func (r *Reconciler) handleRollingUpgrade(log logr.Logger, desiredPod, currentPo
 			if err != nil {
 				return errors.WrapIf(err, "failed to reconcile resource")
 			}
+			// With concurrent rack restart the brokers of the rack being restarted don't have to wait for each other
+			rack := ""
+			if concurrentRackRestart(r.KafkaCluster) {
+				rack = brokerRack(r.KafkaCluster, currentPod.Labels[v1beta1.BrokerIdLabelKey])
+			}
+			rackRestartInProgress := false
 			for _, pod := range podList.Items {
 				pod := pod
+				restarting := k8sutil.IsMarkedForDeletion(pod.ObjectMeta) || k8sutil.IsPodContainsPendingContainer(&pod)
+				// only the pods of the rack restart started by the rolling upgrade are expected to be restarting
+				if restarting && rack != "" && rackRestartStarted(r.KafkaCluster.Status.RollingUpgrade,
+					currentPod.Labels[v1beta1.BrokerIdLabelKey], pod.Labels[v1beta1.BrokerIdLabelKey]) {
+					rackRestartInProgress = true
+					continue
+				}
 				if k8sutil.IsMarkedForDeletion(pod.ObjectMeta) {
 					return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("pod is still terminating"), "rolling upgrade in progress")
 				}
@@ -884,6 +1022,45 @@ func (r *Reconciler) handleRollingUpgrade(log logr.Logger, desiredPod, currentPo
 				}
 			}
 
+			restartedBrokerIDs, err := brokerIDsToRestart(r.KafkaCluster, currentPod.Labels[v1beta1.BrokerIdLabelKey], rack)
+			if err != nil {
+				return errors.WrapIf(err, "could not determine the brokers to restart")
+			}
+			restartedIDs := make([]string, 0, len(restartedBrokerIDs))
+			for _, brokerID := range restartedBrokerIDs {
+				restartedIDs = append(restartedIDs, strconv.Itoa(int(brokerID)))
+			}
+			// the partition checks only cover the restarted nodes which host partitions
+			restartedBrokerIDs, err = brokerNodeIDs(r.KafkaCluster, restartedBrokerIDs)
+			if err != nil {
+				return errors.WrapIf(err, "could not determine the process roles of the restarted brokers")
+			}
+
+			if err := r.reconcileRollingUpgradePause(log); err != nil {
+				return err
+			}
+
+			if rackRestartInProgress {
+				// the restart of the rack has been approved and its health checks passed before its first broker
+				// was restarted, the remaining brokers must still not take any partition offline
+				kClient, close, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+				if err != nil {
+					return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+				}
+				defer close()
+				if err := checkRackRestartSafety(kClient, rack, restartedBrokerIDs); err != nil {
+					return err
+				}
+				log.Info("restarting broker together with its rack", "rack", rack)
+				if err := r.restartBrokerPod(log, currentPod, desiredType); err != nil {
+					return err
+				}
+				return r.recordBrokerRestart(log, nil, currentPod.Labels[v1beta1.BrokerIdLabelKey])
+			}
+			if r.KafkaCluster.Spec.RollingUpgradeConfig.RequireApproval && !restartApproved(currentPod) {
+				return r.waitForRestartApproval(log, restartedIDs)
+			}
+
 			errorCount := r.KafkaCluster.Status.RollingUpgrade.ErrorCount
 
 			kClient, close, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
@@ -919,10 +1096,58 @@ func (r *Reconciler) handleRollingUpgrade(log logr.Logger, desiredPod, currentPo
 			if errorCount >= r.KafkaCluster.Spec.RollingUpgradeConfig.FailureThreshold {
 				return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("cluster is not healthy"), "rolling upgrade in progress")
 			}
+
+			// producers with acks=all would fail because of the restart, it is only refused when configured
+			blockingPartitions, err := kClient.PartitionsBlockingRestart(restartedBrokerIDs)
+			if err != nil {
+				return errors.WrapIf(err, "min.insync.replicas check failed")
+			}
+			if len(blockingPartitions) > 0 {
+				partitions := make([]string, 0, len(blockingPartitions))
+				for _, partition := range blockingPartitions {
+					partitions = append(partitions, partition.String())
+				}
+				log.Info("restart would take partitions below min.insync.replicas", "brokers", restartedBrokerIDs, "partitions", partitions)
+				if r.KafkaCluster.Spec.RollingUpgradeConfig.BlockUnderMinISR {
+					return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+						errors.NewWithDetails("partitions would go below min.insync.replicas", "brokers", restartedBrokerIDs, "partitions", partitions),
+						"broker can not be restarted safely")
+				}
+			}
+
+			if rack != "" {
+				if err := checkRackRestartSafety(kClient, rack, restartedBrokerIDs); err != nil {
+					return err
+				}
+				log.Info("starting the restart of the rack", "rack", rack)
+			}
+
+			if r.KafkaCluster.Spec.RollingUpgradeConfig.LeaderMigration {
+				// the previously restarted brokers get their leaderships back before the next ones are demoted
+				if err := r.reconcilePreferredLeaderElection(context.TODO(), log, kClient, restartedIDs); err != nil {
+					return err
+				}
+				if err := r.migrateLeadership(context.TODO(), log, kClient, restartedBrokerIDs); err != nil {
+					return err
+				}
+			}
+
+			if err := r.restartBrokerPod(log, currentPod, desiredType); err != nil {
+				return err
+			}
+			return r.recordBrokerRestart(log, restartedIDs, currentPod.Labels[v1beta1.BrokerIdLabelKey])
 		}
 	}
 
-	err = r.Client.Delete(context.TODO(), currentPod)
+	return r.restartBrokerPod(log, currentPod, desiredType)
+}
+
+// restartBrokerPod deletes the broker pod so that it is recreated with the desired spec
+func (r *Reconciler) restartBrokerPod(log logr.Logger, currentPod *corev1.Pod, desiredType reflect.Type) error {
+	if err := r.verifyDrainedLogDirsEmpty(log, currentPod); err != nil {
+		return err
+	}
+	err := r.Client.Delete(context.TODO(), currentPod)
 	if err != nil {
 		return errorfactory.New(errorfactory.APIFailure{}, err, "deleting resource failed", "kind", desiredType)
 	}
@@ -0,0 +1,236 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/scale"
+)
+
+// migrateLeadership moves the partition leaderships off the brokers with Cruise Control before they are restarted.
+// It returns nil once the brokers don't lead any partition which could be led by another broker, or when the
+// leadership migration timed out, so the brokers are restarted without moving their remaining leaderships.
+func (r *Reconciler) migrateLeadership(ctx context.Context, log logr.Logger, kClient kafkaclient.KafkaClient, brokerIDs []int32) error {
+	ledPartitions, err := kClient.LeadersOn(brokerIDs)
+	if err != nil {
+		return errors.WrapIf(err, "could not get the partitions led by the brokers")
+	}
+	startedAt := r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt
+	if len(ledPartitions) == 0 {
+		return r.updateLeaderMigrationStartedAt(log, nil)
+	}
+
+	ids := make([]string, 0, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		ids = append(ids, strconv.Itoa(int(brokerID)))
+	}
+
+	if startedAt == nil {
+		now := metav1.Now()
+		if err := r.updateLeaderMigrationStartedAt(log, &now); err != nil {
+			return err
+		}
+	} else if leaderMigrationTimedOut(startedAt, r.KafkaCluster.Spec.RollingUpgradeConfig.GetLeaderMigrationTimeout(), time.Now()) {
+		log.Info("leadership migration timed out, restarting the brokers without moving their remaining leaderships",
+			"brokers", ids, "partitions", ledPartitions, "startedAt", startedAt.Time)
+		return r.updateLeaderMigrationStartedAt(log, nil)
+	}
+
+	cruiseControlURL := scale.CruiseControlURLFromKafkaCluster(r.KafkaCluster)
+	cc, err := r.scaleFactory(ctx, r.KafkaCluster)
+	if err != nil {
+		return errorfactory.New(errorfactory.CruiseControlNotReady{}, err,
+			"failed to initialize Cruise Control Scaler", "cruise control url", cruiseControlURL)
+	}
+	status, err := cc.Status(ctx)
+	if err != nil {
+		return errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "could not get Cruise Control status")
+	}
+	// the demotion requested by an earlier reconciliation may still be in progress
+	if !status.InExecution() {
+		// the brokers are recorded before they are demoted, so they get their leaderships back even if the
+		// leadership migration is interrupted
+		if migrated := mergeBrokerIDs(r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers, ids); len(migrated) != len(r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers) {
+			if err := k8sutil.UpdateLeaderMigratedBrokers(r.Client, r.KafkaCluster, migrated, log); err != nil {
+				return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+			}
+		}
+		if _, err := cc.DemoteBrokers(ctx, ids...); err != nil {
+			return errorfactory.New(errorfactory.CruiseControlTaskFailure{}, err, "could not demote brokers", "brokers", ids)
+		}
+		log.Info("migrating partition leaderships off the brokers before their restart", "brokers", ids, "partitions", len(ledPartitions))
+	}
+
+	return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+		errors.NewWithDetails("brokers still lead partitions", "brokers", ids, "partitions", len(ledPartitions)),
+		"leadership migration in progress")
+}
+
+// leaderMigrationTimedOut returns true when the leadership migration started at the given time is taking longer
+// than the timeout
+func leaderMigrationTimedOut(startedAt *metav1.Time, timeout time.Duration, now time.Time) bool {
+	return startedAt != nil && now.After(startedAt.Add(timeout))
+}
+
+func (r *Reconciler) updateLeaderMigrationStartedAt(log logr.Logger, startedAt *metav1.Time) error {
+	if r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt.Equal(startedAt) {
+		return nil
+	}
+	err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.LeaderMigrationStartedAt = startedAt
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	log.V(1).Info("leadership migration start time updated", "startedAt", startedAt)
+	return nil
+}
+
+// reconcilePreferredLeaderElection runs the preferred leader election once the brokers whose leaderships were
+// migrated before their restart are back in sync. The brokers which are about to be restarted are left out.
+func (r *Reconciler) reconcilePreferredLeaderElection(ctx context.Context, log logr.Logger, kClient kafkaclient.KafkaClient, restartedBrokerIDs []string) error {
+	var waiting, remaining []string
+	for _, brokerID := range r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers {
+		if apiutil.StringSliceContains(restartedBrokerIDs, brokerID) {
+			remaining = append(remaining, brokerID)
+		} else {
+			waiting = append(waiting, brokerID)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+
+	inSync, err := r.brokersBackInSync(ctx, kClient, waiting)
+	if err != nil {
+		return err
+	}
+	if !inSync {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			errors.NewWithDetails("restarted brokers are not back in sync yet", "brokers", waiting),
+			"waiting for preferred leader election")
+	}
+
+	cruiseControlURL := scale.CruiseControlURLFromKafkaCluster(r.KafkaCluster)
+	cc, err := r.scaleFactory(ctx, r.KafkaCluster)
+	if err != nil {
+		return errorfactory.New(errorfactory.CruiseControlNotReady{}, err,
+			"failed to initialize Cruise Control Scaler", "cruise control url", cruiseControlURL)
+	}
+	status, err := cc.Status(ctx)
+	if err != nil {
+		return errorfactory.New(errorfactory.CruiseControlNotReady{}, err, "could not get Cruise Control status")
+	}
+	if status.InExecution() {
+		return errorfactory.New(errorfactory.CruiseControlTaskRunning{},
+			errors.New("Cruise Control is executing another task"), "waiting for preferred leader election")
+	}
+	if _, err := cc.ElectPreferredLeaders(ctx, waiting...); err != nil {
+		return errorfactory.New(errorfactory.CruiseControlTaskFailure{}, err, "preferred leader election failed", "brokers", waiting)
+	}
+	log.Info("preferred leader election started for the restarted brokers", "brokers", waiting)
+
+	if err := k8sutil.UpdateLeaderMigratedBrokers(r.Client, r.KafkaCluster, remaining, log); err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	return nil
+}
+
+// brokersBackInSync returns true when the pods of the brokers are ready and the brokers have neither
+// offline nor out of sync replicas
+func (r *Reconciler) brokersBackInSync(ctx context.Context, kClient kafkaclient.KafkaClient, brokerIDs []string) (bool, error) {
+	podList := &corev1.PodList{}
+	err := r.Client.List(ctx, podList,
+		client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(apiutil.LabelsForKafka(r.KafkaCluster.Name)),
+	)
+	if err != nil {
+		return false, errors.WrapIf(err, "failed to list broker pods")
+	}
+	readyBrokers := make(map[string]struct{}, len(podList.Items))
+	for i := range podList.Items {
+		if isPodReady(&podList.Items[i]) {
+			readyBrokers[podList.Items[i].Labels[v1beta1.BrokerIdLabelKey]] = struct{}{}
+		}
+	}
+
+	offlineReplicas, err := kClient.AllOfflineReplicas()
+	if err != nil {
+		return false, errors.WrapIf(err, "health check failed")
+	}
+	outOfSyncReplicas, err := kClient.OutOfSyncReplicas()
+	if err != nil {
+		return false, errors.WrapIf(err, "health check failed")
+	}
+	impactedBrokers := make(map[string]struct{}, len(offlineReplicas)+len(outOfSyncReplicas))
+	for _, brokerID := range append(offlineReplicas, outOfSyncReplicas...) {
+		impactedBrokers[strconv.Itoa(int(brokerID))] = struct{}{}
+	}
+
+	kafkaBrokers := kClient.Brokers()
+	for _, brokerID := range brokerIDs {
+		if _, ok := readyBrokers[brokerID]; !ok {
+			return false, nil
+		}
+		if _, ok := impactedBrokers[brokerID]; ok {
+			return false, nil
+		}
+		id, err := strconv.ParseInt(brokerID, 10, 32)
+		if err != nil {
+			return false, err
+		}
+		if _, ok := kafkaBrokers[int32(id)]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if k8sutil.IsMarkedForDeletion(pod.ObjectMeta) {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// mergeBrokerIDs returns the broker IDs of both lists without duplicates
+func mergeBrokerIDs(brokerIDs []string, others []string) []string {
+	merged := append([]string(nil), brokerIDs...)
+	for _, brokerID := range others {
+		if !apiutil.StringSliceContains(merged, brokerID) {
+			merged = append(merged, brokerID)
+		}
+	}
+	return merged
+}
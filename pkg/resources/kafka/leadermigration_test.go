@@ -0,0 +1,223 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/controllers/tests/mocks"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/resources"
+	"github.com/banzaicloud/koperator/pkg/scale"
+)
+
+func TestMergeBrokerIDs(t *testing.T) {
+	assert.Equal(t, []string{"1", "2"}, mergeBrokerIDs(nil, []string{"1", "2"}))
+	assert.Equal(t, []string{"1", "2", "3"}, mergeBrokerIDs([]string{"1", "2"}, []string{"2", "3"}))
+	assert.Equal(t, []string{"1"}, mergeBrokerIDs([]string{"1"}, nil))
+}
+
+func TestIsPodReady(t *testing.T) {
+	pod := &corev1.Pod{}
+	assert.False(t, isPodReady(pod))
+
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}
+	assert.False(t, isPodReady(pod))
+
+	pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	assert.True(t, isPodReady(pod))
+
+	now := metav1.Now()
+	pod.DeletionTimestamp = &now
+	assert.False(t, isPodReady(pod))
+}
+
+func TestLeaderMigrationTimedOut(t *testing.T) {
+	now := time.Now()
+	assert.False(t, leaderMigrationTimedOut(nil, 10*time.Minute, now))
+
+	startedAt := metav1.NewTime(now.Add(-5 * time.Minute))
+	assert.False(t, leaderMigrationTimedOut(&startedAt, 10*time.Minute, now))
+	assert.True(t, leaderMigrationTimedOut(&startedAt, time.Minute, now))
+}
+
+func newLeaderMigrationReconciler(t *testing.T, kClient *kafkaClientStub, scaler scale.CruiseControlScaler, objects ...client.Object) (*Reconciler, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			RollingUpgradeConfig: v1beta1.RollingUpgradeConfig{FailureThreshold: 1, LeaderMigration: true},
+			Brokers:              []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{}}, {Id: 1, BrokerConfig: &v1beta1.BrokerConfig{}}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			State:          v1beta1.KafkaClusterRollingUpgrading,
+			RollingUpgrade: v1beta1.RollingUpgradeStatus{PendingBrokers: []string{"0", "1"}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, cluster)...).Build()
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fakeClient,
+			KafkaCluster: cluster,
+		},
+		kafkaClientProvider: kafkaClientProviderStub{client: kClient},
+		scaleFactory: func(context.Context, *v1beta1.KafkaCluster) (scale.CruiseControlScaler, error) {
+			require.NotNil(t, scaler, "Cruise Control must not be called")
+			return scaler, nil
+		},
+	}, fakeClient
+}
+
+func newReadyBrokerPod(brokerID string) *corev1.Pod {
+	pod := newBrokerPod(brokerID)
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	return pod
+}
+
+func TestMigrateLeadership(t *testing.T) {
+	ctx := context.Background()
+	mockCtrl := gomock.NewController(t)
+	scaler := mocks.NewMockCruiseControlScaler(mockCtrl)
+	kClient := &kafkaClientStub{leadersOn: []string{"test-topic-0"}}
+	r, _ := newLeaderMigrationReconciler(t, kClient, scaler)
+
+	// the leaderships are moved off the broker once Cruise Control is idle
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: true}, nil)
+	scaler.EXPECT().DemoteBrokers(gomock.Any(), "1").Return(&scale.Result{}, nil)
+	err := r.migrateLeadership(ctx, logr.Discard(), kClient, []int32{1})
+	assert.True(t, errors.As(err, &errorfactory.ReconcileRollingUpgrade{}), err)
+	assert.Equal(t, []string{"1"}, r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers)
+	startedAt := r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt
+	require.NotNil(t, startedAt)
+
+	// the demotion is not requested again while it is executed
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: false}, nil)
+	err = r.migrateLeadership(ctx, logr.Discard(), kClient, []int32{1})
+	assert.True(t, errors.As(err, &errorfactory.ReconcileRollingUpgrade{}), err)
+	assert.Equal(t, startedAt, r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt)
+
+	// the broker is restarted with its remaining leaderships once the migration timed out
+	timedOut := metav1.NewTime(time.Now().Add(-time.Hour))
+	r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt = &timedOut
+	require.NoError(t, r.migrateLeadership(ctx, logr.Discard(), kClient, []int32{1}))
+	assert.Nil(t, r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt)
+
+	// the broker is restarted right away when it does not lead any partition
+	kClient.leadersOn = nil
+	r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt = &timedOut
+	require.NoError(t, r.migrateLeadership(ctx, logr.Discard(), kClient, []int32{1}))
+	assert.Nil(t, r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt)
+}
+
+func TestReconcilePreferredLeaderElection(t *testing.T) {
+	ctx := context.Background()
+	mockCtrl := gomock.NewController(t)
+	scaler := mocks.NewMockCruiseControlScaler(mockCtrl)
+	kClient := &kafkaClientStub{brokers: map[int32]string{0: "kafka-0:9092", 1: "kafka-1:9092"}}
+	restartedPod := newBrokerPod("0")
+	r, fakeClient := newLeaderMigrationReconciler(t, kClient, scaler, restartedPod, newReadyBrokerPod("1"))
+	r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers = []string{"0", "1"}
+
+	// the restarted broker is not ready yet, broker 1 is about to be restarted
+	err := r.reconcilePreferredLeaderElection(ctx, logr.Discard(), kClient, []string{"1"})
+	assert.True(t, errors.As(err, &errorfactory.ReconcileRollingUpgrade{}), err)
+
+	restartedPod.Status.Conditions = newReadyBrokerPod("0").Status.Conditions
+	require.NoError(t, fakeClient.Status().Update(ctx, restartedPod))
+
+	// Cruise Control is busy
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: false}, nil)
+	err = r.reconcilePreferredLeaderElection(ctx, logr.Discard(), kClient, []string{"1"})
+	assert.True(t, errors.As(err, &errorfactory.CruiseControlTaskRunning{}), err)
+
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: true}, nil)
+	scaler.EXPECT().ElectPreferredLeaders(gomock.Any(), "0").Return(&scale.Result{}, nil)
+	require.NoError(t, r.reconcilePreferredLeaderElection(ctx, logr.Discard(), kClient, []string{"1"}))
+	assert.Equal(t, []string{"1"}, r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers)
+
+	// nothing to do when only the brokers about to be restarted have migrated leaderships
+	require.NoError(t, r.reconcilePreferredLeaderElection(ctx, logr.Discard(), kClient, []string{"1"}))
+}
+
+// TestRollingUpgradeLeaderMigration covers the leadership migration of a rolling upgrade: the leaderships of the first
+// broker are demoted, the broker is restarted once the migration timed out, and its leaderships are given back by a
+// preferred leader election before the next broker is restarted
+func TestRollingUpgradeLeaderMigration(t *testing.T) {
+	ctx := context.Background()
+	mockCtrl := gomock.NewController(t)
+	scaler := mocks.NewMockCruiseControlScaler(mockCtrl)
+	kClient := &kafkaClientStub{
+		brokers:   map[int32]string{0: "kafka-0:9092", 1: "kafka-1:9092"},
+		leadersOn: []string{"test-topic-0"},
+	}
+	r, fakeClient := newLeaderMigrationReconciler(t, kClient, scaler, newReadyBrokerPod("0"), newReadyBrokerPod("1"))
+
+	restart := func(brokerID string) error {
+		currentPod := &corev1.Pod{}
+		require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "kafka-" + brokerID, Namespace: "kafka"}, currentPod))
+		desiredPod := currentPod.DeepCopy()
+		desiredPod.Spec.Containers[0].Image = "kafka:2"
+		return r.handleRollingUpgrade(logr.Discard(), desiredPod, currentPod, reflect.TypeOf(desiredPod))
+	}
+	podExists := func(brokerID string) bool {
+		err := fakeClient.Get(ctx, client.ObjectKey{Name: "kafka-" + brokerID, Namespace: "kafka"}, &corev1.Pod{})
+		return !apierrors.IsNotFound(err)
+	}
+
+	// demote
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: true}, nil)
+	scaler.EXPECT().DemoteBrokers(gomock.Any(), "0").Return(&scale.Result{}, nil)
+	err := restart("0")
+	assert.True(t, errors.As(err, &errorfactory.ReconcileRollingUpgrade{}), err)
+	assert.True(t, podExists("0"))
+	assert.Equal(t, []string{"0"}, r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers)
+
+	// timeout and restart
+	timedOut := metav1.NewTime(time.Now().Add(-time.Hour))
+	r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt = &timedOut
+	require.NoError(t, restart("0"))
+	assert.False(t, podExists("0"))
+	assert.Nil(t, r.KafkaCluster.Status.RollingUpgrade.LeaderMigrationStartedAt)
+	assert.Equal(t, []string{"0"}, r.KafkaCluster.Status.RollingUpgrade.CompletedBrokers)
+
+	// election once the restarted broker is back, then the next broker is restarted without leaderships to move
+	require.NoError(t, fakeClient.Create(ctx, newReadyBrokerPod("0")))
+	kClient.leadersOn = nil
+	scaler.EXPECT().Status(gomock.Any()).Return(scale.CruiseControlStatus{ExecutorReady: true}, nil)
+	scaler.EXPECT().ElectPreferredLeaders(gomock.Any(), "0").Return(&scale.Result{}, nil)
+	require.NoError(t, restart("1"))
+	assert.False(t, podExists("1"))
+	assert.Empty(t, r.KafkaCluster.Status.RollingUpgrade.LeaderMigratedBrokers)
+	assert.Equal(t, []string{"0", "1"}, r.KafkaCluster.Status.RollingUpgrade.CompletedBrokers)
+}
@@ -0,0 +1,298 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+// isStorageDrained returns true when the partition replicas were moved off the log dir of the storage which
+// is marked to be drained, so it is not used by the broker anymore
+func isStorageDrained(cluster *v1beta1.KafkaCluster, brokerID int32, storage v1beta1.StorageConfig) bool {
+	if !storage.Drain {
+		return false
+	}
+	volumeState, ok := cluster.Status.BrokersState[strconv.Itoa(int(brokerID))].GracefulActionState.VolumeStates[storage.MountPath]
+	return ok && volumeState.CruiseControlVolumeState == v1beta1.GracefulDiskDrained
+}
+
+// drainedMountPaths returns the mount paths of the drained storages of the broker
+func drainedMountPaths(cluster *v1beta1.KafkaCluster, brokerID int32) []string {
+	var mountPaths []string
+	for _, broker := range cluster.Spec.Brokers {
+		if broker.Id != brokerID {
+			continue
+		}
+		brokerConfig, err := broker.GetBrokerConfig(cluster.Spec)
+		if err != nil {
+			return nil
+		}
+		for _, storage := range brokerConfig.StorageConfigs {
+			if isStorageDrained(cluster, brokerID, storage) {
+				mountPaths = append(mountPaths, storage.MountPath)
+			}
+		}
+	}
+	return mountPaths
+}
+
+// withoutDrainedStorages returns the broker config without the drained storages of the broker, so their volumes
+// are neither created nor mounted again
+func withoutDrainedStorages(cluster *v1beta1.KafkaCluster, brokerID int32, brokerConfig *v1beta1.BrokerConfig) *v1beta1.BrokerConfig {
+	if brokerConfig == nil {
+		return nil
+	}
+	drained := false
+	for _, storage := range brokerConfig.StorageConfigs {
+		if isStorageDrained(cluster, brokerID, storage) {
+			drained = true
+			break
+		}
+	}
+	if !drained {
+		return brokerConfig
+	}
+
+	brokerConfig = brokerConfig.DeepCopy()
+	storageConfigs := make([]v1beta1.StorageConfig, 0, len(brokerConfig.StorageConfigs))
+	for _, storage := range brokerConfig.StorageConfigs {
+		if !isStorageDrained(cluster, brokerID, storage) {
+			storageConfigs = append(storageConfigs, storage)
+		}
+	}
+	brokerConfig.StorageConfigs = storageConfigs
+	return brokerConfig
+}
+
+// reconcileLogDirDrains moves the partition replicas off the log dirs of the storages marked to be drained with
+// Cruise Control rebalances and marks the storages drained once their log dirs are empty. The PVCs of the drained
+// storages are deleted afterwards.
+// Kafka can not be kept from placing new partition replicas on a log dir while the broker uses it, so the log dirs
+// are checked again right before the broker is restarted without them.
+func (r *Reconciler) reconcileLogDirDrains(ctx context.Context, log logr.Logger) error {
+	var (
+		kClient kafkaclient.KafkaClient
+		drained = make(map[string]map[string]struct{})
+	)
+
+	brokerIDs := make([]string, 0)
+	brokersVolumeStates := make(map[string]map[string]v1beta1.VolumeState)
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerConfig, err := broker.GetBrokerConfig(r.KafkaCluster.Spec)
+		if err != nil {
+			return errors.WrapIf(err, "failed to reconcile resource")
+		}
+		brokerID := strconv.Itoa(int(broker.Id))
+		brokerState, ok := r.KafkaCluster.Status.BrokersState[brokerID]
+		if !ok {
+			continue
+		}
+
+		volumeStates := make(map[string]v1beta1.VolumeState)
+		for _, storage := range brokerConfig.StorageConfigs {
+			if !storage.Drain || storage.PvcSpec == nil {
+				continue
+			}
+			if isStorageDrained(r.KafkaCluster, broker.Id, storage) {
+				if _, ok := drained[brokerID]; !ok {
+					drained[brokerID] = make(map[string]struct{})
+				}
+				drained[brokerID][storage.MountPath] = struct{}{}
+				continue
+			}
+			// the log dir is being rebalanced, its replicas are checked once the rebalance is over
+			if volumeState, ok := brokerState.GracefulActionState.VolumeStates[storage.MountPath]; ok &&
+				volumeState.CruiseControlVolumeState.IsActive() {
+				continue
+			}
+
+			if kClient == nil {
+				var closeClient func()
+				kClient, closeClient, err = r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+				if err != nil {
+					return errorfactory.New(errorfactory.BrokersUnreachable{}, err, "could not connect to kafka brokers")
+				}
+				defer closeClient()
+			}
+
+			logDir := util.StorageConfigKafkaMountPath(storage.MountPath)
+			partitions, err := kClient.ReplicasOnLogDir(broker.Id, logDir)
+			if err != nil {
+				return errorfactory.New(errorfactory.BrokersUnreachable{}, err,
+					"could not get the partition replicas on the log dir", v1beta1.BrokerIdLabelKey, brokerID, "logDir", logDir)
+			}
+			if len(partitions) > 0 {
+				log.Info("draining log dir of the broker", v1beta1.BrokerIdLabelKey, brokerID, "logDir", logDir, "partitions", len(partitions))
+				volumeStates[storage.MountPath] = v1beta1.VolumeState{CruiseControlVolumeState: v1beta1.GracefulDiskRebalanceRequired}
+				continue
+			}
+			log.Info("log dir of the broker is drained", v1beta1.BrokerIdLabelKey, brokerID, "logDir", logDir)
+			volumeStates[storage.MountPath] = v1beta1.VolumeState{CruiseControlVolumeState: v1beta1.GracefulDiskDrained}
+		}
+		if len(volumeStates) > 0 {
+			brokerIDs = append(brokerIDs, brokerID)
+			brokersVolumeStates[brokerID] = volumeStates
+		}
+	}
+
+	if len(brokersVolumeStates) > 0 {
+		if err := k8sutil.UpdateBrokerStatus(r.Client, brokerIDs, r.KafkaCluster, brokersVolumeStates, log); err != nil {
+			return errors.WrapIfWithDetails(err, "could not update status for brokers", "brokers", brokerIDs)
+		}
+	}
+
+	if len(drained) == 0 {
+		return nil
+	}
+	return r.deleteDrainedStoragePVCs(ctx, log, drained)
+}
+
+// deleteDrainedStoragePVCs deletes the PVCs of the drained storages once no broker pod uses them, since the log dir
+// of a mounted storage could get new partition replicas until the broker is restarted without it
+func (r *Reconciler) deleteDrainedStoragePVCs(ctx context.Context, log logr.Logger, drained map[string]map[string]struct{}) error {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err := r.Client.List(ctx, pvcList,
+		client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(apiutil.LabelsForKafka(r.KafkaCluster.Name)),
+	)
+	if err != nil {
+		return errors.WrapIf(err, "failed to list PVCs")
+	}
+	podList := &corev1.PodList{}
+	err = r.Client.List(ctx, podList,
+		client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(apiutil.LabelsForKafka(r.KafkaCluster.Name)),
+	)
+	if err != nil {
+		return errors.WrapIf(err, "failed to list pods")
+	}
+	claimsInUse := make(map[string]struct{})
+	for _, pod := range podList.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claimsInUse[volume.PersistentVolumeClaim.ClaimName] = struct{}{}
+			}
+		}
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		brokerID := pvc.Labels[v1beta1.BrokerIdLabelKey]
+		mountPath := pvc.Annotations["mountPath"]
+		if _, ok := drained[brokerID][mountPath]; !ok || k8sutil.IsMarkedForDeletion(pvc.ObjectMeta) {
+			continue
+		}
+		if _, ok := claimsInUse[pvc.Name]; ok {
+			log.V(1).Info("pvc of the drained storage is still used by the broker", v1beta1.BrokerIdLabelKey, brokerID,
+				"mountPath", mountPath, "pvc name", pvc.Name)
+			continue
+		}
+		if err := r.Client.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+			return errors.WrapIfWithDetails(err, "could not delete pvc of the drained storage", "id", brokerID, "mountPath", mountPath)
+		}
+		log.Info(fmt.Sprintf("pvc of the drained storage %s of broker %s deleted", mountPath, brokerID), "pvc name", pvc.Name)
+	}
+	return nil
+}
+
+// drainedLogDirsInUse returns the mount paths of the drained storages which are still mounted by the broker pod
+func drainedLogDirsInUse(cluster *v1beta1.KafkaCluster, brokerID int32, pod *corev1.Pod) []string {
+	drained := drainedMountPaths(cluster, brokerID)
+	if len(drained) == 0 {
+		return nil
+	}
+
+	var mountPaths []string
+	for _, container := range pod.Spec.Containers {
+		if container.Name != kafkaContainerName {
+			continue
+		}
+		for _, volumeMount := range container.VolumeMounts {
+			if util.StringSliceContains(drained, volumeMount.MountPath) {
+				mountPaths = append(mountPaths, volumeMount.MountPath)
+			}
+		}
+	}
+	return mountPaths
+}
+
+// verifyDrainedLogDirsEmpty checks right before the restart of the broker that no partition replica was placed on
+// the log dirs of its drained storages since they were marked drained, as the restarted broker does not use them
+// anymore. The log dirs which are not empty, or which could not be checked, are drained again and the restart
+// is postponed.
+func (r *Reconciler) verifyDrainedLogDirsEmpty(log logr.Logger, pod *corev1.Pod) error {
+	brokerID := pod.Labels[v1beta1.BrokerIdLabelKey]
+	id, err := strconv.ParseInt(brokerID, 10, 32)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "invalid broker id", "id", brokerID)
+	}
+	mountPaths := drainedLogDirsInUse(r.KafkaCluster, int32(id), pod)
+	if len(mountPaths) == 0 {
+		return nil
+	}
+
+	notEmpty := mountPaths
+	kClient, closeClient, err := r.kafkaClientProvider.NewFromCluster(r.Client, r.KafkaCluster)
+	if err != nil {
+		log.Error(err, "could not verify that the drained log dirs of the broker are empty", v1beta1.BrokerIdLabelKey, brokerID)
+	} else {
+		defer closeClient()
+		notEmpty = nil
+		for _, mountPath := range mountPaths {
+			logDir := util.StorageConfigKafkaMountPath(mountPath)
+			partitions, err := kClient.ReplicasOnLogDir(int32(id), logDir)
+			if err != nil {
+				log.Error(err, "could not verify that the drained log dir of the broker is empty",
+					v1beta1.BrokerIdLabelKey, brokerID, "logDir", logDir)
+				notEmpty = append(notEmpty, mountPath)
+				continue
+			}
+			if len(partitions) > 0 {
+				log.Info("partition replicas were placed on the drained log dir of the broker",
+					v1beta1.BrokerIdLabelKey, brokerID, "logDir", logDir, "partitions", partitions)
+				notEmpty = append(notEmpty, mountPath)
+			}
+		}
+	}
+	if len(notEmpty) == 0 {
+		return nil
+	}
+
+	volumeStates := make(map[string]v1beta1.VolumeState, len(notEmpty))
+	for _, mountPath := range notEmpty {
+		volumeStates[mountPath] = v1beta1.VolumeState{CruiseControlVolumeState: v1beta1.GracefulDiskRebalanceRequired}
+	}
+	if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerID}, r.KafkaCluster, volumeStates, log); err != nil {
+		return errors.WrapIfWithDetails(err, "could not update status for broker", "id", brokerID)
+	}
+	return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+		errors.NewWithDetails("drained log dirs are not empty", v1beta1.BrokerIdLabelKey, brokerID, "mountPaths", notEmpty),
+		"the log dirs are drained again before the broker is restarted")
+}
@@ -0,0 +1,159 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/resources"
+)
+
+func TestWithoutDrainedStorages(t *testing.T) {
+	brokerConfig := &v1beta1.BrokerConfig{
+		StorageConfigs: []v1beta1.StorageConfig{
+			{MountPath: "/kafka-logs1"},
+			{MountPath: "/kafka-logs2", Drain: true},
+			{MountPath: "/kafka-logs3", Drain: true},
+		},
+	}
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{
+				{Id: 0, BrokerConfig: brokerConfig},
+				{Id: 1, BrokerConfig: brokerConfig},
+			},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{VolumeStates: map[string]v1beta1.VolumeState{
+					"/kafka-logs2": {CruiseControlVolumeState: v1beta1.GracefulDiskDrained},
+					"/kafka-logs3": {CruiseControlVolumeState: v1beta1.GracefulDiskRebalanceRunning},
+				}}},
+			},
+		},
+	}
+
+	got := withoutDrainedStorages(cluster, 0, brokerConfig)
+	assert.Equal(t, []v1beta1.StorageConfig{
+		{MountPath: "/kafka-logs1"},
+		{MountPath: "/kafka-logs3", Drain: true},
+	}, got.StorageConfigs)
+	assert.Len(t, brokerConfig.StorageConfigs, 3, "the original broker config must not be modified")
+	assert.Equal(t, []string{"/kafka-logs2"}, drainedMountPaths(cluster, 0))
+
+	assert.Same(t, brokerConfig, withoutDrainedStorages(cluster, 1, brokerConfig))
+	assert.Empty(t, drainedMountPaths(cluster, 1))
+}
+
+func TestDeleteDrainedStoragePVCs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	pvc := func(name, brokerID, mountPath string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "kafka",
+			Labels:      apiutil.MergeLabels(apiutil.LabelsForKafka("kafka"), map[string]string{v1beta1.BrokerIdLabelKey: brokerID}),
+			Annotations: map[string]string{"mountPath": mountPath},
+		}}
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pvc("kafka-0-storage-0", "0", "/kafka-logs1"),
+		pvc("kafka-0-storage-1", "0", "/kafka-logs2"),
+		pvc("kafka-1-storage-1", "1", "/kafka-logs2"),
+		pvc("kafka-2-storage-1", "2", "/kafka-logs2"),
+		// the broker 2 has not been restarted without its drained storage yet
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kafka-2",
+				Namespace: "kafka",
+				Labels:    apiutil.MergeLabels(apiutil.LabelsForKafka("kafka"), map[string]string{v1beta1.BrokerIdLabelKey: "2"}),
+			},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "kafka-data-0",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "kafka-2-storage-1"}},
+			}}},
+		},
+	).Build()
+
+	r := Reconciler{
+		Reconciler: resources.Reconciler{
+			Client: fakeClient,
+			KafkaCluster: &v1beta1.KafkaCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+			},
+		},
+	}
+	err := r.deleteDrainedStoragePVCs(context.Background(), logr.Discard(),
+		map[string]map[string]struct{}{"0": {"/kafka-logs2": {}}, "2": {"/kafka-logs2": {}}})
+	assert.NoError(t, err)
+
+	for name, deleted := range map[string]bool{
+		"kafka-0-storage-0": false,
+		"kafka-0-storage-1": true,
+		"kafka-1-storage-1": false,
+		"kafka-2-storage-1": false,
+	} {
+		err := fakeClient.Get(context.Background(), client.ObjectKey{Name: name, Namespace: "kafka"}, &corev1.PersistentVolumeClaim{})
+		assert.Equal(t, deleted, apierrors.IsNotFound(err), name)
+	}
+}
+
+func TestDrainedLogDirsInUse(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{
+				Id: 0,
+				BrokerConfig: &v1beta1.BrokerConfig{StorageConfigs: []v1beta1.StorageConfig{
+					{MountPath: "/kafka-logs1"},
+					{MountPath: "/kafka-logs2", Drain: true},
+					{MountPath: "/kafka-logs3", Drain: true},
+				}},
+			}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{VolumeStates: map[string]v1beta1.VolumeState{
+					"/kafka-logs2": {CruiseControlVolumeState: v1beta1.GracefulDiskDrained},
+					"/kafka-logs3": {CruiseControlVolumeState: v1beta1.GracefulDiskDrained},
+				}}},
+			},
+		},
+	}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name: kafkaContainerName,
+		VolumeMounts: []corev1.VolumeMount{
+			{MountPath: "/kafka-logs1"},
+			{MountPath: "/kafka-logs2"},
+		},
+	}}}}
+
+	assert.Equal(t, []string{"/kafka-logs2"}, drainedLogDirsInUse(cluster, 0, pod))
+
+	pod.Spec.Containers[0].VolumeMounts = pod.Spec.Containers[0].VolumeMounts[:1]
+	assert.Empty(t, drainedLogDirsInUse(cluster, 0, pod))
+}
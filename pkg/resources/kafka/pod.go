@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	apiutil "github.com/banzaicloud/koperator/api/util"
 	"github.com/banzaicloud/koperator/api/v1beta1"
@@ -90,7 +91,7 @@ func (r *Reconciler) pod(id int32, brokerConfig *v1beta1.BrokerConfig, pvcs []co
 	pod := &corev1.Pod{
 		ObjectMeta: templates.ObjectMetaWithGeneratedNameAndAnnotations(
 			fmt.Sprintf("%s-%d-", r.KafkaCluster.Name, id),
-			brokerConfig.GetBrokerLabels(r.KafkaCluster.Name, id),
+			brokerPodLabels(r.KafkaCluster, brokerConfig, id),
 			brokerConfig.GetBrokerAnnotations(),
 			r.KafkaCluster,
 		),
@@ -121,24 +122,7 @@ fi`},
 						},
 					},
 					SecurityContext: brokerConfig.SecurityContext,
-					Env: generateEnvConfig(brokerConfig, []corev1.EnvVar{
-						{
-							Name:  "CLASSPATH",
-							Value: "/opt/kafka/libs/extensions/*",
-						},
-						{
-							Name:  "KAFKA_OPTS",
-							Value: "-javaagent:/opt/jmx-exporter/jmx_prometheus.jar=9020:/etc/jmx-exporter/config.yaml",
-						},
-						{
-							Name: "ENVOY_SIDECAR_STATUS",
-							ValueFrom: &corev1.EnvVarSource{
-								FieldRef: &corev1.ObjectFieldSelector{
-									FieldPath: `metadata.annotations['sidecar.istio.io/status']`,
-								},
-							},
-						},
-					}),
+					Env:             generateEnvConfig(brokerConfig, r.getDefaultEnvVars()),
 
 					Command: command,
 					Ports: append(kafkaBrokerContainerPorts, []corev1.ContainerPort{
@@ -170,6 +154,35 @@ fi`},
 	return pod
 }
 
+func (r *Reconciler) getDefaultEnvVars() []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "CLASSPATH",
+			Value: "/opt/kafka/libs/extensions/*",
+		},
+		{
+			Name:  "KAFKA_OPTS",
+			Value: "-javaagent:/opt/jmx-exporter/jmx_prometheus.jar=9020:/etc/jmx-exporter/config.yaml",
+		},
+		{
+			Name: "ENVOY_SIDECAR_STATUS",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: `metadata.annotations['sidecar.istio.io/status']`,
+				},
+			},
+		},
+	}
+	// the broker storage is formatted with the cluster ID by the startup script in KRaft mode
+	if r.KafkaCluster.Spec.KRaftMode {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "CLUSTER_ID",
+			Value: r.KafkaCluster.Status.ClusterID,
+		})
+	}
+	return envVars
+}
+
 func getInitContainers(brokerConfig *v1beta1.BrokerConfig, kafkaClusterSpec v1beta1.KafkaClusterSpec) []corev1.Container {
 	initContainers := make([]corev1.Container, 0, len(brokerConfig.InitContainers))
 	initContainers = append(initContainers, brokerConfig.InitContainers...)
@@ -551,3 +564,21 @@ func generateEnvConfig(brokerConfig *v1beta1.BrokerConfig, defaultEnvVars []core
 
 	return mergedEnv
 }
+
+// brokerPodLabels returns the labels of the broker pod, which include the broker config group of the broker
+// so that the pods of a group can be selected
+func brokerPodLabels(cluster *v1beta1.KafkaCluster, brokerConfig *v1beta1.BrokerConfig, id int32) map[string]string {
+	labels := brokerConfig.GetBrokerLabels(cluster.Name, id)
+	// the broker config group label is reserved for the operator
+	delete(labels, v1beta1.BrokerConfigGroupLabelKey)
+	for _, broker := range cluster.Spec.Brokers {
+		if broker.Id != id {
+			continue
+		}
+		if broker.BrokerConfigGroup != "" && len(validation.IsValidLabelValue(broker.BrokerConfigGroup)) == 0 {
+			labels[v1beta1.BrokerConfigGroupLabelKey] = broker.BrokerConfigGroup
+		}
+		break
+	}
+	return labels
+}
@@ -185,3 +185,18 @@ func Test_generateEnvConfig(t *testing.T) {
 		t.Error("Expected:", expected, "Got:", result)
 	}
 }
+
+func TestBrokerPodLabels(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0, BrokerConfigGroup: "default"}, {Id: 1}},
+		},
+	}
+	brokerConfig := &v1beta1.BrokerConfig{BrokerLabels: map[string]string{"brokerConfigGroup": "custom"}}
+
+	assert.DeepEqual(t, map[string]string{"app": "kafka", "kafka_cr": "kafka", "brokerId": "0", "brokerConfigGroup": "default"},
+		brokerPodLabels(cluster, brokerConfig, 0))
+	assert.DeepEqual(t, map[string]string{"app": "kafka", "kafka_cr": "kafka", "brokerId": "1"},
+		brokerPodLabels(cluster, brokerConfig, 1))
+}
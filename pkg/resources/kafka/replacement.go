@@ -0,0 +1,177 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+)
+
+// reconcileBrokerReplacement replaces the brokers whose pod is annotated with "kafka.banzaicloud.io/replace-broker: true",
+// e.g. because their node and local storage are lost. The stale pod and PVCs of the broker are deleted, thus the broker
+// is recreated with new storage, and once it is back its partitions are re-replicated by a Cruise Control add_broker
+// operation. The progress is tracked in the replacementState of the GracefulActionState of the broker.
+func (r *Reconciler) reconcileBrokerReplacement(ctx context.Context, log logr.Logger) error {
+	podList := &corev1.PodList{}
+	err := r.Client.List(ctx, podList,
+		client.InNamespace(r.KafkaCluster.Namespace),
+		client.MatchingLabels(apiutil.LabelsForKafka(r.KafkaCluster.Name)),
+	)
+	if err != nil {
+		return errors.WrapIf(err, "failed to list broker pods that belong to Kafka cluster")
+	}
+	if requested := brokersRequestedForReplacement(r.KafkaCluster, podList.Items); len(requested) > 0 {
+		log.Info("broker replacement requested", "brokers", requested)
+		if err := k8sutil.UpdateBrokerStatus(r.Client, requested, r.KafkaCluster, v1beta1.BrokerReplacementRequired, log); err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update broker replacement state")
+		}
+	}
+
+	var pendingBrokers []string
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		brokerID := strconv.Itoa(int(broker.Id))
+		brokerState, ok := r.KafkaCluster.Status.BrokersState[brokerID]
+		if !ok {
+			continue
+		}
+		switch brokerState.GracefulActionState.ReplacementState {
+		case v1beta1.BrokerReplacementRequired:
+			deleted, err := r.deleteStaleBrokerResources(ctx, log, brokerID)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				pendingBrokers = append(pendingBrokers, brokerID)
+				continue
+			}
+			// the broker is added to Cruise Control again once it is recreated, see reconcileKafkaPod
+			err = k8sutil.UpdateBrokerStatus(r.Client, []string{brokerID}, r.KafkaCluster, v1beta1.GracefulActionState{
+				ReplacementState: v1beta1.BrokerReplacementRunning,
+				VolumeStates:     brokerState.GracefulActionState.VolumeStates,
+			}, log)
+			if err != nil {
+				return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update broker replacement state")
+			}
+			log.Info("stale pod and PVCs of the broker deleted, recreating the broker", v1beta1.BrokerIdLabelKey, brokerID)
+		case v1beta1.BrokerReplacementRunning:
+			if brokerState.GracefulActionState.CruiseControlState != v1beta1.GracefulUpscaleSucceeded {
+				continue
+			}
+			if err := k8sutil.UpdateBrokerStatus(r.Client, []string{brokerID}, r.KafkaCluster, v1beta1.BrokerReplacementSucceeded, log); err != nil {
+				return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update broker replacement state")
+			}
+			log.Info("broker replaced", v1beta1.BrokerIdLabelKey, brokerID)
+		}
+	}
+
+	// the brokers must not be recreated while their stale PVCs exist as their pods would be bound to them again
+	if len(pendingBrokers) > 0 {
+		return errorfactory.New(errorfactory.ResourceNotReady{},
+			errors.NewWithDetails("stale PVCs of the replaced brokers are being deleted", "brokers", pendingBrokers),
+			"broker replacement in progress")
+	}
+	return nil
+}
+
+// brokersRequestedForReplacement returns the IDs of the brokers whose pod requests their replacement and
+// which are not being replaced or removed already
+func brokersRequestedForReplacement(cluster *v1beta1.KafkaCluster, pods []corev1.Pod) []string {
+	var brokerIDs []string
+	for _, pod := range pods {
+		if !strings.EqualFold(pod.GetAnnotations()[v1beta1.ReplaceBrokerAnnotationKey], "true") || k8sutil.IsMarkedForDeletion(pod.ObjectMeta) {
+			continue
+		}
+		brokerID := pod.Labels[v1beta1.BrokerIdLabelKey]
+		brokerState, ok := cluster.Status.BrokersState[brokerID]
+		if !ok || !brokerInSpec(cluster, brokerID) ||
+			brokerState.GracefulActionState.ReplacementState.IsActive() ||
+			brokerState.GracefulActionState.CruiseControlState.IsDownscale() {
+			continue
+		}
+		brokerIDs = append(brokerIDs, brokerID)
+	}
+	sort.Strings(brokerIDs)
+	return brokerIDs
+}
+
+// deleteStaleBrokerResources deletes the pod and the PVCs of the replaced broker, and returns true once they are gone.
+// The pod is deleted without grace period as the kubelet of its lost node can not confirm its termination.
+func (r *Reconciler) deleteStaleBrokerResources(ctx context.Context, log logr.Logger, brokerID string) (bool, error) {
+	matchingLabels := client.MatchingLabels(
+		apiutil.MergeLabels(
+			apiutil.LabelsForKafka(r.KafkaCluster.Name),
+			map[string]string{v1beta1.BrokerIdLabelKey: brokerID},
+		),
+	)
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList, client.InNamespace(r.KafkaCluster.Namespace), matchingLabels); err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not list pods of the broker", "id", brokerID)
+	}
+	for i := range podList.Items {
+		err := r.Client.Delete(ctx, &podList.Items[i], client.GracePeriodSeconds(0))
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.WrapIfWithDetails(err, "could not delete pod of the replaced broker", "id", brokerID)
+		}
+		log.Info("pod of the replaced broker deleted", "pod name", podList.Items[i].Name, v1beta1.BrokerIdLabelKey, brokerID)
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(ctx, pvcList, client.InNamespace(r.KafkaCluster.Namespace), matchingLabels); err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not list pvcs of the broker", "id", brokerID)
+	}
+	remaining := 0
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if k8sutil.IsMarkedForDeletion(pvc.ObjectMeta) {
+			remaining++
+			continue
+		}
+		if err := r.Client.Delete(ctx, pvc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, errors.WrapIfWithDetails(err, "could not delete pvc of the replaced broker", "id", brokerID)
+		}
+		log.Info("pvc of the replaced broker deleted", "pvc name", pvc.Name, v1beta1.BrokerIdLabelKey, brokerID)
+		// the pvc is only gone once its protection finalizer is removed
+		if len(pvc.Finalizers) > 0 {
+			remaining++
+		}
+	}
+	return remaining == 0, nil
+}
+
+func brokerInSpec(cluster *v1beta1.KafkaCluster, brokerID string) bool {
+	for _, broker := range cluster.Spec.Brokers {
+		if strconv.Itoa(int(broker.Id)) == brokerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/resources"
+)
+
+func TestBrokersRequestedForReplacement(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0}, {Id: 1}, {Id: 2}, {Id: 3}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}},
+				"1": {GracefulActionState: v1beta1.GracefulActionState{ReplacementState: v1beta1.BrokerReplacementRunning}},
+				"2": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}},
+				"3": {GracefulActionState: v1beta1.GracefulActionState{
+					CruiseControlState: v1beta1.GracefulUpscaleSucceeded,
+					ReplacementState:   v1beta1.BrokerReplacementSucceeded,
+				}},
+				"4": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulDownscaleRunning}},
+			},
+		},
+	}
+	pod := func(brokerID, replace string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{v1beta1.BrokerIdLabelKey: brokerID},
+			Annotations: map[string]string{v1beta1.ReplaceBrokerAnnotationKey: replace},
+		}}
+	}
+
+	requested := brokersRequestedForReplacement(cluster, []corev1.Pod{
+		pod("0", "true"),
+		pod("1", "true"),
+		pod("2", "false"),
+		pod("3", "True"),
+		pod("4", "true"),
+	})
+	assert.Equal(t, []string{"0", "3"}, requested)
+}
+
+func TestReconcileBrokerReplacement(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	brokerLabels := apiutil.MergeLabels(apiutil.LabelsForKafka("kafka"), map[string]string{v1beta1.BrokerIdLabelKey: "1"})
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0}, {Id: 1}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}},
+				"1": {GracefulActionState: v1beta1.GracefulActionState{CruiseControlState: v1beta1.GracefulUpscaleSucceeded}},
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		cluster,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "kafka-1",
+			Namespace:   "kafka",
+			Labels:      brokerLabels,
+			Annotations: map[string]string{v1beta1.ReplaceBrokerAnnotationKey: "true"},
+		}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:      "kafka-1-storage",
+			Namespace: "kafka",
+			Labels:    brokerLabels,
+		}},
+	).Build()
+
+	r := Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fakeClient,
+			KafkaCluster: cluster,
+		},
+	}
+
+	assert.NoError(t, r.reconcileBrokerReplacement(ctx, logr.Discard()))
+
+	err := fakeClient.Get(ctx, client.ObjectKey{Name: "kafka-1", Namespace: "kafka"}, &corev1.Pod{})
+	assert.True(t, apierrors.IsNotFound(err))
+	err = fakeClient.Get(ctx, client.ObjectKey{Name: "kafka-1-storage", Namespace: "kafka"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Equal(t, v1beta1.GracefulActionState{ReplacementState: v1beta1.BrokerReplacementRunning},
+		cluster.Status.BrokersState["1"].GracefulActionState)
+	assert.Equal(t, v1beta1.GracefulUpscaleSucceeded, cluster.Status.BrokersState["0"].GracefulActionState.CruiseControlState)
+
+	// the replacement succeeds once the recreated broker is added to Cruise Control
+	brokerState := cluster.Status.BrokersState["1"]
+	brokerState.GracefulActionState.CruiseControlState = v1beta1.GracefulUpscaleSucceeded
+	cluster.Status.BrokersState["1"] = brokerState
+	assert.NoError(t, r.reconcileBrokerReplacement(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.BrokerReplacementSucceeded, cluster.Status.BrokersState["1"].GracefulActionState.ReplacementState)
+}
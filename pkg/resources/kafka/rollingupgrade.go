@@ -0,0 +1,295 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+const brokerRackConfigPrefix = "broker.rack="
+
+// concurrentRackRestart returns true when the brokers of the same rack are restarted at once during rolling upgrades
+func concurrentRackRestart(cluster *v1beta1.KafkaCluster) bool {
+	return cluster.Spec.RollingUpgradeConfig.ConcurrentRackRestart && cluster.Spec.RackAwareness != nil
+}
+
+// brokerRack returns the rack of the broker based on its rack awareness state, or the broker.rack set in its
+// read-only config. It returns an empty string when the rack of the broker is not known yet.
+func brokerRack(cluster *v1beta1.KafkaCluster, brokerID string) string {
+	if brokerState, ok := cluster.Status.BrokersState[brokerID]; ok {
+		if rack, found := parseBrokerRack(string(brokerState.RackAwarenessState)); found {
+			return rack
+		}
+	}
+	for _, broker := range cluster.Spec.Brokers {
+		if strconv.Itoa(int(broker.Id)) == brokerID {
+			rack, _ := parseBrokerRack(broker.ReadOnlyConfig)
+			return rack
+		}
+	}
+	return ""
+}
+
+func parseBrokerRack(config string) (string, bool) {
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, brokerRackConfigPrefix) {
+			return strings.TrimPrefix(line, brokerRackConfigPrefix), true
+		}
+	}
+	return "", false
+}
+
+// rackBrokerIDs returns the IDs of the brokers in the rack
+func rackBrokerIDs(cluster *v1beta1.KafkaCluster, rack string) []int32 {
+	var brokerIDs []int32
+	for _, broker := range cluster.Spec.Brokers {
+		if brokerRack(cluster, strconv.Itoa(int(broker.Id))) == rack {
+			brokerIDs = append(brokerIDs, broker.Id)
+		}
+	}
+	return brokerIDs
+}
+
+// brokerIDsToRestart returns the brokers which are taken down together with the given broker: the brokers of its
+// rack when the rack is restarted at once, or the broker alone otherwise
+func brokerIDsToRestart(cluster *v1beta1.KafkaCluster, brokerID string, rack string) ([]int32, error) {
+	if rack != "" {
+		return rackBrokerIDs(cluster, rack), nil
+	}
+	id, err := strconv.ParseInt(brokerID, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return []int32{int32(id)}, nil
+}
+
+// rackRestartStarted tells whether the brokers are restarted together by the rack restart of the rolling upgrade.
+// The brokers of the rack are recorded as the current brokers when the first of them is restarted.
+func rackRestartStarted(status v1beta1.RollingUpgradeStatus, brokerIDs ...string) bool {
+	if len(status.CurrentBrokers) < 2 {
+		return false
+	}
+	started := false
+	for _, brokerID := range status.CurrentBrokers {
+		if apiutil.StringSliceContains(status.CompletedBrokers, brokerID) {
+			started = true
+			break
+		}
+	}
+	if !started {
+		return false
+	}
+	for _, brokerID := range brokerIDs {
+		if !apiutil.StringSliceContains(status.CurrentBrokers, brokerID) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRackRestartSafety refuses the restart of the brokers of the rack when it would take partitions offline
+func checkRackRestartSafety(kClient kafkaclient.KafkaClient, rack string, brokerIDs []int32) error {
+	affectedPartitions, err := kClient.PartitionsInSyncOnlyOn(brokerIDs)
+	if err != nil {
+		return errors.WrapIf(err, "rack-aware replica placement check failed")
+	}
+	if len(affectedPartitions) > 0 {
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+			errors.NewWithDetails("partitions have no in-sync replica outside of the rack", "rack", rack, "partitions", affectedPartitions),
+			"rack can not be restarted at once")
+	}
+	return nil
+}
+
+// brokerNodeIDs returns the given broker IDs without the controller-only KRaft nodes, as those host no partitions
+func brokerNodeIDs(cluster *v1beta1.KafkaCluster, brokerIDs []int32) ([]int32, error) {
+	if !cluster.Spec.KRaftMode {
+		return brokerIDs, nil
+	}
+	controllerOnlyNodes := make(map[int32]struct{})
+	for _, broker := range cluster.Spec.Brokers {
+		brokerConfig, err := broker.GetBrokerConfig(cluster.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if brokerConfig != nil && brokerConfig.IsControllerOnlyNode() {
+			controllerOnlyNodes[broker.Id] = struct{}{}
+		}
+	}
+	nodeIDs := make([]int32, 0, len(brokerIDs))
+	for _, brokerID := range brokerIDs {
+		if _, ok := controllerOnlyNodes[brokerID]; !ok {
+			nodeIDs = append(nodeIDs, brokerID)
+		}
+	}
+	return nodeIDs, nil
+}
+
+// groupBrokersByRack reorders the running brokers so that the brokers of the same rack are reconciled one after
+// the other, which lets a rolling upgrade restart a whole rack within a single reconciliation. The brokers which
+// are not running keep their leading position and the rack of the last broker (the controller) is reconciled last.
+func groupBrokersByRack(brokers []v1beta1.Broker, runningBrokers map[string]struct{}, cluster *v1beta1.KafkaCluster) []v1beta1.Broker {
+	grouped := make([]v1beta1.Broker, 0, len(brokers))
+	var racks []string
+	brokersByRack := make(map[string][]v1beta1.Broker)
+	for _, broker := range brokers {
+		brokerID := fmt.Sprintf("%d", broker.Id)
+		if _, running := runningBrokers[brokerID]; !running {
+			grouped = append(grouped, broker)
+			continue
+		}
+		rack := brokerRack(cluster, brokerID)
+		if _, ok := brokersByRack[rack]; !ok {
+			racks = append(racks, rack)
+		}
+		brokersByRack[rack] = append(brokersByRack[rack], broker)
+	}
+	if len(racks) == 0 {
+		return grouped
+	}
+
+	lastRack := brokerRack(cluster, fmt.Sprintf("%d", brokers[len(brokers)-1].Id))
+	for _, rack := range racks {
+		if rack != lastRack {
+			grouped = append(grouped, brokersByRack[rack]...)
+		}
+	}
+	return append(grouped, brokersByRack[lastRack]...)
+}
+
+// restartApproved returns true when the restart of the broker pod has been approved
+func restartApproved(pod *corev1.Pod) bool {
+	return strings.EqualFold(pod.GetAnnotations()[v1beta1.RestartApprovedAnnotationKey], "true")
+}
+
+// startRollingUpgradeProgress resets the progress of the rolling upgrade when it is started
+func (r *Reconciler) startRollingUpgradeProgress(log logr.Logger) error {
+	pending := make([]string, 0, len(r.KafkaCluster.Spec.Brokers))
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		pending = append(pending, strconv.Itoa(int(broker.Id)))
+	}
+	err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.CompletedBrokers = nil
+		status.CurrentBrokers = nil
+		status.PendingBrokers = pending
+		status.PausedAt = nil
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	log.Info("rolling upgrade started", "pendingBrokers", pending)
+	return nil
+}
+
+// markBrokerUpToDate removes the broker from the pending brokers of the rolling upgrade when it doesn't have to be restarted
+func (r *Reconciler) markBrokerUpToDate(log logr.Logger, brokerID string) error {
+	if r.KafkaCluster.Status.State != v1beta1.KafkaClusterRollingUpgrading ||
+		!apiutil.StringSliceContains(r.KafkaCluster.Status.RollingUpgrade.PendingBrokers, brokerID) {
+		return nil
+	}
+	err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+		status.PendingBrokers = util.StringSliceRemove(status.PendingBrokers, brokerID)
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	log.V(1).Info("broker does not have to be restarted by the rolling upgrade", v1beta1.BrokerIdLabelKey, brokerID)
+	return nil
+}
+
+// recordBrokerRestart records the restart of the broker in the progress of the rolling upgrade. The current brokers
+// are only replaced when a new broker (or rack) restart is started.
+func (r *Reconciler) recordBrokerRestart(log logr.Logger, currentBrokerIDs []string, brokerID string) error {
+	err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+		recordRestart(status, currentBrokerIDs, brokerID)
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	log.Info("broker restarted by the rolling upgrade", v1beta1.BrokerIdLabelKey, brokerID,
+		"pendingBrokers", r.KafkaCluster.Status.RollingUpgrade.PendingBrokers)
+	return nil
+}
+
+func recordRestart(status *v1beta1.RollingUpgradeStatus, currentBrokerIDs []string, brokerID string) {
+	if currentBrokerIDs != nil {
+		status.CurrentBrokers = currentBrokerIDs
+	}
+	if !apiutil.StringSliceContains(status.CompletedBrokers, brokerID) {
+		status.CompletedBrokers = append(status.CompletedBrokers, brokerID)
+	}
+	status.PendingBrokers = util.StringSliceRemove(status.PendingBrokers, brokerID)
+}
+
+// reconcileRollingUpgradePause stops the rolling upgrade while it is paused and records when it was paused
+func (r *Reconciler) reconcileRollingUpgradePause(log logr.Logger) error {
+	paused := r.KafkaCluster.Spec.RollingUpgradeConfig.Paused
+	pausedAt := r.KafkaCluster.Status.RollingUpgrade.PausedAt
+	if paused == (pausedAt != nil) {
+		if paused {
+			return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("rolling upgrade is paused"), "rolling upgrade paused")
+		}
+		return nil
+	}
+
+	err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+		if paused {
+			now := metav1.Now()
+			status.PausedAt = &now
+		} else {
+			status.PausedAt = nil
+		}
+	})
+	if err != nil {
+		return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+	}
+	if paused {
+		log.Info("rolling upgrade paused")
+		return errorfactory.New(errorfactory.ReconcileRollingUpgrade{}, errors.New("rolling upgrade is paused"), "rolling upgrade paused")
+	}
+	log.Info("rolling upgrade resumed")
+	return nil
+}
+
+// waitForRestartApproval records the brokers waiting for the approval of their restart
+func (r *Reconciler) waitForRestartApproval(log logr.Logger, brokerIDs []string) error {
+	if !util.AreStringSlicesIdentical(r.KafkaCluster.Status.RollingUpgrade.CurrentBrokers, brokerIDs) {
+		err := k8sutil.UpdateRollingUpgradeStatus(r.Client, r.KafkaCluster, func(status *v1beta1.RollingUpgradeStatus) {
+			status.CurrentBrokers = brokerIDs
+		})
+		if err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update rolling upgrade status")
+		}
+		log.Info("waiting for the approval of the broker restart", "brokers", brokerIDs,
+			"annotation", v1beta1.RestartApprovedAnnotationKey)
+	}
+	return errorfactory.New(errorfactory.ReconcileRollingUpgrade{},
+		errors.NewWithDetails("broker restart is not approved", "brokers", brokerIDs), "waiting for restart approval")
+}
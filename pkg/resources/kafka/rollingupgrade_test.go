@@ -0,0 +1,373 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiutil "github.com/banzaicloud/koperator/api/util"
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/kafkaclient"
+	"github.com/banzaicloud/koperator/pkg/resources"
+)
+
+// kafkaClientStub overrides the methods of the Kafka client which are used by the tests
+type kafkaClientStub struct {
+	kafkaclient.KafkaClient
+	brokers                   map[int32]string
+	offlineReplicas           []int32
+	outOfSyncReplicas         []int32
+	leadersOn                 []string
+	partitionsInSyncOnlyOn    []string
+	partitionsBlockingRestart []kafkaclient.BlockingPartition
+	replicasOn                []string
+}
+
+func (c *kafkaClientStub) Brokers() map[int32]string {
+	return c.brokers
+}
+
+func (c *kafkaClientStub) LeadersOn([]int32) ([]string, error) {
+	return c.leadersOn, nil
+}
+
+func (c *kafkaClientStub) PartitionsInSyncOnlyOn([]int32) ([]string, error) {
+	return c.partitionsInSyncOnlyOn, nil
+}
+
+func (c *kafkaClientStub) PartitionsBlockingRestart([]int32) ([]kafkaclient.BlockingPartition, error) {
+	return c.partitionsBlockingRestart, nil
+}
+
+func (c *kafkaClientStub) ReplicasOn([]int32) ([]string, error) {
+	return c.replicasOn, nil
+}
+
+func (c *kafkaClientStub) AllOfflineReplicas() ([]int32, error) {
+	return c.offlineReplicas, nil
+}
+
+func (c *kafkaClientStub) OutOfSyncReplicas() ([]int32, error) {
+	return c.outOfSyncReplicas, nil
+}
+
+// kafkaClientProviderStub provides the same Kafka client for every connection
+type kafkaClientProviderStub struct {
+	client kafkaclient.KafkaClient
+}
+
+func (p kafkaClientProviderStub) NewFromCluster(client.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, func(), error) {
+	return p.client, func() {}, nil
+}
+
+func newRackAwareKafkaCluster() *v1beta1.KafkaCluster {
+	return &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			RackAwareness: &v1beta1.RackAwareness{Labels: []string{"topology.kubernetes.io/zone"}},
+			RollingUpgradeConfig: v1beta1.RollingUpgradeConfig{
+				FailureThreshold:      1,
+				ConcurrentRackRestart: true,
+			},
+			Brokers: []v1beta1.Broker{
+				{Id: 0},
+				{Id: 1},
+				{Id: 2},
+				{Id: 3},
+				{Id: 4, ReadOnlyConfig: "auto.create.topics.enable=false\nbroker.rack=zone-b\n"},
+				{Id: 5},
+			},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {RackAwarenessState: "broker.rack=zone-a\n"},
+				"1": {RackAwarenessState: "broker.rack=zone-b\n"},
+				"2": {RackAwarenessState: "broker.rack=zone-a\n"},
+				"3": {RackAwarenessState: "broker.rack=zone-c\n"},
+				"4": {RackAwarenessState: v1beta1.Configured},
+				"5": {RackAwarenessState: v1beta1.WaitingForRackAwareness},
+			},
+		},
+	}
+}
+
+func TestBrokerRack(t *testing.T) {
+	cluster := newRackAwareKafkaCluster()
+
+	assert.Equal(t, "zone-a", brokerRack(cluster, "0"))
+	assert.Equal(t, "zone-b", brokerRack(cluster, "1"))
+	assert.Equal(t, "zone-b", brokerRack(cluster, "4"))
+	assert.Equal(t, "", brokerRack(cluster, "5"))
+	assert.Equal(t, "", brokerRack(cluster, "6"))
+	assert.Equal(t, []int32{0, 2}, rackBrokerIDs(cluster, "zone-a"))
+	assert.Equal(t, []int32{1, 4}, rackBrokerIDs(cluster, "zone-b"))
+
+	brokerIDs, err := brokerIDsToRestart(cluster, "1", "zone-b")
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 4}, brokerIDs)
+	brokerIDs, err = brokerIDsToRestart(cluster, "1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1}, brokerIDs)
+	_, err = brokerIDsToRestart(cluster, "", "")
+	assert.Error(t, err)
+
+	assert.True(t, concurrentRackRestart(cluster))
+	cluster.Spec.RackAwareness = nil
+	assert.False(t, concurrentRackRestart(cluster))
+}
+
+func TestBrokerNodeIDs(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+				"controller": {ProcessRoles: []string{v1beta1.ProcessRoleController}},
+			},
+			Brokers: []v1beta1.Broker{
+				{Id: 0, BrokerConfigGroup: "controller"},
+				{Id: 1, BrokerConfig: &v1beta1.BrokerConfig{ProcessRoles: []string{v1beta1.ProcessRoleController, v1beta1.ProcessRoleBroker}}},
+				{Id: 2, BrokerConfig: &v1beta1.BrokerConfig{ProcessRoles: []string{v1beta1.ProcessRoleBroker}}},
+				{Id: 3},
+			},
+		},
+	}
+
+	// all brokers host partitions in ZooKeeper mode
+	ids, err := brokerNodeIDs(cluster, []int32{0, 1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{0, 1, 2, 3}, ids)
+
+	cluster.Spec.KRaftMode = true
+	ids, err = brokerNodeIDs(cluster, []int32{0, 1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 2, 3}, ids)
+
+	ids, err = brokerNodeIDs(cluster, []int32{0})
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestGroupBrokersByRack(t *testing.T) {
+	cluster := newRackAwareKafkaCluster()
+	running := map[string]struct{}{"0": {}, "1": {}, "2": {}, "3": {}, "4": {}}
+
+	// broker 5 is not running, broker 1 is the controller
+	brokers := []v1beta1.Broker{{Id: 5}, {Id: 0}, {Id: 3}, {Id: 4}, {Id: 2}, {Id: 1}}
+	grouped := groupBrokersByRack(brokers, running, cluster)
+
+	ids := make([]int32, 0, len(grouped))
+	for _, broker := range grouped {
+		ids = append(ids, broker.Id)
+	}
+	assert.Equal(t, []int32{5, 0, 2, 3, 4, 1}, ids)
+}
+
+func TestRecordRestart(t *testing.T) {
+	status := &v1beta1.RollingUpgradeStatus{PendingBrokers: []string{"0", "1", "2", "4"}}
+
+	recordRestart(status, []string{"1", "4"}, "1")
+	assert.Equal(t, []string{"1", "4"}, status.CurrentBrokers)
+	assert.Equal(t, []string{"1"}, status.CompletedBrokers)
+	assert.Equal(t, []string{"0", "2", "4"}, status.PendingBrokers)
+
+	// the brokers of the rack restarted together keep the current brokers
+	recordRestart(status, nil, "4")
+	assert.Equal(t, []string{"1", "4"}, status.CurrentBrokers)
+	assert.Equal(t, []string{"1", "4"}, status.CompletedBrokers)
+	assert.Equal(t, []string{"0", "2"}, status.PendingBrokers)
+
+	recordRestart(status, []string{"0"}, "0")
+	assert.Equal(t, []string{"0"}, status.CurrentBrokers)
+	assert.Equal(t, []string{"1", "4", "0"}, status.CompletedBrokers)
+	assert.Equal(t, []string{"2"}, status.PendingBrokers)
+}
+
+func TestRestartApproved(t *testing.T) {
+	pod := &corev1.Pod{}
+	assert.False(t, restartApproved(pod))
+
+	pod.Annotations = map[string]string{v1beta1.RestartApprovedAnnotationKey: "false"}
+	assert.False(t, restartApproved(pod))
+
+	pod.Annotations[v1beta1.RestartApprovedAnnotationKey] = "true"
+	assert.True(t, restartApproved(pod))
+}
+
+func newBrokerPod(brokerID string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kafka-" + brokerID,
+			Namespace: "kafka",
+			Labels:    apiutil.MergeLabels(apiutil.LabelsForKafka("kafka"), map[string]string{v1beta1.BrokerIdLabelKey: brokerID}),
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "kafka", Image: "kafka:1"}}},
+	}
+}
+
+func TestRackRestartStarted(t *testing.T) {
+	status := v1beta1.RollingUpgradeStatus{}
+	assert.False(t, rackRestartStarted(status, "0", "2"))
+
+	// waiting for the approval of the restart of the rack
+	status.CurrentBrokers = []string{"0", "2"}
+	assert.False(t, rackRestartStarted(status, "0", "2"))
+
+	status.CompletedBrokers = []string{"0"}
+	assert.True(t, rackRestartStarted(status, "0", "2"))
+	assert.False(t, rackRestartStarted(status, "1", "2"))
+
+	// a single broker restart is not a rack restart
+	status.CurrentBrokers = []string{"0"}
+	assert.False(t, rackRestartStarted(status, "0"))
+}
+
+func TestHandleRollingUpgradeRackRestart(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	// broker 0 of the rack is being recreated
+	pendingPod := newBrokerPod("0")
+	pendingPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "kafka",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}}
+
+	testCases := []struct {
+		testName        string
+		paused          bool
+		currentBrokers  []string
+		inSyncOnlyOn    []string
+		expectedError   string
+		expectedDeleted bool
+	}{
+		{
+			testName:      "pending pod which is not restarted by the rack restart",
+			expectedError: "pod is still creating",
+		},
+		{
+			testName:       "rack restart is paused",
+			paused:         true,
+			currentBrokers: []string{"0", "2"},
+			expectedError:  "rolling upgrade is paused",
+		},
+		{
+			testName:       "partitions in sync only on the rack",
+			currentBrokers: []string{"0", "2"},
+			inSyncOnlyOn:   []string{"test-topic-0"},
+			expectedError:  "no in-sync replica outside of the rack",
+		},
+		{
+			testName:        "rack restart continues",
+			currentBrokers:  []string{"0", "2"},
+			expectedDeleted: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		cluster := newRackAwareKafkaCluster()
+		cluster.ObjectMeta = metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"}
+		for i := range cluster.Spec.Brokers {
+			cluster.Spec.Brokers[i].BrokerConfig = &v1beta1.BrokerConfig{}
+		}
+		cluster.Spec.RollingUpgradeConfig.Paused = testCase.paused
+		cluster.Status.State = v1beta1.KafkaClusterRollingUpgrading
+		cluster.Status.RollingUpgrade = v1beta1.RollingUpgradeStatus{
+			CurrentBrokers:   testCase.currentBrokers,
+			CompletedBrokers: []string{"0"},
+			PendingBrokers:   []string{"1", "2", "3", "4", "5"},
+		}
+		currentPod := newBrokerPod("2")
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, pendingPod, currentPod).Build()
+		r := Reconciler{
+			Reconciler: resources.Reconciler{
+				Client:       fakeClient,
+				KafkaCluster: cluster,
+			},
+			kafkaClientProvider: kafkaClientProviderStub{client: &kafkaClientStub{partitionsInSyncOnlyOn: testCase.inSyncOnlyOn}},
+		}
+
+		desiredPod := currentPod.DeepCopy()
+		desiredPod.Spec.Containers[0].Image = "kafka:2"
+		err := r.handleRollingUpgrade(logr.Discard(), desiredPod, currentPod, reflect.TypeOf(desiredPod))
+
+		getErr := fakeClient.Get(ctx, client.ObjectKeyFromObject(currentPod), &corev1.Pod{})
+		if testCase.expectedDeleted {
+			require.NoError(t, err, testCase.testName)
+			assert.True(t, apierrors.IsNotFound(getErr), testCase.testName)
+			assert.Equal(t, []string{"0", "2"}, cluster.Status.RollingUpgrade.CompletedBrokers, testCase.testName)
+		} else {
+			assert.ErrorContains(t, err, testCase.expectedError, testCase.testName)
+			assert.NoError(t, getErr, testCase.testName)
+		}
+	}
+}
+
+func TestHandleRollingUpgradeUnderMinISR(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	for _, blockUnderMinISR := range []bool{true, false} {
+		cluster := &v1beta1.KafkaCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+			Spec: v1beta1.KafkaClusterSpec{
+				RollingUpgradeConfig: v1beta1.RollingUpgradeConfig{FailureThreshold: 1, BlockUnderMinISR: blockUnderMinISR},
+				Brokers:              []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{}}, {Id: 1, BrokerConfig: &v1beta1.BrokerConfig{}}},
+			},
+			Status: v1beta1.KafkaClusterStatus{
+				State:          v1beta1.KafkaClusterRollingUpgrading,
+				RollingUpgrade: v1beta1.RollingUpgradeStatus{PendingBrokers: []string{"0", "1"}},
+			},
+		}
+		currentPod := newBrokerPod("0")
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, currentPod, newBrokerPod("1")).Build()
+		r := Reconciler{
+			Reconciler: resources.Reconciler{
+				Client:       fakeClient,
+				KafkaCluster: cluster,
+			},
+			kafkaClientProvider: kafkaClientProviderStub{client: &kafkaClientStub{
+				partitionsBlockingRestart: []kafkaclient.BlockingPartition{{Topic: "test-topic", Partition: 0, ISR: []int32{0, 1}, MinISR: 2}},
+			}},
+		}
+
+		desiredPod := currentPod.DeepCopy()
+		desiredPod.Spec.Containers[0].Image = "kafka:2"
+		err := r.handleRollingUpgrade(logr.Discard(), desiredPod, currentPod, reflect.TypeOf(desiredPod))
+
+		getErr := fakeClient.Get(ctx, client.ObjectKeyFromObject(currentPod), &corev1.Pod{})
+		if blockUnderMinISR {
+			assert.ErrorContains(t, err, "partitions would go below min.insync.replicas")
+			assert.NoError(t, getErr)
+		} else {
+			// the blocking partitions are only reported
+			assert.NoError(t, err)
+			assert.True(t, apierrors.IsNotFound(getErr))
+		}
+	}
+}
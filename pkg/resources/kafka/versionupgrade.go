@@ -0,0 +1,240 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/errorfactory"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	kafkautils "github.com/banzaicloud/koperator/pkg/util/kafka"
+	properties "github.com/banzaicloud/koperator/properties/pkg"
+)
+
+// reconcileKafkaVersionUpgrade drives the upgrade of the brokers to the Kafka version set in the spec. The brokers keep
+// the protocol version in effect until every broker reports the new version, then the protocol version is bumped which
+// restarts the brokers once more, and the log message format version is bumped only after that.
+func (r *Reconciler) reconcileKafkaVersionUpgrade(ctx context.Context, log logr.Logger) error {
+	targetVersion := r.KafkaCluster.Spec.KafkaVersion
+	if targetVersion == "" || r.KafkaCluster.Spec.KRaftMode {
+		return nil
+	}
+	targetProtocolVersion, err := kafkautils.ProtocolVersion(targetVersion)
+	if err != nil {
+		return errors.WrapIf(err, "invalid Kafka version")
+	}
+
+	status := r.KafkaCluster.Status.KafkaVersionUpgrade.DeepCopy()
+	switch {
+	case status == nil:
+		// the brokers run the protocol version in effect until the operator manages it
+		protocolVersion, messageFormatVersion, known, err := protocolVersionsInEffect(r.KafkaCluster, log)
+		if err != nil {
+			return err
+		}
+		if !known {
+			log.Info("waiting for the brokers to report their Kafka version before starting the Kafka version upgrade",
+				"targetVersion", targetVersion)
+			return nil
+		}
+		status = &v1beta1.KafkaVersionUpgradeStatus{
+			State:                v1beta1.KafkaVersionUpgradeRollingBinaries,
+			TargetVersion:        targetVersion,
+			ProtocolVersion:      protocolVersion,
+			MessageFormatVersion: messageFormatVersion,
+		}
+		log.Info("Kafka version upgrade started", "targetVersion", targetVersion,
+			"protocolVersion", protocolVersion, "messageFormatVersion", messageFormatVersion)
+	case status.TargetVersion != targetVersion:
+		status.State = v1beta1.KafkaVersionUpgradeRollingBinaries
+		status.TargetVersion = targetVersion
+		log.Info("Kafka version upgrade started", "targetVersion", targetVersion,
+			"protocolVersion", status.ProtocolVersion, "messageFormatVersion", status.MessageFormatVersion)
+	}
+	if status.MessageFormatVersion == "" {
+		status.MessageFormatVersion = status.ProtocolVersion
+	}
+
+	// brokers with a Kafka version older than the protocol version could not join the cluster
+	if cmp, err := kafkautils.CompareProtocolVersions(targetProtocolVersion, status.ProtocolVersion); err != nil {
+		return errors.WrapIf(err, "invalid protocol version")
+	} else if cmp < 0 {
+		return errors.NewWithDetails("the brokers can not be downgraded to a Kafka version older than their protocol version",
+			"kafkaVersion", targetVersion, "protocolVersion", status.ProtocolVersion)
+	}
+
+	switch status.State {
+	case v1beta1.KafkaVersionUpgradeRollingBinaries:
+		if !brokersReportVersion(r.KafkaCluster, targetVersion) {
+			break
+		}
+		if cmp, err := kafkautils.CompareProtocolVersions(status.ProtocolVersion, targetProtocolVersion); err != nil {
+			return errors.WrapIf(err, "invalid protocol version")
+		} else if cmp < 0 {
+			status.State = v1beta1.KafkaVersionUpgradeBumpingProtocol
+			status.ProtocolVersion = targetProtocolVersion
+			log.Info("every broker reports the new Kafka version, bumping the protocol version",
+				"kafkaVersion", targetVersion, "protocolVersion", targetProtocolVersion)
+			break
+		}
+		if err := bumpMessageFormatVersion(status, targetProtocolVersion, log); err != nil {
+			return err
+		}
+	case v1beta1.KafkaVersionUpgradeBumpingProtocol:
+		bumped, err := r.brokersRunConfig(ctx, kafkautils.KafkaConfigInterBrokerProtocolVersion, status.ProtocolVersion)
+		if err != nil {
+			return err
+		}
+		if bumped {
+			if err := bumpMessageFormatVersion(status, targetProtocolVersion, log); err != nil {
+				return err
+			}
+		}
+	case v1beta1.KafkaVersionUpgradeBumpingMessageFormat:
+		bumped, err := r.brokersRunConfig(ctx, kafkautils.KafkaConfigLogMessageFormatVersion, status.MessageFormatVersion)
+		if err != nil {
+			return err
+		}
+		if bumped {
+			status.State = v1beta1.KafkaVersionUpgradeCompleted
+		}
+	}
+
+	if !reflect.DeepEqual(status, r.KafkaCluster.Status.KafkaVersionUpgrade) {
+		if err := k8sutil.UpdateKafkaVersionUpgradeStatus(r.Client, r.KafkaCluster, status, log); err != nil {
+			return errorfactory.New(errorfactory.StatusUpdateError{}, err, "could not update Kafka version upgrade status")
+		}
+	}
+	return nil
+}
+
+// bumpMessageFormatVersion moves the upgrade to bumping the log message format version when it is older than the
+// target protocol version, otherwise it completes the upgrade
+func bumpMessageFormatVersion(status *v1beta1.KafkaVersionUpgradeStatus, targetProtocolVersion string, log logr.Logger) error {
+	cmp, err := kafkautils.CompareProtocolVersions(status.MessageFormatVersion, targetProtocolVersion)
+	if err != nil {
+		return errors.WrapIf(err, "invalid log message format version")
+	}
+	if cmp >= 0 {
+		status.State = v1beta1.KafkaVersionUpgradeCompleted
+		return nil
+	}
+	status.State = v1beta1.KafkaVersionUpgradeBumpingMessageFormat
+	status.MessageFormatVersion = targetProtocolVersion
+	log.Info("every broker runs the new protocol version, bumping the log message format version",
+		"protocolVersion", status.ProtocolVersion, "messageFormatVersion", targetProtocolVersion)
+	return nil
+}
+
+// protocolVersionsInEffect returns the oldest inter.broker.protocol.version and log.message.format.version of the
+// brokers. The versions set in the readOnlyConfig take precedence, otherwise they are derived from the Kafka version
+// reported by the broker. It returns false when a broker neither sets the protocol version nor reports its version yet.
+func protocolVersionsInEffect(cluster *v1beta1.KafkaCluster, log logr.Logger) (string, string, bool, error) {
+	var oldestProtocolVersion, oldestMessageFormatVersion string
+	for _, broker := range cluster.Spec.Brokers {
+		readOnlyConfig := getBrokerReadOnlyConfig(broker.Id, cluster, log)
+		protocolVersion := ""
+		if property, ok := readOnlyConfig.Get(kafkautils.KafkaConfigInterBrokerProtocolVersion); ok {
+			protocolVersion = property.Value()
+		} else {
+			version := cluster.Status.BrokersState[strconv.Itoa(int(broker.Id))].Version
+			if version == "" {
+				return "", "", false, nil
+			}
+			var err error
+			if protocolVersion, err = kafkautils.ProtocolVersion(version); err != nil {
+				return "", "", false, errors.WrapIfWithDetails(err, "invalid Kafka version reported by broker", v1beta1.BrokerIdLabelKey, broker.Id)
+			}
+		}
+		// the log message format version defaults to the protocol version
+		messageFormatVersion := protocolVersion
+		if property, ok := readOnlyConfig.Get(kafkautils.KafkaConfigLogMessageFormatVersion); ok {
+			messageFormatVersion = property.Value()
+		}
+
+		var err error
+		if oldestProtocolVersion, err = olderProtocolVersion(oldestProtocolVersion, protocolVersion); err != nil {
+			return "", "", false, errors.WrapIfWithDetails(err, "invalid protocol version", v1beta1.BrokerIdLabelKey, broker.Id)
+		}
+		if oldestMessageFormatVersion, err = olderProtocolVersion(oldestMessageFormatVersion, messageFormatVersion); err != nil {
+			return "", "", false, errors.WrapIfWithDetails(err, "invalid log message format version", v1beta1.BrokerIdLabelKey, broker.Id)
+		}
+	}
+	return oldestProtocolVersion, oldestMessageFormatVersion, oldestProtocolVersion != "", nil
+}
+
+// olderProtocolVersion returns the older of the two protocol versions, an empty version is ignored
+func olderProtocolVersion(a, b string) (string, error) {
+	if a == "" {
+		return b, nil
+	}
+	cmp, err := kafkautils.CompareProtocolVersions(b, a)
+	if err != nil {
+		return "", err
+	}
+	if cmp < 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// brokersReportVersion returns true when every broker reports the Kafka version
+func brokersReportVersion(cluster *v1beta1.KafkaCluster, version string) bool {
+	for _, broker := range cluster.Spec.Brokers {
+		if cluster.Status.BrokersState[strconv.Itoa(int(broker.Id))].Version != version {
+			return false
+		}
+	}
+	return true
+}
+
+// brokersRunConfig returns true when the configuration of every broker holds the value of the property
+// and the brokers have been restarted with it
+func (r *Reconciler) brokersRunConfig(ctx context.Context, key, value string) (bool, error) {
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		if r.KafkaCluster.Status.BrokersState[strconv.Itoa(int(broker.Id))].ConfigurationState != v1beta1.ConfigInSync {
+			return false, nil
+		}
+		configMap := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, client.ObjectKey{
+			Name:      fmt.Sprintf(brokerConfigTemplate+"-%d", r.KafkaCluster.Name, broker.Id),
+			Namespace: r.KafkaCluster.Namespace,
+		}, configMap)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.WrapIfWithDetails(err, "could not get broker configuration", v1beta1.BrokerIdLabelKey, broker.Id)
+		}
+		config, err := properties.NewFromString(configMap.Data[kafkautils.ConfigPropertyName])
+		if err != nil {
+			return false, errors.WrapIfWithDetails(err, "could not parse broker configuration", v1beta1.BrokerIdLabelKey, broker.Id)
+		}
+		if property, ok := config.Get(key); !ok || property.Value() != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,277 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/resources"
+	kafkautils "github.com/banzaicloud/koperator/pkg/util/kafka"
+	properties "github.com/banzaicloud/koperator/properties/pkg"
+)
+
+func TestProtocolVersionsInEffect(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0}, {Id: 1}, {Id: 2}},
+		},
+	}
+
+	// the upgrade waits for the brokers to report their version
+	_, _, known, err := protocolVersionsInEffect(cluster, logr.Discard())
+	assert.NoError(t, err)
+	assert.False(t, known)
+
+	cluster.Status.BrokersState = map[string]v1beta1.BrokerState{
+		"0": {Version: "3.3.2"},
+		"1": {Version: "2.8.1"},
+		"2": {},
+		// brokers which were removed from the spec are ignored
+		"3": {Version: "2.7.0"},
+	}
+	_, _, known, err = protocolVersionsInEffect(cluster, logr.Discard())
+	assert.NoError(t, err)
+	assert.False(t, known)
+
+	cluster.Status.BrokersState["2"] = v1beta1.BrokerState{Version: "3.4.0"}
+	protocolVersion, messageFormatVersion, known, err := protocolVersionsInEffect(cluster, logr.Discard())
+	assert.NoError(t, err)
+	assert.True(t, known)
+	assert.Equal(t, "2.8", protocolVersion)
+	assert.Equal(t, "2.8", messageFormatVersion)
+
+	// the versions pinned in the readOnlyConfig take precedence
+	cluster.Spec.ReadOnlyConfig = "inter.broker.protocol.version=2.7-IV2\nlog.message.format.version=2.6"
+	protocolVersion, messageFormatVersion, known, err = protocolVersionsInEffect(cluster, logr.Discard())
+	assert.NoError(t, err)
+	assert.True(t, known)
+	assert.Equal(t, "2.7-IV2", protocolVersion)
+	assert.Equal(t, "2.6", messageFormatVersion)
+
+	cluster.Spec.ReadOnlyConfig = ""
+	cluster.Status.BrokersState["2"] = v1beta1.BrokerState{Version: "invalid"}
+	_, _, _, err = protocolVersionsInEffect(cluster, logr.Discard())
+	assert.Error(t, err)
+}
+
+func TestBumpMessageFormatVersion(t *testing.T) {
+	status := &v1beta1.KafkaVersionUpgradeStatus{ProtocolVersion: "3.4", MessageFormatVersion: "2.8"}
+	assert.NoError(t, bumpMessageFormatVersion(status, "3.4", logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingMessageFormat, status.State)
+	assert.Equal(t, "3.4", status.MessageFormatVersion)
+
+	assert.NoError(t, bumpMessageFormatVersion(status, "3.4", logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeCompleted, status.State)
+}
+
+func TestBrokersReportVersion(t *testing.T) {
+	cluster := &v1beta1.KafkaCluster{
+		Spec: v1beta1.KafkaClusterSpec{
+			Brokers: []v1beta1.Broker{{Id: 0}, {Id: 1}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {Version: "3.4.0"},
+				"1": {Version: "3.3.2"},
+			},
+		},
+	}
+	assert.False(t, brokersReportVersion(cluster, "3.4.0"))
+
+	cluster.Status.BrokersState["1"] = v1beta1.BrokerState{Version: "3.4.0"}
+	assert.True(t, brokersReportVersion(cluster, "3.4.0"))
+
+	cluster.Spec.Brokers = append(cluster.Spec.Brokers, v1beta1.Broker{Id: 2})
+	assert.False(t, brokersReportVersion(cluster, "3.4.0"))
+}
+
+func newVersionUpgradeReconciler(kafkaVersion string, brokerVersion string) *Reconciler {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	cluster := &v1beta1.KafkaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Spec: v1beta1.KafkaClusterSpec{
+			KafkaVersion: kafkaVersion,
+			Brokers:      []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{}}, {Id: 1, BrokerConfig: &v1beta1.BrokerConfig{}}},
+		},
+		Status: v1beta1.KafkaClusterStatus{
+			BrokersState: map[string]v1beta1.BrokerState{
+				"0": {Version: brokerVersion},
+				"1": {Version: brokerVersion},
+			},
+		},
+	}
+	return &Reconciler{
+		Reconciler: resources.Reconciler{
+			Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+			KafkaCluster: cluster,
+		},
+	}
+}
+
+// setBrokersVersion sets the Kafka version reported by the brokers
+func setBrokersVersion(r *Reconciler, version string) {
+	for id, state := range r.KafkaCluster.Status.BrokersState {
+		state.Version = version
+		r.KafkaCluster.Status.BrokersState[id] = state
+	}
+}
+
+// rollBrokerConfigs writes the generated configuration of the brokers into their configmaps and marks the
+// configuration of the brokers as in sync, as if the brokers had been restarted with it
+func rollBrokerConfigs(t *testing.T, r *Reconciler) {
+	t.Helper()
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf(brokerConfigTemplate+"-%d", r.KafkaCluster.Name, broker.Id),
+				Namespace: r.KafkaCluster.Namespace,
+			},
+			Data: map[string]string{kafkautils.ConfigPropertyName: generatedBrokerConfig(r, broker).String()},
+		}
+		err := r.Client.Update(context.Background(), configMap)
+		if apierrors.IsNotFound(err) {
+			err = r.Client.Create(context.Background(), configMap)
+		}
+		require.NoError(t, err)
+
+		state := r.KafkaCluster.Status.BrokersState[fmt.Sprint(broker.Id)]
+		state.ConfigurationState = v1beta1.ConfigInSync
+		r.KafkaCluster.Status.BrokersState[fmt.Sprint(broker.Id)] = state
+	}
+}
+
+func generatedBrokerConfig(r *Reconciler, broker v1beta1.Broker) *properties.Properties {
+	config, _ := properties.NewFromString(r.generateBrokerConfig(broker.Id, broker.BrokerConfig, nil, nil, nil, nil, "", nil, logr.Discard()))
+	return config
+}
+
+// assertBrokerConfig asserts the value of the property in the generated configuration of every broker,
+// an empty value asserts that the property is not set
+func assertBrokerConfig(t *testing.T, r *Reconciler, key, value string) {
+	t.Helper()
+	for _, broker := range r.KafkaCluster.Spec.Brokers {
+		property, ok := generatedBrokerConfig(r, broker).Get(key)
+		if value == "" {
+			assert.False(t, ok, "broker %d sets %s", broker.Id, key)
+			continue
+		}
+		if assert.True(t, ok, "broker %d does not set %s", broker.Id, key) {
+			assert.Equal(t, value, property.Value(), "broker %d", broker.Id)
+		}
+	}
+}
+
+func TestReconcileKafkaVersionUpgrade(t *testing.T) {
+	ctx := context.Background()
+	r := newVersionUpgradeReconciler("3.4.0", "3.3.2")
+
+	// the protocol version is not managed before the upgrade starts
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "")
+
+	// the new binaries are rolled out with the protocol version in effect
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, &v1beta1.KafkaVersionUpgradeStatus{
+		State:                v1beta1.KafkaVersionUpgradeRollingBinaries,
+		TargetVersion:        "3.4.0",
+		ProtocolVersion:      "3.3",
+		MessageFormatVersion: "3.3",
+	}, r.KafkaCluster.Status.KafkaVersionUpgrade)
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.3")
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigLogMessageFormatVersion, "3.3")
+	rollBrokerConfigs(t, r)
+
+	// the protocol version is kept until every broker reports the new version
+	r.KafkaCluster.Status.BrokersState["0"] = v1beta1.BrokerState{Version: "3.4.0", ConfigurationState: v1beta1.ConfigInSync}
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeRollingBinaries, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.3")
+
+	setBrokersVersion(r, "3.4.0")
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingProtocol, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+	assert.Equal(t, "3.4", r.KafkaCluster.Status.KafkaVersionUpgrade.ProtocolVersion)
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.4")
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigLogMessageFormatVersion, "3.3")
+
+	// the log message format version is bumped only after the brokers run the new protocol version
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingProtocol, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+
+	rollBrokerConfigs(t, r)
+	r.KafkaCluster.Status.BrokersState["1"] = v1beta1.BrokerState{Version: "3.4.0", ConfigurationState: v1beta1.ConfigOutOfSync}
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingProtocol, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+
+	rollBrokerConfigs(t, r)
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingMessageFormat, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+	assert.Equal(t, "3.4", r.KafkaCluster.Status.KafkaVersionUpgrade.MessageFormatVersion)
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.4")
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigLogMessageFormatVersion, "3.4")
+
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeBumpingMessageFormat, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+
+	rollBrokerConfigs(t, r)
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeCompleted, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+
+	// the status is persisted
+	cluster := &v1beta1.KafkaCluster{}
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(r.KafkaCluster), cluster))
+	assert.Equal(t, r.KafkaCluster.Status.KafkaVersionUpgrade, cluster.Status.KafkaVersionUpgrade)
+
+	// the brokers keep the protocol versions of the last upgrade when the Kafka version is removed
+	r.KafkaCluster.Spec.KafkaVersion = ""
+	require.NoError(t, r.reconcileKafkaVersionUpgrade(ctx, logr.Discard()))
+	assert.Equal(t, v1beta1.KafkaVersionUpgradeCompleted, r.KafkaCluster.Status.KafkaVersionUpgrade.State)
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.4")
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigLogMessageFormatVersion, "3.4")
+
+	// until they are set in the readOnlyConfig
+	r.KafkaCluster.Spec.ReadOnlyConfig = "inter.broker.protocol.version=3.4-IV0"
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigInterBrokerProtocolVersion, "3.4-IV0")
+	assertBrokerConfig(t, r, kafkautils.KafkaConfigLogMessageFormatVersion, "3.4")
+}
+
+func TestReconcileKafkaVersionUpgradeDowngrade(t *testing.T) {
+	r := newVersionUpgradeReconciler("3.3.2", "3.4.0")
+	r.KafkaCluster.Status.KafkaVersionUpgrade = &v1beta1.KafkaVersionUpgradeStatus{
+		State:                v1beta1.KafkaVersionUpgradeCompleted,
+		TargetVersion:        "3.4.0",
+		ProtocolVersion:      "3.4",
+		MessageFormatVersion: "3.4",
+	}
+
+	err := r.reconcileKafkaVersionUpgrade(context.Background(), logr.Discard())
+	assert.ErrorContains(t, err, "can not be downgraded")
+	assert.Equal(t, "3.4.0", r.KafkaCluster.Status.KafkaVersionUpgrade.TargetVersion)
+}
@@ -0,0 +1,140 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DemoteBrokerParams are the parameters of the Cruise Control demote_broker operation
+type DemoteBrokerParams struct {
+	// BrokerIDs are the brokers whose partition leaderships are moved to other brokers
+	BrokerIDs []string
+	// SkipURPDemotion skips moving the leadership of the under-replicated partitions
+	SkipURPDemotion bool
+	// ExcludeFollowerDemotion skips moving the follower replicas to the end of the replica lists
+	ExcludeFollowerDemotion       bool
+	ExcludeRecentlyDemotedBrokers bool
+	DryRun                        bool
+}
+
+// Parameters returns the parameters of the demote_broker CruiseControlOperation
+func (p DemoteBrokerParams) Parameters() map[string]string {
+	params := map[string]string{
+		paramSkipURPDemotion:         strconv.FormatBool(p.SkipURPDemotion),
+		paramExcludeFollowerDemotion: strconv.FormatBool(p.ExcludeFollowerDemotion),
+		ParamExcludeDemoted:          strconv.FormatBool(p.ExcludeRecentlyDemotedBrokers),
+		ParamDryRun:                  strconv.FormatBool(p.DryRun),
+	}
+	if len(p.BrokerIDs) > 0 {
+		params[ParamBrokerID] = strings.Join(p.BrokerIDs, ",")
+	}
+	return params
+}
+
+// FixOfflineReplicasParams are the parameters of the Cruise Control fix_offline_replicas operation
+type FixOfflineReplicasParams struct {
+	// Goals are the goals used to compute the proposal, the default goals of Cruise Control are used when it is empty
+	Goals []string
+	// ExcludedTopics is a regular expression of the topics whose replicas are not moved
+	ExcludedTopics                string
+	ExcludeRecentlyDemotedBrokers bool
+	ExcludeRecentlyRemovedBrokers bool
+	DryRun                        bool
+}
+
+// Parameters returns the parameters of the fix_offline_replicas CruiseControlOperation
+func (p FixOfflineReplicasParams) Parameters() map[string]string {
+	params := map[string]string{
+		ParamExcludeDemoted: strconv.FormatBool(p.ExcludeRecentlyDemotedBrokers),
+		ParamExcludeRemoved: strconv.FormatBool(p.ExcludeRecentlyRemovedBrokers),
+		ParamDryRun:         strconv.FormatBool(p.DryRun),
+	}
+	if len(p.Goals) > 0 {
+		params[ParamGoals] = strings.Join(p.Goals, ",")
+	}
+	if p.ExcludedTopics != "" {
+		params[ParamExcludedTopics] = p.ExcludedTopics
+	}
+	return params
+}
+
+// TopicConfigurationParams are the parameters of the Cruise Control topic_configuration operation which changes
+// the replication factor of topics
+type TopicConfigurationParams struct {
+	// Topic is a regular expression of the topics whose replication factor is changed
+	Topic             string
+	ReplicationFactor int32
+	// SkipRackAwarenessCheck allows increasing the replication factor above the number of racks
+	SkipRackAwarenessCheck bool
+	DryRun                 bool
+}
+
+// Parameters returns the parameters of the topic_configuration CruiseControlOperation
+func (p TopicConfigurationParams) Parameters() map[string]string {
+	return map[string]string{
+		paramTopic:                  p.Topic,
+		paramReplicationFactor:      strconv.Itoa(int(p.ReplicationFactor)),
+		paramSkipRackAwarenessCheck: strconv.FormatBool(p.SkipRackAwarenessCheck),
+		ParamDryRun:                 strconv.FormatBool(p.DryRun),
+	}
+}
+
+// RemoveDisksParams are the parameters of the Cruise Control remove_disks operation which moves the replicas off
+// the log dirs to the other log dirs of the same brokers
+type RemoveDisksParams struct {
+	// LogDirsByBroker are the absolute paths of the log dirs to be emptied by broker ID
+	LogDirsByBroker map[string][]string
+	DryRun          bool
+}
+
+// Parameters returns the parameters of the remove_disks CruiseControlOperation
+func (p RemoveDisksParams) Parameters() map[string]string {
+	params := map[string]string{
+		ParamDryRun: strconv.FormatBool(p.DryRun),
+	}
+	brokerIDs := make([]string, 0, len(p.LogDirsByBroker))
+	for brokerID := range p.LogDirsByBroker {
+		brokerIDs = append(brokerIDs, brokerID)
+	}
+	sort.Strings(brokerIDs)
+	var brokerIDAndLogDirs []string
+	for _, brokerID := range brokerIDs {
+		for _, logDir := range p.LogDirsByBroker[brokerID] {
+			brokerIDAndLogDirs = append(brokerIDAndLogDirs, brokerID+"-"+logDir)
+		}
+	}
+	if len(brokerIDAndLogDirs) > 0 {
+		params[paramBrokerIDAndLogDirs] = strings.Join(brokerIDAndLogDirs, ",")
+	}
+	return params
+}
+
+// SamplingParams are the parameters of the Cruise Control pause_sampling and resume_sampling operations
+type SamplingParams struct {
+	// Reason is recorded by Cruise Control for pausing or resuming the metric sampling
+	Reason string
+}
+
+// Parameters returns the parameters of the pause_sampling and resume_sampling CruiseControlOperations
+func (p SamplingParams) Parameters() map[string]string {
+	params := make(map[string]string)
+	if p.Reason != "" {
+		params[paramReason] = p.Reason
+	}
+	return params
+}
@@ -0,0 +1,125 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/banzaicloud/koperator/api/v1beta1"
+)
+
+func TestOperationParameters(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"brokerid":                         "1,2",
+		"skip_urp_demotion":                "false",
+		"exclude_follower_demotion":        "true",
+		"exclude_recently_demoted_brokers": "false",
+		"dryrun":                           "false",
+	}, DemoteBrokerParams{BrokerIDs: []string{"1", "2"}, ExcludeFollowerDemotion: true}.Parameters())
+
+	assert.Equal(t, map[string]string{
+		"goals":                            "RackAwareGoal",
+		"exclude_recently_demoted_brokers": "true",
+		"exclude_recently_removed_brokers": "true",
+		"dryrun":                           "true",
+	}, FixOfflineReplicasParams{
+		Goals:                         []string{"RackAwareGoal"},
+		ExcludeRecentlyDemotedBrokers: true,
+		ExcludeRecentlyRemovedBrokers: true,
+		DryRun:                        true,
+	}.Parameters())
+
+	assert.Equal(t, map[string]string{
+		"topic":                     "orders.*",
+		"replication_factor":        "3",
+		"skip_rack_awareness_check": "false",
+		"dryrun":                    "false",
+	}, TopicConfigurationParams{Topic: "orders.*", ReplicationFactor: 3}.Parameters())
+
+	assert.Equal(t, map[string]string{
+		"brokerid_and_logdirs": "1-/kafka-logs1,1-/kafka-logs2,2-/kafka-logs1",
+		"dryrun":               "false",
+	}, RemoveDisksParams{LogDirsByBroker: map[string][]string{
+		"2": {"/kafka-logs1"},
+		"1": {"/kafka-logs1", "/kafka-logs2"},
+	}}.Parameters())
+
+	assert.Equal(t, map[string]string{"reason": "maintenance"}, SamplingParams{Reason: "maintenance"}.Parameters())
+	assert.Empty(t, SamplingParams{}.Parameters())
+}
+
+func TestOperationParametersValidation(t *testing.T) {
+	cc := &cruiseControlScaler{}
+	ctx := context.Background()
+
+	_, err := cc.DemoteBrokersWithParams(ctx, DemoteBrokerParams{}.Parameters())
+	assert.ErrorContains(t, err, "missing demote_broker parameter: brokerid")
+
+	_, err = cc.DemoteBrokersWithParams(ctx, map[string]string{"brokerid": "1", "skip_urp_demotion": "maybe"})
+	assert.Error(t, err)
+
+	_, err = cc.FixOfflineReplicasWithParams(ctx, FixOfflineReplicasParams{Goals: []string{"UnknownGoal"}}.Parameters())
+	assert.Error(t, err)
+
+	_, err = cc.TopicConfigurationWithParams(ctx, TopicConfigurationParams{ReplicationFactor: 3}.Parameters())
+	assert.ErrorContains(t, err, "missing topic_configuration parameter: topic")
+
+	_, err = cc.TopicConfigurationWithParams(ctx, TopicConfigurationParams{Topic: "orders.*"}.Parameters())
+	assert.ErrorContains(t, err, "replication_factor must be at least 1")
+
+	_, err = cc.RemoveDisksWithParams(ctx, RemoveDisksParams{}.Parameters())
+	assert.ErrorContains(t, err, "missing remove_disks parameter: brokerid_and_logdirs")
+
+	_, err = cc.RemoveDisksWithParams(ctx, map[string]string{"brokerid_and_logdirs": "1-kafka-logs1"})
+	assert.ErrorContains(t, err, "1-kafka-logs1 is not a broker ID and an absolute log dir joined by a dash")
+
+	_, err = cc.RemoveDisksWithParams(ctx, map[string]string{"brokerid_and_logdirs": "broker-/kafka-logs1"})
+	assert.ErrorContains(t, err, "broker-/kafka-logs1 has an invalid broker ID")
+}
+
+func TestRemoveDisksWithParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/kafkacruisecontrol/remove_disks", r.URL.Path)
+		assert.Equal(t, "1-/kafka-logs1", r.URL.Query().Get("brokerid_and_logdirs"))
+		assert.Equal(t, "true", r.URL.Query().Get("json"))
+		w.Header().Set("User-Task-ID", "task-1")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"summary":{"numReplicaMovements":2,"dataToMoveMB":100}}`))
+	}))
+	defer server.Close()
+
+	cc := &cruiseControlScaler{
+		log:        logr.Discard(),
+		serverURL:  server.URL + "/kafkacruisecontrol",
+		httpClient: server.Client(),
+	}
+	res, err := cc.RemoveDisksWithParams(context.Background(), RemoveDisksParams{
+		LogDirsByBroker: map[string][]string{"1": {"/kafka-logs1"}},
+	}.Parameters())
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", res.TaskID)
+	assert.Equal(t, v1beta1.CruiseControlTaskActive, res.State)
+	if assert.NotNil(t, res.Result) {
+		assert.Equal(t, int32(2), res.Result.Summary.NumReplicaMovements)
+	}
+}
@@ -16,8 +16,11 @@ package scale
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -35,11 +38,27 @@ import (
 const (
 	// Constants for the Cruise Control operations parameters
 	// Check for more details: https://github.com/linkedin/cruise-control/wiki/REST-APIs
-	paramBrokerID       = "brokerid"
-	paramExcludeDemoted = "exclude_recently_demoted_brokers"
-	paramExcludeRemoved = "exclude_recently_removed_brokers"
-	paramDestbrokerIDs  = "destination_broker_ids"
-	paramRebalanceDisk  = "rebalance_disk"
+	ParamBrokerID                     = "brokerid"
+	ParamExcludeDemoted               = "exclude_recently_demoted_brokers"
+	ParamExcludeRemoved               = "exclude_recently_removed_brokers"
+	ParamDestbrokerIDs                = "destination_broker_ids"
+	ParamRebalanceDisk                = "rebalance_disk"
+	ParamGoals                        = "goals"
+	ParamExcludedTopics               = "excluded_topics"
+	ParamConcurrentPartitionMovements = "concurrent_partition_movements_per_broker"
+	ParamConcurrentLeaderMovements    = "concurrent_leader_movements"
+	ParamDryRun                       = "dryrun"
+	ParamReplicationThrottle          = "replication_throttle"
+	paramSkipURPDemotion              = "skip_urp_demotion"
+	paramExcludeFollowerDemotion      = "exclude_follower_demotion"
+	paramTopic                        = "topic"
+	paramReplicationFactor            = "replication_factor"
+	paramSkipRackAwarenessCheck       = "skip_rack_awareness_check"
+	paramReason                       = "reason"
+	paramBrokerIDAndLogDirs           = "brokerid_and_logdirs"
+	// The go-cruise-control client does not implement the remove_disks endpoint thus it is called directly
+	removeDisksEndpointPath = "remove_disks"
+	userAgent               = "koperator"
 	// Cruise Control API returns NullPointerException when a broker storage capacity calculations are missing
 	// from the Cruise Control configurations
 	nullPointerExceptionErrString = "NullPointerException"
@@ -50,20 +69,69 @@ const (
 var (
 	newCruiseControlScaler   = createNewDefaultCruiseControlScaler
 	addBrokerSupportedParams = map[string]struct{}{
-		paramBrokerID:       {},
-		paramExcludeDemoted: {},
-		paramExcludeRemoved: {},
+		ParamBrokerID:                     {},
+		ParamExcludeDemoted:               {},
+		ParamExcludeRemoved:               {},
+		ParamDryRun:                       {},
+		ParamConcurrentPartitionMovements: {},
+		ParamConcurrentLeaderMovements:    {},
+		ParamReplicationThrottle:          {},
 	}
 	removeBrokerSupportedParams = map[string]struct{}{
-		paramBrokerID:       {},
-		paramExcludeDemoted: {},
-		paramExcludeRemoved: {},
+		ParamBrokerID:                     {},
+		ParamExcludeDemoted:               {},
+		ParamExcludeRemoved:               {},
+		ParamDryRun:                       {},
+		ParamConcurrentPartitionMovements: {},
+		ParamConcurrentLeaderMovements:    {},
+		ParamReplicationThrottle:          {},
 	}
 	rebalanceSupportedParams = map[string]struct{}{
-		paramDestbrokerIDs:  {},
-		paramRebalanceDisk:  {},
-		paramExcludeDemoted: {},
-		paramExcludeRemoved: {},
+		ParamDestbrokerIDs:                {},
+		ParamRebalanceDisk:                {},
+		ParamExcludeDemoted:               {},
+		ParamExcludeRemoved:               {},
+		ParamGoals:                        {},
+		ParamExcludedTopics:               {},
+		ParamConcurrentPartitionMovements: {},
+		ParamConcurrentLeaderMovements:    {},
+		ParamDryRun:                       {},
+		ParamReplicationThrottle:          {},
+	}
+	demoteBrokerSupportedParams = map[string]struct{}{
+		ParamBrokerID:                  {},
+		paramSkipURPDemotion:           {},
+		paramExcludeFollowerDemotion:   {},
+		ParamExcludeDemoted:            {},
+		ParamDryRun:                    {},
+		ParamConcurrentLeaderMovements: {},
+		ParamReplicationThrottle:       {},
+	}
+	fixOfflineReplicasSupportedParams = map[string]struct{}{
+		ParamGoals:                        {},
+		ParamExcludedTopics:               {},
+		ParamExcludeDemoted:               {},
+		ParamExcludeRemoved:               {},
+		ParamDryRun:                       {},
+		ParamConcurrentPartitionMovements: {},
+		ParamConcurrentLeaderMovements:    {},
+		ParamReplicationThrottle:          {},
+	}
+	topicConfigurationSupportedParams = map[string]struct{}{
+		paramTopic:                        {},
+		paramReplicationFactor:            {},
+		paramSkipRackAwarenessCheck:       {},
+		ParamDryRun:                       {},
+		ParamConcurrentPartitionMovements: {},
+		ParamConcurrentLeaderMovements:    {},
+		ParamReplicationThrottle:          {},
+	}
+	removeDisksSupportedParams = map[string]struct{}{
+		paramBrokerIDAndLogDirs: {},
+		ParamDryRun:             {},
+	}
+	samplingSupportedParams = map[string]struct{}{
+		paramReason: {},
 	}
 )
 
@@ -82,7 +150,7 @@ func createNewDefaultCruiseControlScaler(ctx context.Context, serverURL string)
 
 	cfg := &client.Config{
 		ServerURL: serverURL,
-		UserAgent: "koperator",
+		UserAgent: userAgent,
 	}
 
 	cruisecontrol, err := client.NewClient(cfg)
@@ -91,8 +159,10 @@ func createNewDefaultCruiseControlScaler(ctx context.Context, serverURL string)
 		return nil, err
 	}
 	return &cruiseControlScaler{
-		log:    log,
-		client: cruisecontrol,
+		log:        log,
+		client:     cruisecontrol,
+		serverURL:  serverURL,
+		httpClient: &http.Client{Transport: http.DefaultTransport},
 	}, nil
 }
 
@@ -101,6 +171,9 @@ type cruiseControlScaler struct {
 
 	log    logr.Logger
 	client *client.Client
+	// serverURL and httpClient are used to call the Cruise Control endpoints which are not implemented by the client
+	serverURL  string
+	httpClient *http.Client
 }
 
 // Status returns a CruiseControlStatus describing the internal state of Cruise Control.
@@ -179,6 +252,40 @@ func (cc *cruiseControlScaler) UserTasks(ctx context.Context, taskIDs ...string)
 	return results, nil
 }
 
+// UserTaskResult returns the Result of the User Task from Cruise Control for the provided task ID, including the
+// proposal computed by the task once it is completed. It returns nil when Cruise Control does not know the task.
+func (cc *cruiseControlScaler) UserTaskResult(ctx context.Context, taskID string) (*Result, error) {
+	req := &api.UserTasksRequest{
+		UserTaskIDs:         []string{taskID},
+		FetchCompletedTasks: true,
+	}
+
+	resp, err := cc.client.UserTasks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, taskInfo := range resp.Result.UserTasks {
+		if taskInfo.UserTaskID != taskID {
+			continue
+		}
+		result := &Result{
+			TaskID:     taskInfo.UserTaskID,
+			StartedAt:  taskInfo.StartMs.UTC().String(),
+			RequestURL: taskInfo.RequestURL,
+			State:      v1beta1.CruiseControlUserTaskState(taskInfo.Status.String()),
+		}
+		if taskInfo.Status == types.UserTaskStatusCompleted && taskInfo.OriginalResponse != "" {
+			result.Result = &types.OptimizationResult{}
+			if err := json.Unmarshal([]byte(taskInfo.OriginalResponse), result.Result); err != nil {
+				return nil, errors.WrapIfWithDetails(err, "could not parse the response of the user task", "task ID", taskID)
+			}
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
 // parseBrokerIDtoSlice parses brokerIDs to int slice
 func parseBrokerIDtoSlice(brokerid string) ([]int32, error) {
 	var brokerIDIntSlice []int32
@@ -193,6 +300,37 @@ func parseBrokerIDtoSlice(brokerid string) ([]int32, error) {
 	return brokerIDIntSlice, nil
 }
 
+// parseGoals parses the comma separated list of Cruise Control goals
+// validateBrokerIDAndLogDirs validates the brokerid_and_logdirs parameter of the remove_disks operation which is a comma
+// separated list of broker ID and absolute log dir pairs joined by a dash, e.g. 1-/kafka-logs1,2-/kafka-logs2
+func validateBrokerIDAndLogDirs(brokerIDAndLogDirs string) error {
+	for _, pair := range strings.Split(brokerIDAndLogDirs, ",") {
+		brokerID, logDir, found := strings.Cut(pair, "-")
+		if !found || !strings.HasPrefix(logDir, "/") {
+			return fmt.Errorf("invalid %s parameter: %s is not a broker ID and an absolute log dir joined by a dash", paramBrokerIDAndLogDirs, pair)
+		}
+		if _, err := strconv.ParseInt(brokerID, 10, 32); err != nil {
+			return fmt.Errorf("invalid %s parameter: %s has an invalid broker ID", paramBrokerIDAndLogDirs, pair)
+		}
+	}
+	return nil
+}
+
+func parseGoals(goals string) ([]types.Goal, error) {
+	var parsedGoals []types.Goal
+	for _, name := range strings.Split(goals, ",") {
+		var goal types.Goal
+		if err := goal.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return nil, err
+		}
+		if goal == types.UndefinedGoal {
+			return nil, fmt.Errorf("unsupported goal: %s", name)
+		}
+		parsedGoals = append(parsedGoals, goal)
+	}
+	return parsedGoals, nil
+}
+
 // AddBrokersWithParams requests Cruise Control to add the list of provided brokers to the Kafka cluster
 // by reassigning partition replicas to them. The broker list and operation properties can be added
 // with the use of the params argument.
@@ -205,24 +343,48 @@ func (cc *cruiseControlScaler) AddBrokersWithParams(ctx context.Context, params
 	for param, pvalue := range params {
 		if _, ok := addBrokerSupportedParams[param]; ok {
 			switch param {
-			case paramBrokerID:
+			case ParamBrokerID:
 				ret, err := parseBrokerIDtoSlice(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				addBrokerReq.BrokerIDs = ret
-			case paramExcludeDemoted:
+			case ParamExcludeDemoted:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				addBrokerReq.ExcludeRecentlyDemotedBrokers = ret
-			case paramExcludeRemoved:
+			case ParamExcludeRemoved:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				addBrokerReq.ExcludeRecentlyRemovedBrokers = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				addBrokerReq.DryRun = ret
+			case ParamConcurrentPartitionMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				addBrokerReq.ConcurrentPartitionMovementsPerBroker = int32(ret)
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				addBrokerReq.ConcurrentLeaderMovements = int32(ret)
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				addBrokerReq.ReplicationThrottle = ret
 			default:
 				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationAddBroker, param, addBrokerSupportedParams)
 			}
@@ -282,24 +444,48 @@ func (cc *cruiseControlScaler) RemoveBrokersWithParams(ctx context.Context, para
 	for param, pvalue := range params {
 		if _, ok := removeBrokerSupportedParams[param]; ok {
 			switch param {
-			case paramBrokerID:
+			case ParamBrokerID:
 				ret, err := parseBrokerIDtoSlice(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rmBrokerReq.BrokerIDs = ret
-			case paramExcludeDemoted:
+			case ParamExcludeDemoted:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rmBrokerReq.ExcludeRecentlyDemotedBrokers = ret
-			case paramExcludeRemoved:
+			case ParamExcludeRemoved:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rmBrokerReq.ExcludeRecentlyRemovedBrokers = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				rmBrokerReq.DryRun = ret
+			case ParamConcurrentPartitionMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				rmBrokerReq.ConcurrentPartitionMovementsPerBroker = int32(ret)
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				rmBrokerReq.ConcurrentLeaderMovements = int32(ret)
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				rmBrokerReq.ReplicationThrottle = ret
 			default:
 				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationRemoveBroker, param, removeBrokerSupportedParams)
 			}
@@ -464,30 +650,63 @@ func (cc *cruiseControlScaler) RebalanceWithParams(ctx context.Context, params m
 	for param, pvalue := range params {
 		if _, ok := rebalanceSupportedParams[param]; ok {
 			switch param {
-			case paramDestbrokerIDs:
+			case ParamDestbrokerIDs:
 				ret, err := parseBrokerIDtoSlice(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rebalanceReq.DestinationBrokerIDs = ret
-			case paramRebalanceDisk:
+			case ParamRebalanceDisk:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rebalanceReq.RebalanceDisk = ret
-			case paramExcludeDemoted:
+			case ParamExcludeDemoted:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rebalanceReq.ExcludeRecentlyDemotedBrokers = ret
-			case paramExcludeRemoved:
+			case ParamExcludeRemoved:
 				ret, err := strconv.ParseBool(pvalue)
 				if err != nil {
 					return nil, err
 				}
 				rebalanceReq.ExcludeRecentlyRemovedBrokers = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				rebalanceReq.DryRun = ret
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				rebalanceReq.ReplicationThrottle = ret
+			case ParamGoals:
+				ret, err := parseGoals(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				rebalanceReq.Goals = ret
+				rebalanceReq.UseReadyDefaultGoals = false
+			case ParamExcludedTopics:
+				rebalanceReq.ExcludedTopics = pvalue
+			case ParamConcurrentPartitionMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				rebalanceReq.ConcurrentPartitionMovementsPerBroker = int32(ret)
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				rebalanceReq.ConcurrentLeaderMovements = int32(ret)
 			default:
 				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationRebalance, param, rebalanceSupportedParams)
 			}
@@ -516,6 +735,496 @@ func (cc *cruiseControlScaler) RebalanceWithParams(ctx context.Context, params m
 	}, nil
 }
 
+// DemoteBrokersWithParams requests Cruise Control to move the partition leaderships off the provided brokers.
+// The broker list and operation properties can be added with the use of the params argument.
+func (cc *cruiseControlScaler) DemoteBrokersWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	demoteBrokerReq := api.DemoteBrokerRequestWithDefaults()
+	for param, pvalue := range params {
+		if _, ok := demoteBrokerSupportedParams[param]; ok {
+			switch param {
+			case ParamBrokerID:
+				ret, err := parseBrokerIDtoSlice(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.BrokerIDs = ret
+			case paramSkipURPDemotion:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.SkipUrpDemotion = ret
+			case paramExcludeFollowerDemotion:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.ExcludeFollowerDemotion = ret
+			case ParamExcludeDemoted:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.ExcludeRecentlyDemotedBrokers = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.DryRun = ret
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.ConcurrentLeaderMovements = int32(ret)
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				demoteBrokerReq.ReplicationThrottle = ret
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationDemoteBroker, param, demoteBrokerSupportedParams)
+			}
+		}
+	}
+	if len(demoteBrokerReq.BrokerIDs) == 0 {
+		return nil, fmt.Errorf("missing %s parameter: %s", v1alpha1.OperationDemoteBroker, ParamBrokerID)
+	}
+
+	demoteBrokerResp, err := cc.client.DemoteBroker(ctx, demoteBrokerReq)
+	if err != nil {
+		return &Result{
+			TaskID:             demoteBrokerResp.TaskID,
+			StartedAt:          demoteBrokerResp.Date,
+			ResponseStatusCode: demoteBrokerResp.StatusCode,
+			RequestURL:         demoteBrokerResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             demoteBrokerResp.TaskID,
+		StartedAt:          demoteBrokerResp.Date,
+		ResponseStatusCode: demoteBrokerResp.StatusCode,
+		RequestURL:         demoteBrokerResp.RequestURL,
+		Result:             demoteBrokerResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
+// FixOfflineReplicasWithParams requests Cruise Control to move the offline partition replicas, e.g. the replicas
+// on failed disks, to healthy brokers and log dirs.
+func (cc *cruiseControlScaler) FixOfflineReplicasWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	fixOfflineReplicasReq := &api.FixOfflineReplicasRequest{
+		AllowCapacityEstimation: true,
+		DataFrom:                types.ProposalDataSourceValidWindows,
+		UseReadyDefaultGoals:    true,
+	}
+	for param, pvalue := range params {
+		if _, ok := fixOfflineReplicasSupportedParams[param]; ok {
+			switch param {
+			case ParamGoals:
+				ret, err := parseGoals(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.Goals = ret
+				fixOfflineReplicasReq.UseReadyDefaultGoals = false
+			case ParamExcludedTopics:
+				fixOfflineReplicasReq.ExcludedTopics = pvalue
+			case ParamExcludeDemoted:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.ExcludeRecentlyDemotedBrokers = ret
+			case ParamExcludeRemoved:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.ExcludeRecentlyRemovedBrokers = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.DryRun = ret
+			case ParamConcurrentPartitionMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.ConcurrentPartitionMovementsPerBroker = int32(ret)
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.ConcurrentLeaderMovements = int32(ret)
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				fixOfflineReplicasReq.ReplicationThrottle = ret
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationFixOfflineReplicas, param, fixOfflineReplicasSupportedParams)
+			}
+		}
+	}
+
+	fixOfflineReplicasResp, err := cc.client.FixOfflineReplicas(ctx, fixOfflineReplicasReq)
+	if err != nil {
+		return &Result{
+			TaskID:             fixOfflineReplicasResp.TaskID,
+			StartedAt:          fixOfflineReplicasResp.Date,
+			ResponseStatusCode: fixOfflineReplicasResp.StatusCode,
+			RequestURL:         fixOfflineReplicasResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             fixOfflineReplicasResp.TaskID,
+		StartedAt:          fixOfflineReplicasResp.Date,
+		ResponseStatusCode: fixOfflineReplicasResp.StatusCode,
+		RequestURL:         fixOfflineReplicasResp.RequestURL,
+		Result:             fixOfflineReplicasResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
+// TopicConfigurationWithParams requests Cruise Control to change the replication factor of the topics matching
+// the topic parameter.
+func (cc *cruiseControlScaler) TopicConfigurationWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	topicConfigurationReq := &api.TopicConfigurationRequest{
+		AllowCapacityEstimation: true,
+		DataFrom:                types.ProposalDataSourceValidWindows,
+		UseReadyDefaultGoals:    true,
+	}
+	for param, pvalue := range params {
+		if _, ok := topicConfigurationSupportedParams[param]; ok {
+			switch param {
+			case paramTopic:
+				topicConfigurationReq.Topic = pvalue
+			case paramReplicationFactor:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.ReplicationFactor = int32(ret)
+			case paramSkipRackAwarenessCheck:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.SkipRackAwarenessCheck = ret
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.DryRun = ret
+			case ParamConcurrentPartitionMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.ConcurrentPartitionMovementsPerBroker = int32(ret)
+			case ParamConcurrentLeaderMovements:
+				ret, err := strconv.ParseInt(pvalue, 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.ConcurrentLeaderMovements = int32(ret)
+			case ParamReplicationThrottle:
+				ret, err := strconv.ParseInt(pvalue, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				topicConfigurationReq.ReplicationThrottle = ret
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationTopicConfiguration, param, topicConfigurationSupportedParams)
+			}
+		}
+	}
+	if topicConfigurationReq.Topic == "" {
+		return nil, fmt.Errorf("missing %s parameter: %s", v1alpha1.OperationTopicConfiguration, paramTopic)
+	}
+	if topicConfigurationReq.ReplicationFactor < 1 {
+		return nil, fmt.Errorf("invalid %s parameter: %s must be at least 1", v1alpha1.OperationTopicConfiguration, paramReplicationFactor)
+	}
+
+	topicConfigurationResp, err := cc.client.TopicConfiguration(ctx, topicConfigurationReq)
+	if err != nil {
+		return &Result{
+			TaskID:             topicConfigurationResp.TaskID,
+			StartedAt:          topicConfigurationResp.Date,
+			ResponseStatusCode: topicConfigurationResp.StatusCode,
+			RequestURL:         topicConfigurationResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             topicConfigurationResp.TaskID,
+		StartedAt:          topicConfigurationResp.Date,
+		ResponseStatusCode: topicConfigurationResp.StatusCode,
+		RequestURL:         topicConfigurationResp.RequestURL,
+		Result:             topicConfigurationResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
+// RemoveDisksWithParams requests Cruise Control to move the replicas off the log dirs provided by the
+// brokerid_and_logdirs parameter to the other log dirs of the same brokers.
+func (cc *cruiseControlScaler) RemoveDisksWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	query := url.Values{}
+	for param, pvalue := range params {
+		if _, ok := removeDisksSupportedParams[param]; ok {
+			switch param {
+			case paramBrokerIDAndLogDirs:
+				if err := validateBrokerIDAndLogDirs(pvalue); err != nil {
+					return nil, err
+				}
+				query.Set(paramBrokerIDAndLogDirs, pvalue)
+			case ParamDryRun:
+				ret, err := strconv.ParseBool(pvalue)
+				if err != nil {
+					return nil, err
+				}
+				query.Set(ParamDryRun, strconv.FormatBool(ret))
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationRemoveDisks, param, removeDisksSupportedParams)
+			}
+		}
+	}
+	if query.Get(paramBrokerIDAndLogDirs) == "" {
+		return nil, fmt.Errorf("missing %s parameter: %s", v1alpha1.OperationRemoveDisks, paramBrokerIDAndLogDirs)
+	}
+	query.Set("json", "true")
+
+	removeDisksResp, err := cc.post(ctx, removeDisksEndpointPath, query)
+	if err != nil {
+		return &Result{
+			TaskID:             removeDisksResp.TaskID,
+			StartedAt:          removeDisksResp.Date,
+			ResponseStatusCode: removeDisksResp.StatusCode,
+			RequestURL:         removeDisksResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             removeDisksResp.TaskID,
+		StartedAt:          removeDisksResp.Date,
+		ResponseStatusCode: removeDisksResp.StatusCode,
+		RequestURL:         removeDisksResp.RequestURL,
+		Result:             removeDisksResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
+// post sends a POST request to the Cruise Control endpoint and parses its response which has the same format as the
+// response of the rebalance endpoint
+func (cc *cruiseControlScaler) post(ctx context.Context, endpointPath string, query url.Values) (*api.RebalanceResponse, error) {
+	resp := &api.RebalanceResponse{}
+	reqURL, err := url.JoinPath(cc.serverURL, endpointPath)
+	if err != nil {
+		return resp, errors.WrapIfWithDetails(err, "could not build the URL of the Cruise Control endpoint", "endpoint", endpointPath)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return resp, errors.WrapIfWithDetails(err, "could not create the request of the Cruise Control endpoint", "endpoint", endpointPath)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	httpResp, err := cc.httpClient.Do(req)
+	if err != nil {
+		return resp, errors.WrapIfWithDetails(err, "sending HTTP request to Cruise Control failed", "endpoint", endpointPath)
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			cc.log.Error(err, "could not close the response body of the Cruise Control request", "url", req.URL)
+		}
+	}()
+
+	if err = resp.UnmarshalResponse(httpResp); err != nil {
+		return resp, errors.WrapIfWithDetails(err, "could not parse the response of the Cruise Control endpoint", "endpoint", endpointPath)
+	}
+	if resp.Failed() {
+		return resp, errors.WrapIfWithDetails(resp.Err(), "HTTP request failed", "endpoint", endpointPath)
+	}
+	return resp, nil
+}
+
+// PauseSamplingWithParams requests Cruise Control to pause the metric sampling
+func (cc *cruiseControlScaler) PauseSamplingWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	pauseSamplingReq := api.PauseSamplingRequestWithDefaults()
+	for param, pvalue := range params {
+		if _, ok := samplingSupportedParams[param]; ok {
+			switch param {
+			case paramReason:
+				pauseSamplingReq.Reason = pvalue
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationPauseSampling, param, samplingSupportedParams)
+			}
+		}
+	}
+
+	pauseSamplingResp, err := cc.client.PauseSampling(ctx, pauseSamplingReq)
+	if err != nil {
+		return &Result{
+			TaskID:             pauseSamplingResp.TaskID,
+			StartedAt:          pauseSamplingResp.Date,
+			ResponseStatusCode: pauseSamplingResp.StatusCode,
+			RequestURL:         pauseSamplingResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	result := &Result{
+		TaskID:             pauseSamplingResp.TaskID,
+		StartedAt:          pauseSamplingResp.Date,
+		ResponseStatusCode: pauseSamplingResp.StatusCode,
+		RequestURL:         pauseSamplingResp.RequestURL,
+		State:              v1beta1.CruiseControlTaskActive,
+	}
+	if pauseSamplingResp.Result != nil {
+		result.Message = pauseSamplingResp.Result.Message
+	}
+	return result, nil
+}
+
+// ResumeSamplingWithParams requests Cruise Control to resume the metric sampling
+func (cc *cruiseControlScaler) ResumeSamplingWithParams(ctx context.Context, params map[string]string) (*Result, error) {
+	resumeSamplingReq := api.ResumeSamplingRequestWithDefaults()
+	for param, pvalue := range params {
+		if _, ok := samplingSupportedParams[param]; ok {
+			switch param {
+			case paramReason:
+				resumeSamplingReq.Reason = pvalue
+			default:
+				return nil, fmt.Errorf("unsupported %s parameter: %s, supported parameters: %s", v1alpha1.OperationResumeSampling, param, samplingSupportedParams)
+			}
+		}
+	}
+
+	resumeSamplingResp, err := cc.client.ResumeSampling(ctx, resumeSamplingReq)
+	if err != nil {
+		return &Result{
+			TaskID:             resumeSamplingResp.TaskID,
+			StartedAt:          resumeSamplingResp.Date,
+			ResponseStatusCode: resumeSamplingResp.StatusCode,
+			RequestURL:         resumeSamplingResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	result := &Result{
+		TaskID:             resumeSamplingResp.TaskID,
+		StartedAt:          resumeSamplingResp.Date,
+		ResponseStatusCode: resumeSamplingResp.StatusCode,
+		RequestURL:         resumeSamplingResp.RequestURL,
+		State:              v1beta1.CruiseControlTaskActive,
+	}
+	if resumeSamplingResp.Result != nil {
+		result.Message = resumeSamplingResp.Result.Message
+	}
+	return result, nil
+}
+
+// DemoteBrokers requests Cruise Control to move the partition leaderships off the provided brokers.
+func (cc *cruiseControlScaler) DemoteBrokers(ctx context.Context, brokerIDs ...string) (*Result, error) {
+	if len(brokerIDs) == 0 {
+		return nil, errors.New("no broker id(s) provided for demote brokers request")
+	}
+
+	brokersToDemote, err := brokerIDsFromStringSlice(brokerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	demoteBrokerReq := api.DemoteBrokerRequestWithDefaults()
+	demoteBrokerReq.BrokerIDs = brokersToDemote
+	// the leadership of under-replicated partitions has to be moved as well when there is an in-sync replica for it
+	demoteBrokerReq.SkipUrpDemotion = false
+
+	demoteBrokerResp, err := cc.client.DemoteBroker(ctx, demoteBrokerReq)
+	if err != nil {
+		return &Result{
+			TaskID:             demoteBrokerResp.TaskID,
+			StartedAt:          demoteBrokerResp.Date,
+			ResponseStatusCode: demoteBrokerResp.StatusCode,
+			RequestURL:         demoteBrokerResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             demoteBrokerResp.TaskID,
+		StartedAt:          demoteBrokerResp.Date,
+		ResponseStatusCode: demoteBrokerResp.StatusCode,
+		RequestURL:         demoteBrokerResp.RequestURL,
+		Result:             demoteBrokerResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
+// ElectPreferredLeaders drops the provided brokers from the recently demoted brokers of Cruise Control, so they can
+// lead partitions again, then moves the partition leaderships to the preferred replicas.
+func (cc *cruiseControlScaler) ElectPreferredLeaders(ctx context.Context, brokerIDs ...string) (*Result, error) {
+	if len(brokerIDs) > 0 {
+		demotedBrokers, err := brokerIDsFromStringSlice(brokerIDs)
+		if err != nil {
+			return nil, err
+		}
+		adminReq := api.AdminRequestWithDefaults()
+		adminReq.DropRecentlyDemotedBrokers = demotedBrokers
+		if _, err = cc.client.Admin(ctx, adminReq); err != nil {
+			return nil, errors.WrapIff(err, "could not drop the recently demoted brokers %v", brokerIDs)
+		}
+	}
+
+	rebalanceReq := &api.RebalanceRequest{
+		AllowCapacityEstimation: true,
+		DataFrom:                types.ProposalDataSourceValidWindows,
+		Goals:                   []types.Goal{types.PreferredLeaderElectionGoal},
+		SkipHardGoalCheck:       true,
+	}
+	rebalanceResp, err := cc.client.Rebalance(ctx, rebalanceReq)
+	if err != nil {
+		return &Result{
+			TaskID:             rebalanceResp.TaskID,
+			StartedAt:          rebalanceResp.Date,
+			ResponseStatusCode: rebalanceResp.StatusCode,
+			RequestURL:         rebalanceResp.RequestURL,
+			State:              v1beta1.CruiseControlTaskCompletedWithError,
+			Err:                err,
+		}, err
+	}
+
+	return &Result{
+		TaskID:             rebalanceResp.TaskID,
+		StartedAt:          rebalanceResp.Date,
+		ResponseStatusCode: rebalanceResp.StatusCode,
+		RequestURL:         rebalanceResp.RequestURL,
+		Result:             rebalanceResp.Result,
+		State:              v1beta1.CruiseControlTaskActive,
+	}, nil
+}
+
 func (cc *cruiseControlScaler) KafkaClusterLoad(ctx context.Context) (*api.KafkaClusterLoadResponse, error) {
 	clusterLoadResp, err := cc.client.KafkaClusterLoad(ctx, api.KafkaClusterLoadRequestWithDefaults())
 	if err != nil {
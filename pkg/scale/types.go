@@ -27,14 +27,23 @@ type CruiseControlScaler interface {
 	IsReady(ctx context.Context) bool
 	Status(ctx context.Context) (CruiseControlStatus, error)
 	UserTasks(ctx context.Context, taskIDs ...string) ([]*Result, error)
+	UserTaskResult(ctx context.Context, taskID string) (*Result, error)
 	IsUp(ctx context.Context) bool
 	AddBrokers(ctx context.Context, brokerIDs ...string) (*Result, error)
 	AddBrokersWithParams(ctx context.Context, params map[string]string) (*Result, error)
 	RemoveBrokersWithParams(ctx context.Context, params map[string]string) (*Result, error)
 	RebalanceWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	DemoteBrokersWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	FixOfflineReplicasWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	TopicConfigurationWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	RemoveDisksWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	PauseSamplingWithParams(ctx context.Context, params map[string]string) (*Result, error)
+	ResumeSamplingWithParams(ctx context.Context, params map[string]string) (*Result, error)
 	StopExecution(ctx context.Context) (*Result, error)
 	RemoveBrokers(ctx context.Context, brokerIDs ...string) (*Result, error)
 	RebalanceDisks(ctx context.Context, brokerIDs ...string) (*Result, error)
+	DemoteBrokers(ctx context.Context, brokerIDs ...string) (*Result, error)
+	ElectPreferredLeaders(ctx context.Context, brokerIDs ...string) (*Result, error)
 	BrokersWithState(ctx context.Context, states ...KafkaBrokerState) ([]string, error)
 	KafkaClusterState(ctx context.Context) (*types.KafkaClusterState, error)
 	PartitionReplicasByBroker(ctx context.Context) (map[string]int32, error)
@@ -49,8 +58,10 @@ type Result struct {
 	ResponseStatusCode int
 	RequestURL         string
 	Result             *types.OptimizationResult
-	State              v1beta1.CruiseControlUserTaskState
-	Err                error
+	// Message is returned by the Cruise Control operations which do not compute a proposal, e.g. pause_sampling
+	Message string
+	State   v1beta1.CruiseControlUserTaskState
+	Err     error
 }
 
 type LogDirState int8
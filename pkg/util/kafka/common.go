@@ -42,6 +42,9 @@ var PerBrokerConfigs = []string{
 // commonACLString is the raw representation of an ACL allowing Describe on a Topic
 var commonACLString = "User:%s,Topic,%s,%s,Describe,Allow,*"
 
+// describeConfigsACLString is the raw representation of an ACL allowing DescribeConfigs on a Topic
+var describeConfigsACLString = "User:%s,Topic,%s,%s,DescribeConfigs,Allow,*"
+
 // createACLString is the raw representation of an ACL allowing Create on a Topic
 var createACLString = "User:%s,Topic,%s,%s,Create,Allow,*"
 
@@ -64,8 +67,11 @@ func GrantsToACLStrings(dn string, grants []v1alpha1.UserTopicGrant) []string {
 		}
 		patternType := strings.ToUpper(string(x.PatternType))
 		cmn := fmt.Sprintf(commonACLString, dn, patternType, x.TopicName)
-		if !util.StringSliceContains(acls, cmn) {
-			acls = append(acls, cmn)
+		describeConfigs := fmt.Sprintf(describeConfigsACLString, dn, patternType, x.TopicName)
+		for _, y := range []string{cmn, describeConfigs} {
+			if !util.StringSliceContains(acls, y) {
+				acls = append(acls, y)
+			}
 		}
 		switch x.AccessType {
 		case v1alpha1.KafkaAccessTypeRead:
@@ -89,6 +95,57 @@ func GrantsToACLStrings(dn string, grants []v1alpha1.UserTopicGrant) []string {
 	return acls
 }
 
+// aclResourceTypeNames maps the KafkaACL resource types to their raw representation
+var aclResourceTypeNames = map[v1alpha1.KafkaACLResourceType]string{
+	v1alpha1.KafkaACLResourceTypeTopic:           "Topic",
+	v1alpha1.KafkaACLResourceTypeGroup:           "Group",
+	v1alpha1.KafkaACLResourceTypeCluster:         "Cluster",
+	v1alpha1.KafkaACLResourceTypeTransactionalID: "TransactionalID",
+	v1alpha1.KafkaACLResourceTypeDelegationToken: "DelegationToken",
+}
+
+// aclOperationNames maps the KafkaACL operations to their raw representation
+var aclOperationNames = map[v1alpha1.KafkaACLOperation]string{
+	v1alpha1.KafkaACLOperationAll:             "All",
+	v1alpha1.KafkaACLOperationRead:            "Read",
+	v1alpha1.KafkaACLOperationWrite:           "Write",
+	v1alpha1.KafkaACLOperationCreate:          "Create",
+	v1alpha1.KafkaACLOperationDelete:          "Delete",
+	v1alpha1.KafkaACLOperationAlter:           "Alter",
+	v1alpha1.KafkaACLOperationDescribe:        "Describe",
+	v1alpha1.KafkaACLOperationClusterAction:   "ClusterAction",
+	v1alpha1.KafkaACLOperationDescribeConfigs: "DescribeConfigs",
+	v1alpha1.KafkaACLOperationAlterConfigs:    "AlterConfigs",
+	v1alpha1.KafkaACLOperationIdempotentWrite: "IdempotentWrite",
+}
+
+// aclPermissionTypeNames maps the KafkaACL permission types to their raw representation
+var aclPermissionTypeNames = map[v1alpha1.KafkaACLPermissionType]string{
+	v1alpha1.KafkaACLPermissionTypeAllow: "Allow",
+	v1alpha1.KafkaACLPermissionTypeDeny:  "Deny",
+}
+
+// ACLRulesToACLStrings converts a principal and a list of KafkaACL rules to raw strings
+// in the same format which is used for KafkaUser ACLs
+func ACLRulesToACLStrings(principal string, rules []v1alpha1.KafkaACLRule) []string {
+	acls := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		acl := strings.Join([]string{
+			principal,
+			aclResourceTypeNames[rule.Resource.Type],
+			strings.ToUpper(string(rule.GetPatternType())),
+			rule.GetResourceName(),
+			aclOperationNames[rule.Operation],
+			aclPermissionTypeNames[rule.GetPermissionType()],
+			rule.GetHost(),
+		}, ",")
+		if !util.StringSliceContains(acls, acl) {
+			acls = append(acls, acl)
+		}
+	}
+	return acls
+}
+
 func ShouldRefreshOnlyPerBrokerConfigs(currentConfigs, desiredConfigs *properties.Properties, log logr.Logger) bool {
 	// Get the diff of the configuration
 	configDiff := currentConfigs.Diff(desiredConfigs)
@@ -210,6 +267,16 @@ func getBootstrapServers(cluster *v1beta1.KafkaCluster, useService bool) (string
 	} else {
 		for _, broker := range cluster.Spec.Brokers {
 			broker := broker
+			if cluster.Spec.KRaftMode {
+				brokerConfig, err := broker.GetBrokerConfig(cluster.Spec)
+				if err != nil {
+					return "", err
+				}
+				// controller only nodes don't serve client requests
+				if brokerConfig != nil && brokerConfig.IsControllerOnlyNode() {
+					continue
+				}
+			}
 			fqdn := GetBrokerServiceFqdn(cluster, &broker)
 			bootstrapServersList = append(bootstrapServersList,
 				fmt.Sprintf("%s:%d", fqdn, listener.ContainerPort))
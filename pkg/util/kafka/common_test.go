@@ -15,12 +15,14 @@
 package kafka
 
 import (
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/banzaicloud/koperator/api/v1alpha1"
 	"github.com/banzaicloud/koperator/api/v1beta1"
 	"github.com/banzaicloud/koperator/pkg/util"
 	properties "github.com/banzaicloud/koperator/properties/pkg"
@@ -349,3 +351,39 @@ func TestGetBootstrapServers(t *testing.T) {
 		}
 	})
 }
+
+func TestACLRulesToACLStrings(t *testing.T) {
+	rules := []v1alpha1.KafkaACLRule{
+		{
+			Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "orders-", PatternType: v1alpha1.KafkaPatternTypePrefixed},
+			Operation: v1alpha1.KafkaACLOperationDescribeConfigs,
+		},
+		{
+			Resource:       v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTransactionalID, Name: "tx"},
+			Operation:      v1alpha1.KafkaACLOperationWrite,
+			PermissionType: v1alpha1.KafkaACLPermissionTypeDeny,
+			Host:           "10.0.0.1",
+		},
+		{
+			Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeCluster},
+			Operation: v1alpha1.KafkaACLOperationIdempotentWrite,
+		},
+		// duplicate of the first rule with explicit defaults
+		{
+			Resource:       v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "orders-", PatternType: v1alpha1.KafkaPatternTypePrefixed},
+			Operation:      v1alpha1.KafkaACLOperationDescribeConfigs,
+			PermissionType: v1alpha1.KafkaACLPermissionTypeAllow,
+			Host:           "*",
+		},
+	}
+	expected := []string{
+		"User:CN=alice,Topic,PREFIXED,orders-,DescribeConfigs,Allow,*",
+		"User:CN=alice,TransactionalID,LITERAL,tx,Write,Deny,10.0.0.1",
+		"User:CN=alice,Cluster,LITERAL,kafka-cluster,IdempotentWrite,Allow,*",
+	}
+
+	acls := ACLRulesToACLStrings("User:CN=alice", rules)
+	if !reflect.DeepEqual(acls, expected) {
+		t.Errorf("Expected %v, got %v", expected, acls)
+	}
+}
@@ -26,6 +26,14 @@ const (
 	KafkaConfigBrokerId           = "broker.id"
 	KafkaConfigBrokerLogDirectory = "log.dirs"
 
+	KafkaConfigInterBrokerProtocolVersion = "inter.broker.protocol.version"
+	KafkaConfigLogMessageFormatVersion    = "log.message.format.version"
+
+	KafkaConfigProcessRoles           = "process.roles"
+	KafkaConfigNodeId                 = "node.id"
+	KafkaConfigControllerQuorumVoters = "controller.quorum.voters"
+	KafkaConfigControllerListenerName = "controller.listener.names"
+
 	KafkaConfigListeners                   = "listeners"
 	KafkaConfigListenerName                = "listener.name"
 	KafkaConfigListenerSecurityProtocolMap = "listener.security.protocol.map"
@@ -48,4 +56,8 @@ const (
 	CruiseControlConfigMetricsReporters                 = "metric.reporters"
 	CruiseControlConfigMetricsReportersBootstrapServers = "cruise.control.metrics.reporter.bootstrap.servers"
 	CruiseControlConfigMetricsReporterK8sMode           = "cruise.control.metrics.reporter.kubernetes.mode"
+
+	CruiseControlConfigBrokerFailureDetection   = "kafka.broker.failure.detection.enable"
+	CruiseControlConfigTopicConfigProviderClass = "topic.config.provider.class"
+	CruiseControlKafkaAdminTopicConfigProvider  = "com.linkedin.kafka.cruisecontrol.config.KafkaAdminTopicConfigProvider"
 )
@@ -0,0 +1,71 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// ProtocolVersion returns the inter.broker.protocol.version (and log.message.format.version) which belongs to
+// the Kafka version, e.g. 3.4 for 3.4.0
+func ProtocolVersion(kafkaVersion string) (string, error) {
+	major, minor, err := parseVersion(kafkaVersion)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d", major, minor), nil
+}
+
+// CompareProtocolVersions returns -1, 0 or 1 when the protocol version a is older than, the same as
+// or newer than the protocol version b. Patch versions and inter-version suffixes (e.g. -IV1) are ignored.
+func CompareProtocolVersions(a, b string) (int, error) {
+	aMajor, aMinor, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bMajor, bMinor, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case aMajor < bMajor || (aMajor == bMajor && aMinor < bMinor):
+		return -1, nil
+	case aMajor == bMajor && aMinor == bMinor:
+		return 0, nil
+	default:
+		return 1, nil
+	}
+}
+
+// parseVersion returns the major and minor part of a version in the major.minor[.patch][-IVn] format
+func parseVersion(version string) (int, int, error) {
+	parts := strings.Split(strings.SplitN(version, "-", 2)[0], ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, errors.NewWithDetails("invalid Kafka version", "version", version)
+	}
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, 0, errors.NewWithDetails("invalid Kafka version", "version", version)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers[0], numbers[1], nil
+}
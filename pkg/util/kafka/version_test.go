@@ -0,0 +1,73 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import "testing"
+
+func TestProtocolVersion(t *testing.T) {
+	testCases := []struct {
+		Version         string
+		ProtocolVersion string
+		Valid           bool
+	}{
+		{Version: "3.4.0", ProtocolVersion: "3.4", Valid: true},
+		{Version: "2.8.12", ProtocolVersion: "2.8", Valid: true},
+		{Version: "3.4", ProtocolVersion: "3.4", Valid: true},
+		{Version: "3", Valid: false},
+		{Version: "3.x.0", Valid: false},
+		{Version: "", Valid: false},
+	}
+	for _, testCase := range testCases {
+		protocolVersion, err := ProtocolVersion(testCase.Version)
+		if testCase.Valid && err != nil {
+			t.Errorf("unexpected error for version %q: %v", testCase.Version, err)
+		}
+		if !testCase.Valid && err == nil {
+			t.Errorf("expected error for version %q", testCase.Version)
+		}
+		if protocolVersion != testCase.ProtocolVersion {
+			t.Errorf("protocol version of %q mismatch, expected: %q, got: %q", testCase.Version, testCase.ProtocolVersion, protocolVersion)
+		}
+	}
+}
+
+func TestCompareProtocolVersions(t *testing.T) {
+	testCases := []struct {
+		A      string
+		B      string
+		Result int
+	}{
+		{A: "3.4", B: "3.4", Result: 0},
+		{A: "3.4.1", B: "3.4", Result: 0},
+		{A: "2.8-IV1", B: "2.8", Result: 0},
+		{A: "2.7-IV2", B: "2.8", Result: -1},
+		{A: "3.3", B: "3.4", Result: -1},
+		{A: "2.8", B: "3.0", Result: -1},
+		{A: "3.10", B: "3.9", Result: 1},
+		{A: "4.0", B: "3.9", Result: 1},
+	}
+	for _, testCase := range testCases {
+		result, err := CompareProtocolVersions(testCase.A, testCase.B)
+		if err != nil {
+			t.Errorf("unexpected error comparing %q and %q: %v", testCase.A, testCase.B, err)
+		}
+		if result != testCase.Result {
+			t.Errorf("comparing %q and %q mismatch, expected: %d, got: %d", testCase.A, testCase.B, testCase.Result, result)
+		}
+	}
+	if _, err := CompareProtocolVersions("3.4", "invalid"); err == nil {
+		t.Error("expected error for invalid protocol version")
+	}
+}
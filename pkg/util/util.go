@@ -18,13 +18,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
+	"math/big"
 	"reflect"
 	"sort"
 	"strconv"
@@ -340,15 +341,19 @@ func GetBrokerMetricsReporterImage(brokerConfig *v1beta1.BrokerConfig, kafkaClus
 	return kafkaClusterSpec.GetClusterMetricsReporterImage()
 }
 
-// getRandomString returns a random string containing uppercase, lowercase and number characters with the length given
+// GetRandomString returns a cryptographically secure random string containing uppercase, lowercase and number
+// characters with the length given
 func GetRandomString(length int) (string, error) {
-	rand.Seed(time.Now().UnixNano())
-
 	chars := []rune(symbolSet)
+	max := big.NewInt(int64(len(chars)))
 
 	var b strings.Builder
 	for i := 0; i < length; i++ {
-		b.WriteRune(chars[rand.Intn(len(chars))])
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.WrapIf(err, "failed to generate random number")
+		}
+		b.WriteRune(chars[n.Int64()])
 	}
 	return b.String(), nil
 }
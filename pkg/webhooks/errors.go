@@ -28,6 +28,21 @@ const (
 	outOfRangePartitionsErrMsg                = "number of partitions must be larger than 0 (or set it to be -1 to use the broker's default)"
 	unsupportedRemovingStorageMsg             = "removing storage from a broker is not supported"
 	invalidExternalListenerStartingPortErrMsg = "invalid external listener starting port number"
+	mixedZooKeeperAndKRaftModeErrMsg          = "ZooKeeper and KRaft mode can not be mixed"
+	invalidKRaftConfigErrMsg                  = "invalid KRaft configuration"
+	unsupportedKRaftModeChangeErrMsg          = "switching between ZooKeeper and KRaft mode is not supported"
+	invalidACLPrincipalErrMsg                 = "principal must be in the <type>:<name> form"
+	unsupportedACLOperationErrMsg             = "operation is not supported on the resource type"
+	invalidACLResourceNameErrMsg              = "invalid ACL resource name"
+	protectedTopicDeletionErrMsg              = "topic is protected from deletion"
+	activeConsumerGroupsTopicDeletionErrMsg   = "topic still has active consumer groups"
+	invalidBrokerReplicasErrMsg               = "invalid broker replicas"
+	incompatibleKafkaVersionDowngradeErrMsg   = "the brokers can not be downgraded to a Kafka version older than their inter.broker.protocol.version"
+	invalidStorageDrainErrMsg                 = "invalid storage drain"
+	invalidRebalanceScheduleErrMsg            = "invalid rebalance schedule"
+	invalidRetryOnErrorsPatternErrMsg         = "invalid retry on errors pattern"
+	invalidExecutionWindowErrMsg              = "invalid execution window"
+	duplicateQuotaEntityErrMsg                = "the client quotas of the entity are already managed by another resource"
 
 	// errorDuringValidationMsg is added to infrastructure errors (e.g. failed to connect), but not to field validation errors
 	errorDuringValidationMsg = "error during validation"
@@ -61,6 +76,42 @@ func IsAdmissionInvalidExternalListenerPort(err error) bool {
 	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), invalidExternalListenerStartingPortErrMsg)
 }
 
+func IsAdmissionMixedZooKeeperAndKRaftMode(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), mixedZooKeeperAndKRaftModeErrMsg)
+}
+
+func IsAdmissionInvalidKRaftConfig(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), invalidKRaftConfigErrMsg)
+}
+
+func IsAdmissionUnsupportedKRaftModeChange(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), unsupportedKRaftModeChangeErrMsg)
+}
+
+func IsAdmissionInvalidACLPrincipal(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), invalidACLPrincipalErrMsg)
+}
+
+func IsAdmissionUnsupportedACLOperation(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), unsupportedACLOperationErrMsg)
+}
+
+func IsAdmissionInvalidACLResourceName(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), invalidACLResourceNameErrMsg)
+}
+
+func IsAdmissionProtectedTopicDeletion(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), protectedTopicDeletionErrMsg)
+}
+
+func IsAdmissionActiveConsumerGroupsTopicDeletion(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), activeConsumerGroupsTopicDeletionErrMsg)
+}
+
+func IsAdmissionDuplicateQuotaEntity(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), duplicateQuotaEntityErrMsg)
+}
+
 func IsAdmissionErrorDuringValidation(err error) bool {
 	return apierrors.IsInternalError(err) && strings.Contains(err.Error(), errorDuringValidationMsg)
 }
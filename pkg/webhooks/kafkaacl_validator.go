@@ -0,0 +1,199 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	banzaicloudv1alpha1 "github.com/banzaicloud/koperator/api/v1alpha1"
+	banzaicloudv1beta1 "github.com/banzaicloud/koperator/api/v1beta1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+// supportedACLOperations lists the operations which can be used on the different resource types.
+// More info: https://kafka.apache.org/documentation/#operations_resources_and_protocols
+var supportedACLOperations = map[banzaicloudv1alpha1.KafkaACLResourceType][]banzaicloudv1alpha1.KafkaACLOperation{
+	banzaicloudv1alpha1.KafkaACLResourceTypeTopic: {
+		banzaicloudv1alpha1.KafkaACLOperationAll,
+		banzaicloudv1alpha1.KafkaACLOperationRead,
+		banzaicloudv1alpha1.KafkaACLOperationWrite,
+		banzaicloudv1alpha1.KafkaACLOperationCreate,
+		banzaicloudv1alpha1.KafkaACLOperationDelete,
+		banzaicloudv1alpha1.KafkaACLOperationAlter,
+		banzaicloudv1alpha1.KafkaACLOperationDescribe,
+		banzaicloudv1alpha1.KafkaACLOperationDescribeConfigs,
+		banzaicloudv1alpha1.KafkaACLOperationAlterConfigs,
+	},
+	banzaicloudv1alpha1.KafkaACLResourceTypeGroup: {
+		banzaicloudv1alpha1.KafkaACLOperationAll,
+		banzaicloudv1alpha1.KafkaACLOperationRead,
+		banzaicloudv1alpha1.KafkaACLOperationDelete,
+		banzaicloudv1alpha1.KafkaACLOperationDescribe,
+	},
+	banzaicloudv1alpha1.KafkaACLResourceTypeCluster: {
+		banzaicloudv1alpha1.KafkaACLOperationAll,
+		banzaicloudv1alpha1.KafkaACLOperationCreate,
+		banzaicloudv1alpha1.KafkaACLOperationAlter,
+		banzaicloudv1alpha1.KafkaACLOperationDescribe,
+		banzaicloudv1alpha1.KafkaACLOperationClusterAction,
+		banzaicloudv1alpha1.KafkaACLOperationDescribeConfigs,
+		banzaicloudv1alpha1.KafkaACLOperationAlterConfigs,
+		banzaicloudv1alpha1.KafkaACLOperationIdempotentWrite,
+	},
+	banzaicloudv1alpha1.KafkaACLResourceTypeTransactionalID: {
+		banzaicloudv1alpha1.KafkaACLOperationAll,
+		banzaicloudv1alpha1.KafkaACLOperationWrite,
+		banzaicloudv1alpha1.KafkaACLOperationDescribe,
+	},
+	banzaicloudv1alpha1.KafkaACLResourceTypeDelegationToken: {
+		banzaicloudv1alpha1.KafkaACLOperationAll,
+		banzaicloudv1alpha1.KafkaACLOperationDescribe,
+	},
+}
+
+type KafkaACLValidator struct {
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (s KafkaACLValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return s.validate(ctx, obj)
+}
+
+func (s KafkaACLValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return s.validate(ctx, newObj)
+}
+
+func (s KafkaACLValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (s *KafkaACLValidator) validate(ctx context.Context, obj runtime.Object) error {
+	kafkaACL := obj.(*banzaicloudv1alpha1.KafkaACL)
+	log := s.Log.WithValues("name", kafkaACL.GetName(), "namespace", kafkaACL.GetNamespace())
+
+	fieldErrs, err := s.validateKafkaACL(ctx, log, kafkaACL)
+	if err != nil {
+		log.Error(err, errorDuringValidationMsg)
+		return apierrors.NewInternalError(errors.WithMessage(err, errorDuringValidationMsg))
+	}
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	log.Info("rejected", "invalid field(s)", fieldErrs.ToAggregate().Error())
+	return apierrors.NewInvalid(
+		kafkaACL.GetObjectKind().GroupVersionKind().GroupKind(),
+		kafkaACL.Name, fieldErrs)
+}
+
+func (s *KafkaACLValidator) validateKafkaACL(ctx context.Context, log logr.Logger, acl *banzaicloudv1alpha1.KafkaACL) (field.ErrorList, error) {
+	allErrs := checkKafkaACLSpec(acl.Spec)
+
+	// Get the referenced KafkaCluster
+	clusterName := acl.Spec.ClusterRef.Name
+	clusterNamespace := acl.Spec.ClusterRef.Namespace
+	if clusterNamespace == "" {
+		clusterNamespace = acl.GetNamespace()
+	}
+
+	var cluster *banzaicloudv1beta1.KafkaCluster
+	var err error
+	// Check if the cluster being referenced actually exists
+	if cluster, err = k8sutil.LookupKafkaCluster(ctx, s.Client, clusterName, clusterNamespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, cantConnectAPIServerMsg)
+		}
+		if k8sutil.IsMarkedForDeletion(acl.ObjectMeta) {
+			log.Info("Deleted as a result of a cluster deletion")
+			return nil, nil
+		}
+		logMsg := fmt.Sprintf("kafkaCluster '%s' in the namespace '%s' does not exist", clusterName, clusterNamespace)
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("clusterRef").Child("name"), clusterName, logMsg))
+		return allErrs, nil
+	}
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		log.Info("Cluster is going down for deletion, assuming a delete acl request")
+		return nil, nil
+	}
+
+	if util.ObjectManagedByClusterRegistry(cluster) {
+		// referencing remote Kafka clusters is not allowed
+		logMsg := fmt.Sprintf("kafkaCluster '%s' in the namespace '%s' is a remote kafka cluster", clusterName, clusterNamespace)
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("clusterRef").Child("name"), clusterName, logMsg))
+	}
+
+	return allErrs, nil
+}
+
+// checkKafkaACLSpec checks whether the principal and the rules of the KafkaACL describe valid Kafka ACLs
+func checkKafkaACLSpec(spec banzaicloudv1alpha1.KafkaACLSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	principalType, principalName, found := strings.Cut(spec.Principal, ":")
+	if !found || principalType == "" || principalName == "" {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("principal"), spec.Principal, invalidACLPrincipalErrMsg))
+	}
+
+	for i, rule := range spec.Rules {
+		rulePath := field.NewPath("spec").Child("rules").Index(i)
+		resourceName := rule.GetResourceName()
+
+		switch {
+		case resourceName == "":
+			allErrs = append(allErrs, field.Required(rulePath.Child("resource", "name"),
+				fmt.Sprintf("%s: resource name must be set for the %s resource type", invalidACLResourceNameErrMsg, rule.Resource.Type)))
+		case rule.Resource.Type == banzaicloudv1alpha1.KafkaACLResourceTypeCluster && resourceName != banzaicloudv1alpha1.KafkaACLClusterResourceName:
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("resource", "name"), resourceName,
+				fmt.Sprintf("%s: the name of the cluster resource must be %q", invalidACLResourceNameErrMsg, banzaicloudv1alpha1.KafkaACLClusterResourceName)))
+		case resourceName == "*" && rule.GetPatternType() != banzaicloudv1alpha1.KafkaPatternTypeLiteral:
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("resource", "name"), resourceName,
+				fmt.Sprintf("%s: the wildcard resource name can only be used with the literal pattern type", invalidACLResourceNameErrMsg)))
+		case strings.Contains(resourceName, ","):
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("resource", "name"), resourceName,
+				fmt.Sprintf("%s: resource name must not contain commas", invalidACLResourceNameErrMsg)))
+		}
+
+		if supported, ok := supportedACLOperations[rule.Resource.Type]; ok && !operationSupported(supported, rule.Operation) {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("operation"), rule.Operation,
+				fmt.Sprintf("%s %q (supported operations: %v)", unsupportedACLOperationErrMsg, rule.Resource.Type, supported)))
+		}
+
+		if strings.Contains(rule.GetHost(), ",") {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("host"), rule.Host, "host must not contain commas"))
+		}
+	}
+
+	return allErrs
+}
+
+func operationSupported(operations []banzaicloudv1alpha1.KafkaACLOperation, operation banzaicloudv1alpha1.KafkaACLOperation) bool {
+	for _, op := range operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
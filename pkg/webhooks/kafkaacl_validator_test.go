@@ -0,0 +1,172 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+)
+
+func TestCheckKafkaACLSpec(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		spec           v1alpha1.KafkaACLSpec
+		expectedErrors []string
+	}{
+		{
+			testName: "valid allow and deny rules",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "orders-", PatternType: v1alpha1.KafkaPatternTypePrefixed},
+						Operation: v1alpha1.KafkaACLOperationRead,
+					},
+					{
+						Resource:       v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeGroup, Name: "orders-consumer"},
+						Operation:      v1alpha1.KafkaACLOperationRead,
+						PermissionType: v1alpha1.KafkaACLPermissionTypeAllow,
+					},
+					{
+						Resource:       v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "orders-secret"},
+						Operation:      v1alpha1.KafkaACLOperationAll,
+						PermissionType: v1alpha1.KafkaACLPermissionTypeDeny,
+						Host:           "10.0.0.1",
+					},
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeCluster},
+						Operation: v1alpha1.KafkaACLOperationIdempotentWrite,
+					},
+				},
+			},
+		},
+		{
+			testName: "invalid principal",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "*"},
+						Operation: v1alpha1.KafkaACLOperationDescribe,
+					},
+				},
+			},
+			expectedErrors: []string{invalidACLPrincipalErrMsg},
+		},
+		{
+			testName: "unsupported operation on the resource type",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeGroup, Name: "*"},
+						Operation: v1alpha1.KafkaACLOperationWrite,
+					},
+				},
+			},
+			expectedErrors: []string{unsupportedACLOperationErrMsg},
+		},
+		{
+			testName: "missing resource name",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTransactionalID},
+						Operation: v1alpha1.KafkaACLOperationWrite,
+					},
+				},
+			},
+			expectedErrors: []string{invalidACLResourceNameErrMsg},
+		},
+		{
+			testName: "invalid cluster resource name",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeCluster, Name: "my-cluster"},
+						Operation: v1alpha1.KafkaACLOperationAlter,
+					},
+				},
+			},
+			expectedErrors: []string{invalidACLResourceNameErrMsg},
+		},
+		{
+			testName: "wildcard resource name with prefixed pattern type",
+			spec: v1alpha1.KafkaACLSpec{
+				Principal: "User:CN=alice",
+				Rules: []v1alpha1.KafkaACLRule{
+					{
+						Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "*", PatternType: v1alpha1.KafkaPatternTypePrefixed},
+						Operation: v1alpha1.KafkaACLOperationRead,
+					},
+				},
+			},
+			expectedErrors: []string{invalidACLResourceNameErrMsg},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			fieldErrs := checkKafkaACLSpec(testCase.spec)
+			require.Len(t, fieldErrs, len(testCase.expectedErrors))
+			for i, expected := range testCase.expectedErrors {
+				require.Contains(t, fieldErrs[i].Error(), expected)
+			}
+		})
+	}
+}
+
+func TestKafkaACLValidatorClusterRef(t *testing.T) {
+	cluster := newMockCluster()
+	client, _, _ := newMockClients(cluster)
+	validator := KafkaACLValidator{
+		Client: client,
+		Log:    logr.Discard(),
+	}
+
+	acl := &v1alpha1.KafkaACL{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-acl", Namespace: cluster.Namespace},
+		Spec: v1alpha1.KafkaACLSpec{
+			ClusterRef: v1alpha1.ClusterReference{Name: cluster.Name},
+			Principal:  "User:CN=alice",
+			Rules: []v1alpha1.KafkaACLRule{
+				{
+					Resource:  v1alpha1.KafkaACLResource{Type: v1alpha1.KafkaACLResourceTypeTopic, Name: "test-topic"},
+					Operation: v1alpha1.KafkaACLOperationRead,
+				},
+			},
+		},
+	}
+
+	// the referenced cluster does not exist yet
+	fieldErrs, err := validator.validateKafkaACL(context.Background(), logr.Discard(), acl)
+	require.NoError(t, err)
+	require.Len(t, fieldErrs, 1)
+	require.Contains(t, fieldErrs[0].Error(), "does not exist")
+
+	require.NoError(t, client.Create(context.Background(), cluster))
+	fieldErrs, err = validator.validateKafkaACL(context.Background(), logr.Discard(), acl)
+	require.NoError(t, err)
+	require.Empty(t, fieldErrs)
+}
@@ -17,17 +17,23 @@ package webhooks
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"time"
 
 	"emperror.dev/errors"
+	"github.com/robfig/cron/v3"
 	"golang.org/x/exp/slices"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/go-logr/logr"
 
 	banzaicloudv1beta1 "github.com/banzaicloud/koperator/api/v1beta1"
 	"github.com/banzaicloud/koperator/pkg/util"
+	kafkautils "github.com/banzaicloud/koperator/pkg/util/kafka"
 )
 
 type KafkaClusterValidator struct {
@@ -40,7 +46,7 @@ func (s KafkaClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newOb
 	kafkaClusterNew := newObj.(*banzaicloudv1beta1.KafkaCluster)
 	log := s.Log.WithValues("name", kafkaClusterNew.GetName(), "namespace", kafkaClusterNew.GetNamespace())
 
-	fieldErr, err := checkBrokerStorageRemoval(&kafkaClusterOld.Spec, &kafkaClusterNew.Spec)
+	fieldErr, err := checkBrokerStorageRemoval(&kafkaClusterOld.Spec, &kafkaClusterNew.Spec, kafkaClusterOld.Status.BrokersState)
 	if err != nil {
 		log.Error(err, errorDuringValidationMsg)
 		return apierrors.NewInternalError(errors.WithMessage(err, errorDuringValidationMsg))
@@ -54,6 +60,21 @@ func (s KafkaClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newOb
 		allErrs = append(allErrs, listenerErrs...)
 	}
 
+	if kafkaClusterOld.Spec.KRaftMode != kafkaClusterNew.Spec.KRaftMode {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("kRaft"), unsupportedKRaftModeChangeErrMsg))
+	}
+
+	allErrs = append(allErrs, checkZooKeeperAndKRaftConfig(&kafkaClusterNew.Spec)...)
+	allErrs = append(allErrs, checkBrokerReplicas(&kafkaClusterNew.Spec)...)
+	allErrs = append(allErrs, checkStorageDrain(&kafkaClusterNew.Spec)...)
+	allErrs = append(allErrs, checkRebalanceConfig(&kafkaClusterNew.Spec)...)
+	allErrs = append(allErrs, checkCruiseControlOperationRetryPolicy(&kafkaClusterNew.Spec)...)
+	allErrs = append(allErrs, checkExecutionPolicy(&kafkaClusterNew.Spec)...)
+
+	if fieldErr := checkKafkaVersionDowngrade(kafkaClusterOld, kafkaClusterNew); fieldErr != nil {
+		allErrs = append(allErrs, fieldErr)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -74,6 +95,13 @@ func (s KafkaClusterValidator) ValidateCreate(ctx context.Context, obj runtime.O
 		allErrs = append(allErrs, listenerErrs...)
 	}
 
+	allErrs = append(allErrs, checkZooKeeperAndKRaftConfig(&kafkaCluster.Spec)...)
+	allErrs = append(allErrs, checkBrokerReplicas(&kafkaCluster.Spec)...)
+	allErrs = append(allErrs, checkStorageDrain(&kafkaCluster.Spec)...)
+	allErrs = append(allErrs, checkRebalanceConfig(&kafkaCluster.Spec)...)
+	allErrs = append(allErrs, checkCruiseControlOperationRetryPolicy(&kafkaCluster.Spec)...)
+	allErrs = append(allErrs, checkExecutionPolicy(&kafkaCluster.Spec)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -89,7 +117,9 @@ func (s KafkaClusterValidator) ValidateDelete(ctx context.Context, obj runtime.O
 }
 
 // checkBrokerStorageRemoval checks if there is any broker storage which has been removed. If yes, admission will be rejected
-func checkBrokerStorageRemoval(kafkaClusterSpecOld, kafkaClusterSpecNew *banzaicloudv1beta1.KafkaClusterSpec) (*field.Error, error) {
+// unless the removed storage has been drained.
+func checkBrokerStorageRemoval(kafkaClusterSpecOld, kafkaClusterSpecNew *banzaicloudv1beta1.KafkaClusterSpec,
+	brokersStateOld map[string]banzaicloudv1beta1.BrokerState) (*field.Error, error) {
 	for j := range kafkaClusterSpecOld.Brokers {
 		brokerOld := &kafkaClusterSpecOld.Brokers[j]
 		for k := range kafkaClusterSpecNew.Brokers {
@@ -120,7 +150,7 @@ func checkBrokerStorageRemoval(kafkaClusterSpecOld, kafkaClusterSpecNew *banzaic
 							break
 						}
 					}
-					if !isStorageFound {
+					if !isStorageFound && !isStorageDrained(brokersStateOld, brokerOld.Id, storageConfigOld) {
 						fromConfigGroup := getMissingMounthPathLocation(storageConfigOld.MountPath, kafkaClusterSpecOld, int32(k))
 						if fromConfigGroup != nil && *fromConfigGroup {
 							return field.Invalid(field.NewPath("spec").Child("brokers").Index(k).Child("brokerConfigGroup"), brokerNew.BrokerConfigGroup, fmt.Sprintf("%s, missing storageConfig mounthPath: %s", unsupportedRemovingStorageMsg, storageConfigOld.MountPath)), nil
@@ -133,6 +163,16 @@ func checkBrokerStorageRemoval(kafkaClusterSpecOld, kafkaClusterSpecNew *banzaic
 	}
 	return nil, nil
 }
+
+// isStorageDrained returns true when the storage was marked to be drained and its log dir has been drained
+func isStorageDrained(brokersState map[string]banzaicloudv1beta1.BrokerState, brokerID int32, storageConfig *banzaicloudv1beta1.StorageConfig) bool {
+	if !storageConfig.Drain {
+		return false
+	}
+	volumeState, ok := brokersState[strconv.Itoa(int(brokerID))].GracefulActionState.VolumeStates[storageConfig.MountPath]
+	return ok && volumeState.CruiseControlVolumeState == banzaicloudv1beta1.GracefulDiskDrained
+}
+
 func getMissingMounthPathLocation(mounthPath string, kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec, brokerId int32) (fromConfigGroup *bool) {
 	if brokerId < 0 || int(brokerId) >= len(kafkaClusterSpec.Brokers) {
 		return nil
@@ -219,3 +259,202 @@ func checkExternalListenerStartingPort(kafkaClusterSpec *banzaicloudv1beta1.Kafk
 	}
 	return allErrs
 }
+
+// checkBrokerReplicas checks that the number of brokers is only managed for broker config groups
+func checkBrokerReplicas(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, broker := range kafkaClusterSpec.Brokers {
+		if broker.BrokerConfig != nil && broker.BrokerConfig.Replicas != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("brokers").Index(i).Child("brokerConfig").Child("replicas"),
+				invalidBrokerReplicasErrMsg+", replicas can only be set in brokerConfigGroups"))
+		}
+	}
+	if kafkaClusterSpec.ScaleBrokerConfigGroup != "" {
+		if _, ok := kafkaClusterSpec.BrokerConfigGroups[kafkaClusterSpec.ScaleBrokerConfigGroup]; !ok {
+			allErrs = append(allErrs, field.NotFound(field.NewPath("spec").Child("scaleBrokerConfigGroup"), kafkaClusterSpec.ScaleBrokerConfigGroup))
+		}
+		// the broker pods of the group are selected by their broker config group label
+		for _, msg := range validation.IsValidLabelValue(kafkaClusterSpec.ScaleBrokerConfigGroup) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("scaleBrokerConfigGroup"), kafkaClusterSpec.ScaleBrokerConfigGroup,
+				invalidBrokerReplicasErrMsg+", "+msg))
+		}
+	} else if kafkaClusterSpec.Replicas != nil {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("scaleBrokerConfigGroup"),
+			invalidBrokerReplicasErrMsg+", scaleBrokerConfigGroup must be set when replicas is set"))
+	}
+
+	return allErrs
+}
+
+// checkStorageDrain checks that only the storages backed by persistent volumes are drained and
+// the brokers keep at least one storage which is not drained
+func checkStorageDrain(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, broker := range kafkaClusterSpec.Brokers {
+		brokerConfig, err := broker.GetBrokerConfig(*kafkaClusterSpec)
+		if err != nil {
+			// the missing broker config group is reported by the other checks
+			continue
+		}
+		kept := 0
+		for _, storageConfig := range brokerConfig.StorageConfigs {
+			if !storageConfig.Drain {
+				kept++
+				continue
+			}
+			if storageConfig.PvcSpec == nil {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("brokers").Index(i), storageConfig.MountPath,
+					invalidStorageDrainErrMsg+", only storages with pvcSpec can be drained"))
+			}
+		}
+		if kept == 0 && len(brokerConfig.StorageConfigs) > 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("brokers").Index(i), broker.Id,
+				invalidStorageDrainErrMsg+", the broker must keep at least one storage which is not drained"))
+		}
+	}
+
+	return allErrs
+}
+
+// checkRebalanceConfig checks that the schedule of the rebalances is a valid cron expression
+func checkRebalanceConfig(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	rebalanceConfig := kafkaClusterSpec.CruiseControlConfig.RebalanceConfig
+	if rebalanceConfig == nil || rebalanceConfig.Schedule == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(rebalanceConfig.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("cruiseControlConfig").Child("rebalanceConfig").Child("schedule"),
+			rebalanceConfig.Schedule, fmt.Sprintf("%s, %s", invalidRebalanceScheduleErrMsg, err)))
+	}
+
+	return allErrs
+}
+
+// checkCruiseControlOperationRetryPolicy checks that the retry on errors patterns of the default retry policy of
+// the CruiseControlOperations are valid regular expressions
+func checkCruiseControlOperationRetryPolicy(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	retryPolicy := kafkaClusterSpec.CruiseControlConfig.CruiseControlOperationSpec.GetRetryPolicy()
+	if retryPolicy == nil {
+		return nil
+	}
+	for i, pattern := range retryPolicy.RetryOnErrors {
+		if _, err := regexp.Compile(pattern); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("cruiseControlConfig").Child("cruiseControlOperationSpec").
+				Child("retryPolicy").Child("retryOnErrors").Index(i), pattern, fmt.Sprintf("%s, %s", invalidRetryOnErrorsPatternErrMsg, err)))
+		}
+	}
+
+	return allErrs
+}
+
+// checkExecutionPolicy checks that the execution windows of the Cruise Control operations are valid
+func checkExecutionPolicy(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	executionPolicy := kafkaClusterSpec.CruiseControlConfig.ExecutionPolicy
+	if executionPolicy == nil {
+		return nil
+	}
+	for i, window := range executionPolicy.ExecutionWindows {
+		if _, err := window.Contains(time.Now()); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("cruiseControlConfig").Child("executionPolicy").
+				Child("executionWindows").Index(i), window, fmt.Sprintf("%s, %s", invalidExecutionWindowErrMsg, err)))
+		}
+	}
+
+	return allErrs
+}
+
+// checkKafkaVersionDowngrade checks that the brokers are not downgraded to a Kafka version which does not support
+// the protocol version they already run with. The protocol version is only known once the operator manages it.
+func checkKafkaVersionDowngrade(kafkaClusterOld, kafkaClusterNew *banzaicloudv1beta1.KafkaCluster) *field.Error {
+	versionUpgrade := kafkaClusterOld.Status.KafkaVersionUpgrade
+	if kafkaClusterNew.Spec.KafkaVersion == "" || kafkaClusterNew.Spec.KRaftMode || versionUpgrade == nil {
+		return nil
+	}
+	kafkaVersionPath := field.NewPath("spec").Child("kafkaVersion")
+	protocolVersion, err := kafkautils.ProtocolVersion(kafkaClusterNew.Spec.KafkaVersion)
+	if err != nil {
+		return field.Invalid(kafkaVersionPath, kafkaClusterNew.Spec.KafkaVersion, err.Error())
+	}
+	cmp, err := kafkautils.CompareProtocolVersions(protocolVersion, versionUpgrade.ProtocolVersion)
+	if err != nil || cmp >= 0 {
+		return nil
+	}
+	return field.Forbidden(kafkaVersionPath,
+		fmt.Sprintf("%s, the protocol version is %s", incompatibleKafkaVersionDowngradeErrMsg, versionUpgrade.ProtocolVersion))
+}
+
+// checkZooKeeperAndKRaftConfig checks that the cluster is configured either for ZooKeeper or for KRaft mode but not for both of them
+func checkZooKeeperAndKRaftConfig(kafkaClusterSpec *banzaicloudv1beta1.KafkaClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if !kafkaClusterSpec.KRaftMode {
+		if len(kafkaClusterSpec.ZKAddresses) == 0 {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("zkAddresses"), "zkAddresses must be set when KRaft mode is not enabled"))
+		}
+		for name, group := range kafkaClusterSpec.BrokerConfigGroups {
+			if len(group.ProcessRoles) > 0 {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("brokerConfigGroups").Key(name).Child("processRoles"),
+					mixedZooKeeperAndKRaftModeErrMsg+", processRoles can only be set in KRaft mode"))
+			}
+		}
+		for i, broker := range kafkaClusterSpec.Brokers {
+			if broker.BrokerConfig != nil && len(broker.BrokerConfig.ProcessRoles) > 0 {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("brokers").Index(i).Child("brokerConfig").Child("processRoles"),
+					mixedZooKeeperAndKRaftModeErrMsg+", processRoles can only be set in KRaft mode"))
+			}
+		}
+		return allErrs
+	}
+
+	if len(kafkaClusterSpec.ZKAddresses) > 0 {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("zkAddresses"),
+			mixedZooKeeperAndKRaftModeErrMsg+", zkAddresses must be empty in KRaft mode"))
+	}
+
+	controllerListenerFound := false
+	for i, iListener := range kafkaClusterSpec.ListenersConfig.InternalListeners {
+		if !iListener.UsedForControllerCommunication {
+			continue
+		}
+		controllerListenerFound = true
+		if iListener.UsedForInnerBrokerCommunication {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("listenersConfig").Child("internalListeners").Index(i).Child("usedForInnerBrokerCommunication"),
+				iListener.UsedForInnerBrokerCommunication, invalidKRaftConfigErrMsg+", the controller listener can not be used for inter broker communication"))
+		}
+	}
+	if !controllerListenerFound {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("listenersConfig").Child("internalListeners"),
+			invalidKRaftConfigErrMsg+", an internal listener with usedForControllerCommunication is required"))
+	}
+
+	controllerFound := false
+	for i, broker := range kafkaClusterSpec.Brokers {
+		brokerConfig, err := broker.GetBrokerConfig(*kafkaClusterSpec)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("brokers").Index(i).Child("brokerConfigGroup"), broker.BrokerConfigGroup, err.Error()))
+			continue
+		}
+		if brokerConfig == nil || len(brokerConfig.ProcessRoles) == 0 {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("brokers").Index(i).Child("brokerConfig").Child("processRoles"),
+				invalidKRaftConfigErrMsg+fmt.Sprintf(", processRoles must be set for broker %d either directly or through its brokerConfigGroup", broker.Id)))
+			continue
+		}
+		if brokerConfig.IsControllerNode() {
+			controllerFound = true
+		}
+	}
+	if !controllerFound {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("brokers"),
+			invalidKRaftConfigErrMsg+", at least one broker must have the controller process role"))
+	}
+
+	return allErrs
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/banzaicloud/koperator/api/v1beta1"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -30,8 +32,97 @@ func TestCheckBrokerStorageRemoval(t *testing.T) {
 		testName            string
 		kafkaClusterSpecNew v1beta1.KafkaClusterSpec
 		kafkaClusterSpecOld v1beta1.KafkaClusterSpec
+		brokersStateOld     map[string]v1beta1.BrokerState
 		isValid             bool
 	}{
+		{
+			testName: "drained storage is removed",
+			kafkaClusterSpecNew: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+					"default": {
+						StorageConfigs: []v1beta1.StorageConfig{
+							{MountPath: "logs1"},
+						},
+					},
+				},
+				Brokers: []v1beta1.Broker{
+					{
+						Id:                1,
+						BrokerConfigGroup: "default",
+					},
+				},
+			},
+			kafkaClusterSpecOld: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+					"default": {
+						StorageConfigs: []v1beta1.StorageConfig{
+							{MountPath: "logs1"},
+							{MountPath: "logs2", Drain: true},
+						},
+					},
+				},
+				Brokers: []v1beta1.Broker{
+					{
+						Id:                1,
+						BrokerConfigGroup: "default",
+					},
+				},
+			},
+			brokersStateOld: map[string]v1beta1.BrokerState{
+				"1": {
+					GracefulActionState: v1beta1.GracefulActionState{
+						VolumeStates: map[string]v1beta1.VolumeState{
+							"logs2": {CruiseControlVolumeState: v1beta1.GracefulDiskDrained},
+						},
+					},
+				},
+			},
+			isValid: true,
+		},
+		{
+			testName: "storage being drained is removed",
+			kafkaClusterSpecNew: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+					"default": {
+						StorageConfigs: []v1beta1.StorageConfig{
+							{MountPath: "logs1"},
+						},
+					},
+				},
+				Brokers: []v1beta1.Broker{
+					{
+						Id:                1,
+						BrokerConfigGroup: "default",
+					},
+				},
+			},
+			kafkaClusterSpecOld: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+					"default": {
+						StorageConfigs: []v1beta1.StorageConfig{
+							{MountPath: "logs1"},
+							{MountPath: "logs2", Drain: true},
+						},
+					},
+				},
+				Brokers: []v1beta1.Broker{
+					{
+						Id:                1,
+						BrokerConfigGroup: "default",
+					},
+				},
+			},
+			brokersStateOld: map[string]v1beta1.BrokerState{
+				"1": {
+					GracefulActionState: v1beta1.GracefulActionState{
+						VolumeStates: map[string]v1beta1.VolumeState{
+							"logs2": {CruiseControlVolumeState: v1beta1.GracefulDiskRebalanceRunning},
+						},
+					},
+				},
+			},
+			isValid: false,
+		},
 		{
 			testName: "there is no storage remove",
 			kafkaClusterSpecNew: v1beta1.KafkaClusterSpec{
@@ -479,7 +570,7 @@ func TestCheckBrokerStorageRemoval(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		res, err := checkBrokerStorageRemoval(&testCase.kafkaClusterSpecOld, &testCase.kafkaClusterSpecNew)
+		res, err := checkBrokerStorageRemoval(&testCase.kafkaClusterSpecOld, &testCase.kafkaClusterSpecNew, testCase.brokersStateOld)
 		if err != nil {
 			t.Errorf("testName: %s, err should be nil, got %s", testCase.testName, err)
 		}
@@ -690,3 +781,395 @@ func TestCheckExternalListenerStartingPort(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckZooKeeperAndKRaftConfig(t *testing.T) {
+	kraftListeners := v1beta1.ListenersConfig{
+		InternalListeners: []v1beta1.InternalListenerConfig{
+			{
+				CommonListenerSpec:              v1beta1.CommonListenerSpec{Name: "internal"},
+				UsedForInnerBrokerCommunication: true,
+			},
+			{
+				CommonListenerSpec:             v1beta1.CommonListenerSpec{Name: "controller"},
+				UsedForControllerCommunication: true,
+			},
+		},
+	}
+	testCases := []struct {
+		testName         string
+		kafkaClusterSpec v1beta1.KafkaClusterSpec
+		expected         field.ErrorList
+	}{
+		{
+			testName: "valid ZooKeeper config",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				ZKAddresses: []string{"example.zk:2181"},
+				Brokers:     []v1beta1.Broker{{Id: 0}},
+			},
+			expected: nil,
+		},
+		{
+			testName: "invalid ZooKeeper config: missing zkAddresses and processRoles set",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{ProcessRoles: []string{v1beta1.ProcessRoleBroker}}}},
+			},
+			expected: append(field.ErrorList{},
+				field.Required(field.NewPath("spec").Child("zkAddresses"), "zkAddresses must be set when KRaft mode is not enabled"),
+				field.Forbidden(field.NewPath("spec").Child("brokers").Index(0).Child("brokerConfig").Child("processRoles"),
+					mixedZooKeeperAndKRaftModeErrMsg+", processRoles can only be set in KRaft mode"),
+			),
+		},
+		{
+			testName: "valid KRaft config",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				KRaftMode:       true,
+				ListenersConfig: kraftListeners,
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{
+					"default": {ProcessRoles: []string{v1beta1.ProcessRoleBroker}},
+				},
+				Brokers: []v1beta1.Broker{
+					{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{ProcessRoles: []string{v1beta1.ProcessRoleController}}},
+					{Id: 1, BrokerConfigGroup: "default"},
+				},
+			},
+			expected: nil,
+		},
+		{
+			testName: "invalid KRaft config: zkAddresses set, no controller listener and no controller node",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				KRaftMode:   true,
+				ZKAddresses: []string{"example.zk:2181"},
+				Brokers: []v1beta1.Broker{
+					{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{ProcessRoles: []string{v1beta1.ProcessRoleBroker}}},
+					{Id: 1, BrokerConfig: &v1beta1.BrokerConfig{}},
+				},
+			},
+			expected: append(field.ErrorList{},
+				field.Forbidden(field.NewPath("spec").Child("zkAddresses"),
+					mixedZooKeeperAndKRaftModeErrMsg+", zkAddresses must be empty in KRaft mode"),
+				field.Required(field.NewPath("spec").Child("listenersConfig").Child("internalListeners"),
+					invalidKRaftConfigErrMsg+", an internal listener with usedForControllerCommunication is required"),
+				field.Required(field.NewPath("spec").Child("brokers").Index(1).Child("brokerConfig").Child("processRoles"),
+					invalidKRaftConfigErrMsg+", processRoles must be set for broker 1 either directly or through its brokerConfigGroup"),
+				field.Required(field.NewPath("spec").Child("brokers"),
+					invalidKRaftConfigErrMsg+", at least one broker must have the controller process role"),
+			),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkZooKeeperAndKRaftConfig(&testCase.kafkaClusterSpec)
+			require.Equal(t, testCase.expected, got)
+		})
+	}
+}
+
+func TestCheckKafkaVersionDowngrade(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		kafkaVersion    string
+		kRaftMode       bool
+		protocolVersion string
+		expected        *field.Error
+	}{
+		{
+			testName:     "Kafka version not managed yet",
+			kafkaVersion: "3.3.2",
+			expected:     nil,
+		},
+		{
+			testName:        "upgrade",
+			kafkaVersion:    "3.5.0",
+			protocolVersion: "3.4",
+			expected:        nil,
+		},
+		{
+			testName:        "downgrade to a compatible Kafka version",
+			kafkaVersion:    "3.4.0",
+			protocolVersion: "3.4",
+			expected:        nil,
+		},
+		{
+			testName:        "downgrade in KRaft mode",
+			kafkaVersion:    "3.3.2",
+			kRaftMode:       true,
+			protocolVersion: "3.4",
+			expected:        nil,
+		},
+		{
+			testName:        "downgrade past the protocol version",
+			kafkaVersion:    "3.3.2",
+			protocolVersion: "3.4",
+			expected: field.Forbidden(field.NewPath("spec").Child("kafkaVersion"),
+				incompatibleKafkaVersionDowngradeErrMsg+", the protocol version is 3.4"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			kafkaClusterOld := &v1beta1.KafkaCluster{}
+			if testCase.protocolVersion != "" {
+				kafkaClusterOld.Status.KafkaVersionUpgrade = &v1beta1.KafkaVersionUpgradeStatus{
+					State:           v1beta1.KafkaVersionUpgradeCompleted,
+					TargetVersion:   testCase.protocolVersion + ".0",
+					ProtocolVersion: testCase.protocolVersion,
+				}
+			}
+			kafkaClusterNew := kafkaClusterOld.DeepCopy()
+			kafkaClusterNew.Spec.KafkaVersion = testCase.kafkaVersion
+			kafkaClusterNew.Spec.KRaftMode = testCase.kRaftMode
+
+			got := checkKafkaVersionDowngrade(kafkaClusterOld, kafkaClusterNew)
+			require.Equal(t, testCase.expected, got)
+		})
+	}
+}
+
+func TestCheckBrokerReplicas(t *testing.T) {
+	replicas := int32(3)
+	testCases := []struct {
+		testName         string
+		kafkaClusterSpec v1beta1.KafkaClusterSpec
+		expected         field.ErrorList
+	}{
+		{
+			testName: "replicas set in broker config group",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups: map[string]v1beta1.BrokerConfig{"default": {Replicas: &replicas}},
+			},
+			expected: nil,
+		},
+		{
+			testName: "replicas of the scaled broker config group",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups:     map[string]v1beta1.BrokerConfig{"default": {}},
+				ScaleBrokerConfigGroup: "default",
+				Replicas:               &replicas,
+			},
+			expected: nil,
+		},
+		{
+			testName: "replicas set for a broker",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{Replicas: &replicas}}},
+			},
+			expected: append(field.ErrorList{},
+				field.Forbidden(field.NewPath("spec").Child("brokers").Index(0).Child("brokerConfig").Child("replicas"),
+					invalidBrokerReplicasErrMsg+", replicas can only be set in brokerConfigGroups"),
+			),
+		},
+		{
+			testName: "replicas set without scaled broker config group",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Replicas: &replicas,
+			},
+			expected: append(field.ErrorList{},
+				field.Required(field.NewPath("spec").Child("scaleBrokerConfigGroup"),
+					invalidBrokerReplicasErrMsg+", scaleBrokerConfigGroup must be set when replicas is set"),
+			),
+		},
+		{
+			testName: "missing scaled broker config group",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				ScaleBrokerConfigGroup: "missing",
+				Replicas:               &replicas,
+			},
+			expected: append(field.ErrorList{},
+				field.NotFound(field.NewPath("spec").Child("scaleBrokerConfigGroup"), "missing"),
+			),
+		},
+		{
+			testName: "scaled broker config group which is not a valid label value",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				BrokerConfigGroups:     map[string]v1beta1.BrokerConfig{"default group": {}},
+				ScaleBrokerConfigGroup: "default group",
+				Replicas:               &replicas,
+			},
+			expected: append(field.ErrorList{},
+				field.Invalid(field.NewPath("spec").Child("scaleBrokerConfigGroup"), "default group",
+					invalidBrokerReplicasErrMsg+", "+validation.IsValidLabelValue("default group")[0]),
+			),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkBrokerReplicas(&testCase.kafkaClusterSpec)
+			require.Equal(t, testCase.expected, got)
+		})
+	}
+}
+
+func TestCheckStorageDrain(t *testing.T) {
+	pvcSpec := &corev1.PersistentVolumeClaimSpec{}
+	testCases := []struct {
+		testName         string
+		kafkaClusterSpec v1beta1.KafkaClusterSpec
+		expected         field.ErrorList
+	}{
+		{
+			testName: "persistent storage drained",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{
+					StorageConfigs: []v1beta1.StorageConfig{
+						{MountPath: "/kafka-logs1", PvcSpec: pvcSpec},
+						{MountPath: "/kafka-logs2", PvcSpec: pvcSpec, Drain: true},
+					},
+				}}},
+			},
+			expected: nil,
+		},
+		{
+			testName: "emptyDir storage drained",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{
+					StorageConfigs: []v1beta1.StorageConfig{
+						{MountPath: "/kafka-logs1", PvcSpec: pvcSpec},
+						{MountPath: "/kafka-logs2", EmptyDir: &corev1.EmptyDirVolumeSource{}, Drain: true},
+					},
+				}}},
+			},
+			expected: append(field.ErrorList{},
+				field.Invalid(field.NewPath("spec").Child("brokers").Index(0), "/kafka-logs2",
+					invalidStorageDrainErrMsg+", only storages with pvcSpec can be drained"),
+			),
+		},
+		{
+			testName: "all storages drained",
+			kafkaClusterSpec: v1beta1.KafkaClusterSpec{
+				Brokers: []v1beta1.Broker{{Id: 0, BrokerConfig: &v1beta1.BrokerConfig{
+					StorageConfigs: []v1beta1.StorageConfig{
+						{MountPath: "/kafka-logs1", PvcSpec: pvcSpec, Drain: true},
+					},
+				}}},
+			},
+			expected: append(field.ErrorList{},
+				field.Invalid(field.NewPath("spec").Child("brokers").Index(0), int32(0),
+					invalidStorageDrainErrMsg+", the broker must keep at least one storage which is not drained"),
+			),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkStorageDrain(&testCase.kafkaClusterSpec)
+			require.Equal(t, testCase.expected, got)
+		})
+	}
+}
+
+func TestCheckRebalanceConfig(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		rebalanceConfig *v1beta1.RebalanceConfig
+		expectedErrors  int
+	}{
+		{
+			testName:       "no rebalance config",
+			expectedErrors: 0,
+		},
+		{
+			testName:        "valid schedule",
+			rebalanceConfig: &v1beta1.RebalanceConfig{Schedule: "0 2 * * 6"},
+			expectedErrors:  0,
+		},
+		{
+			testName:        "imbalance trigger without schedule",
+			rebalanceConfig: &v1beta1.RebalanceConfig{ImbalanceTrigger: &v1beta1.ImbalanceTrigger{ThresholdPercent: 20}},
+			expectedErrors:  0,
+		},
+		{
+			testName:        "invalid schedule",
+			rebalanceConfig: &v1beta1.RebalanceConfig{Schedule: "every saturday"},
+			expectedErrors:  1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkRebalanceConfig(&v1beta1.KafkaClusterSpec{
+				CruiseControlConfig: v1beta1.CruiseControlConfig{RebalanceConfig: testCase.rebalanceConfig},
+			})
+			require.Len(t, got, testCase.expectedErrors)
+		})
+	}
+}
+
+func TestCheckCruiseControlOperationRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		retryPolicy    *v1beta1.CruiseControlOperationRetryPolicy
+		expectedErrors int
+	}{
+		{
+			testName:       "no retry policy",
+			expectedErrors: 0,
+		},
+		{
+			testName:       "valid patterns",
+			retryPolicy:    &v1beta1.CruiseControlOperationRetryPolicy{RetryOnErrors: []string{"NotEnoughValidWindowsException", "timed out.*"}},
+			expectedErrors: 0,
+		},
+		{
+			testName:       "invalid pattern",
+			retryPolicy:    &v1beta1.CruiseControlOperationRetryPolicy{RetryOnErrors: []string{"NotEnoughValidWindowsException", "timed out(.*"}},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkCruiseControlOperationRetryPolicy(&v1beta1.KafkaClusterSpec{
+				CruiseControlConfig: v1beta1.CruiseControlConfig{
+					CruiseControlOperationSpec: &v1beta1.CruiseControlOperationSpec{RetryPolicy: testCase.retryPolicy},
+				},
+			})
+			require.Len(t, got, testCase.expectedErrors)
+		})
+	}
+}
+
+func TestCheckExecutionPolicy(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		executionPolicy *v1beta1.CruiseControlExecutionPolicy
+		expectedErrors  int
+	}{
+		{
+			testName:       "no execution policy",
+			expectedErrors: 0,
+		},
+		{
+			testName: "valid execution windows",
+			executionPolicy: &v1beta1.CruiseControlExecutionPolicy{ExecutionWindows: []v1beta1.ExecutionWindow{
+				{Start: "22:00", End: "06:00", TimeZone: "Europe/Budapest"},
+				{Days: []v1beta1.Weekday{"Saturday", "Sunday"}, Start: "00:00", End: "23:59"},
+			}},
+			expectedErrors: 0,
+		},
+		{
+			testName: "invalid time zone",
+			executionPolicy: &v1beta1.CruiseControlExecutionPolicy{ExecutionWindows: []v1beta1.ExecutionWindow{
+				{Start: "22:00", End: "06:00", TimeZone: "Mars/Olympus_Mons"},
+			}},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			got := checkExecutionPolicy(&v1beta1.KafkaClusterSpec{
+				CruiseControlConfig: v1beta1.CruiseControlConfig{ExecutionPolicy: testCase.executionPolicy},
+			})
+			require.Len(t, got, testCase.expectedErrors)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"emperror.dev/errors"
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	banzaicloudv1alpha1 "github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/pkg/k8sutil"
+)
+
+type KafkaQuotaValidator struct {
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (s KafkaQuotaValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return s.validate(ctx, obj)
+}
+
+func (s KafkaQuotaValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return s.validate(ctx, newObj)
+}
+
+func (s KafkaQuotaValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (s *KafkaQuotaValidator) validate(ctx context.Context, obj runtime.Object) error {
+	kafkaQuota := obj.(*banzaicloudv1alpha1.KafkaQuota)
+	log := s.Log.WithValues("name", kafkaQuota.GetName(), "namespace", kafkaQuota.GetNamespace())
+
+	fieldErrs, err := s.validateKafkaQuota(ctx, kafkaQuota)
+	if err != nil {
+		log.Error(err, errorDuringValidationMsg)
+		return apierrors.NewInternalError(errors.WithMessage(err, errorDuringValidationMsg))
+	}
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	log.Info("rejected", "invalid field(s)", fieldErrs.ToAggregate().Error())
+	return apierrors.NewInvalid(
+		kafkaQuota.GetObjectKind().GroupVersionKind().GroupKind(),
+		kafkaQuota.Name, fieldErrs)
+}
+
+// validateKafkaQuota rejects the KafkaQuotas whose entity is already managed by another KafkaQuota or by the
+// spec.quotas of a KafkaUser of the same cluster, as the resources would overwrite the client quotas of each other
+func (s *KafkaQuotaValidator) validateKafkaQuota(ctx context.Context, quota *banzaicloudv1alpha1.KafkaQuota) (field.ErrorList, error) {
+	if k8sutil.IsMarkedForDeletion(quota.ObjectMeta) {
+		return nil, nil
+	}
+	clusterNamespace := clusterRefNamespace(quota.Namespace, quota.Spec.ClusterRef)
+	entityPath := field.NewPath("spec").Child("entity")
+
+	quotaList := &banzaicloudv1alpha1.KafkaQuotaList{}
+	if err := s.Client.List(ctx, quotaList); err != nil {
+		return nil, errors.Wrap(err, cantConnectAPIServerMsg)
+	}
+	for i := range quotaList.Items {
+		other := &quotaList.Items[i]
+		if (other.Namespace == quota.Namespace && other.Name == quota.Name) || k8sutil.IsMarkedForDeletion(other.ObjectMeta) ||
+			other.Spec.ClusterRef.Name != quota.Spec.ClusterRef.Name ||
+			clusterRefNamespace(other.Namespace, other.Spec.ClusterRef) != clusterNamespace ||
+			other.Spec.Entity != quota.Spec.Entity {
+			continue
+		}
+		return field.ErrorList{field.Invalid(entityPath, quota.Spec.Entity,
+			fmt.Sprintf("%s: KafkaQuota '%s' in the namespace '%s'", duplicateQuotaEntityErrMsg, other.Name, other.Namespace))}, nil
+	}
+
+	if quota.Spec.Entity.Type != banzaicloudv1alpha1.KafkaQuotaEntityTypeUser || quota.Spec.Entity.IsDefault() {
+		return nil, nil
+	}
+	userList := &banzaicloudv1alpha1.KafkaUserList{}
+	if err := s.Client.List(ctx, userList); err != nil {
+		return nil, errors.Wrap(err, cantConnectAPIServerMsg)
+	}
+	for i := range userList.Items {
+		user := &userList.Items[i]
+		if user.Spec.Quotas == nil || k8sutil.IsMarkedForDeletion(user.ObjectMeta) ||
+			user.Spec.ClusterRef.Name != quota.Spec.ClusterRef.Name ||
+			clusterRefNamespace(user.Namespace, user.Spec.ClusterRef) != clusterNamespace ||
+			kafkaUserPrincipal(user) != quota.Spec.Entity.Name {
+			continue
+		}
+		return field.ErrorList{field.Invalid(entityPath, quota.Spec.Entity,
+			fmt.Sprintf("%s: KafkaUser '%s' in the namespace '%s'", duplicateQuotaEntityErrMsg, user.Name, user.Namespace))}, nil
+	}
+	return nil, nil
+}
+
+// kafkaUserPrincipal returns the name of the principal of the KafkaUser, the certificates generated for the users
+// carry the name of the KafkaUser as their common name
+func kafkaUserPrincipal(user *banzaicloudv1alpha1.KafkaUser) string {
+	if user.Spec.IsScramAuthentication() {
+		return user.Name
+	}
+	return fmt.Sprintf("CN=%s", user.Name)
+}
+
+func clusterRefNamespace(namespace string, ref banzaicloudv1alpha1.ClusterReference) string {
+	if ref.Namespace != "" {
+		return ref.Namespace
+	}
+	return namespace
+}
@@ -0,0 +1,113 @@
+// Copyright © 2023 Cisco Systems, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/koperator/api/v1alpha1"
+	"github.com/banzaicloud/koperator/pkg/util"
+)
+
+func TestValidateKafkaQuota(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	newKafkaQuota := func(name, namespace, clusterName string, entity v1alpha1.KafkaQuotaEntity) *v1alpha1.KafkaQuota {
+		return &v1alpha1.KafkaQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: v1alpha1.KafkaQuotaSpec{
+				ClusterRef: v1alpha1.ClusterReference{Name: clusterName, Namespace: "kafka"},
+				Entity:     entity,
+			},
+		}
+	}
+	userEntity := func(name string) v1alpha1.KafkaQuotaEntity {
+		return v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeUser, Name: name}
+	}
+
+	validator := KafkaQuotaValidator{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newKafkaQuota("alice", "kafka", "kafka", userEntity("CN=alice")),
+			newKafkaQuota("default-client-id", "default", "kafka", v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID}),
+			&v1alpha1.KafkaUser{
+				ObjectMeta: metav1.ObjectMeta{Name: "bob", Namespace: "kafka"},
+				Spec: v1alpha1.KafkaUserSpec{
+					ClusterRef:     v1alpha1.ClusterReference{Name: "kafka"},
+					Authentication: &v1alpha1.UserAuthentication{Type: v1alpha1.UserAuthenticationTypeScramSha512},
+					Quotas:         &v1alpha1.KafkaQuotas{ProducerByteRate: util.Int64Pointer(1024)},
+				},
+			},
+			&v1alpha1.KafkaUser{
+				ObjectMeta: metav1.ObjectMeta{Name: "carol", Namespace: "kafka"},
+				Spec:       v1alpha1.KafkaUserSpec{ClusterRef: v1alpha1.ClusterReference{Name: "kafka"}},
+			},
+		).Build(),
+		Log: logr.Discard(),
+	}
+
+	testCases := []struct {
+		testName      string
+		quota         *v1alpha1.KafkaQuota
+		expectedError bool
+	}{
+		{
+			testName: "the KafkaQuota itself is updated",
+			quota:    newKafkaQuota("alice", "kafka", "kafka", userEntity("CN=alice")),
+		},
+		{
+			testName:      "entity managed by another KafkaQuota",
+			quota:         newKafkaQuota("alice-2", "kafka", "kafka", userEntity("CN=alice")),
+			expectedError: true,
+		},
+		{
+			testName:      "default entity managed by another KafkaQuota",
+			quota:         newKafkaQuota("client-ids", "kafka", "kafka", v1alpha1.KafkaQuotaEntity{Type: v1alpha1.KafkaQuotaEntityTypeClientID}),
+			expectedError: true,
+		},
+		{
+			testName: "same entity in another cluster",
+			quota:    newKafkaQuota("alice-2", "kafka", "other", userEntity("CN=alice")),
+		},
+		{
+			testName:      "entity managed by the quotas of a KafkaUser",
+			quota:         newKafkaQuota("bob", "kafka", "kafka", userEntity("bob")),
+			expectedError: true,
+		},
+		{
+			testName: "KafkaUser without quotas",
+			quota:    newKafkaQuota("carol", "kafka", "kafka", userEntity("CN=carol")),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.testName, func(t *testing.T) {
+			err := validator.ValidateCreate(context.Background(), testCase.quota)
+			if testCase.expectedError {
+				assert.True(t, IsAdmissionDuplicateQuotaEntity(err), "unexpected error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
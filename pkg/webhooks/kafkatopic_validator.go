@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 
@@ -41,6 +42,12 @@ import (
 const (
 	TopicManagedByAnnotationKey            = "managedBy"
 	TopicManagedByKoperatorAnnotationValue = "koperator"
+	// TopicDeletionProtectionAnnotationKey set to "true" prevents the KafkaTopic from being deleted
+	TopicDeletionProtectionAnnotationKey = "deletionProtection"
+
+	// activeConsumerGroupsCheckTimeout bounds the Kafka requests of a KafkaTopic deletion, which must be
+	// admitted well within the timeout of the webhook
+	activeConsumerGroupsCheckTimeout = 5 * time.Second
 )
 
 type KafkaTopicValidator struct {
@@ -50,22 +57,23 @@ type KafkaTopicValidator struct {
 }
 
 func (s KafkaTopicValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
-	return s.validate(ctx, obj)
+	return s.validate(ctx, obj, s.validateKafkaTopic)
 }
 
 func (s KafkaTopicValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
-	return s.validate(ctx, newObj)
+	return s.validate(ctx, newObj, s.validateKafkaTopic)
 }
 
 func (s KafkaTopicValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
-	return nil
+	return s.validate(ctx, obj, s.validateKafkaTopicDeletion)
 }
 
-func (s *KafkaTopicValidator) validate(ctx context.Context, obj runtime.Object) error {
+func (s *KafkaTopicValidator) validate(ctx context.Context, obj runtime.Object,
+	validateFn func(context.Context, logr.Logger, *banzaicloudv1alpha1.KafkaTopic) (field.ErrorList, error)) error {
 	kafkaTopic := obj.(*banzaicloudv1alpha1.KafkaTopic)
 	log := s.Log.WithValues("name", kafkaTopic.GetName(), "namespace", kafkaTopic.GetNamespace())
 
-	fieldErrs, err := s.validateKafkaTopic(ctx, log, kafkaTopic)
+	fieldErrs, err := validateFn(ctx, log, kafkaTopic)
 	if err != nil {
 		log.Error(err, errorDuringValidationMsg)
 		return apierrors.NewInternalError(errors.WithMessage(err, errorDuringValidationMsg))
@@ -206,10 +214,12 @@ func (s *KafkaTopicValidator) checkKafka(ctx context.Context, topic *banzaicloud
 				fmt.Sprintf("kafka does not support decreasing partition count on an existing topic (from %v to %v)", existing.NumPartitions, topic.Spec.Partitions)))
 		}
 
-		// check if the user is trying to change the replication factor
-		if existing.ReplicationFactor != int16(topic.Spec.ReplicationFactor) {
+		// the replication factor of an existing topic is changed by reassigning its replicas,
+		// make sure there are enough brokers for the new replication factor
+		if topic.Spec.ReplicationFactor > 0 && existing.ReplicationFactor != int16(topic.Spec.ReplicationFactor) &&
+			int(topic.Spec.ReplicationFactor) > broker.NumBrokers() {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("replicationFactor"), topic.Spec.ReplicationFactor,
-				fmt.Sprintf("kafka does not support changing the replication factor on an existing topic (from %v to %v)", existing.ReplicationFactor, topic.Spec.ReplicationFactor)))
+				fmt.Sprintf("%s (available brokers: %v)", invalidReplicationFactorErrMsg, broker.NumBrokers())))
 		}
 
 		// the topic does not exist check if requesting a replication factor larger than the broker size
@@ -260,3 +270,95 @@ func (s *KafkaTopicValidator) checkExistingKafkaTopicCRs(ctx context.Context,
 
 	return nil, nil
 }
+
+// validateKafkaTopicDeletion refuses the deletion of protected KafkaTopics and of topics which would be deleted
+// from the Kafka cluster while they still have active consumer groups
+func (s *KafkaTopicValidator) validateKafkaTopicDeletion(ctx context.Context, log logr.Logger, topic *banzaicloudv1alpha1.KafkaTopic) (field.ErrorList, error) {
+	cluster, err := k8sutil.LookupKafkaCluster(ctx, s.Client, topic.Spec.ClusterRef.Name, getClusterRefNamespace(topic))
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrap(err, cantConnectAPIServerMsg)
+		}
+		log.Info("Deleted as a result of a cluster deletion")
+		return nil, nil
+	}
+	if k8sutil.IsMarkedForDeletion(cluster.ObjectMeta) {
+		// Let this through, the topics are deleted together with their cluster
+		log.Info("Cluster is going down for deletion, assuming a delete topic request")
+		return nil, nil
+	}
+
+	if strings.ToLower(topic.GetAnnotations()[TopicDeletionProtectionAnnotationKey]) == "true" {
+		return field.ErrorList{field.Forbidden(field.NewPath("metadata").Child("annotations").Key(TopicDeletionProtectionAnnotationKey),
+			fmt.Sprintf(`%s, remove the "%s" annotation to delete it`, protectedTopicDeletionErrMsg, TopicDeletionProtectionAnnotationKey))}, nil
+	}
+
+	// the topic is only deleted from the Kafka cluster when it is managed by Koperator and its deletion policy is Delete
+	if util.ObjectManagedByClusterRegistry(cluster) || topic.Spec.GetDeletionPolicy(cluster) != banzaicloudv1alpha1.TopicDeletionPolicyDelete {
+		return nil, nil
+	}
+	if manager, ok := topic.GetAnnotations()[TopicManagedByAnnotationKey]; ok && strings.ToLower(manager) != TopicManagedByKoperatorAnnotationValue {
+		return nil, nil
+	}
+
+	// the active consumer groups are checked on a best effort basis, an unreachable or slow Kafka cluster must not
+	// block the deletion of the KafkaTopic, and so the deletion of its namespace
+	ctx, cancel := context.WithTimeout(ctx, activeConsumerGroupsCheckTimeout)
+	defer cancel()
+	done := make(chan activeConsumerGroupsResult, 1)
+	go func() {
+		groups, err := s.activeConsumerGroups(cluster, topic.Spec.Name)
+		done <- activeConsumerGroupsResult{groups: groups, err: err}
+	}()
+
+	var groups []string
+	select {
+	case result := <-done:
+		if result.err != nil {
+			log.Info("WARNING: failed to list the active consumer groups of the topic, allowing the deletion",
+				"kafkaCluster", topic.Spec.ClusterRef.Name, "error", result.err.Error())
+			return nil, nil
+		}
+		groups = result.groups
+	case <-ctx.Done():
+		log.Info("WARNING: listing the active consumer groups of the topic timed out, allowing the deletion",
+			"kafkaCluster", topic.Spec.ClusterRef.Name, "timeout", activeConsumerGroupsCheckTimeout.String())
+		return nil, nil
+	}
+	if len(groups) > 0 {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec").Child("name"),
+			fmt.Sprintf(`%s (%s), stop the consumers or set the "Retain" or "Orphan" deletion policy to keep the topic`,
+				activeConsumerGroupsTopicDeletionErrMsg, strings.Join(groups, ", ")))}, nil
+	}
+	return nil, nil
+}
+
+type activeConsumerGroupsResult struct {
+	groups []string
+	err    error
+}
+
+// activeConsumerGroups returns the active consumer groups of the topic, or none if the topic does not exist
+func (s *KafkaTopicValidator) activeConsumerGroups(cluster *banzaicloudv1beta1.KafkaCluster, topic string) ([]string, error) {
+	broker, closeClient, err := s.NewKafkaFromCluster(s.Client, cluster)
+	if err != nil {
+		return nil, errors.WrapIf(err, cantConnectErrorMsg)
+	}
+	defer closeClient()
+
+	existing, err := broker.GetTopic(topic)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to get the topic")
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	return broker.ListActiveConsumerGroups(topic)
+}
+
+func getClusterRefNamespace(topic *banzaicloudv1alpha1.KafkaTopic) string {
+	if topic.Spec.ClusterRef.Namespace != "" {
+		return topic.Spec.ClusterRef.Namespace
+	}
+	return topic.GetNamespace()
+}
@@ -16,8 +16,10 @@ package webhooks
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -330,7 +332,7 @@ func TestValidateTopic(t *testing.T) {
 		t.Error("Expected not allowed for reason: kafka does not support decreasing partition count")
 	}
 
-	// replication factor change attempt
+	// replication factor increase beyond the number of brokers
 	topic.Spec.Partitions = 2
 	topic.Spec.ReplicationFactor = 2
 	fieldErrorList, err = kafkaTopicValidator.validateKafkaTopic(context.Background(), logr.Discard(), topic)
@@ -338,8 +340,157 @@ func TestValidateTopic(t *testing.T) {
 		t.Errorf("err should be nil, got: %s", err)
 	}
 	if len(fieldErrorList) != 1 {
-		t.Error("Expected not allowed due to replication factor change, got allowed")
-	} else if !strings.Contains(fieldErrorList.ToAggregate().Error(), "kafka does not support changing the replication factor") {
-		t.Error("Expected not allowed for reason: kafka does not support changing the replication factor")
+		t.Error("Expected not allowed due to replication factor larger than num brokers, got allowed")
+	} else if !strings.Contains(fieldErrorList.ToAggregate().Error(), invalidReplicationFactorErrMsg) {
+		t.Errorf("Expected not allowed for reason: %s", invalidReplicationFactorErrMsg)
+	}
+
+	// broker's default replication factor on an existing topic
+	topic.Spec.ReplicationFactor = -1
+	fieldErrorList, err = kafkaTopicValidator.validateKafkaTopic(context.Background(), logr.Discard(), topic)
+	if err != nil {
+		t.Errorf("err should be nil, got: %s", err)
+	}
+	if len(fieldErrorList) != 0 {
+		t.Error("Expected allowed, got:", fieldErrorList.ToAggregate().Error())
+	}
+}
+
+// consumerGroupsKafkaClient reports the given active consumer groups for every topic
+type consumerGroupsKafkaClient struct {
+	kafkaclient.KafkaClient
+	groups []string
+}
+
+func (c consumerGroupsKafkaClient) ListActiveConsumerGroups(string) ([]string, error) {
+	return c.groups, nil
+}
+
+func TestValidateKafkaTopicDeletion(t *testing.T) {
+	cluster := newMockCluster()
+	client, kafkaClient, _ := newMockClients(cluster)
+	if err := client.Create(context.TODO(), cluster); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	if err := kafkaClient.CreateTopic(&kafkaclient.CreateTopicOptions{Name: "test-topic", ReplicationFactor: 1, Partitions: 2}); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+
+	testCases := []struct {
+		testName       string
+		annotations    map[string]string
+		deletionPolicy v1alpha1.TopicDeletionPolicy
+		groups         []string
+		expectedError  string
+	}{
+		{
+			testName: "topic without consumers",
+		},
+		{
+			testName:      "protected topic",
+			annotations:   map[string]string{TopicDeletionProtectionAnnotationKey: "true"},
+			expectedError: protectedTopicDeletionErrMsg,
+		},
+		{
+			testName:       "protected topic with retain deletion policy",
+			annotations:    map[string]string{TopicDeletionProtectionAnnotationKey: "true"},
+			deletionPolicy: v1alpha1.TopicDeletionPolicyRetain,
+			expectedError:  protectedTopicDeletionErrMsg,
+		},
+		{
+			testName:      "topic with active consumer groups",
+			groups:        []string{"test-group"},
+			expectedError: activeConsumerGroupsTopicDeletionErrMsg,
+		},
+		{
+			testName:       "topic with active consumer groups and retain deletion policy",
+			groups:         []string{"test-group"},
+			deletionPolicy: v1alpha1.TopicDeletionPolicyRetain,
+		},
+		{
+			testName:    "topic with active consumer groups not managed by koperator",
+			groups:      []string{"test-group"},
+			annotations: map[string]string{TopicManagedByAnnotationKey: "other"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testName, func(t *testing.T) {
+			kafkaTopicValidator := KafkaTopicValidator{
+				Client: client,
+				NewKafkaFromCluster: func(runtimeClient.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, func(), error) {
+					return consumerGroupsKafkaClient{KafkaClient: kafkaClient, groups: testCase.groups}, func() {}, nil
+				},
+			}
+			topic := newMockTopic()
+			topic.Annotations = testCase.annotations
+			topic.Spec.DeletionPolicy = testCase.deletionPolicy
+
+			fieldErrorList, err := kafkaTopicValidator.validateKafkaTopicDeletion(context.Background(), logr.Discard(), topic)
+			if err != nil {
+				t.Errorf("err should be nil, got: %s", err)
+			}
+			if testCase.expectedError == "" {
+				if len(fieldErrorList) != 0 {
+					t.Error("Expected allowed, got:", fieldErrorList.ToAggregate().Error())
+				}
+			} else if len(fieldErrorList) != 1 || !strings.Contains(fieldErrorList.ToAggregate().Error(), testCase.expectedError) {
+				t.Errorf("Expected not allowed for reason: %s, got: %v", testCase.expectedError, fieldErrorList)
+			}
+		})
+	}
+
+	// the deletion is allowed when the Kafka cluster is unreachable
+	kafkaTopicValidator := KafkaTopicValidator{
+		Client: client,
+		NewKafkaFromCluster: func(runtimeClient.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, func(), error) {
+			return nil, nil, errors.New("kafka cluster is unreachable")
+		},
+	}
+	fieldErrorList, err := kafkaTopicValidator.validateKafkaTopicDeletion(context.Background(), logr.Discard(), newMockTopic())
+	if err != nil {
+		t.Errorf("err should be nil, got: %s", err)
+	}
+	if len(fieldErrorList) != 0 {
+		t.Error("Expected allowed, got:", fieldErrorList.ToAggregate().Error())
+	}
+
+	// the deletion is allowed when the Kafka cluster does not answer in time
+	unblock := make(chan struct{})
+	defer close(unblock)
+	kafkaTopicValidator = KafkaTopicValidator{
+		Client: client,
+		NewKafkaFromCluster: func(runtimeClient.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, func(), error) {
+			<-unblock
+			return consumerGroupsKafkaClient{KafkaClient: kafkaClient, groups: []string{"test-group"}}, func() {}, nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	fieldErrorList, err = kafkaTopicValidator.validateKafkaTopicDeletion(ctx, logr.Discard(), newMockTopic())
+	if err != nil {
+		t.Errorf("err should be nil, got: %s", err)
+	}
+	if len(fieldErrorList) != 0 {
+		t.Error("Expected allowed, got:", fieldErrorList.ToAggregate().Error())
+	}
+
+	// the cluster default deletion policy applies to the topics which do not set one
+	cluster.Spec.TopicDeletionPolicy = string(v1alpha1.TopicDeletionPolicyOrphan)
+	if err := client.Update(context.TODO(), cluster); err != nil {
+		t.Error("Expected no error, got:", err)
+	}
+	kafkaTopicValidator = KafkaTopicValidator{
+		Client: client,
+		NewKafkaFromCluster: func(runtimeClient.Client, *v1beta1.KafkaCluster) (kafkaclient.KafkaClient, func(), error) {
+			return consumerGroupsKafkaClient{KafkaClient: kafkaClient, groups: []string{"test-group"}}, func() {}, nil
+		},
+	}
+	fieldErrorList, err = kafkaTopicValidator.validateKafkaTopicDeletion(context.Background(), logr.Discard(), newMockTopic())
+	if err != nil {
+		t.Errorf("err should be nil, got: %s", err)
+	}
+	if len(fieldErrorList) != 0 {
+		t.Error("Expected allowed, got:", fieldErrorList.ToAggregate().Error())
 	}
 }